@@ -2,27 +2,29 @@
 package api
 
 import (
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/ykaseng/articles-library/api/app"
+	"github.com/ykaseng/articles-library/codec"
 	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/events"
 	"github.com/ykaseng/articles-library/logging"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/render"
+	"github.com/go-pg/pg/orm"
 )
 
-// New configures application resources and routes.
-func New() (*chi.Mux, error) {
+// New configures application resources and routes on the primary database db, reading from
+// replicas where stale reads are acceptable. Article changes published to broker are streamed to
+// subscribers of /articles/stream until shutdown is closed. Article reads are cached in
+// articleCache, unless it is nil.
+func New(db orm.DB, replicas *database.Replicas, broker *events.Broker, articleCache *app.ArticleCache, shutdown <-chan struct{}) (*chi.Mux, error) {
 	logger := logging.NewLogger()
 
-	db, err := database.DBConn()
-	if err != nil {
-		logger.WithField("module", "database").Error(err)
-		return nil, err
-	}
-
 	// authStore := database.NewAuthStore(db)
 	// authResource, err := pwdless.NewResource(authStore)
 	// if err != nil {
@@ -30,21 +32,26 @@ func New() (*chi.Mux, error) {
 	// 	return nil, err
 	// }
 
-	appAPI, err := app.NewAPI(db)
+	appAPI, err := app.NewAPI(db, replicas, broker, articleCache)
 	if err != nil {
 		logger.WithField("module", "app").Error(err)
 		return nil, err
 	}
+	appAPI.Stream.Done = shutdown
+
+	// responses of every handler are encoded in the media type negotiated by app.Negotiate
+	render.Respond = app.Respond
 
 	r := chi.NewRouter()
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
 	// r.Use(middleware.RealIP)
 	r.Use(middleware.DefaultCompress)
-	r.Use(middleware.Timeout(15 * time.Second))
+	r.Use(timeout(15 * time.Second))
 
 	r.Use(logging.NewStructuredLogger(logger))
-	r.Use(render.SetContentType(render.ContentTypeJSON))
+	r.Use(app.Negotiate)
+	r.Use(app.NewHTTPCache().Handler)
 	r.NotFound(app.NotFoundHandler())
 
 	r.Group(func(r chi.Router) {
@@ -53,3 +60,34 @@ func New() (*chi.Mux, error) {
 
 	return r, nil
 }
+
+// streams lists the long-lived routes which are exempt from the request timeout.
+var streams = map[string]bool{
+	"/articles/stream": true,
+}
+
+// download reports whether a path is an export download, which may take longer than the timeout
+// for large exports.
+func download(path string) bool {
+	return strings.HasPrefix(path, "/exports/") && strings.HasSuffix(path, "/download")
+}
+
+// ndjson reports whether a request lists articles as NDJSON, which streams the whole library.
+func ndjson(r *http.Request) bool {
+	return r.URL.Path == "/articles" && codec.Prefers(r.Header.Get("Accept"), codec.NDJSON)
+}
+
+// timeout cancels the context of requests other than streams, downloads and NDJSON lists after the
+// given timeout.
+func timeout(d time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		withTimeout := middleware.Timeout(d)(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if streams[r.URL.Path] || download(r.URL.Path) || ndjson(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			withTimeout.ServeHTTP(w, r)
+		})
+	}
+}
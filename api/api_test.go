@@ -12,6 +12,8 @@ import (
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/ykaseng/articles-library/api/app"
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/events"
 	"github.com/ykaseng/articles-library/models"
 )
 
@@ -59,9 +61,15 @@ func TestRouter(t *testing.T) {
 		},
 	}
 
+	db, err := database.DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+	defer db.Close()
+
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			api, err := New()
+			api, err := New(db, nil, events.NewBroker(0), nil, nil)
 			if err != nil {
 				t.Errorf("failed to create api : %v", err)
 			}
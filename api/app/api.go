@@ -3,27 +3,68 @@ package app
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/go-pg/pg/orm"
 	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 
 	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/events"
 	"github.com/ykaseng/articles-library/logging"
+	"github.com/ykaseng/articles-library/transfer"
 )
 
 // API provides application resources and handlers.
 type API struct {
-	Article *ArticleResource
+	Article     *ArticleResource
+	Feed        *FeedResource
+	GraphQL     *GraphQLResource
+	Stream      *StreamResource
+	Webhook     *WebhookResource
+	Job         *JobResource
+	Export      *ExportResource
+	Idempotency *Idempotency
+	OpenAPI     *OpenAPIResource
+	Cache       *ArticleCache
+	Sticky      *ReadYourWrites
 }
 
-// NewAPI configures and returns application API.
-func NewAPI(db orm.DB) (*API, error) {
-	articleStore := database.NewArticleStore(db)
-	article := NewArticleResource(articleStore)
+// NewAPI configures and returns application API. Article changes published to broker are
+// streamed to subscribers. Articles are read from replicas while they are healthy, and reads are
+// cached in articleCache, unless they are nil.
+func NewAPI(db orm.DB, replicas *database.Replicas, broker *events.Broker, articleCache *ArticleCache) (*API, error) {
+	articleStore := database.NewReplicatedArticleStore(db, replicas)
+	cachedStore := articleCache.Wrap(articleStore)
+	article := NewArticleResource(cachedStore)
+	article.Primary = articleStore.Primary()
+	feed := NewFeedResource(articleStore, article.Renderer)
+
+	graphQL, err := NewGraphQLResource(cachedStore, article.Renderer)
+	if err != nil {
+		return nil, err
+	}
+	graphQL.Primary = articleStore.Primary()
+
+	ttl := viper.GetDuration("idempotency_ttl")
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	idempotency := NewIdempotency(database.NewIdempotencyStore(db), ttl)
 
 	api := &API{
-		Article: article,
+		Article:     article,
+		Feed:        feed,
+		GraphQL:     graphQL,
+		Stream:      NewStreamResource(broker),
+		Webhook:     NewWebhookResource(database.NewWebhookStore(db)),
+		Job:         NewJobResource(database.NewJobStore(db)),
+		Export:      NewExportResource(database.NewExportStore(db), transfer.ArtifactDir()),
+		Idempotency: idempotency,
+		OpenAPI:     NewOpenAPIResource(),
+		Cache:       articleCache,
+		Sticky:      NewReadYourWrites(),
 	}
 
 	return api, nil
@@ -33,8 +74,22 @@ func NewAPI(db orm.DB) (*API, error) {
 func (a *API) Router() *chi.Mux {
 	r := chi.NewRouter()
 	r.NotFound(NotFoundHandler())
+	r.Use(a.Sticky.Handler)
+	r.Use(a.OpenAPI.validate(r))
 
-	r.Mount("/articles", a.Article.router())
+	r.Get("/articles/stream", a.Stream.stream)
+	r.With(a.Idempotency.Handler).Mount("/articles", a.Article.router())
+	r.With(a.Idempotency.Handler).Post("/articles:batch", a.Article.batch)
+	r.Mount("/feeds", a.Feed.router())
+	r.Mount("/graphql", a.GraphQL.router())
+	r.Mount("/webhooks", a.Webhook.router())
+	r.Mount("/exports", a.Export.router())
+	r.Mount("/admin/jobs", a.Job.router())
+	r.Get("/admin/cache", a.Cache.stats)
+	r.Get("/openapi.json", a.OpenAPI.spec(r))
+	if a.OpenAPI.SwaggerUI {
+		r.Get("/docs", a.OpenAPI.swaggerUI)
+	}
 
 	return r
 }
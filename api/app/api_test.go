@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/events"
 	"github.com/ykaseng/articles-library/models"
 )
 
@@ -66,7 +67,7 @@ func TestRouter(t *testing.T) {
 
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			api, err := NewAPI(db)
+			api, err := NewAPI(db, nil, events.NewBroker(0), nil)
 			if err != nil {
 				t.Errorf("failed to create api : %v", err)
 			}
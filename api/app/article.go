@@ -1,46 +1,101 @@
 package app
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
+	"github.com/spf13/viper"
 
+	"github.com/ykaseng/articles-library/codec"
+	"github.com/ykaseng/articles-library/content"
+	"github.com/ykaseng/articles-library/database"
 	"github.com/ykaseng/articles-library/models"
 )
 
 // The list of error types returned from article resource.
 var (
-	ErrEmptyRequest = errors.New("request cannot be empty")
+	ErrEmptyRequest    = errors.New("request cannot be empty")
+	ErrBatchTooLarge   = errors.New("batch exceeds the maximum number of operations")
+	ErrInvalidBatchArg = errors.New("atomic must be true or false")
+	ErrInvalidRender   = errors.New("render must be html")
+	ErrNotStreamable   = errors.New("q, limit and include cannot be used with application/x-ndjson")
 )
 
+// defaultListLimit is the number of articles listed by search and pages without a limit.
+const defaultListLimit = 20
+
 // ArticleStore defines database operations for article.
 type ArticleStore interface {
+	Resolve(id models.PublicID) (int, error)
 	Get(id int) (*[]models.Article, error)
-	GetAll() (*[]models.Article, error)
+	GetBySlug(slug string) (*models.Article, error)
+	GetAll(fields models.ArticleFields) (*[]models.Article, error)
+	Page(filter models.ArticleFilter, fields models.ArticleFields, after models.PublicID, limit int) (*[]models.Article, error)
 	Post(*models.Article) (*models.ArticleID, error)
+	Update(id int, article *models.Article) error
+	Delete(id int) error
+	Each(fn func(*models.Article) error) error
+	Search(query string, fields models.ArticleFields, limit int) (*[]models.Article, error)
+	Stream(ctx context.Context, filter models.ArticleFilter, fields models.ArticleFields, after models.PublicID, fn func(*models.Article) error) error
+	GetAuthors(names []string) ([]models.Author, error)
+	GetTags(names []string) ([]models.Tag, error)
+	Batch(ops []models.BatchOperation, atomic bool) ([]models.BatchResult, error)
 }
 
 // ArticleResource implements article management handler.
 type ArticleResource struct {
 	Store ArticleStore
+	// Primary serves the reads of clients which must see their own writes, see ReadYourWrites.
+	// Store serves them when it is nil.
+	Primary    ArticleStore
+	Renderer   *content.Renderer
+	BatchLimit int
 }
 
 // NewArticleResource creates and returns an article resource.
 func NewArticleResource(store ArticleStore) *ArticleResource {
+	batchLimit := viper.GetInt("batch_max_operations")
+	if batchLimit <= 0 {
+		batchLimit = 100
+	}
+
 	return &ArticleResource{
-		Store: store,
+		Store:      store,
+		Renderer:   content.NewRenderer(viper.GetInt("render_cache_size")),
+		BatchLimit: batchLimit,
+	}
+}
+
+// reads returns the store serving the reads of a request.
+func (rs *ArticleResource) reads(r *http.Request) ArticleStore {
+	if rs.Primary != nil && readsPrimary(r) {
+		return rs.Primary
 	}
+	return rs.Store
+}
+
+// writes returns the store resolving the articles to write, which must not lag behind the primary.
+func (rs *ArticleResource) writes() ArticleStore {
+	if rs.Primary != nil {
+		return rs.Primary
+	}
+	return rs.Store
 }
 
 func (rs *ArticleResource) router() *chi.Mux {
 	r := chi.NewRouter()
 	r.Post("/", rs.post)
 	r.Get("/", rs.getAll)
+	r.Get("/by-slug/{slug}", rs.getBySlug)
 	r.Route("/{articleID}", func(r chi.Router) {
 		r.Get("/", rs.get)
+		r.Put("/", rs.update)
+		r.Delete("/", rs.delete)
 	})
 	return r
 }
@@ -51,18 +106,24 @@ func (rs *ArticleResource) get(w http.ResponseWriter, r *http.Request) {
 		Data *[]models.Article `json:"data"`
 	}
 
-	id, err := strconv.Atoi(chi.URLParam(r, "articleID"))
-	if err != nil {
-		render.Render(w, r, ErrBadRequest(err))
+	store := rs.reads(r)
+	id, ok := rs.resolve(w, r, store)
+	if !ok {
 		return
 	}
 
-	article, err := rs.Store.Get(id)
+	article, err := store.Get(id.ID)
 	if err != nil {
 		render.Render(w, r, ErrUnprocessableEntity(err))
 		return
 	}
 
+	if err := rs.render(r, *article); err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	surrogateKeys(w, articleSurrogateKey(id.PublicID))
 	render.Respond(w, r, &getArticleResponse{
 		Status: Status{
 			Code:    http.StatusOK,
@@ -72,13 +133,152 @@ func (rs *ArticleResource) get(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// resolve returns the ids of the article addressed by the URL in store, rendering an error response
+// for IDs which are not ULIDs and unknown articles.
+func (rs *ArticleResource) resolve(w http.ResponseWriter, r *http.Request, store ArticleStore) (models.ArticleID, bool) {
+	publicID, err := models.ParsePublicID(chi.URLParam(r, "articleID"))
+	if err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return models.ArticleID{}, false
+	}
+
+	id, err := store.Resolve(publicID)
+	if err != nil {
+		render.Render(w, r, storeError(err))
+		return models.ArticleID{}, false
+	}
+
+	return models.ArticleID{ID: id, PublicID: publicID}, true
+}
+
+// storeError returns status 404 Not Found for unknown articles and 422 Unprocessable Entity for
+// other errors of the article store.
+func storeError(err error) render.Renderer {
+	if err == database.ErrArticleNotFound {
+		return &ErrResponse{Status: Status{Code: http.StatusNotFound, Message: err.Error()}}
+	}
+	return ErrUnprocessableEntity(err)
+}
+
+// getBySlug responds with the article of a slug. A slug the article had before redirects permanently
+// to its current slug.
+func (rs *ArticleResource) getBySlug(w http.ResponseWriter, r *http.Request) {
+	type getArticleResponse struct {
+		Status
+		Data *[]models.Article `json:"data"`
+	}
+
+	slug := chi.URLParam(r, "slug")
+	article, err := rs.reads(r).GetBySlug(slug)
+	if err != nil {
+		render.Render(w, r, storeError(err))
+		return
+	}
+
+	if article.Slug != slug {
+		u := *r.URL
+		u.Path = strings.TrimSuffix(u.Path, slug) + article.Slug
+		u.RawPath = ""
+		http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
+		return
+	}
+
+	articles := []models.Article{*article}
+	if err := rs.render(r, articles); err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	surrogateKeys(w, articleSurrogateKey(article.PublicID))
+
+	render.Respond(w, r, &getArticleResponse{
+		Status: Status{
+			Code:    http.StatusOK,
+			Message: "SUCCESS",
+		},
+		Data: &articles,
+	})
+}
+
+// getAll responds with all articles. Articles matching a full text query are listed with ?q=, best
+// matches first. Pages of articles are listed with ?limit= and ?after=, optionally restricted to an
+// ?author= or ?tag=, and the cursor of the next page is returned as next. Only the fields named by
+// ?fields= are loaded, and the authors and tags named by ?include= are embedded as objects. Requests
+// preferring application/x-ndjson are streamed, see stream.
 func (rs *ArticleResource) getAll(w http.ResponseWriter, r *http.Request) {
 	type getAllArticlesResponse struct {
 		Status
-		Data *[]models.Article `json:"data"`
+		Data []listedArticle `json:"data"`
+		Next string          `json:"next,omitempty"`
+	}
+
+	query := r.URL.Query()
+	limit := defaultListLimit
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > 100 {
+			render.Render(w, r, ErrBadRequest(ErrInvalidLimit))
+			return
+		}
+	}
+
+	after, err := decodeCursor(query.Get("after"))
+	if err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	fields, err := models.ParseArticleFields(query.Get("fields"))
+	if err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	include, err := parseInclude(query.Get("include"))
+	if err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	filter := models.ArticleFilter{Author: query.Get("author"), Tag: query.Get("tag")}
+	surrogateKeys(w, surrogateKeyArticles)
+
+	if codec.Prefers(r.Header.Get("Accept"), codec.NDJSON) {
+		if query.Get("q") != "" || query.Get("limit") != "" || include != nil {
+			render.Render(w, r, ErrBadRequest(ErrNotStreamable))
+			return
+		}
+		rs.stream(w, r, filter, fields, after)
+		return
+	}
+
+	// included relations are listed even when they are not selected
+	load := rendered(r, fields.With(include...))
+
+	var articles *[]models.Article
+	var next string
+	switch {
+	case query.Get("q") != "":
+		articles, err = rs.reads(r).Search(query.Get("q"), load, limit)
+	case query.Get("limit") != "" || query.Get("after") != "" || filter != (models.ArticleFilter{}):
+		if articles, err = rs.reads(r).Page(filter, load, after, limit+1); err == nil && len(*articles) > limit {
+			*articles = (*articles)[:limit]
+			next = encodeCursor((*articles)[limit-1].PublicID)
+		}
+	default:
+		articles, err = rs.reads(r).GetAll(load)
+	}
+	if err != nil {
+		render.Render(w, r, ErrUnprocessableEntity(err))
+		return
 	}
 
-	articles, err := rs.Store.GetAll()
+	if err := rs.render(r, *articles); err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	listed, err := rs.list(r, *articles, fields.With(include...), include)
 	if err != nil {
 		render.Render(w, r, ErrUnprocessableEntity(err))
 		return
@@ -89,10 +289,42 @@ func (rs *ArticleResource) getAll(w http.ResponseWriter, r *http.Request) {
 			Code:    http.StatusOK,
 			Message: "SUCCESS",
 		},
-		Data: articles,
+		Data: listed,
+		Next: next,
 	})
 }
 
+// rendered returns the fields to load for the fields of a response, adding the format of content
+// rendered with ?render=.
+func rendered(r *http.Request, fields models.ArticleFields) models.ArticleFields {
+	if fields.Has("content") && r.URL.Query().Get("render") != "" {
+		return fields.With("content_format")
+	}
+	return fields
+}
+
+// render replaces article content with sanitized HTML when requested with ?render=html.
+func (rs *ArticleResource) render(r *http.Request, articles []models.Article) error {
+	switch r.URL.Query().Get("render") {
+	case "":
+		return nil
+	case content.FormatHTML:
+	default:
+		return ErrInvalidRender
+	}
+
+	for i := range articles {
+		html, err := rs.Renderer.Render(articles[i].ContentFormat, articles[i].Content)
+		if err != nil {
+			return err
+		}
+		articles[i].Content = html
+		articles[i].ContentFormat = content.FormatHTML
+	}
+
+	return nil
+}
+
 func (rs *ArticleResource) post(w http.ResponseWriter, r *http.Request) {
 	type postArticleRequest struct{ *models.Article }
 	type postArticleResponse struct {
@@ -130,3 +362,171 @@ func (rs *ArticleResource) post(w http.ResponseWriter, r *http.Request) {
 		Data: articleID,
 	})
 }
+
+// update replaces the fields of an article and responds with the updated article.
+func (rs *ArticleResource) update(w http.ResponseWriter, r *http.Request) {
+	type updateArticleRequest struct{ *models.Article }
+	type updateArticleResponse struct {
+		Status
+		Data *models.Article `json:"data"`
+	}
+
+	id, ok := rs.resolve(w, r, rs.writes())
+	if !ok {
+		return
+	}
+
+	data := &updateArticleRequest{}
+	if err := render.DecodeJSON(r.Body, data); err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	if *data == (updateArticleRequest{}) {
+		render.Render(w, r, ErrBadRequest(ErrEmptyRequest))
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	if err := rs.Store.Update(id.ID, data.Article); err != nil {
+		render.Render(w, r, storeError(err))
+		return
+	}
+	data.ArticleID = id
+
+	render.Respond(w, r, &updateArticleResponse{
+		Status: Status{
+			Code:    http.StatusOK,
+			Message: "SUCCESS",
+		},
+		Data: data.Article,
+	})
+}
+
+func (rs *ArticleResource) delete(w http.ResponseWriter, r *http.Request) {
+	type deleteArticleResponse struct {
+		Status
+		Data *models.ArticleID `json:"data"`
+	}
+
+	id, ok := rs.resolve(w, r, rs.writes())
+	if !ok {
+		return
+	}
+
+	if err := rs.Store.Delete(id.ID); err != nil {
+		render.Render(w, r, storeError(err))
+		return
+	}
+
+	render.Respond(w, r, &deleteArticleResponse{
+		Status: Status{
+			Code:    http.StatusOK,
+			Message: "SUCCESS",
+		},
+		Data: &id,
+	})
+}
+
+type batchArticlesRequest struct {
+	Operations []models.BatchOperation `json:"operations"`
+}
+
+// batchResult reports the outcome of a batch operation by its index in the request.
+type batchResult struct {
+	Index int    `json:"index"`
+	Op    string `json:"op"`
+	Status
+	ID models.PublicID `json:"id,omitempty"`
+}
+
+func (rs *ArticleResource) batch(w http.ResponseWriter, r *http.Request) {
+	type batchArticlesResponse struct {
+		Status
+		Data []batchResult `json:"data"`
+	}
+
+	atomic := false
+	if v := r.URL.Query().Get("atomic"); v != "" {
+		var err error
+		if atomic, err = strconv.ParseBool(v); err != nil {
+			render.Render(w, r, ErrBadRequest(ErrInvalidBatchArg))
+			return
+		}
+	}
+
+	data := &batchArticlesRequest{}
+	if err := render.DecodeJSON(r.Body, data); err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	if len(data.Operations) == 0 {
+		render.Render(w, r, ErrBadRequest(ErrEmptyRequest))
+		return
+	}
+
+	if len(data.Operations) > rs.BatchLimit {
+		render.Render(w, r, ErrBadRequest(ErrBatchTooLarge))
+		return
+	}
+
+	results := make([]batchResult, len(data.Operations))
+	var valid []models.BatchOperation
+	var indexes []int
+	for i, op := range data.Operations {
+		results[i] = batchResult{Index: i, Op: op.Op}
+		if err := op.Validate(); err != nil {
+			if atomic {
+				render.Render(w, r, ErrBadRequest(&models.BatchError{Index: i, Err: err}))
+				return
+			}
+			results[i].Status = Status{Code: http.StatusBadRequest, Message: err.Error()}
+			continue
+		}
+		valid = append(valid, op)
+		indexes = append(indexes, i)
+	}
+
+	if len(valid) > 0 {
+		stored, err := rs.Store.Batch(valid, atomic)
+		if err != nil {
+			if berr, ok := err.(*models.BatchError); ok {
+				err = &models.BatchError{Index: indexes[berr.Index], Err: berr.Err}
+			}
+			render.Render(w, r, ErrUnprocessableEntity(err))
+			return
+		}
+
+		for k, res := range stored {
+			i := indexes[k]
+			results[i].ID = res.PublicID
+			results[i].Status = batchStatus(valid[k].Op, res.Err)
+		}
+	}
+
+	render.Respond(w, r, &batchArticlesResponse{
+		Status: Status{
+			Code:    http.StatusOK,
+			Message: "SUCCESS",
+		},
+		Data: results,
+	})
+}
+
+func batchStatus(op string, err error) Status {
+	switch {
+	case err == database.ErrArticleNotFound:
+		return Status{Code: http.StatusNotFound, Message: err.Error()}
+	case err != nil:
+		return Status{Code: http.StatusUnprocessableEntity, Message: err.Error()}
+	case op == models.BatchCreate:
+		return Status{Code: http.StatusCreated, Message: "SUCCESS"}
+	default:
+		return Status{Code: http.StatusOK, Message: "SUCCESS"}
+	}
+}
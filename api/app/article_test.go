@@ -3,11 +3,13 @@ package app
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi"
@@ -62,12 +64,10 @@ func TestGetAll(t *testing.T) {
 				},
 				Data: []models.Article{
 					{
-						ArticleID: models.ArticleID{
-							ID: 1,
-						},
 						Title:   "Test Title",
 						Content: "Test Content",
 						Author:  "Test Author",
+						Slug:    "test-title",
 					},
 				},
 			},
@@ -93,20 +93,16 @@ func TestGetAll(t *testing.T) {
 				},
 				Data: []models.Article{
 					{
-						ArticleID: models.ArticleID{
-							ID: 1,
-						},
 						Title:   "Test Title",
 						Content: "Test Content",
 						Author:  "Test Author",
+						Slug:    "test-title",
 					},
 					{
-						ArticleID: models.ArticleID{
-							ID: 2,
-						},
 						Title:   "Another Test Title",
 						Content: "Another Test Content",
 						Author:  "Another Test Author",
+						Slug:    "another-test-title",
 					},
 				},
 			},
@@ -131,11 +127,12 @@ func TestGetAll(t *testing.T) {
 			}()
 
 			article := NewArticleResource(database.NewArticleStore(tx))
-			for _, seed := range tc.seeds {
-				_, err := article.Store.Post(&seed)
+			for i, seed := range tc.seeds {
+				articleID, err := article.Store.Post(&seed)
 				if err != nil {
-					t.Errorf("failed to seed: %v", err)
+					t.Fatalf("failed to seed: %v", err)
 				}
+				tc.expected.Data[i].PublicID = articleID.PublicID
 			}
 
 			req, err := http.NewRequest("GET", "localhost:8080/api/v1/articles", nil)
@@ -173,7 +170,7 @@ func TestGet(t *testing.T) {
 	tt := []struct {
 		name     string
 		seeds    []models.Article
-		id       int
+		seed     int // 1-based index of the requested seed, 0 requests an unknown article
 		expected getArticleResponse
 	}{
 		{
@@ -181,8 +178,8 @@ func TestGet(t *testing.T) {
 			seeds: []models.Article{},
 			expected: getArticleResponse{
 				Status: Status{
-					Code:    http.StatusOK,
-					Message: "SUCCESS",
+					Code:    http.StatusNotFound,
+					Message: "article not found",
 				},
 				Data: []models.Article(nil),
 			},
@@ -196,7 +193,7 @@ func TestGet(t *testing.T) {
 					Author:  "Test Author",
 				},
 			},
-			id: 1,
+			seed: 1,
 			expected: getArticleResponse{
 				Status: Status{
 					Code:    http.StatusOK,
@@ -204,12 +201,10 @@ func TestGet(t *testing.T) {
 				},
 				Data: []models.Article{
 					{
-						ArticleID: models.ArticleID{
-							ID: 1,
-						},
 						Title:   "Test Title",
 						Content: "Test Content",
 						Author:  "Test Author",
+						Slug:    "test-title",
 					},
 				},
 			},
@@ -228,7 +223,7 @@ func TestGet(t *testing.T) {
 					Author:  "Another Test Author",
 				},
 			},
-			id: 2,
+			seed: 2,
 			expected: getArticleResponse{
 				Status: Status{
 					Code:    http.StatusOK,
@@ -236,12 +231,10 @@ func TestGet(t *testing.T) {
 				},
 				Data: []models.Article{
 					{
-						ArticleID: models.ArticleID{
-							ID: 2,
-						},
 						Title:   "Another Test Title",
 						Content: "Another Test Content",
 						Author:  "Another Test Author",
+						Slug:    "another-test-title",
 					},
 				},
 			},
@@ -260,11 +253,10 @@ func TestGet(t *testing.T) {
 					Author:  "Another Test Author",
 				},
 			},
-			id: 3,
 			expected: getArticleResponse{
 				Status: Status{
-					Code:    http.StatusOK,
-					Message: "SUCCESS",
+					Code:    http.StatusNotFound,
+					Message: "article not found",
 				},
 				Data: []models.Article(nil),
 			},
@@ -289,21 +281,29 @@ func TestGet(t *testing.T) {
 			}()
 
 			article := NewArticleResource(database.NewArticleStore(tx))
+			var ids []models.PublicID
 			for _, seed := range tc.seeds {
-				_, err := article.Store.Post(&seed)
+				articleID, err := article.Store.Post(&seed)
 				if err != nil {
-					t.Errorf("failed to seed: %v", err)
+					t.Fatalf("failed to seed: %v", err)
 				}
+				ids = append(ids, articleID.PublicID)
+			}
+
+			id := models.PublicID("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+			if tc.seed > 0 {
+				id = ids[tc.seed-1]
+				tc.expected.Data[0].PublicID = id
 			}
 
-			req, err := http.NewRequest("GET", fmt.Sprintf("localhost:8080/api/v1/articles/%d", tc.id), nil)
+			req, err := http.NewRequest("GET", fmt.Sprintf("localhost:8080/api/v1/articles/%s", id), nil)
 			if err != nil {
 				t.Errorf("create request failed: %v", err)
 			}
 
 			rec := httptest.NewRecorder()
 			rctx := chi.NewRouteContext()
-			rctx.URLParams.Add("articleID", fmt.Sprintf("%d", tc.id))
+			rctx.URLParams.Add("articleID", string(id))
 
 			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
 
@@ -356,13 +356,13 @@ func TestPost(t *testing.T) {
 						Code:    http.StatusCreated,
 						Message: "SUCCESS",
 					},
-					Data: models.ArticleID{ID: 1},
 				},
 				article: models.Article{
 					ArticleID: models.ArticleID{ID: 1},
 					Title:     "Test Title",
 					Author:    "Test Author",
 					Content:   "Test Content",
+					Slug:      "test-title",
 				},
 			},
 		},
@@ -410,11 +410,18 @@ func TestPost(t *testing.T) {
 				t.Errorf("unmarshal response failed: %v", err)
 			}
 
-			actualArticle, err := article.Store.Get(actual.Data.ID)
+			id, err := article.Store.Resolve(actual.Data.PublicID)
+			if err != nil {
+				t.Fatalf("failed to resolve article: %v", err)
+			}
+
+			actualArticle, err := article.Store.Get(id)
 			if err != nil {
 				t.Errorf("failed to retrieve article: %v", err)
 			}
 
+			tc.expected.resp.Data.PublicID = actual.Data.PublicID
+			tc.expected.article.PublicID = actual.Data.PublicID
 			assert.Equal(t, tc.expected.resp, actual)
 			assert.Equal(t, []models.Article{tc.expected.article}, *actualArticle)
 		})
@@ -427,3 +434,437 @@ func restartSerial(t *testing.T, db orm.DB) {
 		t.Errorf("could not restart serial: %v", err)
 	}
 }
+
+// testPublicID returns a fixed public ID for the article with a serial ID.
+func testPublicID(id int) models.PublicID {
+	return models.PublicID(fmt.Sprintf("01ARZ3NDEKTSV4RRFFQ69G5F%02d", id))
+}
+
+// slugArticleStore serves articles by current and replaced slugs.
+type slugArticleStore struct {
+	ArticleStore
+	articles map[string]models.Article
+}
+
+func (s *slugArticleStore) GetBySlug(slug string) (*models.Article, error) {
+	if a, ok := s.articles[slug]; ok {
+		return &a, nil
+	}
+	return nil, database.ErrArticleNotFound
+}
+
+func TestGetBySlug(t *testing.T) {
+	article := models.Article{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "Über Title", Content: "*Test Content*", ContentFormat: "markdown", Author: "Test Author", Slug: "über-title"}
+
+	tt := []struct {
+		name     string
+		endpoint string
+		code     int
+		location string
+		contains []string
+	}{
+		{
+			name:     "current slug",
+			endpoint: "/articles/by-slug/%C3%BCber-title",
+			code:     http.StatusOK,
+			contains: []string{`"id":"01ARZ3NDEKTSV4RRFFQ69G5F01"`, `"slug":"über-title"`},
+		},
+		{
+			name:     "current slug rendered",
+			endpoint: "/articles/by-slug/%C3%BCber-title?render=html",
+			code:     http.StatusOK,
+			contains: []string{`"content_format":"html"`, `\u003cem\u003eTest Content\u003c/em\u003e`},
+		},
+		{
+			name:     "replaced slug",
+			endpoint: "/articles/by-slug/test-title?render=html",
+			code:     http.StatusMovedPermanently,
+			location: "/articles/by-slug/%C3%BCber-title?render=html",
+		},
+		{
+			name:     "unknown slug",
+			endpoint: "/articles/by-slug/unknown",
+			code:     http.StatusNotFound,
+			contains: []string{"article not found"},
+		},
+	}
+
+	store := &slugArticleStore{articles: map[string]models.Article{"über-title": article, "test-title": article}}
+	r := chi.NewRouter()
+	r.Mount("/articles", NewArticleResource(store).router())
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest("GET", tc.endpoint, nil))
+
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+			assert.Equal(t, tc.location, rec.Header().Get("Location"))
+			for _, s := range tc.contains {
+				assert.Contains(t, rec.Body.String(), s)
+			}
+		})
+	}
+}
+
+// publicArticleStore resolves the public IDs of articles held in memory.
+type publicArticleStore struct {
+	ArticleStore
+	articles []models.Article
+
+	// fields are the fields loaded by the last list of articles.
+	fields models.ArticleFields
+}
+
+func (s *publicArticleStore) Resolve(id models.PublicID) (int, error) {
+	for _, a := range s.articles {
+		if a.PublicID == id {
+			return a.ID, nil
+		}
+	}
+	return 0, database.ErrArticleNotFound
+}
+
+func (s *publicArticleStore) Get(id int) (*[]models.Article, error) {
+	var articles []models.Article
+	for _, a := range s.articles {
+		if a.ID == id {
+			articles = append(articles, a)
+		}
+	}
+	return &articles, nil
+}
+
+func (s *publicArticleStore) Post(article *models.Article) (*models.ArticleID, error) {
+	article.ArticleID = models.ArticleID{ID: len(s.articles) + 1, PublicID: testPublicID(len(s.articles) + 1)}
+	s.articles = append(s.articles, *article)
+	return &article.ArticleID, nil
+}
+
+func (s *publicArticleStore) Page(filter models.ArticleFilter, fields models.ArticleFields, after models.PublicID, limit int) (*[]models.Article, error) {
+	s.fields = fields
+
+	var articles []models.Article
+	for _, a := range s.articles {
+		if len(articles) < limit && a.PublicID > after && (filter.Author == "" || a.Author == filter.Author) {
+			articles = append(articles, a)
+		}
+	}
+	return &articles, nil
+}
+
+func (s *publicArticleStore) Search(query string, fields models.ArticleFields, limit int) (*[]models.Article, error) {
+	s.fields = fields
+
+	var articles []models.Article
+	for _, a := range s.articles {
+		if len(articles) < limit && strings.Contains(a.Title, query) {
+			articles = append(articles, a)
+		}
+	}
+	return &articles, nil
+}
+
+func (s *publicArticleStore) Stream(ctx context.Context, filter models.ArticleFilter, fields models.ArticleFields, after models.PublicID, fn func(*models.Article) error) error {
+	s.fields = fields
+
+	for _, a := range s.articles {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if a.PublicID > after && (filter.Author == "" || a.Author == filter.Author) {
+			if err := fn(&a); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *publicArticleStore) GetAuthors(names []string) ([]models.Author, error) {
+	var authors []models.Author
+	for _, name := range names {
+		author := models.Author{Name: name}
+		for _, a := range s.articles {
+			if a.Author == name {
+				author.Articles++
+			}
+		}
+		if author.Articles > 0 {
+			authors = append(authors, author)
+		}
+	}
+	return authors, nil
+}
+
+func (s *publicArticleStore) GetTags(names []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	for _, name := range names {
+		tag := models.Tag{Name: name}
+		for _, a := range s.articles {
+			for _, t := range a.Tags {
+				if t == name {
+					tag.Articles++
+				}
+			}
+		}
+		if tag.Articles > 0 {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (s *publicArticleStore) Update(id int, article *models.Article) error {
+	for i, a := range s.articles {
+		if a.ID == id {
+			article.ArticleID = a.ArticleID
+			s.articles[i] = *article
+			return nil
+		}
+	}
+	return database.ErrArticleNotFound
+}
+
+func (s *publicArticleStore) Delete(id int) error {
+	for i, a := range s.articles {
+		if a.ID == id {
+			s.articles = append(s.articles[:i], s.articles[i+1:]...)
+			return nil
+		}
+	}
+	return database.ErrArticleNotFound
+}
+
+// Batch runs the operations in order, restoring the articles on the first failure of an atomic batch.
+func (s *publicArticleStore) Batch(ops []models.BatchOperation, atomic bool) ([]models.BatchResult, error) {
+	saved := append([]models.Article(nil), s.articles...)
+	results := make([]models.BatchResult, len(ops))
+	for i, op := range ops {
+		var err error
+		if op.Op == models.BatchCreate {
+			var id *models.ArticleID
+			if id, err = s.Post(op.Article); err == nil {
+				results[i].ArticleID = *id
+			}
+		} else {
+			results[i].PublicID = op.ID
+			if results[i].ID, err = s.Resolve(op.ID); err == nil {
+				if op.Op == models.BatchDelete {
+					err = s.Delete(results[i].ID)
+				} else {
+					err = s.Update(results[i].ID, op.Article)
+				}
+			}
+		}
+
+		if err != nil {
+			if atomic {
+				s.articles = saved
+				return results, &models.BatchError{Index: i, Err: err}
+			}
+			results[i].Err = err
+		}
+	}
+	return results, nil
+}
+
+func TestGetByPublicID(t *testing.T) {
+	tt := []struct {
+		name     string
+		endpoint string
+		code     int
+		contains string
+	}{
+		{"public id", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", http.StatusOK, `"id":"01ARZ3NDEKTSV4RRFFQ69G5F01"`},
+		{"lowercase public id", "/articles/01arz3ndektsv4rrffq69g5f01", http.StatusOK, `"title":"Test Title"`},
+		{"serial id", "/articles/1", http.StatusBadRequest, "article id must be a ULID"},
+		{"unknown public id", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F02", http.StatusNotFound, "article not found"},
+	}
+
+	store := &publicArticleStore{articles: []models.Article{{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "Test Title", Content: "Test Content", Author: "Test Author"}}}
+	r := chi.NewRouter()
+	r.Mount("/articles", NewArticleResource(store).router())
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest("GET", tc.endpoint, nil))
+
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+			assert.Contains(t, rec.Body.String(), tc.contains)
+			assert.NotContains(t, rec.Body.String(), `"id":1`)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	tt := []struct {
+		name     string
+		endpoint string
+		code     int
+		ids      []models.PublicID
+		next     string
+	}{
+		{"first page", "/articles?limit=2", http.StatusOK, []models.PublicID{testPublicID(1), testPublicID(2)}, encodeCursor(testPublicID(2))},
+		{"last page", "/articles?limit=2&after=" + encodeCursor(testPublicID(2)), http.StatusOK, []models.PublicID{testPublicID(3)}, ""},
+		{"author", "/articles?author=Other%20Author", http.StatusOK, []models.PublicID{testPublicID(2)}, ""},
+		{"search", "/articles?q=Third", http.StatusOK, []models.PublicID{testPublicID(3)}, ""},
+		{"invalid limit", "/articles?limit=0", http.StatusBadRequest, nil, ""},
+		{"invalid cursor", "/articles?after=1", http.StatusBadRequest, nil, ""},
+		{"serial id cursor", "/articles?after=" + base64.RawURLEncoding.EncodeToString([]byte("article:2")), http.StatusBadRequest, nil, ""},
+	}
+
+	store := &publicArticleStore{articles: []models.Article{
+		{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "First Title", Content: "Test Content", Author: "Test Author"},
+		{ArticleID: models.ArticleID{ID: 2, PublicID: testPublicID(2)}, Title: "Second Title", Content: "Test Content", Author: "Other Author"},
+		{ArticleID: models.ArticleID{ID: 3, PublicID: testPublicID(3)}, Title: "Third Title", Content: "Test Content", Author: "Test Author"},
+	}}
+	r := chi.NewRouter()
+	r.Mount("/articles", NewArticleResource(store).router())
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest("GET", tc.endpoint, nil))
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+
+			var res struct {
+				Data []models.Article `json:"data"`
+				Next string           `json:"next"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatalf("unmarshal response failed: %v", err)
+			}
+
+			var ids []models.PublicID
+			for _, a := range res.Data {
+				ids = append(ids, a.PublicID)
+			}
+			assert.Equal(t, tc.ids, ids)
+			assert.Equal(t, tc.next, res.Next)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	tt := []struct {
+		name     string
+		endpoint string
+		body     string
+		code     int
+		contains string
+	}{
+		{"update", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", `{"title":"New Title","content":"New Content","author":"Test Author"}`, http.StatusOK, `"id":"01ARZ3NDEKTSV4RRFFQ69G5F01","title":"New Title"`},
+		{"invalid article", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", `{"title":"New Title"}`, http.StatusBadRequest, "cannot be blank"},
+		{"empty request", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", `{}`, http.StatusBadRequest, "request cannot be empty"},
+		{"serial id", "/articles/1", `{"title":"New Title","content":"New Content","author":"Test Author"}`, http.StatusBadRequest, "article id must be a ULID"},
+		{"unknown article", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F02", `{"title":"New Title","content":"New Content","author":"Test Author"}`, http.StatusNotFound, "article not found"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &publicArticleStore{articles: []models.Article{{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "Test Title", Content: "Test Content", Author: "Test Author"}}}
+			r := chi.NewRouter()
+			r.Mount("/articles", NewArticleResource(store).router())
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest("PUT", tc.endpoint, strings.NewReader(tc.body)))
+
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+			assert.Contains(t, rec.Body.String(), tc.contains)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tt := []struct {
+		name     string
+		endpoint string
+		code     int
+		remains  int
+	}{
+		{"delete", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", http.StatusOK, 0},
+		{"serial id", "/articles/1", http.StatusBadRequest, 1},
+		{"unknown article", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F02", http.StatusNotFound, 1},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &publicArticleStore{articles: []models.Article{{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "Test Title", Content: "Test Content", Author: "Test Author"}}}
+			r := chi.NewRouter()
+			r.Mount("/articles", NewArticleResource(store).router())
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest("DELETE", tc.endpoint, nil))
+
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+			assert.Len(t, store.articles, tc.remains)
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	const (
+		create  = `{"op":"create","article":{"title":"New Title","content":"New Content","author":"New Author"}}`
+		invalid = `{"op":"create","article":{"title":"New Title"}}`
+		update  = `{"op":"update","id":"01ARZ3NDEKTSV4RRFFQ69G5F02","article":{"title":"New Title","content":"New Content","author":"Test Author"}}`
+		remove  = `{"op":"delete","id":"01ARZ3NDEKTSV4RRFFQ69G5F01"}`
+		unknown = `{"op":"delete","id":"01ARZ3NDEKTSV4RRFFQ69G5F09"}`
+	)
+
+	tt := []struct {
+		name     string
+		endpoint string
+		ops      []string
+		code     int
+		statuses []int
+		contains string
+		titles   []string
+	}{
+		{"mixed", "/articles:batch", []string{create, invalid, unknown, remove}, http.StatusOK, []int{http.StatusCreated, http.StatusBadRequest, http.StatusNotFound, http.StatusOK}, "SUCCESS", []string{"New Title"}},
+		{"atomic", "/articles:batch?atomic=true", []string{create, update, remove}, http.StatusOK, []int{http.StatusCreated, http.StatusOK, http.StatusOK}, "SUCCESS", []string{"New Title"}},
+		{"atomic rollback", "/articles:batch?atomic=true", []string{create, update, unknown}, http.StatusUnprocessableEntity, nil, "operation 2: article not found", []string{"Test Title"}},
+		{"atomic invalid", "/articles:batch?atomic=true", []string{create, invalid}, http.StatusBadRequest, nil, "operation 1", []string{"Test Title"}},
+		{"invalid atomic", "/articles:batch?atomic=maybe", []string{create}, http.StatusBadRequest, nil, ErrInvalidBatchArg.Error(), []string{"Test Title"}},
+		{"empty", "/articles:batch", nil, http.StatusBadRequest, nil, ErrEmptyRequest.Error(), []string{"Test Title"}},
+		{"limit", "/articles:batch", []string{create, create, create, create}, http.StatusOK, []int{http.StatusCreated, http.StatusCreated, http.StatusCreated, http.StatusCreated}, "SUCCESS", []string{"Test Title", "New Title", "New Title", "New Title", "New Title"}},
+		{"too large", "/articles:batch", []string{create, create, create, create, create}, http.StatusBadRequest, nil, ErrBatchTooLarge.Error(), []string{"Test Title"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &publicArticleStore{articles: []models.Article{{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "Test Title", Content: "Test Content", Author: "Test Author"}}}
+			rs := NewArticleResource(store)
+			rs.BatchLimit = 4
+			r := chi.NewRouter()
+			r.Post("/articles:batch", rs.batch)
+
+			body := `{"operations":[` + strings.Join(tc.ops, ",") + `]}`
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest("POST", tc.endpoint, strings.NewReader(body)))
+
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+			assert.Contains(t, rec.Body.String(), tc.contains)
+
+			var res struct {
+				Data []batchResult `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatalf("unmarshal response failed: %v", err)
+			}
+			var statuses []int
+			for i, result := range res.Data {
+				assert.Equal(t, i, result.Index)
+				statuses = append(statuses, result.Code)
+			}
+			assert.Equal(t, tc.statuses, statuses)
+
+			var titles []string
+			for _, a := range store.articles {
+				titles = append(titles, a.Title)
+			}
+			assert.Equal(t, tc.titles, titles)
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/spf13/viper"
+
+	"github.com/ykaseng/articles-library/cache"
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/events"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// ArticleCache caches the reads of article stores wrapped by it in a cache.Backend. Concurrent
+// misses of a key are loaded once. Entries are invalidated by writes made through a wrapped store
+// and, with Run, by the article changes of every server instance announced by Postgres NOTIFY.
+type ArticleCache struct {
+	Backend cache.Backend
+	TTL     time.Duration
+	Broker  *events.Broker
+	// Settle is the time after an invalidation during which loaded values are not cached, since
+	// they may be read from a replica which lags behind the write.
+	Settle time.Duration
+
+	group cache.Group
+	// generation is incremented by every invalidation, values loaded before are not cached. It is
+	// part of the keys of lists and slugs, so that an invalidation replaces all of them without
+	// sweeping the backend, and the entries of earlier generations age out.
+	generation uint64
+	// resolves is part of the keys of resolved public IDs and incremented when an article whose
+	// public ID is unknown is deleted.
+	resolves uint64
+	// invalidated is the Unix time in nanoseconds of the last invalidation.
+	invalidated int64
+
+	hits, misses, coalesced, invalidations uint64
+}
+
+// NewArticleCache returns an in-process ArticleCache configured by viper, or nil when caching is
+// disabled.
+func NewArticleCache(broker *events.Broker) *ArticleCache {
+	if !viper.GetBool("cache_enabled") {
+		return nil
+	}
+
+	ttl := viper.GetDuration("cache_ttl")
+	if ttl <= 0 {
+		ttl = time.Minute
+	}
+	maxBytes := viper.GetInt64("cache_max_bytes")
+	if maxBytes <= 0 {
+		maxBytes = 64 << 20
+	}
+
+	return &ArticleCache{
+		Backend: cache.NewLRU(maxBytes),
+		TTL:     ttl,
+		Broker:  broker,
+		Settle:  database.ReplicaMaxLag(),
+	}
+}
+
+// instance tells apart the generations of the server instances sharing a backend, whose
+// invalidations are not counted in step.
+var instance = models.NewPublicID()
+
+// listKey returns the key of a list or slug of the current generation.
+func (c *ArticleCache) listKey(format string, a ...interface{}) string {
+	return fmt.Sprintf("%s@%s.%d", fmt.Sprintf(format, a...), instance, atomic.LoadUint64(&c.generation))
+}
+
+// resolveKey returns the key of a resolved public ID.
+func (c *ArticleCache) resolveKey(id models.PublicID) string {
+	return fmt.Sprintf("resolve:%s@%s.%d", id, instance, atomic.LoadUint64(&c.resolves))
+}
+
+// Wrap returns store with its reads cached, or store itself without a cache. Streams and the
+// batched reads of GraphQL are not cached.
+func (c *ArticleCache) Wrap(store GraphQLStore) GraphQLStore {
+	if c == nil {
+		return store
+	}
+	return &cachedArticleStore{GraphQLStore: store, cache: c}
+}
+
+// Run invalidates the entries of the articles changed on any server instance, as published to the
+// broker, until ctx is done. Everything is invalidated when the subscription falls behind or a reset
+// is published, since changes may have been missed.
+func (c *ArticleCache) Run(ctx context.Context) {
+	for {
+		_, ch := c.Broker.Subscribe(0)
+		for open := true; open; {
+			select {
+			case <-ctx.Done():
+				c.Broker.Unsubscribe(ch)
+				return
+			case e, ok := <-ch:
+				if open = ok; ok && e.Type == events.TypeReset {
+					c.invalidateAll()
+				} else if ok {
+					c.invalidate(e.Article, e.Type == events.TypeDeleted)
+				}
+			}
+		}
+		c.invalidateAll()
+	}
+}
+
+// load decodes the cached value of key into v. A missing value is loaded with fn and cached, unless
+// the cache was invalidated in the meantime or less than Settle before. Concurrent misses of a key
+// share one call of fn.
+func (c *ArticleCache) load(key string, v interface{}, fn func() (interface{}, error)) error {
+	if b, ok := c.Backend.Get(key); ok {
+		if err := gob.NewDecoder(bytes.NewReader(b)).Decode(v); err == nil {
+			atomic.AddUint64(&c.hits, 1)
+			return nil
+		}
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	generation := atomic.LoadUint64(&c.generation)
+	b, err, shared := c.group.Do(fmt.Sprintf("%s@%d", key, generation), func() ([]byte, error) {
+		settled := time.Since(time.Unix(0, atomic.LoadInt64(&c.invalidated))) >= c.Settle
+		value, err := fn()
+		if err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+			return nil, err
+		}
+		if settled && atomic.LoadUint64(&c.generation) == generation {
+			c.Backend.Set(key, buf.Bytes(), c.TTL)
+		}
+		return buf.Bytes(), nil
+	})
+	if shared {
+		atomic.AddUint64(&c.coalesced, 1)
+	}
+	if err != nil {
+		return err
+	}
+
+	return gob.NewDecoder(bytes.NewReader(b)).Decode(v)
+}
+
+// invalidate removes the cached article and moves on to a new generation of lists, which may hold
+// it, and slugs, which move between articles. Public IDs are removed only when the article is
+// deleted.
+func (c *ArticleCache) invalidate(article models.ArticleID, deleted bool) {
+	if deleted && article.PublicID != "" {
+		c.Backend.Delete(c.resolveKey(article.PublicID))
+	} else if deleted {
+		atomic.AddUint64(&c.resolves, 1)
+	}
+
+	atomic.AddUint64(&c.generation, 1)
+	atomic.AddUint64(&c.invalidations, 1)
+	atomic.StoreInt64(&c.invalidated, time.Now().UnixNano())
+
+	c.Backend.Delete(fmt.Sprintf("article:%d", article.ID))
+}
+
+func (c *ArticleCache) invalidateAll() {
+	atomic.AddUint64(&c.generation, 1)
+	atomic.AddUint64(&c.invalidations, 1)
+	atomic.StoreInt64(&c.invalidated, time.Now().UnixNano())
+	c.Backend.DeletePrefix("")
+}
+
+// CacheStats holds the counters of an ArticleCache. Misses include the coalesced misses, which
+// waited for the load of another request rather than loading the value themselves.
+type CacheStats struct {
+	Enabled       bool         `json:"enabled"`
+	Hits          uint64       `json:"hits"`
+	Misses        uint64       `json:"misses"`
+	Coalesced     uint64       `json:"coalesced"`
+	Invalidations uint64       `json:"invalidations"`
+	Usage         *cache.Usage `json:"usage,omitempty"`
+}
+
+// Stats returns the counters of the cache, a nil cache is disabled.
+func (c *ArticleCache) Stats() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+
+	stats := CacheStats{
+		Enabled:       true,
+		Hits:          atomic.LoadUint64(&c.hits),
+		Misses:        atomic.LoadUint64(&c.misses),
+		Coalesced:     atomic.LoadUint64(&c.coalesced),
+		Invalidations: atomic.LoadUint64(&c.invalidations),
+	}
+	if m, ok := c.Backend.(cache.Measured); ok {
+		usage := m.Usage()
+		stats.Usage = &usage
+	}
+	return stats
+}
+
+func (c *ArticleCache) stats(w http.ResponseWriter, r *http.Request) {
+	type getCacheStatsResponse struct {
+		Status
+		Data CacheStats `json:"data"`
+	}
+
+	render.Respond(w, r, &getCacheStatsResponse{
+		Status: Status{
+			Code:    http.StatusOK,
+			Message: "SUCCESS",
+		},
+		Data: c.Stats(),
+	})
+}
+
+// cachedArticleStore caches the reads of a GraphQLStore in an ArticleCache and invalidates them on
+// writes.
+type cachedArticleStore struct {
+	GraphQLStore
+	cache *ArticleCache
+}
+
+// fieldsKey returns the part of a cache key naming the loaded fields.
+func fieldsKey(fields models.ArticleFields) string {
+	if fields == nil {
+		return "*"
+	}
+	return strings.Join(fields, ",")
+}
+
+func (s *cachedArticleStore) Resolve(id models.PublicID) (int, error) {
+	var resolved int
+	err := s.cache.load(s.cache.resolveKey(id), &resolved, func() (interface{}, error) {
+		return s.GraphQLStore.Resolve(id)
+	})
+	return resolved, err
+}
+
+func (s *cachedArticleStore) Get(id int) (*[]models.Article, error) {
+	return s.list(fmt.Sprintf("article:%d", id), func() (*[]models.Article, error) {
+		return s.GraphQLStore.Get(id)
+	})
+}
+
+func (s *cachedArticleStore) GetBySlug(slug string) (*models.Article, error) {
+	var article models.Article
+	err := s.cache.load(s.cache.listKey("slug:%s", slug), &article, func() (interface{}, error) {
+		return s.GraphQLStore.GetBySlug(slug)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &article, nil
+}
+
+func (s *cachedArticleStore) GetAll(fields models.ArticleFields) (*[]models.Article, error) {
+	return s.list(s.cache.listKey("list:all:%s", fieldsKey(fields)), func() (*[]models.Article, error) {
+		return s.GraphQLStore.GetAll(fields)
+	})
+}
+
+func (s *cachedArticleStore) Page(filter models.ArticleFilter, fields models.ArticleFields, after models.PublicID, limit int) (*[]models.Article, error) {
+	key := s.cache.listKey("list:page:%q:%q:%s:%s:%d", filter.Author, filter.Tag, fieldsKey(fields), after, limit)
+	return s.list(key, func() (*[]models.Article, error) {
+		return s.GraphQLStore.Page(filter, fields, after, limit)
+	})
+}
+
+func (s *cachedArticleStore) Search(query string, fields models.ArticleFields, limit int) (*[]models.Article, error) {
+	key := s.cache.listKey("list:search:%q:%s:%d", query, fieldsKey(fields), limit)
+	return s.list(key, func() (*[]models.Article, error) {
+		return s.GraphQLStore.Search(query, fields, limit)
+	})
+}
+
+func (s *cachedArticleStore) GetAuthors(names []string) ([]models.Author, error) {
+	var authors []models.Author
+	err := s.cache.load(s.cache.listKey("list:authors:%q", names), &authors, func() (interface{}, error) {
+		return s.GraphQLStore.GetAuthors(names)
+	})
+	return authors, err
+}
+
+func (s *cachedArticleStore) GetTags(names []string) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := s.cache.load(s.cache.listKey("list:tags:%q", names), &tags, func() (interface{}, error) {
+		return s.GraphQLStore.GetTags(names)
+	})
+	return tags, err
+}
+
+// list returns the cached articles of key, loading them with fn.
+func (s *cachedArticleStore) list(key string, fn func() (*[]models.Article, error)) (*[]models.Article, error) {
+	var articles []models.Article
+	err := s.cache.load(key, &articles, func() (interface{}, error) {
+		a, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		return *a, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &articles, nil
+}
+
+func (s *cachedArticleStore) Post(article *models.Article) (*models.ArticleID, error) {
+	id, err := s.GraphQLStore.Post(article)
+	if err == nil {
+		s.cache.invalidate(*id, false)
+	}
+	return id, err
+}
+
+func (s *cachedArticleStore) Update(id int, article *models.Article) error {
+	err := s.GraphQLStore.Update(id, article)
+	s.cache.invalidate(models.ArticleID{ID: id}, false)
+	return err
+}
+
+func (s *cachedArticleStore) Delete(id int) error {
+	err := s.GraphQLStore.Delete(id)
+	s.cache.invalidate(models.ArticleID{ID: id}, true)
+	return err
+}
+
+func (s *cachedArticleStore) Batch(ops []models.BatchOperation, atomic bool) ([]models.BatchResult, error) {
+	results, err := s.GraphQLStore.Batch(ops, atomic)
+	for i, result := range results {
+		s.cache.invalidate(result.ArticleID, ops[i].Op == models.BatchDelete)
+	}
+	return results, err
+}
@@ -0,0 +1,256 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/cache"
+	"github.com/ykaseng/articles-library/events"
+	"github.com/ykaseng/articles-library/models"
+)
+
+func testArticleCache() *ArticleCache {
+	return &ArticleCache{Backend: cache.NewLRU(1 << 20), TTL: time.Minute, Broker: events.NewBroker(0)}
+}
+
+func TestArticleCache(t *testing.T) {
+	tt := []struct {
+		name  string
+		read  func(store GraphQLStore) (interface{}, error)
+		write func(store GraphQLStore) error
+		call  string
+		calls int
+	}{
+		{
+			name:  "get",
+			read:  func(store GraphQLStore) (interface{}, error) { return store.Get(1) },
+			call:  "Get",
+			calls: 1,
+		},
+		{
+			name:  "get after update",
+			read:  func(store GraphQLStore) (interface{}, error) { return store.Get(1) },
+			write: func(store GraphQLStore) error { return store.Update(1, &models.Article{Title: "Updated"}) },
+			call:  "Get",
+			calls: 2,
+		},
+		{
+			name:  "list fields",
+			read:  func(store GraphQLStore) (interface{}, error) { return store.GetAll(models.ArticleFields{"title"}) },
+			call:  "GetAll",
+			calls: 1,
+		},
+		{
+			name:  "list after post",
+			read:  func(store GraphQLStore) (interface{}, error) { return store.GetAll(nil) },
+			write: func(store GraphQLStore) error { _, err := store.Post(&models.Article{Title: "Posted"}); return err },
+			call:  "GetAll",
+			calls: 2,
+		},
+		{
+			name: "page after delete",
+			read: func(store GraphQLStore) (interface{}, error) {
+				return store.Page(models.ArticleFilter{Author: "Author A"}, nil, "", 10)
+			},
+			write: func(store GraphQLStore) error { return store.Delete(3) },
+			call:  "Page",
+			calls: 2,
+		},
+		{
+			name:  "resolve",
+			read:  func(store GraphQLStore) (interface{}, error) { return store.Resolve(testPublicID(2)) },
+			call:  "Resolve",
+			calls: 1,
+		},
+		{
+			name:  "resolve after update",
+			read:  func(store GraphQLStore) (interface{}, error) { return store.Resolve(testPublicID(2)) },
+			write: func(store GraphQLStore) error { return store.Update(2, &models.Article{Title: "Updated"}) },
+			call:  "Resolve",
+			calls: 1,
+		},
+		{
+			name:  "resolve after delete",
+			read:  func(store GraphQLStore) (interface{}, error) { return store.Resolve(testPublicID(2)) },
+			write: func(store GraphQLStore) error { return store.Delete(2) },
+			call:  "Resolve",
+			calls: 2,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			backing := testGraphQLStore()
+			store := testArticleCache().Wrap(backing)
+
+			first, err := tc.read(store)
+			assert.NoError(t, err)
+			if tc.write != nil {
+				assert.NoError(t, tc.write(store))
+			}
+			second, err := tc.read(store)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.calls, backing.calls[tc.call])
+			if tc.write == nil {
+				assert.Equal(t, first, second, "cached reads must return the loaded value")
+			}
+		})
+	}
+}
+
+func TestArticleCacheUpdated(t *testing.T) {
+	store := testArticleCache().Wrap(testGraphQLStore())
+
+	_, err := store.Get(1)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Update(1, &models.Article{Title: "Updated"}))
+
+	articles, err := store.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Updated", (*articles)[0].Title, "updated articles must not be read from the cache")
+}
+
+// sweepingBackend counts the prefix deletions, which scan every entry.
+type sweepingBackend struct {
+	cache.Backend
+	sweeps int
+}
+
+func (b *sweepingBackend) DeletePrefix(prefix string) {
+	b.sweeps++
+	b.Backend.DeletePrefix(prefix)
+}
+
+func TestArticleCacheGenerations(t *testing.T) {
+	backend := &sweepingBackend{Backend: cache.NewLRU(1 << 20)}
+	c := testArticleCache()
+	c.Backend = backend
+	backing := testGraphQLStore()
+	store := c.Wrap(backing)
+
+	for i := 0; i < 2; i++ {
+		_, err := store.GetAll(nil)
+		assert.NoError(t, err)
+		_, err = store.Resolve(testPublicID(2))
+		assert.NoError(t, err)
+		assert.NoError(t, store.Delete(2))
+	}
+
+	assert.Equal(t, 2, backing.calls["GetAll"])
+	assert.Equal(t, 2, backing.calls["Resolve"])
+	assert.Equal(t, 0, backend.sweeps, "writes must not sweep the backend")
+}
+
+func TestArticleCacheEvents(t *testing.T) {
+	c := testArticleCache()
+	backing := testGraphQLStore()
+	store := c.Wrap(backing)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	_, err := store.Get(1)
+	assert.NoError(t, err)
+
+	// an update made by another server instance, as published by the listener
+	for atomic.LoadUint64(&c.invalidations) == 0 {
+		c.Broker.Publish(events.Event{ID: 1, Type: events.TypeUpdated, Article: models.ArticleID{ID: 1, PublicID: testPublicID(1)}})
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err = store.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, backing.calls["Get"], "articles changed on other instances must be loaded again")
+}
+
+func TestArticleCacheReset(t *testing.T) {
+	c := testArticleCache()
+	backing := testGraphQLStore()
+	store := c.Wrap(backing)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	_, err := store.Get(1)
+	assert.NoError(t, err)
+
+	// published by the listener after reconnecting, changes made meanwhile are unknown
+	for atomic.LoadUint64(&c.invalidations) == 0 {
+		c.Broker.Publish(events.Event{Type: events.TypeReset})
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err = store.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, backing.calls["Get"], "every article must be loaded again after a reset")
+}
+
+func TestCacheStats(t *testing.T) {
+	enabled := testArticleCache()
+	store := enabled.Wrap(testGraphQLStore())
+	for i := 0; i < 3; i++ {
+		_, err := store.Get(1)
+		assert.NoError(t, err)
+	}
+
+	tt := []struct {
+		name     string
+		cache    *ArticleCache
+		expected CacheStats
+	}{
+		{name: "disabled", expected: CacheStats{}},
+		{
+			name:  "enabled",
+			cache: enabled,
+			expected: CacheStats{
+				Enabled: true,
+				Hits:    2,
+				Misses:  1,
+				Usage:   &cache.Usage{Entries: 1, Bytes: enabled.Backend.(*cache.LRU).Usage().Bytes, MaxBytes: 1 << 20},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.cache.stats(rec, httptest.NewRequest("GET", "/admin/cache", nil))
+
+			var body struct {
+				Data CacheStats `json:"data"`
+			}
+			assert.Equal(t, 200, rec.Code)
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tc.expected, body.Data)
+		})
+	}
+}
+
+func TestArticleCacheSettle(t *testing.T) {
+	c := testArticleCache()
+	c.Settle = time.Hour
+	backing := testGraphQLStore()
+	store := c.Wrap(backing)
+
+	_, err := store.Get(1)
+	assert.NoError(t, err)
+	_, err = store.Get(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, backing.calls["Get"], "reads long after the last invalidation must be cached")
+
+	assert.NoError(t, store.Update(1, &models.Article{Title: "Updated"}))
+	for i := 0; i < 2; i++ {
+		_, err := store.Get(1)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, backing.calls["Get"], "reads right after a write may come from a lagging replica and must not be cached")
+}
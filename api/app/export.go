@@ -0,0 +1,187 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/jobs"
+	"github.com/ykaseng/articles-library/models"
+	"github.com/ykaseng/articles-library/transfer"
+)
+
+// The list of error types returned from export resource.
+var (
+	ErrExportNotCompleted = errors.New("export is not completed yet")
+	ErrExportExpired      = errors.New("export has expired")
+)
+
+// exportAttempts is the number of attempts of the job writing an export.
+const exportAttempts = 3
+
+// exportContentTypes maps export formats to the content type of their download.
+var exportContentTypes = map[string]string{
+	transfer.FormatJSONL:       "application/x-ndjson",
+	transfer.FormatCSV:         "text/csv; charset=utf-8",
+	transfer.FormatMarkdownZip: "application/zip",
+}
+
+// ExportStore defines database operations for bulk exports.
+type ExportStore interface {
+	Create(e *models.Export, args func(id int64) jobs.Args, opts jobs.Options) error
+	Get(id int64) (*models.Export, error)
+}
+
+// ExportResource implements bulk export handler. Exports are written by a background job into Dir,
+// which therefore has to be shared with every worker.
+type ExportResource struct {
+	Store ExportStore
+	Dir   string
+}
+
+// NewExportResource creates and returns an export resource serving artifacts from dir.
+func NewExportResource(store ExportStore, dir string) *ExportResource {
+	return &ExportResource{
+		Store: store,
+		Dir:   dir,
+	}
+}
+
+func (rs *ExportResource) router() *chi.Mux {
+	r := chi.NewRouter()
+	r.Post("/", rs.post)
+	r.Route("/{exportID}", func(r chi.Router) {
+		r.Get("/", rs.get)
+		r.Get("/download", rs.download)
+	})
+	return r
+}
+
+type exportResponse struct {
+	Status
+	Data *models.Export `json:"data"`
+}
+
+type postExportRequest struct {
+	Format string               `json:"format" openapi:"enum=jsonl|csv|markdown-zip"`
+	Filter models.ArticleFilter `json:"filter,omitempty"`
+}
+
+// post creates an export of the articles matching the filter and enqueues the job writing it.
+func (rs *ExportResource) post(w http.ResponseWriter, r *http.Request) {
+	data := &postExportRequest{}
+	if err := render.DecodeJSON(r.Body, data); err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	export := &models.Export{Format: data.Format, Author: data.Filter.Author, Tag: data.Filter.Tag}
+	if err := export.Validate(); err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	// a failed attempt is reported on the export until the export is written again by a retry
+	args := func(id int64) jobs.Args { return transfer.ExportArticles{ExportID: id} }
+	if err := rs.Store.Create(export, args, jobs.Options{MaxAttempts: exportAttempts}); err != nil {
+		render.Render(w, r, ErrUnprocessableEntity(err))
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/exports/%d", export.ID))
+	render.Status(r, http.StatusAccepted)
+	render.Respond(w, r, &exportResponse{
+		Status: Status{
+			Code:    http.StatusAccepted,
+			Message: "SUCCESS",
+		},
+		Data: export,
+	})
+}
+
+// get responds with the status and progress of an export.
+func (rs *ExportResource) get(w http.ResponseWriter, r *http.Request) {
+	export, ok := rs.export(w, r)
+	if !ok {
+		return
+	}
+
+	render.Respond(w, r, &exportResponse{
+		Status: Status{
+			Code:    http.StatusOK,
+			Message: "SUCCESS",
+		},
+		Data: export,
+	})
+}
+
+// download serves the artifact file of a completed export.
+func (rs *ExportResource) download(w http.ResponseWriter, r *http.Request) {
+	export, ok := rs.export(w, r)
+	if !ok {
+		return
+	}
+
+	switch export.Status {
+	case models.ExportCompleted:
+	case models.ExportExpired:
+		render.Render(w, r, &ErrResponse{Status: Status{Code: http.StatusGone, Message: ErrExportExpired.Error()}})
+		return
+	default:
+		render.Render(w, r, &ErrResponse{Status: Status{Code: http.StatusConflict, Message: ErrExportNotCompleted.Error()}})
+		return
+	}
+
+	path := transfer.ArtifactPath(rs.Dir, export)
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) && (export.ExpiresAt == nil || export.ExpiresAt.Before(time.Now())) {
+			render.Render(w, r, &ErrResponse{Status: Status{Code: http.StatusGone, Message: ErrExportExpired.Error()}})
+			return
+		}
+		if os.IsNotExist(err) {
+			// the export was written by a worker which does not share export_dir with this server
+			log(r).WithField("export_id", export.ID).Errorf("artifact %s of completed export is missing, export_dir must be shared by all servers and workers", path)
+		}
+		render.Render(w, r, ErrInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", exportContentTypes[export.Format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filepath.Base(path)))
+	var modified time.Time
+	if export.FinishedAt != nil {
+		modified = *export.FinishedAt
+	}
+	http.ServeContent(w, r, "", modified, f)
+}
+
+// export loads the export of the request and renders an error response when it cannot.
+func (rs *ExportResource) export(w http.ResponseWriter, r *http.Request) (*models.Export, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "exportID"), 10, 64)
+	if err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return nil, false
+	}
+
+	export, err := rs.Store.Get(id)
+	if err != nil {
+		if err == database.ErrExportNotFound {
+			render.Render(w, r, &ErrResponse{Status: Status{Code: http.StatusNotFound, Message: err.Error()}})
+			return nil, false
+		}
+		render.Render(w, r, ErrUnprocessableEntity(err))
+		return nil, false
+	}
+
+	return export, true
+}
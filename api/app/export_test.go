@@ -0,0 +1,175 @@
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/jobs"
+	"github.com/ykaseng/articles-library/logging"
+	"github.com/ykaseng/articles-library/models"
+	"github.com/ykaseng/articles-library/transfer"
+)
+
+type memoryExportStore struct {
+	exports []models.Export
+	args    []jobs.Args
+	opts    []jobs.Options
+}
+
+func (s *memoryExportStore) Create(e *models.Export, args func(id int64) jobs.Args, opts jobs.Options) error {
+	e.ID = int64(len(s.exports) + 1)
+	e.Status = models.ExportPending
+	e.JobID = 7
+	s.exports = append(s.exports, *e)
+	s.args = append(s.args, args(e.ID))
+	s.opts = append(s.opts, opts)
+	return nil
+}
+
+func (s *memoryExportStore) Get(id int64) (*models.Export, error) {
+	for _, e := range s.exports {
+		if e.ID == id {
+			return &e, nil
+		}
+	}
+	return nil, database.ErrExportNotFound
+}
+
+func TestExport(t *testing.T) {
+	finished := time.Date(2019, 10, 1, 9, 30, 0, 0, time.UTC)
+	expires := time.Now().Add(time.Hour)
+
+	tt := []struct {
+		name     string
+		method   string
+		endpoint string
+		body     string
+		code     int
+		header   map[string]string
+		contains []string
+	}{
+		{
+			name:     "create",
+			method:   "POST",
+			endpoint: "/",
+			body:     `{"format": "csv", "filter": {"author": "Test Author"}}`,
+			code:     http.StatusAccepted,
+			header:   map[string]string{"Location": "/exports/6"},
+			contains: []string{`"id":6`, `"job_id":7`, `"status":"pending"`, `"author":"Test Author"`},
+		},
+		{
+			name:     "create unknown format",
+			method:   "POST",
+			endpoint: "/",
+			body:     `{"format": "xml"}`,
+			code:     http.StatusBadRequest,
+			contains: []string{"format: must be jsonl, csv or markdown-zip."},
+		},
+		{
+			name:     "get running",
+			method:   "GET",
+			endpoint: "/2",
+			code:     http.StatusOK,
+			contains: []string{`"status":"running"`, `"exported":1000`, `"total":2500`},
+		},
+		{
+			name:     "get missing",
+			method:   "GET",
+			endpoint: "/9",
+			code:     http.StatusNotFound,
+			contains: []string{"export not found"},
+		},
+		{
+			name:     "download",
+			method:   "GET",
+			endpoint: "/1/download",
+			code:     http.StatusOK,
+			header: map[string]string{
+				"Content-Type":        "application/x-ndjson",
+				"Content-Disposition": `attachment; filename="export-1.jsonl"`,
+				"Last-Modified":       "Tue, 01 Oct 2019 09:30:00 GMT",
+			},
+			contains: []string{`{"id":1}`},
+		},
+		{
+			name:     "download running",
+			method:   "GET",
+			endpoint: "/2/download",
+			code:     http.StatusConflict,
+			contains: []string{ErrExportNotCompleted.Error()},
+		},
+		{
+			name:     "download expired",
+			method:   "GET",
+			endpoint: "/3/download",
+			code:     http.StatusGone,
+			contains: []string{ErrExportExpired.Error()},
+		},
+		{
+			name:     "download missing file",
+			method:   "GET",
+			endpoint: "/4/download",
+			code:     http.StatusGone,
+		},
+		{
+			name:     "download file missing before expiry",
+			method:   "GET",
+			endpoint: "/5/download",
+			code:     http.StatusInternalServerError,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "exports")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "export-1.jsonl"), []byte(`{"id":1}`+"\n"), 0644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &memoryExportStore{
+				exports: []models.Export{
+					{ID: 1, Format: transfer.FormatJSONL, Status: models.ExportCompleted, Exported: 1, Total: 1, FinishedAt: &finished},
+					{ID: 2, Format: transfer.FormatCSV, Status: models.ExportRunning, Exported: 1000, Total: 2500},
+					{ID: 3, Format: transfer.FormatCSV, Status: models.ExportExpired},
+					{ID: 4, Format: transfer.FormatMarkdownZip, Status: models.ExportCompleted, FinishedAt: &finished},
+					{ID: 5, Format: transfer.FormatCSV, Status: models.ExportCompleted, FinishedAt: &finished, ExpiresAt: &expires},
+				},
+			}
+			rs := NewExportResource(store, dir)
+
+			r := httptest.NewRequest(tc.method, tc.endpoint, strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			router := chi.NewRouter()
+			router.Use(logging.NewStructuredLogger(logging.NewLogger()))
+			router.Mount("/", rs.router())
+			router.ServeHTTP(rec, r)
+
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+			for k, v := range tc.header {
+				assert.Equal(t, v, rec.Header().Get(k))
+			}
+			for _, s := range tc.contains {
+				assert.Contains(t, rec.Body.String(), s)
+			}
+			if tc.method == "POST" && tc.code == http.StatusAccepted {
+				assert.Equal(t, []jobs.Args{transfer.ExportArticles{ExportID: 6}}, store.args)
+				assert.Equal(t, []jobs.Options{{MaxAttempts: exportAttempts}}, store.opts)
+			}
+		})
+	}
+}
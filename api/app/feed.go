@@ -0,0 +1,205 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/spf13/viper"
+
+	"github.com/ykaseng/articles-library/content"
+	"github.com/ykaseng/articles-library/feed"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// FeedStore defines database operations for feeds.
+type FeedStore interface {
+	Recent(filter models.ArticleFilter, limit int) (*[]models.Article, error)
+}
+
+// FeedResource implements Atom, RSS and JSON Feed syndication of articles.
+type FeedResource struct {
+	Store    FeedStore
+	Renderer *content.Renderer
+
+	Title string
+	// Length is the maximum number of articles in a feed.
+	Length int
+	// FullContent includes rendered article content instead of a plain text excerpt.
+	FullContent   bool
+	ExcerptLength int
+	// BaseURL prefixes links in feeds, the request host is used when empty.
+	BaseURL string
+}
+
+// NewFeedResource creates and returns a feed resource configured from viper.
+func NewFeedResource(store FeedStore, renderer *content.Renderer) *FeedResource {
+	rs := &FeedResource{
+		Store:         store,
+		Renderer:      renderer,
+		Title:         viper.GetString("feed_title"),
+		Length:        viper.GetInt("feed_length"),
+		FullContent:   viper.GetBool("feed_full_content"),
+		ExcerptLength: viper.GetInt("feed_excerpt_length"),
+		BaseURL:       strings.TrimSuffix(viper.GetString("feed_base_url"), "/"),
+	}
+
+	if rs.Title == "" {
+		rs.Title = "Articles Library"
+	}
+	if rs.Length <= 0 {
+		rs.Length = 20
+	}
+	if rs.ExcerptLength <= 0 {
+		rs.ExcerptLength = 280
+	}
+
+	return rs
+}
+
+func (rs *FeedResource) router() *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/articles.{format:atom|rss|json}", rs.articles)
+	r.Get("/authors/{author}/articles.{format:atom|rss|json}", rs.articles)
+	r.Get("/tags/{tag}/articles.{format:atom|rss|json}", rs.articles)
+	return r
+}
+
+func (rs *FeedResource) articles(w http.ResponseWriter, r *http.Request) {
+	format := chi.URLParam(r, "format")
+	filter := models.ArticleFilter{
+		Author: chi.URLParam(r, "author"),
+		Tag:    chi.URLParam(r, "tag"),
+	}
+
+	articles, err := rs.Store.Recent(filter, rs.Length)
+	if err != nil {
+		render.Render(w, r, ErrUnprocessableEntity(err))
+		return
+	}
+
+	f, err := rs.feed(r, filter, *articles)
+	if err != nil {
+		render.Render(w, r, ErrUnprocessableEntity(err))
+		return
+	}
+
+	b, err := feed.Encode(format, f)
+	if err != nil {
+		render.Render(w, r, ErrUnprocessableEntity(err))
+		return
+	}
+
+	sum := sha256.Sum256(b)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	lastModified := f.Updated.UTC().Truncate(time.Second)
+
+	surrogateKeys(w, surrogateKeyArticles)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", feed.ContentType(format))
+	w.Write(b)
+}
+
+func (rs *FeedResource) feed(r *http.Request, filter models.ArticleFilter, articles []models.Article) (*feed.Feed, error) {
+	base := rs.BaseURL
+	if base == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+
+	title := rs.Title
+	switch {
+	case filter.Author != "":
+		title = fmt.Sprintf("%s: articles by %s", rs.Title, filter.Author)
+	case filter.Tag != "":
+		title = fmt.Sprintf("%s: articles tagged %s", rs.Title, filter.Tag)
+	}
+
+	f := &feed.Feed{
+		Title:   title,
+		Link:    base + "/articles",
+		FeedURL: base + r.URL.EscapedPath(),
+		// an empty feed has a fixed update time so that its ETag stays stable
+		Updated: time.Unix(0, 0),
+	}
+
+	for _, a := range articles {
+		html, err := rs.Renderer.Render(a.ContentFormat, a.Content)
+		if err != nil {
+			return nil, err
+		}
+
+		url := fmt.Sprintf("%s/articles/%s", base, a.PublicID)
+		item := feed.Item{
+			ID:     url,
+			URL:    url,
+			Title:  a.Title,
+			Author: a.Author,
+			Tags:   a.Tags,
+		}
+
+		if a.UpdatedAt != nil {
+			item.Updated = *a.UpdatedAt
+		}
+		switch {
+		case a.PublishedAt != nil:
+			item.Published = *a.PublishedAt
+		case a.CreatedAt != nil:
+			item.Published = *a.CreatedAt
+		default:
+			item.Published = item.Updated
+		}
+
+		if rs.FullContent {
+			item.Content = html
+		} else {
+			item.Summary = content.Excerpt(html, rs.ExcerptLength)
+		}
+
+		if item.Updated.After(f.Updated) {
+			f.Updated = item.Updated
+		}
+		f.Items = append(f.Items, item)
+	}
+
+	return f, nil
+}
+
+// notModified reports whether a conditional GET matches the current ETag or modification time.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Header.Get("If-None-Match") != "" {
+		return etagMatches(r, etag)
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lastModified.After(t)
+	}
+
+	return false
+}
+
+// etagMatches reports whether the If-None-Match header of a request names etag, comparing weakly.
+func etagMatches(r *http.Request, etag string) bool {
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+	return false
+}
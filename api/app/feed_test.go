@@ -0,0 +1,133 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/content"
+	"github.com/ykaseng/articles-library/models"
+)
+
+type memoryFeedStore struct {
+	articles []models.Article
+	filter   models.ArticleFilter
+}
+
+func (s *memoryFeedStore) Recent(filter models.ArticleFilter, limit int) (*[]models.Article, error) {
+	s.filter = filter
+	articles := s.articles
+	if len(articles) > limit {
+		articles = articles[:limit]
+	}
+	return &articles, nil
+}
+
+func TestFeed(t *testing.T) {
+	updated := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	store := &memoryFeedStore{
+		articles: []models.Article{
+			{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "Test Title", Content: "Test *Content*", ContentFormat: content.FormatMarkdown, Author: "Test Author", Tags: []string{"go"}, CreatedAt: &updated, UpdatedAt: &updated},
+		},
+	}
+
+	tt := []struct {
+		name        string
+		endpoint    string
+		full        bool
+		filter      models.ArticleFilter
+		contentType string
+		contains    []string
+	}{
+		{
+			name:        "atom excerpt",
+			endpoint:    "/articles.atom",
+			contentType: "application/atom+xml; charset=utf-8",
+			contains:    []string{"<feed", "<id>http://example.com/articles/01ARZ3NDEKTSV4RRFFQ69G5F01</id>", `<summary type="text">Test Content</summary>`, "2020-01-02T03:04:05Z"},
+		},
+		{
+			name:        "rss full content",
+			endpoint:    "/articles.rss",
+			full:        true,
+			contentType: "application/rss+xml; charset=utf-8",
+			contains:    []string{"<rss", "<link>http://example.com/articles/01ARZ3NDEKTSV4RRFFQ69G5F01</link>", "&lt;em&gt;Content&lt;/em&gt;"},
+		},
+		{
+			name:        "json feed by author",
+			endpoint:    "/authors/Test%20Author/articles.json",
+			filter:      models.ArticleFilter{Author: "Test Author"},
+			contentType: "application/feed+json; charset=utf-8",
+			contains:    []string{`"version": "https://jsonfeed.org/version/1.1"`, "articles by Test Author"},
+		},
+		{
+			name:        "atom by tag",
+			endpoint:    "/tags/go/articles.atom",
+			filter:      models.ArticleFilter{Tag: "go"},
+			contentType: "application/atom+xml; charset=utf-8",
+			contains:    []string{"articles tagged go"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rs := NewFeedResource(store, content.NewRenderer(10))
+			rs.FullContent = tc.full
+
+			r := httptest.NewRequest("GET", "http://example.com"+tc.endpoint, nil)
+			rec := httptest.NewRecorder()
+			rs.router().ServeHTTP(rec, r)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tc.filter, store.filter)
+			assert.Equal(t, tc.contentType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, updated.Format(http.TimeFormat), rec.Header().Get("Last-Modified"))
+			for _, s := range tc.contains {
+				assert.True(t, strings.Contains(rec.Body.String(), s), "expected body to contain %q:\n%s", s, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestFeedConditionalGet(t *testing.T) {
+	updated := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	store := &memoryFeedStore{
+		articles: []models.Article{
+			{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "Test Title", Content: "Test Content", Author: "Test Author", CreatedAt: &updated, UpdatedAt: &updated},
+		},
+	}
+	rs := NewFeedResource(store, content.NewRenderer(10))
+
+	rec := httptest.NewRecorder()
+	rs.router().ServeHTTP(rec, httptest.NewRequest("GET", "/articles.atom", nil))
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	tt := []struct {
+		name     string
+		header   string
+		value    string
+		expected int
+	}{
+		{name: "matching etag", header: "If-None-Match", value: etag, expected: http.StatusNotModified},
+		{name: "weak matching etag", header: "If-None-Match", value: `"other", W/` + etag, expected: http.StatusNotModified},
+		{name: "stale etag", header: "If-None-Match", value: `"other"`, expected: http.StatusOK},
+		{name: "not modified since", header: "If-Modified-Since", value: updated.Format(http.TimeFormat), expected: http.StatusNotModified},
+		{name: "modified since", header: "If-Modified-Since", value: updated.Add(-time.Hour).Format(http.TimeFormat), expected: http.StatusOK},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/articles.atom", nil)
+			r.Header.Set(tc.header, tc.value)
+			rec := httptest.NewRecorder()
+			rs.router().ServeHTTP(rec, r)
+
+			assert.Equal(t, tc.expected, rec.Code)
+			assert.Equal(t, etag, rec.Header().Get("ETag"))
+		})
+	}
+}
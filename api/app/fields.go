@@ -0,0 +1,128 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// includable are the relations of articles which can be embedded with ?include=.
+var includable = []string{"author", "tags"}
+
+// listedArticle is an article of a list holding only the selected fields. An included author is
+// embedded as a models.Author and included tags as a list of models.Tag in place of their names.
+type listedArticle struct {
+	ID            models.PublicID `json:"id,omitempty" openapi:"readOnly"`
+	Title         string          `json:"title,omitempty"`
+	Content       string          `json:"content,omitempty"`
+	Author        interface{}     `json:"author,omitempty"`
+	Slug          string          `json:"slug,omitempty"`
+	ContentFormat string          `json:"content_format,omitempty" openapi:"enum=plain|markdown|html"`
+	Tags          interface{}     `json:"tags,omitempty"`
+	PublishedAt   *time.Time      `json:"published_at,omitempty"`
+}
+
+// parseInclude parses a comma separated list of the relations to embed.
+func parseInclude(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var include []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if !contains(includable, name) {
+			return nil, fmt.Errorf("unknown include %q, relations are %s", name, strings.Join(includable, ", "))
+		}
+		if !contains(include, name) {
+			include = append(include, name)
+		}
+	}
+	return include, nil
+}
+
+// list returns the given fields of articles, embedding the included relations. The authors and tags
+// of all articles are loaded with one query each, from the same database as the articles of r.
+func (rs *ArticleResource) list(r *http.Request, articles []models.Article, fields models.ArticleFields, include []string) ([]listedArticle, error) {
+	authors := map[string]models.Author{}
+	if contains(include, "author") {
+		var names []string
+		for _, a := range articles {
+			if _, ok := authors[a.Author]; !ok {
+				authors[a.Author] = models.Author{Name: a.Author}
+				names = append(names, a.Author)
+			}
+		}
+		found, err := rs.reads(r).GetAuthors(names)
+		if err != nil {
+			return nil, err
+		}
+		for _, author := range found {
+			authors[author.Name] = author
+		}
+	}
+
+	tags := map[string]models.Tag{}
+	if contains(include, "tags") {
+		var names []string
+		for _, a := range articles {
+			for _, tag := range a.Tags {
+				if _, ok := tags[tag]; !ok {
+					tags[tag] = models.Tag{Name: tag}
+					names = append(names, tag)
+				}
+			}
+		}
+		found, err := rs.reads(r).GetTags(names)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range found {
+			tags[tag.Name] = tag
+		}
+	}
+
+	var listed []listedArticle
+	for _, a := range articles {
+		l := listedArticle{}
+		if fields.Has("id") {
+			l.ID = a.PublicID
+		}
+		if fields.Has("title") {
+			l.Title = a.Title
+		}
+		if fields.Has("content") {
+			l.Content = a.Content
+		}
+		if fields.Has("author") {
+			l.Author = a.Author
+			if contains(include, "author") {
+				l.Author = authors[a.Author]
+			}
+		}
+		if fields.Has("slug") {
+			l.Slug = a.Slug
+		}
+		if fields.Has("content_format") {
+			l.ContentFormat = a.ContentFormat
+		}
+		if fields.Has("tags") && len(a.Tags) > 0 {
+			l.Tags = a.Tags
+			if contains(include, "tags") {
+				embedded := make([]models.Tag, len(a.Tags))
+				for i, tag := range a.Tags {
+					embedded[i] = tags[tag]
+				}
+				l.Tags = embedded
+			}
+		}
+		if fields.Has("published_at") {
+			l.PublishedAt = a.PublishedAt
+		}
+		listed = append(listed, l)
+	}
+	return listed, nil
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+func TestListFields(t *testing.T) {
+	tt := []struct {
+		name     string
+		endpoint string
+		code     int
+		loaded   models.ArticleFields
+		body     string
+	}{
+		{"all fields", "/articles?limit=1", http.StatusOK, nil, `{"status":200,"mesage":"SUCCESS","data":[{"id":"01ARZ3NDEKTSV4RRFFQ69G5F01","title":"First Title","content":"*Test Content*","author":"Test Author","content_format":"markdown","tags":["go","web"]}],"next":"YXJ0aWNsZTowMUFSWjNOREVLVFNWNFJSRkZRNjlHNUYwMQ"}`},
+		{"fields", "/articles?limit=1&fields=title,%20author", http.StatusOK, models.ArticleFields{"title", "author"}, `{"status":200,"mesage":"SUCCESS","data":[{"title":"First Title","author":"Test Author"}],"next":"YXJ0aWNsZTowMUFSWjNOREVLVFNWNFJSRkZRNjlHNUYwMQ"}`},
+		{"fields in any order", "/articles?q=Second&fields=author,id,author", http.StatusOK, models.ArticleFields{"id", "author"}, `{"status":200,"mesage":"SUCCESS","data":[{"id":"01ARZ3NDEKTSV4RRFFQ69G5F02","author":"Other Author"}]}`},
+		{"rendered content", "/articles?limit=1&fields=content&render=html", http.StatusOK, models.ArticleFields{"content", "content_format"}, `{"status":200,"mesage":"SUCCESS","data":[{"content":"<p><em>Test Content</em></p>\n"}],"next":"YXJ0aWNsZTowMUFSWjNOREVLVFNWNFJSRkZRNjlHNUYwMQ"}`},
+		{"include author", "/articles?limit=1&fields=title&include=author", http.StatusOK, models.ArticleFields{"title", "author"}, `{"status":200,"mesage":"SUCCESS","data":[{"title":"First Title","author":{"name":"Test Author","articles":2}}],"next":"YXJ0aWNsZTowMUFSWjNOREVLVFNWNFJSRkZRNjlHNUYwMQ"}`},
+		{"include tags", "/articles?author=Test%20Author&fields=id&include=tags", http.StatusOK, models.ArticleFields{"id", "tags"}, `{"status":200,"mesage":"SUCCESS","data":[{"id":"01ARZ3NDEKTSV4RRFFQ69G5F01","tags":[{"name":"go","articles":2},{"name":"web","articles":1}]},{"id":"01ARZ3NDEKTSV4RRFFQ69G5F03","tags":[{"name":"go","articles":2}]}]}`},
+		{"unknown field", "/articles?limit=1&fields=title,body", http.StatusBadRequest, nil, `{"status":400,"mesage":"unknown field \"body\", fields are id, title, content, author, slug, content_format, tags, published_at","data":null}`},
+		{"unknown include", "/articles?limit=1&include=comments", http.StatusBadRequest, nil, `{"status":400,"mesage":"unknown include \"comments\", relations are author, tags","data":null}`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &publicArticleStore{articles: []models.Article{
+				{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "First Title", Content: "*Test Content*", ContentFormat: "markdown", Author: "Test Author", Tags: []string{"go", "web"}},
+				{ArticleID: models.ArticleID{ID: 2, PublicID: testPublicID(2)}, Title: "Second Title", Content: "Test Content", Author: "Other Author"},
+				{ArticleID: models.ArticleID{ID: 3, PublicID: testPublicID(3)}, Title: "Third Title", Content: "Test Content", Author: "Test Author", Tags: []string{"go"}},
+			}}
+			r := chi.NewRouter()
+			r.Mount("/articles", NewArticleResource(store).router())
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest("GET", tc.endpoint, nil))
+
+			assert.Equal(t, tc.code, rec.Code)
+			assert.JSONEq(t, tc.body, rec.Body.String())
+			assert.Equal(t, tc.loaded, store.fields, "only the selected fields must be loaded")
+		})
+	}
+}
@@ -0,0 +1,360 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/spf13/viper"
+
+	"github.com/ykaseng/articles-library/content"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// The list of error types returned from GraphQL resource.
+var (
+	ErrNoOperation    = errors.New("must provide an operation")
+	ErrMutationMethod = errors.New("mutations must use POST")
+)
+
+// GraphQLStore defines database operations for the GraphQL API.
+type GraphQLStore interface {
+	ArticleStore
+	GetMany(ids []models.PublicID) (*[]models.Article, error)
+	ByAuthors(names []string, after models.PublicID, limit int) (*[]models.Article, error)
+	Authors(names []string) ([]string, error)
+}
+
+// GraphQLResource implements the GraphQL endpoint for articles and authors.
+type GraphQLResource struct {
+	Store GraphQLStore
+	// Primary serves the queries of clients which must see their own writes, see ReadYourWrites.
+	// Store serves them when it is nil.
+	Primary  GraphQLStore
+	Renderer *content.Renderer
+	Schema   graphql.Schema
+
+	// MaxDepth and MaxComplexity reject expensive queries before they are executed.
+	MaxDepth      int
+	MaxComplexity int
+	// PageSize is the default and MaxPageSize the maximum value of first on connections.
+	PageSize    int
+	MaxPageSize int
+	// GraphiQL serves the GraphiQL IDE to browsers requesting the endpoint.
+	GraphiQL bool
+}
+
+// NewGraphQLResource creates and returns a GraphQL resource configured from viper.
+func NewGraphQLResource(store GraphQLStore, renderer *content.Renderer) (*GraphQLResource, error) {
+	rs := &GraphQLResource{
+		Store:         store,
+		Renderer:      renderer,
+		MaxDepth:      viper.GetInt("graphql_max_depth"),
+		MaxComplexity: viper.GetInt("graphql_max_complexity"),
+		PageSize:      viper.GetInt("graphql_page_size"),
+		MaxPageSize:   viper.GetInt("graphql_max_page_size"),
+		GraphiQL:      viper.GetBool("graphql_graphiql"),
+	}
+
+	if rs.MaxDepth <= 0 {
+		rs.MaxDepth = 10
+	}
+	if rs.MaxComplexity <= 0 {
+		rs.MaxComplexity = 1000
+	}
+	if rs.MaxPageSize <= 0 {
+		rs.MaxPageSize = 100
+	}
+	if rs.PageSize <= 0 {
+		rs.PageSize = 20
+	}
+	if rs.PageSize > rs.MaxPageSize {
+		rs.PageSize = rs.MaxPageSize
+	}
+
+	schema, err := rs.schema()
+	if err != nil {
+		return nil, err
+	}
+	rs.Schema = schema
+
+	return rs, nil
+}
+
+// reads returns the store serving the queries of a request.
+func (rs *GraphQLResource) reads(r *http.Request) GraphQLStore {
+	if rs.Primary != nil && readsPrimary(r) {
+		return rs.Primary
+	}
+	return rs.Store
+}
+
+// writes returns the store resolving the articles to mutate, which must not lag behind the primary.
+func (rs *GraphQLResource) writes() GraphQLStore {
+	if rs.Primary != nil {
+		return rs.Primary
+	}
+	return rs.Store
+}
+
+func (rs *GraphQLResource) router() *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/", rs.query)
+	r.Post("/", rs.query)
+	return r
+}
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type ctxKey int
+
+const loadersCtxKey ctxKey = 0
+
+func (rs *GraphQLResource) query(w http.ResponseWriter, r *http.Request) {
+	var req graphQLRequest
+	if r.Method == http.MethodGet {
+		if rs.GraphiQL && r.URL.Query().Get("query") == "" && strings.Contains(r.Header.Get("Accept"), "text/html") {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(graphiQLPage))
+			return
+		}
+
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				rs.reject(w, r, gqlerrors.FormatErrors(err))
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		rs.reject(w, r, gqlerrors.FormatErrors(err))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		rs.reject(w, r, gqlerrors.FormatErrors(err))
+		return
+	}
+
+	if vr := graphql.ValidateDocument(&rs.Schema, doc, nil); !vr.IsValid {
+		rs.reject(w, r, vr.Errors)
+		return
+	}
+
+	op := operation(doc, req.OperationName)
+	if op == nil {
+		rs.reject(w, r, gqlerrors.FormatErrors(ErrNoOperation))
+		return
+	}
+
+	if err := rs.checkLimits(doc, op, req.Variables); err != nil {
+		rs.reject(w, r, gqlerrors.FormatErrors(err))
+		return
+	}
+
+	// mutations are only allowed over POST so that they cannot be triggered by links
+	if r.Method == http.MethodGet && op.Operation == ast.OperationTypeMutation {
+		w.Header().Set("Allow", http.MethodPost)
+		render.Status(r, http.StatusMethodNotAllowed)
+		render.JSON(w, r, &graphql.Result{Errors: gqlerrors.FormatErrors(ErrMutationMethod)})
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), loadersCtxKey, newLoaders(rs.reads(r)))
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        rs.Schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+
+	render.JSON(w, r, result)
+}
+
+// reject responds with 400 Bad Request to a query which cannot be executed.
+func (rs *GraphQLResource) reject(w http.ResponseWriter, r *http.Request, errs []gqlerrors.FormattedError) {
+	render.Status(r, http.StatusBadRequest)
+	render.JSON(w, r, &graphql.Result{Errors: errs})
+}
+
+// checkLimits returns an error if the depth or complexity of the executed operation exceed the
+// configured limits. Introspection fields are not counted so that tools such as GraphiQL work.
+func (rs *GraphQLResource) checkLimits(doc *ast.Document, op *ast.OperationDefinition, variables map[string]interface{}) error {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		if f, ok := def.(*ast.FragmentDefinition); ok {
+			fragments[f.Name.Value] = f
+		}
+	}
+
+	defaults := make(map[string]ast.Value)
+	for _, def := range op.VariableDefinitions {
+		if def.DefaultValue != nil {
+			defaults[def.Variable.Name.Value] = def.DefaultValue
+		}
+	}
+
+	c := &limitCounter{rs: rs, fragments: fragments, variables: variables, defaults: defaults}
+	depth, complexity := c.count(op.SelectionSet, 0)
+	if depth > rs.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, rs.MaxDepth)
+	}
+	if complexity > rs.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, rs.MaxComplexity)
+	}
+
+	return nil
+}
+
+// operation returns the operation of a document to execute, or nil if there is no such operation.
+func operation(doc *ast.Document, name string) *ast.OperationDefinition {
+	for _, def := range doc.Definitions {
+		if op, ok := def.(*ast.OperationDefinition); ok {
+			if name == "" || (op.Name != nil && op.Name.Value == name) {
+				return op
+			}
+		}
+	}
+	return nil
+}
+
+type limitCounter struct {
+	rs        *GraphQLResource
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	// defaults holds the default values of the variables of the operation
+	defaults map[string]ast.Value
+	// visiting guards against fragment cycles, which validation already rejects
+	visiting map[string]bool
+}
+
+// count returns the depth and complexity of a selection set. Every field costs one, and the fields
+// below a connection cost as many times as the connection returns articles.
+func (c *limitCounter) count(set *ast.SelectionSet, depth int) (int, int) {
+	if set == nil {
+		return depth, 0
+	}
+
+	maxDepth, complexity := depth, 0
+	for _, sel := range set.Selections {
+		var d, n int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(sel.Name.Value, "__") {
+				continue
+			}
+			d, n = c.count(sel.SelectionSet, depth+1)
+			n = 1 + n*c.multiplier(sel)
+		case *ast.InlineFragment:
+			d, n = c.count(sel.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			f, ok := c.fragments[sel.Name.Value]
+			if !ok || c.visiting[sel.Name.Value] {
+				continue
+			}
+			if c.visiting == nil {
+				c.visiting = make(map[string]bool)
+			}
+			c.visiting[sel.Name.Value] = true
+			d, n = c.count(f.SelectionSet, depth)
+			delete(c.visiting, sel.Name.Value)
+		}
+
+		if d > maxDepth {
+			maxDepth = d
+		}
+		complexity += n
+	}
+
+	return maxDepth, complexity
+}
+
+// multiplier returns the number of articles a connection field may return, or one for other fields.
+// Arguments are coerced as on execution, so that the connection is counted with the page size it
+// is executed with.
+func (c *limitCounter) multiplier(field *ast.Field) int {
+	if field.Name.Value != "articles" {
+		return 1
+	}
+
+	args := make(map[string]interface{})
+	for _, arg := range field.Arguments {
+		if arg.Name.Value == "first" {
+			args["first"] = c.intValue(arg.Value)
+		}
+	}
+	return c.rs.first(args)
+}
+
+// intValue coerces an Int argument, taking variables from the request or their default values.
+func (c *limitCounter) intValue(v ast.Value) interface{} {
+	if v, ok := v.(*ast.Variable); ok {
+		if value := c.variables[v.Name.Value]; value != nil {
+			return graphql.Int.ParseValue(value)
+		}
+		if def, ok := c.defaults[v.Name.Value]; ok {
+			return graphql.Int.ParseLiteral(def)
+		}
+		return nil
+	}
+	return graphql.Int.ParseLiteral(v)
+}
+
+// first returns the page size of a connection field from its arguments.
+func (rs *GraphQLResource) first(args map[string]interface{}) int {
+	first, ok := args["first"].(int)
+	if !ok || first <= 0 {
+		first = rs.PageSize
+	}
+	if first > rs.MaxPageSize {
+		first = rs.MaxPageSize
+	}
+	return first
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersCtxKey).(*loaders)
+}
+
+const graphiQLPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>GraphiQL</title>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@0.17.5/graphiql.min.css" />
+  <style>body { height: 100vh; margin: 0; overflow: hidden; } #graphiql { height: 100vh; }</style>
+</head>
+<body>
+  <div id="graphiql">Loading...</div>
+  <script src="https://unpkg.com/react@16.12.0/umd/react.production.min.js"></script>
+  <script src="https://unpkg.com/react-dom@16.12.0/umd/react-dom.production.min.js"></script>
+  <script src="https://unpkg.com/graphiql@0.17.5/graphiql.min.js"></script>
+  <script>
+    function fetcher(params) {
+      return fetch(window.location.pathname, {
+        method: 'POST',
+        headers: { 'Accept': 'application/json', 'Content-Type': 'application/json' },
+        body: JSON.stringify(params),
+      }).then(function (response) { return response.json(); });
+    }
+    ReactDOM.render(React.createElement(GraphiQL, { fetcher: fetcher }), document.getElementById('graphiql'));
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,278 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// ErrInvalidID is returned for an article ID argument which is not a ULID.
+var ErrInvalidID = errors.New("invalid article id")
+
+// schema builds the GraphQL schema. Article and Author refer to each other, so their fields are
+// declared as thunks.
+func (rs *GraphQLResource) schema() (graphql.Schema, error) {
+	var articleType, authorType, connectionType *graphql.Object
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "ArticleEdge",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"node":   &graphql.Field{Type: graphql.NewNonNull(articleType)},
+			}
+		}),
+	})
+
+	connectionType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "ArticleConnection",
+		Fields: graphql.Fields{
+			"edges":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType)))},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+		},
+	})
+
+	articleType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Article",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.ID),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return string(p.Source.(*models.Article).PublicID), nil
+					},
+				},
+				"title":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"content": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"contentFormat": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if f := p.Source.(*models.Article).ContentFormat; f != "" {
+							return f, nil
+						}
+						return "plain", nil
+					},
+				},
+				"html": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.String),
+					Description: "The content rendered as sanitized HTML.",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						a := p.Source.(*models.Article)
+						return rs.Renderer.Render(a.ContentFormat, a.Content)
+					},
+				},
+				"tags": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if tags := p.Source.(*models.Article).Tags; tags != nil {
+							return tags, nil
+						}
+						return []string{}, nil
+					},
+				},
+				"publishedAt": &graphql.Field{Type: graphql.DateTime},
+				"author": &graphql.Field{
+					Type: graphql.NewNonNull(authorType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return &author{Name: p.Source.(*models.Article).Author}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	authorType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Author",
+		Fields: graphql.Fields{
+			"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"articles": &graphql.Field{
+				Type: graphql.NewNonNull(connectionType),
+				Args: graphql.FieldConfigArgument{
+					"first": &graphql.ArgumentConfig{Type: graphql.Int},
+					"after": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					after, err := decodeCursor(stringArg(p.Args, "after"))
+					if err != nil {
+						return nil, err
+					}
+
+					key := authorArticlesKey{name: p.Source.(*author).Name, after: after, first: rs.first(p.Args)}
+					return loadersFrom(p.Context).authorArticles.load(key), nil
+				},
+			},
+		},
+	})
+
+	articleInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ArticleInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"title":         &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"content":       &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"author":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"contentFormat": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"tags":          &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"publishedAt":   &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"article": &graphql.Field{
+				Type: articleType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := idArg(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					return loadersFrom(p.Context).article.load(id), nil
+				},
+			},
+			"articles": &graphql.Field{
+				Type: graphql.NewNonNull(connectionType),
+				Args: graphql.FieldConfigArgument{
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String},
+					"author": &graphql.ArgumentConfig{Type: graphql.String},
+					"tag":    &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					after, err := decodeCursor(stringArg(p.Args, "after"))
+					if err != nil {
+						return nil, err
+					}
+
+					filter := models.ArticleFilter{Author: stringArg(p.Args, "author"), Tag: stringArg(p.Args, "tag")}
+					first := rs.first(p.Args)
+					articles, err := loadersFrom(p.Context).store.Page(filter, nil, after, first+1)
+					if err != nil {
+						return nil, err
+					}
+					return newConnection(*articles, first), nil
+				},
+			},
+			"author": &graphql.Field{
+				Type: authorType,
+				Args: graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadersFrom(p.Context).author.load(stringArg(p.Args, "name")), nil
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createArticle": &graphql.Field{
+				Type: graphql.NewNonNull(articleType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(articleInputType)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					article := articleInput(p.Args)
+					if err := article.Validate(); err != nil {
+						return nil, err
+					}
+
+					articleID, err := rs.Store.Post(article)
+					if err != nil {
+						return nil, err
+					}
+					article.ArticleID = *articleID
+					return article, nil
+				},
+			},
+			"updateArticle": &graphql.Field{
+				Type: articleType,
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(articleInputType)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					publicID, err := idArg(p.Args)
+					if err != nil {
+						return nil, err
+					}
+
+					article := articleInput(p.Args)
+					if err := article.Validate(); err != nil {
+						return nil, err
+					}
+
+					id, err := rs.writes().Resolve(publicID)
+					if err == nil {
+						err = rs.Store.Update(id, article)
+					}
+					if err != nil {
+						if err == database.ErrArticleNotFound {
+							return nil, nil
+						}
+						return nil, err
+					}
+					article.ArticleID = models.ArticleID{ID: id, PublicID: publicID}
+					return article, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
+
+func idArg(args map[string]interface{}) (models.PublicID, error) {
+	id, err := models.ParsePublicID(stringArg(args, "id"))
+	if err != nil {
+		return "", ErrInvalidID
+	}
+	return id, nil
+}
+
+// articleInput returns the article of an ArticleInput argument.
+func articleInput(args map[string]interface{}) *models.Article {
+	input, _ := args["input"].(map[string]interface{})
+
+	article := &models.Article{
+		Title:         stringArg(input, "title"),
+		Content:       stringArg(input, "content"),
+		Author:        stringArg(input, "author"),
+		ContentFormat: stringArg(input, "contentFormat"),
+	}
+
+	if tags, ok := input["tags"].([]interface{}); ok {
+		for _, tag := range tags {
+			if s, ok := tag.(string); ok {
+				article.Tags = append(article.Tags, s)
+			}
+		}
+	}
+
+	if t, ok := input["publishedAt"].(time.Time); ok {
+		article.PublishedAt = &t
+	}
+
+	return article
+}
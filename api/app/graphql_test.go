@@ -0,0 +1,466 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/content"
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// memoryGraphQLStore holds articles in ID order and counts the queries made against it.
+type memoryGraphQLStore struct {
+	articles []models.Article
+	calls    map[string]int
+}
+
+func newMemoryGraphQLStore(articles ...models.Article) *memoryGraphQLStore {
+	return &memoryGraphQLStore{articles: articles, calls: map[string]int{}}
+}
+
+func (s *memoryGraphQLStore) Get(id int) (*[]models.Article, error) {
+	s.calls["Get"]++
+	return s.filter(func(a models.Article) bool { return a.ID == id }, 0), nil
+}
+
+func (s *memoryGraphQLStore) GetAll(fields models.ArticleFields) (*[]models.Article, error) {
+	s.calls["GetAll"]++
+	return s.filter(func(models.Article) bool { return true }, 0), nil
+}
+
+func (s *memoryGraphQLStore) Resolve(id models.PublicID) (int, error) {
+	s.calls["Resolve"]++
+	for _, a := range s.articles {
+		if a.PublicID == id {
+			return a.ID, nil
+		}
+	}
+	return 0, database.ErrArticleNotFound
+}
+
+func (s *memoryGraphQLStore) GetMany(ids []models.PublicID) (*[]models.Article, error) {
+	s.calls["GetMany"]++
+	return s.filter(func(a models.Article) bool {
+		for _, id := range ids {
+			if a.PublicID == id {
+				return true
+			}
+		}
+		return false
+	}, 0), nil
+}
+
+func (s *memoryGraphQLStore) Page(filter models.ArticleFilter, fields models.ArticleFields, after models.PublicID, limit int) (*[]models.Article, error) {
+	s.calls["Page"]++
+	return s.filter(func(a models.Article) bool {
+		return a.PublicID > after && (filter.Author == "" || a.Author == filter.Author)
+	}, limit), nil
+}
+
+func (s *memoryGraphQLStore) ByAuthors(names []string, after models.PublicID, limit int) (*[]models.Article, error) {
+	s.calls["ByAuthors"]++
+	sort.Strings(names)
+
+	var articles []models.Article
+	for _, name := range names {
+		page := s.filter(func(a models.Article) bool { return a.PublicID > after && a.Author == name }, limit)
+		articles = append(articles, *page...)
+	}
+	return &articles, nil
+}
+
+func (s *memoryGraphQLStore) Authors(names []string) ([]string, error) {
+	s.calls["Authors"]++
+
+	var existing []string
+	for _, name := range names {
+		if len(*s.filter(func(a models.Article) bool { return a.Author == name }, 0)) > 0 {
+			existing = append(existing, name)
+		}
+	}
+	return existing, nil
+}
+
+func (s *memoryGraphQLStore) Post(article *models.Article) (*models.ArticleID, error) {
+	s.calls["Post"]++
+	a := *article
+	a.ArticleID = models.ArticleID{ID: len(s.articles) + 1, PublicID: testPublicID(len(s.articles) + 1)}
+	s.articles = append(s.articles, a)
+	return &a.ArticleID, nil
+}
+
+func (s *memoryGraphQLStore) Update(id int, article *models.Article) error {
+	s.calls["Update"]++
+	for i := range s.articles {
+		if s.articles[i].ID == id {
+			articleID := s.articles[i].ArticleID
+			s.articles[i] = *article
+			s.articles[i].ArticleID = articleID
+			return nil
+		}
+	}
+	return database.ErrArticleNotFound
+}
+
+func (s *memoryGraphQLStore) Delete(id int) error {
+	return nil
+}
+
+func (s *memoryGraphQLStore) Each(fn func(*models.Article) error) error {
+	return nil
+}
+
+func (s *memoryGraphQLStore) GetBySlug(slug string) (*models.Article, error) {
+	return nil, database.ErrArticleNotFound
+}
+
+func (s *memoryGraphQLStore) Search(query string, fields models.ArticleFields, limit int) (*[]models.Article, error) {
+	return &[]models.Article{}, nil
+}
+
+func (s *memoryGraphQLStore) Stream(ctx context.Context, filter models.ArticleFilter, fields models.ArticleFields, after models.PublicID, fn func(*models.Article) error) error {
+	return nil
+}
+
+func (s *memoryGraphQLStore) GetAuthors(names []string) ([]models.Author, error) {
+	return nil, nil
+}
+
+func (s *memoryGraphQLStore) GetTags(names []string) ([]models.Tag, error) {
+	return nil, nil
+}
+
+func (s *memoryGraphQLStore) Batch(ops []models.BatchOperation, atomic bool) ([]models.BatchResult, error) {
+	return nil, nil
+}
+
+func (s *memoryGraphQLStore) filter(match func(models.Article) bool, limit int) *[]models.Article {
+	articles := []models.Article{}
+	for _, a := range s.articles {
+		if match(a) && (limit == 0 || len(articles) < limit) {
+			articles = append(articles, a)
+		}
+	}
+	return &articles
+}
+
+func testGraphQLStore() *memoryGraphQLStore {
+	return newMemoryGraphQLStore(
+		models.Article{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "Title 1", Content: "*Content*", ContentFormat: content.FormatMarkdown, Author: "Author A", Tags: []string{"go"}},
+		models.Article{ArticleID: models.ArticleID{ID: 2, PublicID: testPublicID(2)}, Title: "Title 2", Content: "Content", Author: "Author B"},
+		models.Article{ArticleID: models.ArticleID{ID: 3, PublicID: testPublicID(3)}, Title: "Title 3", Content: "Content", Author: "Author A"},
+		models.Article{ArticleID: models.ArticleID{ID: 4, PublicID: testPublicID(4)}, Title: "Title 4", Content: "Content", Author: "Author C"},
+	)
+}
+
+func postGraphQL(t *testing.T, rs *GraphQLResource, query string, variables map[string]interface{}) (int, map[string]interface{}) {
+	b, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	rs.router().ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader(string(b))))
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("unmarshal failed: %v\n%s", err, rec.Body.String())
+	}
+	return rec.Code, result
+}
+
+func TestGraphQLQuery(t *testing.T) {
+	tt := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		expected  string
+		calls     map[string]int
+	}{
+		{
+			name:     "article",
+			query:    `{ article(id: "01ARZ3NDEKTSV4RRFFQ69G5F01") { id title contentFormat html tags author { name } } }`,
+			expected: `{"data":{"article":{"author":{"name":"Author A"},"contentFormat":"markdown","html":"\u003cp\u003e\u003cem\u003eContent\u003c/em\u003e\u003c/p\u003e\n","id":"01ARZ3NDEKTSV4RRFFQ69G5F01","tags":["go"],"title":"Title 1"}}}`,
+			calls:    map[string]int{"GetMany": 1},
+		},
+		{
+			name:     "missing article",
+			query:    `{ article(id: "01ARZ3NDEKTSV4RRFFQ69G5F09") { id } }`,
+			expected: `{"data":{"article":null}}`,
+			calls:    map[string]int{"GetMany": 1},
+		},
+		{
+			name:     "aliased articles are batched",
+			query:    `{ a: article(id: "01ARZ3NDEKTSV4RRFFQ69G5F01") { title } b: article(id: "01ARZ3NDEKTSV4RRFFQ69G5F02") { title } c: article(id: "01ARZ3NDEKTSV4RRFFQ69G5F01") { title } }`,
+			expected: `{"data":{"a":{"title":"Title 1"},"b":{"title":"Title 2"},"c":{"title":"Title 1"}}}`,
+			calls:    map[string]int{"GetMany": 1},
+		},
+		{
+			name:     "articles page",
+			query:    `{ articles(first: 2) { edges { cursor node { id } } pageInfo { hasNextPage endCursor } } }`,
+			expected: `{"data":{"articles":{"edges":[{"cursor":"YXJ0aWNsZTowMUFSWjNOREVLVFNWNFJSRkZRNjlHNUYwMQ","node":{"id":"01ARZ3NDEKTSV4RRFFQ69G5F01"}},{"cursor":"YXJ0aWNsZTowMUFSWjNOREVLVFNWNFJSRkZRNjlHNUYwMg","node":{"id":"01ARZ3NDEKTSV4RRFFQ69G5F02"}}],"pageInfo":{"endCursor":"YXJ0aWNsZTowMUFSWjNOREVLVFNWNFJSRkZRNjlHNUYwMg","hasNextPage":true}}}}`,
+			calls:    map[string]int{"Page": 1},
+		},
+		{
+			name:      "articles after cursor",
+			query:     `query ($after: String) { articles(first: 2, after: $after) { edges { node { id } } pageInfo { hasNextPage } } }`,
+			variables: map[string]interface{}{"after": "YXJ0aWNsZTowMUFSWjNOREVLVFNWNFJSRkZRNjlHNUYwMg"},
+			expected:  `{"data":{"articles":{"edges":[{"node":{"id":"01ARZ3NDEKTSV4RRFFQ69G5F03"}},{"node":{"id":"01ARZ3NDEKTSV4RRFFQ69G5F04"}}],"pageInfo":{"hasNextPage":false}}}}`,
+			calls:     map[string]int{"Page": 1},
+		},
+		{
+			name:     "authors of articles are batched",
+			query:    `{ articles { edges { node { author { articles(first: 1) { edges { node { id } } } } } } } }`,
+			expected: `{"data":{"articles":{"edges":[{"node":{"author":{"articles":{"edges":[{"node":{"id":"01ARZ3NDEKTSV4RRFFQ69G5F01"}}]}}}},{"node":{"author":{"articles":{"edges":[{"node":{"id":"01ARZ3NDEKTSV4RRFFQ69G5F02"}}]}}}},{"node":{"author":{"articles":{"edges":[{"node":{"id":"01ARZ3NDEKTSV4RRFFQ69G5F01"}}]}}}},{"node":{"author":{"articles":{"edges":[{"node":{"id":"01ARZ3NDEKTSV4RRFFQ69G5F04"}}]}}}}]}}}`,
+			calls:    map[string]int{"Page": 1, "ByAuthors": 1},
+		},
+		{
+			name:     "author",
+			query:    `{ a: author(name: "Author A") { name articles { edges { node { id } } } } b: author(name: "Nobody") { name } }`,
+			expected: `{"data":{"a":{"articles":{"edges":[{"node":{"id":"01ARZ3NDEKTSV4RRFFQ69G5F01"}},{"node":{"id":"01ARZ3NDEKTSV4RRFFQ69G5F03"}}]},"name":"Author A"},"b":null}}`,
+			calls:    map[string]int{"Authors": 1, "ByAuthors": 1},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := testGraphQLStore()
+			rs, err := NewGraphQLResource(store, content.NewRenderer(10))
+			if err != nil {
+				t.Fatalf("schema failed: %v", err)
+			}
+
+			code, result := postGraphQL(t, rs, tc.query, tc.variables)
+			actual, _ := json.Marshal(result)
+
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, tc.expected, string(actual))
+			assert.Equal(t, tc.calls, store.calls)
+		})
+	}
+}
+
+func TestGraphQLMutation(t *testing.T) {
+	tt := []struct {
+		name     string
+		query    string
+		expected string
+		articles int
+	}{
+		{
+			name:     "create",
+			query:    `mutation { createArticle(input: {title: "New Title", content: "New Content", author: "Author D", tags: ["new"], publishedAt: "2019-10-01T09:30:00Z"}) { id title author { name } tags publishedAt } }`,
+			expected: `{"data":{"createArticle":{"author":{"name":"Author D"},"id":"01ARZ3NDEKTSV4RRFFQ69G5F05","publishedAt":"2019-10-01T09:30:00Z","tags":["new"],"title":"New Title"}}}`,
+			articles: 5,
+		},
+		{
+			name:     "create invalid",
+			query:    `mutation { createArticle(input: {title: "", content: "New Content", author: "Author D"}) { id } }`,
+			expected: `title: cannot be blank.`,
+			articles: 4,
+		},
+		{
+			name:     "update",
+			query:    `mutation { updateArticle(id: "01ARZ3NDEKTSV4RRFFQ69G5F02", input: {title: "New Title", content: "New Content", author: "Author B"}) { id title } }`,
+			expected: `{"data":{"updateArticle":{"id":"01ARZ3NDEKTSV4RRFFQ69G5F02","title":"New Title"}}}`,
+			articles: 4,
+		},
+		{
+			name:     "update missing",
+			query:    `mutation { updateArticle(id: "01ARZ3NDEKTSV4RRFFQ69G5F09", input: {title: "New Title", content: "New Content", author: "Author B"}) { id } }`,
+			expected: `{"data":{"updateArticle":null}}`,
+			articles: 4,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := testGraphQLStore()
+			rs, err := NewGraphQLResource(store, content.NewRenderer(10))
+			if err != nil {
+				t.Fatalf("schema failed: %v", err)
+			}
+
+			code, result := postGraphQL(t, rs, tc.query, nil)
+			actual, _ := json.Marshal(result)
+
+			assert.Equal(t, http.StatusOK, code)
+			assert.Contains(t, string(actual), tc.expected)
+			assert.Len(t, store.articles, tc.articles)
+		})
+	}
+}
+
+func TestGraphQLReadYourWrites(t *testing.T) {
+	query := `{ article(id: "01ARZ3NDEKTSV4RRFFQ69G5F01") { id } articles(first: 1) { edges { node { id } } } }`
+	mutation := `mutation { updateArticle(id: "01ARZ3NDEKTSV4RRFFQ69G5F02", input: {title: "New Title", content: "New Content", author: "Author B"}) { id } }`
+
+	tt := []struct {
+		name    string
+		query   string
+		cookie  bool
+		replica map[string]int
+		primary map[string]int
+	}{
+		{name: "replica", query: query, replica: map[string]int{"GetMany": 1, "Page": 1}, primary: map[string]int{}},
+		{name: "after write", query: query, cookie: true, replica: map[string]int{}, primary: map[string]int{"GetMany": 1, "Page": 1}},
+		{name: "mutation", query: mutation, replica: map[string]int{"Update": 1}, primary: map[string]int{"Resolve": 1}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			replica, primary := testGraphQLStore(), testGraphQLStore()
+			rs, err := NewGraphQLResource(replica, content.NewRenderer(10))
+			if err != nil {
+				t.Fatalf("schema failed: %v", err)
+			}
+			rs.Primary = primary
+
+			b, err := json.Marshal(map[string]interface{}{"query": tc.query})
+			if err != nil {
+				t.Fatalf("marshal failed: %v", err)
+			}
+			req := httptest.NewRequest("POST", "/", strings.NewReader(string(b)))
+			if tc.cookie {
+				req.AddCookie(&http.Cookie{Name: ReadPrimaryCookie, Value: strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)})
+			}
+
+			rec := httptest.NewRecorder()
+			(&ReadYourWrites{Window: 5 * time.Second}).Handler(rs.router()).ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.NotContains(t, rec.Body.String(), "errors")
+			assert.Equal(t, tc.replica, replica.calls)
+			assert.Equal(t, tc.primary, primary.calls)
+		})
+	}
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	tt := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		code      int
+		expected  string
+	}{
+		{
+			name:  "within limits",
+			query: `{ articles(first: 10) { edges { node { title } } } }`,
+			code:  http.StatusOK,
+		},
+		{
+			name:     "too deep",
+			query:    `{ article(id: "01ARZ3NDEKTSV4RRFFQ69G5F01") { author { articles(first: 1) { edges { node { author { articles(first: 1) { edges { node { author { name } } } } } } } } } } }`,
+			code:     http.StatusBadRequest,
+			expected: "query depth 11 exceeds the maximum of 10",
+		},
+		{
+			name:     "too deep through fragments",
+			query:    `{ article(id: "01ARZ3NDEKTSV4RRFFQ69G5F01") { ...a } } fragment a on Article { author { articles(first: 1) { edges { node { author { articles(first: 1) { edges { node { author { name } } } } } } } } } }`,
+			code:     http.StatusBadRequest,
+			expected: "query depth 11 exceeds the maximum of 10",
+		},
+		{
+			name:     "too complex",
+			query:    `{ articles(first: 100) { edges { node { author { articles(first: 100) { edges { node { id } } } } } } } }`,
+			code:     http.StatusBadRequest,
+			expected: "query complexity",
+		},
+		{
+			name:      "too complex through variables",
+			query:     `query ($first: Int) { articles(first: $first) { edges { node { author { articles(first: $first) { edges { node { id } } } } } } } }`,
+			variables: map[string]interface{}{"first": 50},
+			code:      http.StatusBadRequest,
+			expected:  "query complexity",
+		},
+		{
+			name:     "too complex with the default page size",
+			query:    `{ articles(first: 0) { edges { node { author { articles(first: -1) { edges { node { id } } } } } } } }`,
+			code:     http.StatusBadRequest,
+			expected: "query complexity",
+		},
+		{
+			name:     "too complex through variable defaults",
+			query:    `query ($first: Int = 50) { articles(first: $first) { edges { node { author { articles(first: 10) { edges { node { id } } } } } } } }`,
+			code:     http.StatusBadRequest,
+			expected: "query complexity",
+		},
+		{
+			name:      "variables override defaults",
+			query:     `query ($first: Int = 50) { articles(first: $first) { edges { node { author { articles(first: 10) { edges { node { id } } } } } } } }`,
+			variables: map[string]interface{}{"first": 2},
+			code:      http.StatusOK,
+		},
+		{
+			name:  "introspection is not counted",
+			query: `{ __schema { types { name fields { name type { name ofType { name ofType { name ofType { name ofType { name } } } } } } } } }`,
+			code:  http.StatusOK,
+		},
+		{
+			name:     "invalid query",
+			query:    `{ article(id: "01ARZ3NDEKTSV4RRFFQ69G5F01") { unknown } }`,
+			code:     http.StatusBadRequest,
+			expected: `Cannot query field \"unknown\" on type \"Article\".`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rs, err := NewGraphQLResource(testGraphQLStore(), content.NewRenderer(10))
+			if err != nil {
+				t.Fatalf("schema failed: %v", err)
+			}
+
+			code, result := postGraphQL(t, rs, tc.query, tc.variables)
+			actual, _ := json.Marshal(result)
+
+			assert.Equal(t, tc.code, code, string(actual))
+			assert.Contains(t, string(actual), tc.expected)
+		})
+	}
+}
+
+func TestGraphQLGet(t *testing.T) {
+	rs, err := NewGraphQLResource(testGraphQLStore(), content.NewRenderer(10))
+	if err != nil {
+		t.Fatalf("schema failed: %v", err)
+	}
+
+	tt := []struct {
+		name     string
+		endpoint string
+		accept   string
+		graphiQL bool
+		code     int
+		expected string
+	}{
+		{name: "query", endpoint: `/?query={article(id:"01ARZ3NDEKTSV4RRFFQ69G5F01"){title}}`, code: http.StatusOK, expected: `"title":"Title 1"`},
+		{name: "mutation", endpoint: `/?query=mutation{updateArticle(id:"01ARZ3NDEKTSV4RRFFQ69G5F01",input:{title:"a",content:"b",author:"c"}){id}}`, code: http.StatusMethodNotAllowed, expected: "mutations must use POST"},
+		{name: "graphiql disabled", endpoint: "/", accept: "text/html", code: http.StatusBadRequest},
+		{name: "graphiql", endpoint: "/", accept: "text/html", graphiQL: true, code: http.StatusOK, expected: "GraphiQL"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rs.GraphiQL = tc.graphiQL
+			r := httptest.NewRequest("GET", tc.endpoint, nil)
+			r.Header.Set("Accept", tc.accept)
+			rec := httptest.NewRecorder()
+			rs.router().ServeHTTP(rec, r)
+
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+			assert.Contains(t, rec.Body.String(), tc.expected)
+		})
+	}
+}
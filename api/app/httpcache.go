@@ -0,0 +1,198 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/spf13/viper"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// surrogateKeyArticles names every list of articles, which changes with any write.
+const surrogateKeyArticles = "articles"
+
+// HTTPCache sets the caching headers of GET responses, so that clients and CDNs may cache them and
+// revalidate them with conditional requests. Cache-Control directives are chosen by route.
+type HTTPCache struct {
+	Lists    string
+	Articles string
+	Feeds    string
+	Docs     string
+	Default  string
+}
+
+// NewHTTPCache returns an HTTPCache with the Cache-Control directives configured by viper.
+func NewHTTPCache() *HTTPCache {
+	return &HTTPCache{
+		Lists:    viper.GetString("cache_control_lists"),
+		Articles: viper.GetString("cache_control_articles"),
+		Feeds:    viper.GetString("cache_control_feeds"),
+		Docs:     viper.GetString("cache_control_docs"),
+		Default:  viper.GetString("cache_control_default"),
+	}
+}
+
+// Handler sets Cache-Control and Vary on GET responses. Successful responses are given a weak ETag
+// over their body before it is compressed, and requests whose If-None-Match matches it are answered
+// with 304 Not Modified. Responses which carry their own validators, downloads, and responses which
+// are flushed as they are written are passed through without an ETag rather than held in memory.
+func (c *HTTPCache) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &cachedResponseWriter{ResponseWriter: w, cache: c, r: r}
+		next.ServeHTTP(cw, r)
+		if cw.committed {
+			return
+		}
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+
+		sum := sha256.Sum256(cw.body.Bytes())
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		c.setHeaders(w.Header(), r, cw.status)
+		w.Header().Set("ETag", "W/"+etag)
+
+		if etagMatches(r, etag) {
+			w.Header().Del("Content-Type")
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(cw.status)
+		w.Write(cw.body.Bytes())
+	})
+}
+
+// policy returns the Cache-Control directives of a route pattern.
+func (c *HTTPCache) policy(pattern string) string {
+	switch {
+	case pattern == "/articles":
+		return c.Lists
+	case pattern == "/articles/{articleID}" || pattern == "/articles/by-slug/{slug}":
+		return c.Articles
+	case strings.HasPrefix(pattern, "/feeds/"):
+		return c.Feeds
+	case pattern == "/openapi.json" || pattern == "/docs":
+		return c.Docs
+	}
+	return c.Default
+}
+
+// setHeaders sets Cache-Control and Vary on a response with the given status. Cache-Control set by
+// the handler is kept, and errors are not stored. Responses read from the primary database for a
+// client with a ReadPrimaryCookie are not stored either, since they may hold writes the replicas
+// have not seen yet, and Vary on Cookie keeps caches from serving such clients a replica response.
+func (c *HTTPCache) setHeaders(header http.Header, r *http.Request, status int) {
+	header.Add("Vary", "Accept, Accept-Encoding, Cookie")
+	// the cache runs before ReadYourWrites marks the request, so it reads the cookie itself
+	if hasReadPrimaryCookie(r, time.Now()) {
+		header.Set("Cache-Control", "private, no-store")
+		return
+	}
+	if header.Get("Cache-Control") != "" {
+		return
+	}
+
+	policy := "no-store"
+	if status < http.StatusBadRequest {
+		pattern := "/"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			pattern = rctx.RoutePattern()
+		}
+		if len(pattern) > 1 {
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		policy = c.policy(pattern)
+	}
+	if policy != "" {
+		header.Set("Cache-Control", policy)
+	}
+}
+
+// cachedResponseWriter holds a response until its ETag is known. It writes through instead once the
+// status is not 200 OK, the handler set its own ETag or Last-Modified, the response is a download,
+// or it is flushed.
+type cachedResponseWriter struct {
+	http.ResponseWriter
+	cache *HTTPCache
+	r     *http.Request
+
+	status    int
+	body      bytes.Buffer
+	committed bool
+}
+
+func (w *cachedResponseWriter) WriteHeader(status int) {
+	if w.committed || w.status != 0 {
+		return
+	}
+	w.status = status
+
+	if status != http.StatusOK || passThrough(w.Header()) {
+		w.commit()
+	}
+}
+
+// passThrough reports whether a response is written as it is, since it carries its own validators
+// or is a download, which may be too large to hold.
+func passThrough(header http.Header) bool {
+	if header.Get("ETag") != "" || header.Get("Last-Modified") != "" {
+		return true
+	}
+	disposition, _, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	return disposition == "attachment"
+}
+
+func (w *cachedResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.committed {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.body.Write(p)
+}
+
+// Flush writes the response held so far, and the rest of it as it is written.
+func (w *cachedResponseWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+	if !w.committed {
+		w.commit()
+		w.ResponseWriter.Write(w.body.Bytes())
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *cachedResponseWriter) commit() {
+	w.committed = true
+	w.cache.setHeaders(w.Header(), w.r, w.status)
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// surrogateKeys adds keys to the Surrogate-Key header of a response, so that a CDN can purge every
+// response naming a key. A response holding an article is named by articleSurrogateKey, and lists
+// of articles by surrogateKeyArticles.
+func surrogateKeys(w http.ResponseWriter, keys ...string) {
+	if existing := w.Header().Get("Surrogate-Key"); existing != "" {
+		keys = append([]string{existing}, keys...)
+	}
+	w.Header().Set("Surrogate-Key", strings.Join(keys, " "))
+}
+
+// articleSurrogateKey returns the surrogate key of the responses holding an article.
+func articleSurrogateKey(id models.PublicID) string {
+	return "article-" + string(id)
+}
@@ -0,0 +1,189 @@
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/content"
+	"github.com/ykaseng/articles-library/models"
+	"github.com/ykaseng/articles-library/transfer"
+)
+
+func testHTTPCache() *HTTPCache {
+	return &HTTPCache{
+		Lists:    "public, max-age=0, s-maxage=60",
+		Articles: "public, max-age=0, s-maxage=300",
+		Feeds:    "public, max-age=300",
+		Docs:     "public, max-age=3600",
+		Default:  "private, no-cache",
+	}
+}
+
+// httpCacheRouter returns the routes of an API behind an HTTPCache, as served by the server.
+// Completed exports are downloaded from exportDir.
+func httpCacheRouter(exportDir string) *chi.Mux {
+	updated := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	feeds := &memoryFeedStore{articles: []models.Article{
+		{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "Test Title", Content: "Test Content", Author: "Test Author", CreatedAt: &updated, UpdatedAt: &updated},
+	}}
+	exports := &memoryExportStore{exports: []models.Export{
+		{ID: 1, Format: transfer.FormatJSONL, Status: models.ExportCompleted, Exported: 1, Total: 1},
+	}}
+
+	a := &API{
+		Article:     NewArticleResource(newStreamStore()),
+		Feed:        NewFeedResource(feeds, content.NewRenderer(10)),
+		GraphQL:     &GraphQLResource{},
+		Stream:      &StreamResource{},
+		Webhook:     NewWebhookResource(&memoryWebhookStore{}),
+		Job:         NewJobResource(&memoryJobStore{}),
+		Export:      NewExportResource(exports, exportDir),
+		Idempotency: NewIdempotency(nil, 0),
+		OpenAPI:     &OpenAPIResource{ValidateRequests: true, ValidateResponses: true},
+		Sticky:      &ReadYourWrites{Window: time.Minute},
+	}
+
+	r := chi.NewRouter()
+	r.Use(Negotiate)
+	r.Use(testHTTPCache().Handler)
+	r.Mount("/", a.Router())
+	return r
+}
+
+func TestHTTPCacheHeaders(t *testing.T) {
+	tt := []struct {
+		name         string
+		endpoint     string
+		accept       string
+		code         int
+		cacheControl string
+		surrogateKey string
+		etag         string
+	}{
+		{"list", "/articles?limit=10", "", http.StatusOK, "public, max-age=0, s-maxage=60", "articles", "weak"},
+		{"article", "/articles/" + string(testPublicID(1)), "", http.StatusOK, "public, max-age=0, s-maxage=300", "article-" + string(testPublicID(1)), "weak"},
+		{"unknown article", "/articles/" + string(testPublicID(9)), "", http.StatusNotFound, "no-store", "", ""},
+		{"streamed list", "/articles", "application/x-ndjson", http.StatusOK, "public, max-age=0, s-maxage=60", "articles", ""},
+		{"own validator", "/feeds/articles.atom", "", http.StatusOK, "public, max-age=300", "articles", "own"},
+		{"download", "/exports/1/download", "", http.StatusOK, "private, no-cache", "", ""},
+		{"default", "/admin/jobs", "", http.StatusOK, "private, no-cache", "", "weak"},
+	}
+
+	dir, err := ioutil.TempDir("", "exports")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "export-1.jsonl"), []byte(`{"id":1}`+"\n"), 0644); err != nil {
+		t.Fatalf("write file failed: %v", err)
+	}
+
+	r := httpCacheRouter(dir)
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.endpoint, nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+			assert.Equal(t, tc.cacheControl, rec.Header().Get("Cache-Control"))
+			assert.Equal(t, "Accept, Accept-Encoding, Cookie", rec.Header().Get("Vary"))
+			assert.Equal(t, tc.surrogateKey, rec.Header().Get("Surrogate-Key"))
+			assert.NotEmpty(t, rec.Body.String())
+
+			etag := rec.Header().Get("ETag")
+			switch tc.etag {
+			case "weak":
+				assert.True(t, strings.HasPrefix(etag, `W/"`), "expected a weak ETag, got %q", etag)
+			case "own":
+				assert.True(t, strings.HasPrefix(etag, `"`), "expected the ETag of the handler, got %q", etag)
+			default:
+				assert.Equal(t, tc.etag, etag)
+			}
+		})
+	}
+}
+
+func TestHTTPCacheReadPrimary(t *testing.T) {
+	r := httpCacheRouter("")
+
+	tt := []struct {
+		name         string
+		until        time.Time
+		cacheControl string
+	}{
+		{"unexpired cookie", time.Now().Add(time.Minute), "private, no-store"},
+		{"expired cookie", time.Now().Add(-time.Minute), "public, max-age=0, s-maxage=300"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/articles/"+string(testPublicID(1)), nil)
+			req.AddCookie(&http.Cookie{Name: ReadPrimaryCookie, Value: strconv.FormatInt(tc.until.Unix(), 10)})
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, tc.cacheControl, rec.Header().Get("Cache-Control"))
+			assert.Equal(t, "Accept, Accept-Encoding, Cookie", rec.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestHTTPCacheConditional(t *testing.T) {
+	r := httpCacheRouter("")
+	endpoint := "/articles/" + string(testPublicID(1))
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", endpoint, nil))
+	etag := rec.Header().Get("ETag")
+
+	tt := []struct {
+		name        string
+		ifNoneMatch string
+		accept      string
+		code        int
+		changed     bool
+	}{
+		{"matching", etag, "", http.StatusNotModified, false},
+		{"strong form", strings.TrimPrefix(etag, "W/"), "", http.StatusNotModified, false},
+		{"one of many", `"other", ` + etag, "", http.StatusNotModified, false},
+		{"stale", `W/"other"`, "", http.StatusOK, false},
+		{"other media type", etag, "application/xml", http.StatusOK, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", endpoint, nil)
+			req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code)
+			assert.Equal(t, "public, max-age=0, s-maxage=300", rec.Header().Get("Cache-Control"))
+			assert.Equal(t, tc.changed, etag != rec.Header().Get("ETag"))
+			if tc.code == http.StatusNotModified {
+				assert.Empty(t, rec.Body.String())
+			} else {
+				assert.NotEmpty(t, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// IdempotencyKeyHeader is the request header carrying a client generated idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// The list of error types returned from idempotency middleware.
+var (
+	ErrIdempotencyKeyInvalid = errors.New("idempotency key must be between 1 and 255 characters")
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyPending = errors.New("a request with this idempotency key is still in progress")
+)
+
+// IdempotencyStore defines database operations for idempotency keys.
+type IdempotencyStore interface {
+	// Claim marks a key as in progress until expiresAt unless it has an unexpired entry, which is
+	// returned instead. The StatusCode of an entry is 0 while its request is in progress.
+	Claim(key, fingerprint string, expiresAt time.Time) (*models.IdempotencyKey, error)
+	Save(*models.IdempotencyKey) error
+	Release(key string) error
+}
+
+// Idempotency replays stored responses for repeated requests with the same Idempotency-Key. Keys
+// are claimed in the store before the request is handled, so that requests sharing a key on other
+// server instances are rejected while the first one is in progress. A claim left by a crashed
+// instance expires after Lease.
+type Idempotency struct {
+	Store IdempotencyStore
+	TTL   time.Duration
+	Lease time.Duration
+
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewIdempotency creates and returns an idempotency middleware storing responses for ttl.
+func NewIdempotency(store IdempotencyStore, ttl time.Duration) *Idempotency {
+	return &Idempotency{
+		Store: store,
+		TTL:   ttl,
+		Lease: time.Minute,
+		locks: make(map[string]*keyLock),
+	}
+}
+
+// Handler applies idempotency to POST and PATCH requests carrying an Idempotency-Key header.
+func (i *Idempotency) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > 255 {
+			render.Render(w, r, ErrBadRequest(ErrIdempotencyKeyInvalid))
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			render.Render(w, r, ErrBadRequest(err))
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		// serialize in-flight requests sharing a key on this instance, so that they replay the
+		// response of the first one rather than being rejected by its claim
+		unlock := i.lock(key)
+		defer unlock()
+
+		fingerprint := fingerprint(r, body)
+		stored, err := i.Store.Claim(key, fingerprint, time.Now().Add(i.Lease))
+		if err != nil {
+			log(r).WithField("idempotency_key", key).Error(err)
+			render.Render(w, r, ErrInternalServerError)
+			return
+		}
+
+		if stored != nil {
+			if stored.Fingerprint != fingerprint {
+				render.Render(w, r, ErrUnprocessableEntity(ErrIdempotencyKeyReused))
+				return
+			}
+
+			if stored.StatusCode == 0 {
+				render.Render(w, r, &ErrResponse{Status: Status{Code: http.StatusConflict, Message: ErrIdempotencyKeyPending.Error()}})
+				return
+			}
+
+			w.Header().Set("Content-Type", stored.ContentType)
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.Body)
+			return
+		}
+
+		saved := false
+		defer func() {
+			if saved {
+				return
+			}
+			if err := i.Store.Release(key); err != nil {
+				log(r).WithField("idempotency_key", key).Error(err)
+			}
+		}()
+
+		var buf bytes.Buffer
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		ww.Tee(&buf)
+		next.ServeHTTP(ww, r)
+
+		// server errors are not stored so that the client may retry them, their claim is released
+		if ww.Status() == 0 || ww.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		if err := i.Store.Save(&models.IdempotencyKey{
+			Key:         key,
+			Fingerprint: fingerprint,
+			StatusCode:  ww.Status(),
+			ContentType: ww.Header().Get("Content-Type"),
+			Body:        buf.Bytes(),
+			ExpiresAt:   time.Now().Add(i.TTL),
+		}); err != nil {
+			log(r).WithField("idempotency_key", key).Error(err)
+			return
+		}
+		saved = true
+	})
+}
+
+func (i *Idempotency) lock(key string) func() {
+	i.mu.Lock()
+	l, ok := i.locks[key]
+	if !ok {
+		l = &keyLock{}
+		i.locks[key] = l
+	}
+	l.refs++
+	i.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		i.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(i.locks, key)
+		}
+		i.mu.Unlock()
+	}
+}
+
+// fingerprint identifies the request a key was used for by its method, path, query and body. The
+// query is only added when there is one, so that fingerprints stored for requests without a query
+// stay the same.
+func fingerprint(r *http.Request, body []byte) string {
+	target := r.URL.Path
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + target + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
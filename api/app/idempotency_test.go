@@ -0,0 +1,258 @@
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ykaseng/articles-library/models"
+)
+
+type memoryIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]models.IdempotencyKey
+}
+
+func (s *memoryIdempotencyStore) Claim(key, fingerprint string, expiresAt time.Time) (*models.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.keys[key]
+	if ok && !k.ExpiresAt.Before(time.Now()) {
+		return &k, nil
+	}
+	s.keys[key] = models.IdempotencyKey{Key: key, Fingerprint: fingerprint, ExpiresAt: expiresAt}
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Save(k *models.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[k.Key] = *k
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keys[key].StatusCode == 0 {
+		delete(s.keys, key)
+	}
+	return nil
+}
+
+func TestIdempotencyHandler(t *testing.T) {
+	type request struct {
+		method string
+		target string
+		key    string
+		body   string
+	}
+
+	tt := []struct {
+		name     string
+		requests []request
+		expected struct {
+			calls    int32
+			statuses []int
+		}
+	}{
+		{
+			name: "requests without key are not deduplicated",
+			requests: []request{
+				{method: "POST", body: `{"title":"Test Title"}`},
+				{method: "POST", body: `{"title":"Test Title"}`},
+			},
+			expected: struct {
+				calls    int32
+				statuses []int
+			}{calls: 2, statuses: []int{http.StatusCreated, http.StatusCreated}},
+		},
+		{
+			name: "repeated key replays response",
+			requests: []request{
+				{method: "POST", key: "abc", body: `{"title":"Test Title"}`},
+				{method: "POST", key: "abc", body: `{"title":"Test Title"}`},
+			},
+			expected: struct {
+				calls    int32
+				statuses []int
+			}{calls: 1, statuses: []int{http.StatusCreated, http.StatusCreated}},
+		},
+		{
+			name: "reused key with different body",
+			requests: []request{
+				{method: "POST", key: "abc", body: `{"title":"Test Title"}`},
+				{method: "POST", key: "abc", body: `{"title":"Another Test Title"}`},
+			},
+			expected: struct {
+				calls    int32
+				statuses []int
+			}{calls: 1, statuses: []int{http.StatusCreated, http.StatusUnprocessableEntity}},
+		},
+		{
+			name: "reused key with different query",
+			requests: []request{
+				{method: "POST", target: "/articles/batch?atomic=true", key: "abc", body: `[]`},
+				{method: "POST", target: "/articles/batch", key: "abc", body: `[]`},
+			},
+			expected: struct {
+				calls    int32
+				statuses []int
+			}{calls: 1, statuses: []int{http.StatusCreated, http.StatusUnprocessableEntity}},
+		},
+		{
+			name: "key is ignored on GET",
+			requests: []request{
+				{method: "GET", key: "abc"},
+				{method: "GET", key: "abc"},
+			},
+			expected: struct {
+				calls    int32
+				statuses []int
+			}{calls: 2, statuses: []int{http.StatusCreated, http.StatusCreated}},
+		},
+		{
+			name: "key too long",
+			requests: []request{
+				{method: "POST", key: strings.Repeat("k", 256), body: `{"title":"Test Title"}`},
+			},
+			expected: struct {
+				calls    int32
+				statuses []int
+			}{calls: 0, statuses: []int{http.StatusBadRequest}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+			idempotency := NewIdempotency(&memoryIdempotencyStore{keys: map[string]models.IdempotencyKey{}}, time.Hour)
+			h := idempotency.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(strings.Repeat("x", int(n))))
+			}))
+
+			var first string
+			for i, req := range tc.requests {
+				target := req.target
+				if target == "" {
+					target = "/articles"
+				}
+				r := httptest.NewRequest(req.method, target, strings.NewReader(req.body))
+				if req.key != "" {
+					r.Header.Set(IdempotencyKeyHeader, req.key)
+				}
+
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, r)
+
+				b, err := ioutil.ReadAll(rec.Result().Body)
+				if err != nil {
+					t.Errorf("read response failed: %v", err)
+				}
+
+				assert.Equal(t, tc.expected.statuses[i], rec.Code)
+				if i == 0 {
+					first = string(b)
+				} else if tc.expected.calls == 1 && rec.Code == http.StatusCreated {
+					assert.Equal(t, first, string(b))
+					assert.Equal(t, "true", rec.Header().Get("Idempotent-Replayed"))
+				}
+			}
+
+			assert.Equal(t, tc.expected.calls, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestIdempotencyHandlerConcurrent(t *testing.T) {
+	var calls int32
+	idempotency := NewIdempotency(&memoryIdempotencyStore{keys: map[string]models.IdempotencyKey{}}, time.Hour)
+	h := idempotency.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := httptest.NewRequest("POST", "/articles", strings.NewReader(`{"title":"Test Title"}`))
+			r.Header.Set(IdempotencyKeyHeader, "abc")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+			assert.Equal(t, http.StatusCreated, rec.Code)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Empty(t, idempotency.locks)
+}
+
+func TestIdempotencyHandlerClaimed(t *testing.T) {
+	fp := fingerprint(httptest.NewRequest("POST", "/articles", nil), []byte(`{"title":"Test Title"}`))
+
+	tt := []struct {
+		name   string
+		stored models.IdempotencyKey
+		code   int
+		calls  int32
+	}{
+		{
+			name:   "in progress on another instance",
+			stored: models.IdempotencyKey{Key: "abc", Fingerprint: fp, ExpiresAt: time.Now().Add(time.Minute)},
+			code:   http.StatusConflict,
+		},
+		{
+			name:   "claim of a crashed instance expired",
+			stored: models.IdempotencyKey{Key: "abc", Fingerprint: fp, ExpiresAt: time.Now().Add(-time.Second)},
+			code:   http.StatusCreated,
+			calls:  1,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+			idempotency := NewIdempotency(&memoryIdempotencyStore{keys: map[string]models.IdempotencyKey{"abc": tc.stored}}, time.Hour)
+			h := idempotency.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.WriteHeader(http.StatusCreated)
+			}))
+
+			r := httptest.NewRequest("POST", "/articles", strings.NewReader(`{"title":"Test Title"}`))
+			r.Header.Set(IdempotencyKeyHeader, "abc")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+
+			assert.Equal(t, tc.code, rec.Code)
+			assert.Equal(t, tc.calls, atomic.LoadInt32(&calls))
+		})
+	}
+}
+
+func TestIdempotencyHandlerReleased(t *testing.T) {
+	store := &memoryIdempotencyStore{keys: map[string]models.IdempotencyKey{}}
+	h := NewIdempotency(store, time.Hour).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	r := httptest.NewRequest("POST", "/articles", strings.NewReader(`{"title":"Test Title"}`))
+	r.Header.Set(IdempotencyKeyHeader, "abc")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Empty(t, store.keys, "claims of failed requests must be released for retries")
+}
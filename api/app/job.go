@@ -0,0 +1,147 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// The list of error types returned from job resource.
+var (
+	ErrInvalidJobStatus = errors.New("status must be pending, running, completed, dead or cancelled")
+)
+
+// jobListLimit is the default number of jobs returned by the job list.
+const jobListLimit = 50
+
+// JobStore defines database operations for job inspection.
+type JobStore interface {
+	Get(id int64) (*models.Job, error)
+	List(status, kind string, limit int) (*[]models.Job, error)
+	Retry(id int64) (*models.Job, error)
+	Cancel(id int64) (*models.Job, error)
+}
+
+// JobResource implements background job inspection handler.
+type JobResource struct {
+	Store JobStore
+}
+
+// NewJobResource creates and returns a job resource.
+func NewJobResource(store JobStore) *JobResource {
+	return &JobResource{
+		Store: store,
+	}
+}
+
+func (rs *JobResource) router() *chi.Mux {
+	r := chi.NewRouter()
+	r.Get("/", rs.list)
+	r.Route("/{jobID}", func(r chi.Router) {
+		r.Get("/", rs.get)
+		r.Post("/retry", rs.retry)
+		r.Post("/cancel", rs.cancel)
+	})
+	return r
+}
+
+type jobResponse struct {
+	Status
+	Data *models.Job `json:"data"`
+}
+
+// list responds with jobs, newest first. The list is filtered by ?status= and ?kind= and holds up
+// to ?limit= jobs.
+func (rs *JobResource) list(w http.ResponseWriter, r *http.Request) {
+	type listJobsResponse struct {
+		Status
+		Data *[]models.Job `json:"data"`
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.JobPending, models.JobRunning, models.JobCompleted, models.JobDead, models.JobCancelled:
+	default:
+		render.Render(w, r, ErrBadRequest(ErrInvalidJobStatus))
+		return
+	}
+
+	limit := jobListLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 100 {
+			render.Render(w, r, ErrBadRequest(ErrInvalidLimit))
+			return
+		}
+	}
+
+	jobs, err := rs.Store.List(status, r.URL.Query().Get("kind"), limit)
+	if err != nil {
+		render.Render(w, r, ErrUnprocessableEntity(err))
+		return
+	}
+
+	render.Respond(w, r, &listJobsResponse{
+		Status: Status{
+			Code:    http.StatusOK,
+			Message: "SUCCESS",
+		},
+		Data: jobs,
+	})
+}
+
+func (rs *JobResource) get(w http.ResponseWriter, r *http.Request) {
+	rs.respond(w, r, rs.Store.Get)
+}
+
+// retry schedules a job which is not running to run again now with its attempts reset, such as a
+// dead job once its cause is fixed.
+func (rs *JobResource) retry(w http.ResponseWriter, r *http.Request) {
+	rs.respond(w, r, rs.Store.Retry)
+}
+
+// cancel stops a pending job from running.
+func (rs *JobResource) cancel(w http.ResponseWriter, r *http.Request) {
+	rs.respond(w, r, rs.Store.Cancel)
+}
+
+// respond calls fn with the job ID of the request and responds with the returned job.
+func (rs *JobResource) respond(w http.ResponseWriter, r *http.Request, fn func(id int64) (*models.Job, error)) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "jobID"), 10, 64)
+	if err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	job, err := fn(id)
+	if err != nil {
+		rs.renderError(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, &jobResponse{
+		Status: Status{
+			Code:    http.StatusOK,
+			Message: "SUCCESS",
+		},
+		Data: job,
+	})
+}
+
+func (rs *JobResource) renderError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case database.ErrJobNotFound:
+		render.Render(w, r, &ErrResponse{Status: Status{Code: http.StatusNotFound, Message: err.Error()}})
+	case database.ErrJobStatus:
+		render.Render(w, r, &ErrResponse{Status: Status{Code: http.StatusConflict, Message: err.Error()}})
+	default:
+		render.Render(w, r, ErrUnprocessableEntity(err))
+	}
+}
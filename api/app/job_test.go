@@ -0,0 +1,179 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/models"
+)
+
+type memoryJobStore struct {
+	jobs []models.Job
+}
+
+func (s *memoryJobStore) Get(id int64) (*models.Job, error) {
+	for _, j := range s.jobs {
+		if j.ID == id {
+			return &j, nil
+		}
+	}
+	return nil, database.ErrJobNotFound
+}
+
+func (s *memoryJobStore) List(status, kind string, limit int) (*[]models.Job, error) {
+	jobs := []models.Job{}
+	for _, j := range s.jobs {
+		if (status == "" || j.Status == status) && (kind == "" || j.Kind == kind) && len(jobs) < limit {
+			jobs = append(jobs, j)
+		}
+	}
+	return &jobs, nil
+}
+
+func (s *memoryJobStore) Retry(id int64) (*models.Job, error) {
+	return s.transition(id, models.JobPending, func(j *models.Job) bool { return j.Status != models.JobRunning })
+}
+
+func (s *memoryJobStore) Cancel(id int64) (*models.Job, error) {
+	return s.transition(id, models.JobCancelled, func(j *models.Job) bool { return j.Status == models.JobPending })
+}
+
+func (s *memoryJobStore) transition(id int64, status string, allowed func(*models.Job) bool) (*models.Job, error) {
+	for i := range s.jobs {
+		if s.jobs[i].ID == id {
+			if !allowed(&s.jobs[i]) {
+				return nil, database.ErrJobStatus
+			}
+			s.jobs[i].Status = status
+			s.jobs[i].Attempts = 0
+			return &s.jobs[i], nil
+		}
+	}
+	return nil, database.ErrJobNotFound
+}
+
+func TestJob(t *testing.T) {
+	tt := []struct {
+		name     string
+		method   string
+		endpoint string
+		code     int
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "list",
+			method:   "GET",
+			endpoint: "/",
+			code:     http.StatusOK,
+			contains: []string{`"id":1`, `"id":2`, `"id":3`},
+		},
+		{
+			name:     "list by status",
+			method:   "GET",
+			endpoint: "/?status=dead",
+			code:     http.StatusOK,
+			contains: []string{`"id":2`, `"last_error":"connection refused"`},
+			excludes: []string{`"id":1`, `"id":3`},
+		},
+		{
+			name:     "list by kind",
+			method:   "GET",
+			endpoint: "/?kind=idempotency.cleanup",
+			code:     http.StatusOK,
+			contains: []string{`"id":3`},
+			excludes: []string{`"id":1`, `"id":2`},
+		},
+		{
+			name:     "list invalid status",
+			method:   "GET",
+			endpoint: "/?status=lost",
+			code:     http.StatusBadRequest,
+			contains: []string{ErrInvalidJobStatus.Error()},
+		},
+		{
+			name:     "list invalid limit",
+			method:   "GET",
+			endpoint: "/?limit=0",
+			code:     http.StatusBadRequest,
+			contains: []string{ErrInvalidLimit.Error()},
+		},
+		{
+			name:     "get",
+			method:   "GET",
+			endpoint: "/1",
+			code:     http.StatusOK,
+			contains: []string{`"kind":"export"`, `"args":{"format":"json"}`},
+		},
+		{
+			name:     "get missing",
+			method:   "GET",
+			endpoint: "/9",
+			code:     http.StatusNotFound,
+			contains: []string{"job not found"},
+		},
+		{
+			name:     "retry dead",
+			method:   "POST",
+			endpoint: "/2/retry",
+			code:     http.StatusOK,
+			contains: []string{`"status":"pending"`, `"attempts":0`},
+		},
+		{
+			name:     "retry running",
+			method:   "POST",
+			endpoint: "/3/retry",
+			code:     http.StatusConflict,
+			contains: []string{database.ErrJobStatus.Error()},
+		},
+		{
+			name:     "cancel pending",
+			method:   "POST",
+			endpoint: "/1/cancel",
+			code:     http.StatusOK,
+			contains: []string{`"status":"cancelled"`},
+		},
+		{
+			name:     "cancel dead",
+			method:   "POST",
+			endpoint: "/2/cancel",
+			code:     http.StatusConflict,
+		},
+		{
+			name:     "cancel missing",
+			method:   "POST",
+			endpoint: "/9/cancel",
+			code:     http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &memoryJobStore{
+				jobs: []models.Job{
+					{ID: 1, Kind: "export", Args: json.RawMessage(`{"format":"json"}`), Status: models.JobPending, MaxAttempts: 5},
+					{ID: 2, Kind: "export", Args: json.RawMessage(`{}`), Status: models.JobDead, Attempts: 5, MaxAttempts: 5, LastError: "connection refused"},
+					{ID: 3, Kind: "idempotency.cleanup", Args: json.RawMessage(`{}`), Status: models.JobRunning, Attempts: 1, MaxAttempts: 5},
+				},
+			}
+			rs := NewJobResource(store)
+
+			r := httptest.NewRequest(tc.method, tc.endpoint, nil)
+			rec := httptest.NewRecorder()
+			rs.router().ServeHTTP(rec, r)
+
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+			for _, s := range tc.contains {
+				assert.Contains(t, rec.Body.String(), s)
+			}
+			for _, s := range tc.excludes {
+				assert.NotContains(t, rec.Body.String(), s)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package app
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// loader batches the keys requested while the GraphQL executor resolves one level of a query into a
+// single fetch. load returns a thunk which the executor calls once the level is complete, so the first
+// thunk called fetches every key queued so far. Results are cached for the lifetime of the loader,
+// which is one request.
+type loader struct {
+	fetch func(keys []interface{}) (map[interface{}]interface{}, error)
+
+	mu      sync.Mutex
+	pending []interface{}
+	results map[interface{}]interface{}
+	errs    map[interface{}]error
+}
+
+func newLoader(fetch func(keys []interface{}) (map[interface{}]interface{}, error)) *loader {
+	return &loader{
+		fetch:   fetch,
+		results: make(map[interface{}]interface{}),
+		errs:    make(map[interface{}]error),
+	}
+}
+
+func (l *loader) load(key interface{}) func() (interface{}, error) {
+	l.mu.Lock()
+	_, done := l.results[key]
+	_, failed := l.errs[key]
+	if !done && !failed {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) > 0 {
+			keys := dedupe(l.pending)
+			l.pending = nil
+
+			results, err := l.fetch(keys)
+			for _, k := range keys {
+				if err != nil {
+					l.errs[k] = err
+					continue
+				}
+				l.results[k] = results[k]
+			}
+		}
+
+		return l.results[key], l.errs[key]
+	}
+}
+
+func dedupe(keys []interface{}) []interface{} {
+	seen := make(map[interface{}]bool, len(keys))
+	var out []interface{}
+	for _, k := range keys {
+		if !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// authorArticlesKey identifies a page of the articles of an author.
+type authorArticlesKey struct {
+	name  string
+	after models.PublicID
+	first int
+}
+
+// loaders holds the dataloaders of one GraphQL request.
+type loaders struct {
+	// store serves the reads of the request which are not batched.
+	store GraphQLStore
+
+	article        *loader
+	author         *loader
+	authorArticles *loader
+}
+
+func newLoaders(store GraphQLStore) *loaders {
+	return &loaders{
+		store: store,
+
+		// article resolves an article ID to a *models.Article, or nil when it does not exist
+		article: newLoader(func(keys []interface{}) (map[interface{}]interface{}, error) {
+			ids := make([]models.PublicID, len(keys))
+			for i, k := range keys {
+				ids[i] = k.(models.PublicID)
+			}
+
+			articles, err := store.GetMany(ids)
+			if err != nil {
+				return nil, err
+			}
+
+			results := make(map[interface{}]interface{}, len(*articles))
+			for i := range *articles {
+				results[(*articles)[i].PublicID] = &(*articles)[i]
+			}
+			return results, nil
+		}),
+
+		// author resolves an author name to an *author, or nil when it does not exist
+		author: newLoader(func(keys []interface{}) (map[interface{}]interface{}, error) {
+			names := make([]string, len(keys))
+			for i, k := range keys {
+				names[i] = k.(string)
+			}
+
+			existing, err := store.Authors(names)
+			if err != nil {
+				return nil, err
+			}
+
+			results := make(map[interface{}]interface{}, len(existing))
+			for _, name := range existing {
+				results[name] = &author{Name: name}
+			}
+			return results, nil
+		}),
+
+		// authorArticles resolves an authorArticlesKey to a *connection, fetching the pages of all
+		// authors requested with the same arguments at once
+		authorArticles: newLoader(func(keys []interface{}) (map[interface{}]interface{}, error) {
+			type page struct {
+				after models.PublicID
+				first int
+			}
+			groups := make(map[page][]string)
+			for _, k := range keys {
+				key := k.(authorArticlesKey)
+				p := page{key.after, key.first}
+				groups[p] = append(groups[p], key.name)
+			}
+
+			results := make(map[interface{}]interface{}, len(keys))
+			for p, names := range groups {
+				// one extra article per author tells whether there is a next page
+				articles, err := store.ByAuthors(names, p.after, p.first+1)
+				if err != nil {
+					return nil, err
+				}
+
+				byAuthor := make(map[string][]models.Article)
+				for _, a := range *articles {
+					byAuthor[a.Author] = append(byAuthor[a.Author], a)
+				}
+				for _, name := range names {
+					results[authorArticlesKey{name, p.after, p.first}] = newConnection(byAuthor[name], p.first)
+				}
+			}
+			return results, nil
+		}),
+	}
+}
+
+// author is the GraphQL representation of an author, identified by name.
+type author struct {
+	Name string
+}
+
+// connection is a page of articles in the shape of a Relay cursor connection.
+type connection struct {
+	Edges    []edge
+	PageInfo pageInfo
+}
+
+type edge struct {
+	Cursor string
+	Node   *models.Article
+}
+
+type pageInfo struct {
+	HasNextPage bool
+	EndCursor   *string
+}
+
+// newConnection returns a connection of the first articles, which may hold one more article than
+// first to signal a next page.
+func newConnection(articles []models.Article, first int) *connection {
+	c := &connection{Edges: []edge{}}
+	if len(articles) > first {
+		articles = articles[:first]
+		c.PageInfo.HasNextPage = true
+	}
+
+	for i := range articles {
+		c.Edges = append(c.Edges, edge{Cursor: encodeCursor(articles[i].PublicID), Node: &articles[i]})
+	}
+	if len(c.Edges) > 0 {
+		c.PageInfo.EndCursor = &c.Edges[len(c.Edges)-1].Cursor
+	}
+
+	return c
+}
+
+// ErrInvalidCursor is returned for a pagination cursor which was not issued by the API.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const cursorPrefix = "article:"
+
+// encodeCursor returns the opaque pagination cursor of an article. Pages are ordered by public ID,
+// so that cursors do not reveal the serial ID.
+func encodeCursor(id models.PublicID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + string(id)))
+}
+
+// decodeCursor returns the public ID of the article of a cursor, an empty cursor starts at the
+// first article. Cursors holding a serial ID, issued before pages were ordered by public ID, are
+// invalid.
+func decodeCursor(cursor string) (models.PublicID, error) {
+	if cursor == "" {
+		return "", nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(b), cursorPrefix) {
+		return "", ErrInvalidCursor
+	}
+
+	id, err := models.ParsePublicID(strings.TrimPrefix(string(b), cursorPrefix))
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+
+	return id, nil
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/ykaseng/articles-library/codec"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// stream writes the articles of a list as NDJSON, one article per line, as they are read from the
+// store, so that the whole library can be read without holding it in memory. Every line is flushed
+// to the client, and the store stops reading once the client disconnects. Errors before the first
+// article are rendered, later errors end the stream early and are only logged.
+func (rs *ArticleResource) stream(w http.ResponseWriter, r *http.Request, filter models.ArticleFilter, fields models.ArticleFields, after models.PublicID) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		render.Render(w, r, ErrInternalServerError)
+		return
+	}
+
+	if err := rs.render(r, nil); err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	started := false
+	start := func() {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", codec.NDJSON)
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	err := rs.reads(r).Stream(r.Context(), filter, rendered(r, fields), after, func(a *models.Article) error {
+		articles := []models.Article{*a}
+		if err := rs.render(r, articles); err != nil {
+			return err
+		}
+		listed, err := rs.list(r, articles, fields, nil)
+		if err != nil {
+			return err
+		}
+
+		start()
+		if err := enc.Encode(listed[0]); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		start()
+	case r.Context().Err() != nil:
+		// the client disconnected
+	case !started:
+		render.Render(w, r, ErrUnprocessableEntity(err))
+	default:
+		log(r).Error(err)
+	}
+}
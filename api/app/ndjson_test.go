@@ -0,0 +1,99 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/codec"
+	"github.com/ykaseng/articles-library/models"
+)
+
+func newStreamStore() *publicArticleStore {
+	return &publicArticleStore{articles: []models.Article{
+		{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "First Title", Content: "*Test Content*", ContentFormat: "markdown", Author: "Test Author"},
+		{ArticleID: models.ArticleID{ID: 2, PublicID: testPublicID(2)}, Title: "Second Title", Content: "Test Content", Author: "Other Author"},
+		{ArticleID: models.ArticleID{ID: 3, PublicID: testPublicID(3)}, Title: "Third Title", Content: "Test Content", Author: "Test Author"},
+	}}
+}
+
+func TestStreamArticles(t *testing.T) {
+	tt := []struct {
+		name     string
+		endpoint string
+		accept   string
+		code     int
+		loaded   models.ArticleFields
+		body     string
+	}{
+		{"all articles", "/articles", "application/x-ndjson", http.StatusOK, nil, `{"id":"01ARZ3NDEKTSV4RRFFQ69G5F01","title":"First Title","content":"*Test Content*","author":"Test Author","content_format":"markdown"}` + "\n" +
+			`{"id":"01ARZ3NDEKTSV4RRFFQ69G5F02","title":"Second Title","content":"Test Content","author":"Other Author"}` + "\n" +
+			`{"id":"01ARZ3NDEKTSV4RRFFQ69G5F03","title":"Third Title","content":"Test Content","author":"Test Author"}` + "\n"},
+		{"filter and fields", "/articles?author=Test%20Author&after=" + encodeCursor(testPublicID(1)) + "&fields=id", "application/x-ndjson, application/json;q=0.5", http.StatusOK, models.ArticleFields{"id"}, `{"id":"01ARZ3NDEKTSV4RRFFQ69G5F03"}` + "\n"},
+		{"rendered content", "/articles?fields=content&render=html&author=Test%20Author", "application/x-ndjson", http.StatusOK, models.ArticleFields{"content", "content_format"}, `{"content":"\u003cp\u003e\u003cem\u003eTest Content\u003c/em\u003e\u003c/p\u003e\n"}` + "\n" + `{"content":"\u003cp\u003eTest Content\u003c/p\u003e\n"}` + "\n"},
+		{"no articles", "/articles?author=Nobody", "application/x-ndjson", http.StatusOK, nil, ""},
+		{"search", "/articles?q=Title", "application/x-ndjson", http.StatusBadRequest, nil, `{"status":400,"mesage":"q, limit and include cannot be used with application/x-ndjson","data":null}` + "\n"},
+		{"include", "/articles?include=author", "application/x-ndjson", http.StatusBadRequest, nil, `{"status":400,"mesage":"q, limit and include cannot be used with application/x-ndjson","data":null}` + "\n"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := newStreamStore()
+			r := chi.NewRouter()
+			r.Mount("/articles", NewArticleResource(store).router())
+
+			req := httptest.NewRequest("GET", tc.endpoint, nil)
+			req.Header.Set("Accept", tc.accept)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code)
+			assert.Equal(t, tc.body, rec.Body.String())
+			if tc.code == http.StatusOK {
+				assert.Equal(t, codec.NDJSON, rec.Header().Get("Content-Type"))
+				assert.Equal(t, tc.loaded, store.fields)
+			}
+		})
+	}
+}
+
+// disconnectingRecorder cancels the request once the first line of a stream is flushed, as when the
+// client goes away.
+type disconnectingRecorder struct {
+	*httptest.ResponseRecorder
+	cancel context.CancelFunc
+}
+
+func (r *disconnectingRecorder) Flush() {
+	r.ResponseRecorder.Flush()
+	r.cancel()
+}
+
+func TestStreamArticlesDisconnect(t *testing.T) {
+	r := chi.NewRouter()
+	r.Mount("/articles", NewArticleResource(newStreamStore()).router())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/articles?fields=id", nil).WithContext(ctx)
+	req.Header.Set("Accept", "application/x-ndjson")
+	rec := &disconnectingRecorder{ResponseRecorder: httptest.NewRecorder(), cancel: cancel}
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, `{"id":"01ARZ3NDEKTSV4RRFFQ69G5F01"}`+"\n", rec.Body.String(), "the stream must stop once the client disconnects")
+}
+
+func TestStreamArticlesValidated(t *testing.T) {
+	req := httptest.NewRequest("GET", "/articles?fields=title", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+	validatedRouter(newStreamStore(), nil).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 3, strings.Count(rec.Body.String(), "\n"), "streams must not be held for response validation")
+	assert.True(t, rec.Flushed)
+}
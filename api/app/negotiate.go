@@ -0,0 +1,116 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/go-chi/render"
+
+	"github.com/ykaseng/articles-library/codec"
+)
+
+// acceptedCtxKey holds the codecs accepted by a request, in order of preference.
+const acceptedCtxKey ctxKey = 1
+
+var (
+	// ErrNotAcceptable returns status 406 Not Acceptable for responses in none of the accepted media types.
+	ErrNotAcceptable = &ErrResponse{Status: Status{Code: http.StatusNotAcceptable, Message: "response can be encoded as application/json, application/xml, application/msgpack or, for lists, text/csv"}}
+
+	// ErrUnsupportedMediaType returns status 415 Unsupported Media Type for request bodies which cannot be decoded.
+	ErrUnsupportedMediaType = &ErrResponse{Status: Status{Code: http.StatusUnsupportedMediaType, Message: "request body must be application/json, application/xml or application/msgpack"}}
+)
+
+// Negotiate records the media types accepted by a request for Respond, and decodes XML and
+// MessagePack request bodies into JSON, so that handlers only decode JSON. Request bodies of other
+// media types are rejected with 415 Unsupported Media Type, and bodies without a Content-Type are
+// taken to be JSON.
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepted := codec.Accepted(r.Header.Get("Accept"))
+		r = r.WithContext(context.WithValue(r.Context(), acceptedCtxKey, accepted))
+
+		ct := r.Header.Get("Content-Type")
+		if ct == "" || r.ContentLength == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(ct)
+		c := codec.ByMediaType(mediaType)
+		if err != nil || c == nil || c == codec.CSV {
+			render.Render(w, r, ErrUnsupportedMediaType)
+			return
+		}
+		if c == codec.JSON {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		value, err := c.Decode(r.Body)
+		if err != nil {
+			render.Render(w, r, ErrBadRequest(err))
+			return
+		}
+		body, err := json.Marshal(value)
+		if err != nil {
+			render.Render(w, r, ErrBadRequest(err))
+			return
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		r.Header.Set("Content-Type", "application/json")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Respond writes v in the first media type accepted by the request which can encode it, replacing
+// render.DefaultResponder. Requests which passed Negotiate and accept none are answered with 406
+// Not Acceptable, while errors are written as JSON so that their message is not lost.
+func Respond(w http.ResponseWriter, r *http.Request, v interface{}) {
+	accepted, ok := r.Context().Value(acceptedCtxKey).([]codec.Codec)
+	if !ok || (len(accepted) > 0 && accepted[0] == codec.JSON) {
+		render.JSON(w, r, v)
+		return
+	}
+
+	value, err := codec.Value(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, c := range accepted {
+		if c == codec.JSON {
+			render.JSON(w, r, v)
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := c.Encode(&buf, value); err != nil {
+			if errors.Is(err, codec.ErrUnsupported) {
+				continue
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", c.ContentType())
+		if status, ok := r.Context().Value(render.StatusCtxKey).(int); ok {
+			w.WriteHeader(status)
+		}
+		w.Write(buf.Bytes())
+		return
+	}
+
+	if _, ok := v.(*ErrResponse); !ok {
+		render.Status(r, http.StatusNotAcceptable)
+		v = ErrNotAcceptable
+	}
+	render.JSON(w, r, v)
+}
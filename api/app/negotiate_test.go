@@ -0,0 +1,81 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/codec"
+	"github.com/ykaseng/articles-library/models"
+)
+
+func TestNegotiate(t *testing.T) {
+	article, _ := codec.Parse([]byte(`{"title":"New Title","content":"New Content","author":"Test Author","tags":["go"]}`))
+	var msgpack bytes.Buffer
+	codec.MessagePack.Encode(&msgpack, article)
+
+	tt := []struct {
+		name         string
+		method       string
+		endpoint     string
+		accept       string
+		contentType  string
+		body         string
+		code         int
+		responseType string
+		contains     []string
+	}{
+		{"json by default", "GET", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", "", "", "", http.StatusOK, "application/json", []string{`"title":"Test Title"`}},
+		{"xml", "GET", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", "application/xml", "", "", http.StatusOK, "application/xml", []string{"<response><status>200</status>", "<title>Test Title</title>"}},
+		{"csv list", "GET", "/articles?limit=10", "text/csv", "", "", http.StatusOK, "text/csv", []string{"id,title,content,author\n01ARZ3NDEKTSV4RRFFQ69G5F01,Test Title,Test Content,Test Author\n"}},
+		{"msgpack", "GET", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", "application/msgpack", "", "", http.StatusOK, "application/msgpack", []string{"\xa5title\xaaTest Title"}},
+		{"preferred encoding of a value", "DELETE", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", "text/csv, application/xml;q=0.5", "", "", http.StatusOK, "application/xml", []string{"<id>01ARZ3NDEKTSV4RRFFQ69G5F01</id>"}},
+		{"csv of a value", "DELETE", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", "text/csv", "", "", http.StatusNotAcceptable, "application/json", []string{`"status":406`}},
+		{"not acceptable", "GET", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", "image/png", "", "", http.StatusNotAcceptable, "application/json", []string{`"status":406`}},
+		{"error in xml", "GET", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F02", "application/xml", "", "", http.StatusNotFound, "application/xml", []string{"<mesage>article not found</mesage>"}},
+		{"error of a csv request", "GET", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F02", "text/csv", "", "", http.StatusNotFound, "application/json", []string{`"mesage":"article not found"`}},
+		{"xml request", "POST", "/articles", "", "application/xml", `<article><title>New Title</title><content>New Content</content><author>Test Author</author><tags><item>go</item></tags></article>`, http.StatusOK, "application/json", []string{`"id":"01ARZ3NDEKTSV4RRFFQ69G5F02"`}},
+		{"msgpack request", "POST", "/articles", "application/msgpack", "application/msgpack", msgpack.String(), http.StatusOK, "application/msgpack", []string{"\xa2id\xba01ARZ3NDEKTSV4RRFFQ69G5F02"}},
+		{"malformed xml request", "POST", "/articles", "", "application/xml", `<article><title>`, http.StatusBadRequest, "application/json", nil},
+		{"unsupported media type", "POST", "/articles", "", "text/plain", `title=New Title`, http.StatusUnsupportedMediaType, "application/json", []string{`"status":415`}},
+		{"csv request", "POST", "/articles", "", "text/csv", "title\nNew Title\n", http.StatusUnsupportedMediaType, "application/json", []string{`"status":415`}},
+	}
+
+	respond := render.Respond
+	render.Respond = Respond
+	defer func() { render.Respond = respond }()
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &publicArticleStore{articles: []models.Article{{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "Test Title", Content: "Test Content", Author: "Test Author"}}}
+			r := chi.NewRouter()
+			r.Use(Negotiate)
+			r.Mount("/articles", NewArticleResource(store).router())
+
+			req := httptest.NewRequest(tc.method, tc.endpoint, strings.NewReader(tc.body))
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+			assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), tc.responseType), rec.Header().Get("Content-Type"))
+			for _, s := range tc.contains {
+				assert.Contains(t, rec.Body.String(), s)
+			}
+			if tc.method == "POST" && tc.code == http.StatusOK {
+				assert.Equal(t, []string{"go"}, store.articles[1].Tags)
+			}
+		})
+	}
+}
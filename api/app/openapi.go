@@ -0,0 +1,543 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/spf13/viper"
+
+	"github.com/ykaseng/articles-library/codec"
+)
+
+// openAPIVersion is the version of the OpenAPI specification the document follows.
+const openAPIVersion = "3.1.0"
+
+// OpenAPIResource serves the OpenAPI document of the API and validates requests against it. The
+// document is generated from the registered routes, which are described by routeDocs, and the
+// request and response types of their handlers.
+type OpenAPIResource struct {
+	// SwaggerUI serves the Swagger UI page for the document at /docs.
+	SwaggerUI bool
+	// ValidateRequests rejects requests which do not match the document before they reach their
+	// handlers.
+	ValidateRequests bool
+	// ValidateResponses replaces responses which do not match the document with an error. It is
+	// meant for tests, as responses are buffered until they are validated.
+	ValidateResponses bool
+
+	once sync.Once
+	doc  []byte
+	v    *openAPIValidator
+	err  error
+}
+
+// NewOpenAPIResource creates and returns an OpenAPI resource configured from viper.
+func NewOpenAPIResource() *OpenAPIResource {
+	return &OpenAPIResource{
+		SwaggerUI:         viper.GetBool("openapi_swagger_ui"),
+		ValidateRequests:  viper.GetBool("openapi_validate_requests"),
+		ValidateResponses: viper.GetBool("openapi_validate_responses"),
+	}
+}
+
+// generate generates the OpenAPI document of routes on first use, once every route is registered.
+func (rs *OpenAPIResource) generate(routes chi.Routes) {
+	rs.once.Do(func() {
+		var doc *openAPIDoc
+		if doc, rs.err = newOpenAPIDoc(routes); rs.err != nil {
+			return
+		}
+		if rs.doc, rs.err = json.Marshal(doc); rs.err != nil {
+			return
+		}
+		rs.v = newOpenAPIValidator(doc)
+	})
+}
+
+// validator returns the validator of the OpenAPI document of routes.
+func (rs *OpenAPIResource) validator(routes chi.Routes) (*openAPIValidator, error) {
+	rs.generate(routes)
+	return rs.v, rs.err
+}
+
+// spec returns a handler responding with the OpenAPI document of routes.
+func (rs *OpenAPIResource) spec(routes chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rs.generate(routes)
+		if rs.err != nil {
+			log(r).Error(rs.err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(rs.doc)
+	}
+}
+
+func (rs *OpenAPIResource) swaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
+
+// openAPIDoc is an OpenAPI document. Only the parts of the specification used by the API are
+// declared.
+type openAPIDoc struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       openAPIInfo         `json:"info"`
+	Paths      map[string]pathItem `json:"paths"`
+	Components openAPIComponents   `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas   map[string]*schema   `json:"schemas"`
+	Responses map[string]*response `json:"responses"`
+}
+
+// pathItem holds the operations of a path by lowercase method.
+type pathItem map[string]*openAPIOperation
+
+type openAPIOperation struct {
+	Summary     string               `json:"summary"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*parameter         `json:"parameters,omitempty"`
+	RequestBody *requestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*response `json:"responses"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+// schema is a JSON Schema of a value.
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 schemaType         `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *int               `json:"minimum,omitempty"`
+	Maximum              *int               `json:"maximum,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*schema          `json:"anyOf,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	WriteOnly            bool               `json:"writeOnly,omitempty"`
+}
+
+// schemaType holds the types a value may have, encoded as a single type unless it is nullable.
+type schemaType []string
+
+func (t schemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// nullable returns a schema which also matches null.
+func nullable(s *schema) *schema {
+	if s.Ref != "" {
+		return &schema{AnyOf: []*schema{s, {Type: schemaType{"null"}}}}
+	}
+	n := *s
+	if len(n.Type) > 0 {
+		n.Type = append(schemaType{}, s.Type...)
+		n.Type = append(n.Type, "null")
+	}
+	return &n
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaGenerator generates the schemas of Go types as encoded by encoding/json. Named structs
+// become components, which are referenced by the schemas of other types.
+type schemaGenerator struct {
+	components map[string]*schema
+	names      map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{components: map[string]*schema{}, names: map[reflect.Type]string{}}
+}
+
+// of returns the schema of the type of v.
+func (g *schemaGenerator) of(v interface{}) *schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) *schema {
+	switch t {
+	case timeType:
+		return &schema{Type: schemaType{"string"}, Format: "date-time"}
+	case rawMessageType:
+		return &schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schema(t.Elem())
+	case reflect.String:
+		return &schema{Type: schemaType{"string"}}
+	case reflect.Bool:
+		return &schema{Type: schemaType{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema{Type: schemaType{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: schemaType{"number"}}
+	case reflect.Slice, reflect.Array:
+		return &schema{Type: schemaType{"array"}, Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: schemaType{"object"}, AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.component(t)
+	}
+	return &schema{}
+}
+
+// component returns a reference to the component of a named struct, generating it first.
+func (g *schemaGenerator) component(t reflect.Type) *schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = exported(t.Name())
+		if _, taken := g.components[name]; taken {
+			name = exported(t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]) + name
+		}
+		g.names[t] = name
+		g.components[name] = &schema{}
+		*g.components[name] = *g.object(t)
+	}
+	return &schema{Ref: "#/components/schemas/" + name}
+}
+
+// object returns the schema of a struct. Fields without omitempty are required, and fields which
+// encode as null when unset are nullable. The openapi tag marks fields readOnly or writeOnly and
+// lists the values of a field with enum=a|b.
+func (g *schemaGenerator) object(t reflect.Type) *schema {
+	s := &schema{Type: schemaType{"object"}, Properties: map[string]*schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+
+		opts := strings.Split(tag, ",")
+		name := opts[0]
+		if f.Anonymous && name == "" {
+			embedded := g.object(indirect(f.Type))
+			for k, p := range embedded.Properties {
+				s.Properties[k] = p
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		p := g.schema(f.Type)
+		omitempty := contains(opts[1:], "omitempty")
+		if !omitempty {
+			s.Required = append(s.Required, name)
+			if k := f.Type.Kind(); k == reflect.Ptr || k == reflect.Slice || k == reflect.Map {
+				p = nullable(p)
+			}
+		}
+
+		for _, opt := range strings.Split(f.Tag.Get("openapi"), ",") {
+			switch {
+			case opt == "readOnly":
+				p.ReadOnly = true
+			case opt == "writeOnly":
+				p.WriteOnly = true
+			case strings.HasPrefix(opt, "enum="):
+				p.Enum = strings.Split(strings.TrimPrefix(opt, "enum="), "|")
+			}
+		}
+
+		s.Properties[name] = p
+	}
+	return s
+}
+
+func exported(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// envelope returns the schema of a response envelope holding data, or only a status without data.
+func envelope(data *schema, extra map[string]*schema) *schema {
+	s := &schema{
+		Type: schemaType{"object"},
+		Properties: map[string]*schema{
+			"status": {Type: schemaType{"integer"}, Description: "HTTP status code"},
+			"mesage": {Type: schemaType{"string"}, Description: "SUCCESS or the error message"},
+		},
+		Required: []string{"status", "mesage"},
+	}
+	if data != nil {
+		s.Properties["data"] = data
+		s.Required = append(s.Required, "data")
+	}
+	for k, p := range extra {
+		s.Properties[k] = p
+	}
+	return s
+}
+
+// negotiated adds the media types of an enveloped response, which are negotiated by Negotiate, to
+// content.
+func negotiated(content map[string]mediaType, s *schema) map[string]mediaType {
+	for _, c := range []codec.Codec{codec.JSON, codec.XML, codec.MessagePack} {
+		content[c.MediaTypes()[0]] = mediaType{Schema: s}
+	}
+	return content
+}
+
+// routeParam matches chi URL parameters, which may restrict their values with a regexp.
+var routeParam = regexp.MustCompile(`\{(\w+)(?::([^}]+))?\}`)
+
+// openAPIPath returns the OpenAPI path of a chi route pattern and the values of parameters which
+// are restricted to a list by a regexp such as {format:atom|rss|json}. The /* of mounted routers
+// is removed from the pattern.
+func openAPIPath(pattern string) (string, map[string][]string) {
+	pattern = strings.Replace(pattern, "/*", "", -1)
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	enums := map[string][]string{}
+	path := routeParam.ReplaceAllStringFunc(pattern, func(m string) string {
+		sub := routeParam.FindStringSubmatch(m)
+		if sub[2] != "" {
+			enums[sub[1]] = strings.Split(sub[2], "|")
+		}
+		return "{" + sub[1] + "}"
+	})
+	return path, enums
+}
+
+// newOpenAPIDoc generates the OpenAPI document of routes. Routes without an entry in routeDocs are
+// left out of the document.
+func newOpenAPIDoc(routes chi.Routes) (*openAPIDoc, error) {
+	g := newSchemaGenerator()
+	doc := &openAPIDoc{
+		OpenAPI: openAPIVersion,
+		Info:    openAPIInfo{Title: "Articles Library", Version: "1.0.0"},
+		Paths:   map[string]pathItem{},
+	}
+
+	err := chi.Walk(routes, func(method, pattern string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		path, enums := openAPIPath(pattern)
+		rd, ok := routeDocs[method+" "+path]
+		if !ok {
+			return nil
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = pathItem{}
+		}
+		doc.Paths[path][strings.ToLower(method)] = rd.operation(g, path, enums)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Components = openAPIComponents{
+		Schemas: g.components,
+		Responses: map[string]*response{
+			"Error": {
+				Description: "Error",
+				Content:     negotiated(map[string]mediaType{}, envelope(nullable(g.of([]violation{})), nil)),
+			},
+		},
+	}
+	return doc, nil
+}
+
+// routeDoc documents an operation of the API. Successful responses carry data in the response
+// envelope, or content of other media types.
+type routeDoc struct {
+	summary     string
+	description string
+	tag         string
+	params      []*parameter
+	// body is a value of the type of the JSON request body.
+	body interface{}
+	// status is the status of successful responses, 200 OK by default.
+	status int
+	// data is a value of the type of the data of successful responses, nil responds with a
+	// status only. next adds the cursor of the next page to the envelope.
+	data interface{}
+	next bool
+	// streamed adds the NDJSON stream of the items of a list, requested with Accept.
+	streamed bool
+	// content lists the media types of responses without an envelope.
+	content map[string]*schema
+	// responses describes other successful responses, such as redirects.
+	responses map[int]string
+	errors    []int
+}
+
+func (rd routeDoc) operation(g *schemaGenerator, path string, enums map[string][]string) *openAPIOperation {
+	op := &openAPIOperation{
+		Summary:     rd.summary,
+		Description: rd.description,
+		Responses:   map[string]*response{},
+	}
+	if rd.tag != "" {
+		op.Tags = []string{rd.tag}
+	}
+
+	for _, m := range routeParam.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, rd.pathParam(m[1], enums[m[1]]))
+	}
+	for _, p := range rd.params {
+		if p.In != "path" {
+			op.Parameters = append(op.Parameters, p)
+		}
+	}
+
+	if rd.body != nil {
+		body := g.of(rd.body)
+		op.RequestBody = &requestBody{Required: true, Content: map[string]mediaType{}}
+		for _, c := range []codec.Codec{codec.JSON, codec.XML, codec.MessagePack} {
+			op.RequestBody.Content[c.MediaTypes()[0]] = mediaType{Schema: body}
+		}
+	}
+
+	status := rd.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &response{Description: http.StatusText(status), Content: map[string]mediaType{}}
+	if rd.content != nil {
+		for contentType, s := range rd.content {
+			success.Content[contentType] = mediaType{Schema: s}
+		}
+	} else {
+		var data *schema
+		if rd.data != nil {
+			data = nullable(g.of(rd.data))
+		}
+		var extra map[string]*schema
+		if rd.next {
+			extra = map[string]*schema{"next": {Type: schemaType{"string"}, Description: "cursor of the next page, passed as after"}}
+		}
+		negotiated(success.Content, envelope(data, extra))
+		if rd.data != nil && reflect.TypeOf(rd.data).Kind() == reflect.Slice {
+			success.Content[codec.CSV.MediaTypes()[0]] = mediaType{Schema: &schema{Type: schemaType{"string"}, Description: "a record of every item of data"}}
+			if rd.streamed {
+				success.Content[codec.NDJSON] = mediaType{Schema: g.schema(reflect.TypeOf(rd.data).Elem())}
+			}
+		}
+	}
+	op.Responses[fmt.Sprint(status)] = success
+
+	for code, description := range rd.responses {
+		op.Responses[fmt.Sprint(code)] = &response{Description: description}
+	}
+
+	// requests which do not match the document are rejected before they reach the handler
+	codes := append([]int{}, rd.errors...)
+	if len(op.Parameters) > 0 || op.RequestBody != nil {
+		codes = append(codes, http.StatusBadRequest)
+	}
+	if op.RequestBody != nil {
+		codes = append(codes, http.StatusUnsupportedMediaType)
+	}
+	if rd.content == nil {
+		codes = append(codes, http.StatusNotAcceptable)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		op.Responses[fmt.Sprint(code)] = &response{Ref: "#/components/responses/Error"}
+	}
+
+	return op
+}
+
+// pathParam returns the documented parameter of a path, or a string parameter.
+func (rd routeDoc) pathParam(name string, enum []string) *parameter {
+	for _, p := range rd.params {
+		if p.In == "path" && p.Name == name {
+			return p
+		}
+	}
+	return &parameter{Name: name, In: "path", Required: true, Schema: &schema{Type: schemaType{"string"}, Enum: enum}}
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8" />
+  <title>Articles Library API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
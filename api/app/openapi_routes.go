@@ -0,0 +1,287 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/ykaseng/articles-library/models"
+	"github.com/ykaseng/articles-library/transfer"
+)
+
+// The list of reusable parameters of routeDocs.
+var (
+	articleIDParam      = &parameter{Name: "articleID", In: "path", Required: true, Description: "public ID of the article, a ULID", Schema: &schema{Type: schemaType{"string"}}}
+	idempotencyKeyParam = &parameter{Name: IdempotencyKeyHeader, In: "header", Description: "replays the stored response of a request with the same key", Schema: &schema{Type: schemaType{"string"}}}
+	renderParam         = &parameter{Name: "render", In: "query", Description: "renders content as sanitized HTML", Schema: &schema{Type: schemaType{"string"}, Enum: []string{"html"}}}
+	webhookIDParam      = &parameter{Name: "webhookID", In: "path", Required: true, Schema: &schema{Type: schemaType{"integer"}}}
+	exportIDParam       = &parameter{Name: "exportID", In: "path", Required: true, Schema: &schema{Type: schemaType{"integer"}}}
+	jobIDParam          = &parameter{Name: "jobID", In: "path", Required: true, Schema: &schema{Type: schemaType{"integer"}}}
+)
+
+// limitParam returns the ?limit= parameter of a list holding up to 100 items.
+func limitParam(description string) *parameter {
+	min, max := 1, 100
+	return &parameter{Name: "limit", In: "query", Description: description, Schema: &schema{Type: schemaType{"integer"}, Minimum: &min, Maximum: &max}}
+}
+
+func queryParam(name, description string, enum ...string) *parameter {
+	return &parameter{Name: name, In: "query", Description: description, Schema: &schema{Type: schemaType{"string"}, Enum: enum}}
+}
+
+// routeDocs documents the routes of the API by method and OpenAPI path. Routes without an entry
+// are left out of the OpenAPI document.
+var routeDocs = map[string]routeDoc{
+	"GET /articles": {
+		summary:     "List articles",
+		description: "Without parameters all articles are returned. limit, after, author and tag return a page of articles in public ID order, and q returns the best matches of a full text search. With Accept: application/x-ndjson the articles are streamed one per line in public ID order, restricted by after, author and tag.",
+		tag:         "articles",
+		params: []*parameter{
+			queryParam("q", "full text search of title and content"),
+			limitParam("number of articles of a page or search, 20 by default"),
+			queryParam("after", "cursor of the next page"),
+			queryParam("author", "name of the author of the articles"),
+			queryParam("tag", "tag of the articles"),
+			queryParam("fields", "comma separated fields of the articles to return, of id, title, content, author, slug, content_format, tags and published_at"),
+			queryParam("include", "comma separated relations to embed as objects in place of their names, of author and tags"),
+			renderParam,
+		},
+		data:     []listedArticle{},
+		next:     true,
+		streamed: true,
+		errors:   []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+	},
+	"POST /articles": {
+		summary: "Create an article",
+		tag:     "articles",
+		params:  []*parameter{idempotencyKeyParam},
+		body:    models.Article{},
+		data:    models.ArticleID{},
+		errors:  []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+	"GET /articles/by-slug/{slug}": {
+		summary:   "Get an article by slug",
+		tag:       "articles",
+		params:    []*parameter{renderParam},
+		data:      []models.Article{},
+		responses: map[int]string{http.StatusMovedPermanently: "Redirect from a replaced slug to the current slug"},
+		errors:    []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	"GET /articles/{articleID}": {
+		summary: "Get an article",
+		tag:     "articles",
+		params:  []*parameter{articleIDParam, renderParam},
+		data:    []models.Article{},
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	"PUT /articles/{articleID}": {
+		summary: "Update an article",
+		tag:     "articles",
+		params:  []*parameter{articleIDParam},
+		body:    models.Article{},
+		data:    models.Article{},
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	"DELETE /articles/{articleID}": {
+		summary: "Delete an article",
+		tag:     "articles",
+		params:  []*parameter{articleIDParam},
+		data:    models.ArticleID{},
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	"POST /articles:batch": {
+		summary:     "Create, update and delete articles in a batch",
+		description: "With atomic=true all operations are applied in one transaction which rolls back on the first failure. Otherwise every operation reports its own status.",
+		tag:         "articles",
+		params: []*parameter{
+			{Name: "atomic", In: "query", Schema: &schema{Type: schemaType{"boolean"}}},
+			idempotencyKeyParam,
+		},
+		body:   batchArticlesRequest{},
+		data:   []batchResult{},
+		errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+	"GET /articles/stream": {
+		summary:     "Stream article changes",
+		description: "Server-sent events of created, updated and deleted articles.",
+		tag:         "articles",
+		params: []*parameter{
+			{Name: "Last-Event-ID", In: "header", Description: "resumes the stream after an event", Schema: &schema{Type: schemaType{"integer"}}},
+		},
+		content: map[string]*schema{"text/event-stream": {Type: schemaType{"string"}}},
+		errors:  []int{http.StatusBadRequest},
+	},
+	"GET /feeds/articles.{format}": {
+		summary:   "Feed of recent articles",
+		tag:       "feeds",
+		content:   feedContent,
+		responses: map[int]string{http.StatusNotModified: "Not Modified"},
+		errors:    []int{http.StatusUnprocessableEntity},
+	},
+	"GET /feeds/authors/{author}/articles.{format}": {
+		summary:   "Feed of recent articles of an author",
+		tag:       "feeds",
+		content:   feedContent,
+		responses: map[int]string{http.StatusNotModified: "Not Modified"},
+		errors:    []int{http.StatusUnprocessableEntity},
+	},
+	"GET /feeds/tags/{tag}/articles.{format}": {
+		summary:   "Feed of recent articles with a tag",
+		tag:       "feeds",
+		content:   feedContent,
+		responses: map[int]string{http.StatusNotModified: "Not Modified"},
+		errors:    []int{http.StatusUnprocessableEntity},
+	},
+	"GET /graphql": {
+		summary: "Run a GraphQL query",
+		tag:     "graphql",
+		params: []*parameter{
+			queryParam("query", "GraphQL query"),
+			queryParam("operationName", "operation of the query to run"),
+			queryParam("variables", "JSON object of variables"),
+		},
+		content: graphQLContent,
+	},
+	"POST /graphql": {
+		summary: "Run a GraphQL query or mutation",
+		tag:     "graphql",
+		body:    graphQLRequest{},
+		content: graphQLContent,
+	},
+	"POST /webhooks": {
+		summary: "Create a webhook",
+		tag:     "webhooks",
+		body:    models.Webhook{},
+		status:  http.StatusCreated,
+		data:    models.Webhook{},
+		errors:  []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+	},
+	"GET /webhooks": {
+		summary: "List webhooks",
+		tag:     "webhooks",
+		data:    []models.Webhook{},
+		errors:  []int{http.StatusUnprocessableEntity},
+	},
+	"GET /webhooks/{webhookID}": {
+		summary: "Get a webhook",
+		tag:     "webhooks",
+		params:  []*parameter{webhookIDParam},
+		data:    models.Webhook{},
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	"DELETE /webhooks/{webhookID}": {
+		summary: "Delete a webhook",
+		tag:     "webhooks",
+		params:  []*parameter{webhookIDParam},
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	"GET /webhooks/{webhookID}/deliveries": {
+		summary: "List deliveries of a webhook, newest first",
+		tag:     "webhooks",
+		params: []*parameter{
+			webhookIDParam,
+			queryParam("status", "status of the deliveries", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead),
+			limitParam("number of deliveries, 50 by default"),
+		},
+		data:   []models.Delivery{},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	"POST /webhooks/{webhookID}/deliveries/{deliveryID}/redeliver": {
+		summary: "Send a delivery again",
+		tag:     "webhooks",
+		params: []*parameter{
+			webhookIDParam,
+			{Name: "deliveryID", In: "path", Required: true, Schema: &schema{Type: schemaType{"integer"}}},
+		},
+		status: http.StatusAccepted,
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	"POST /exports": {
+		summary: "Export articles",
+		tag:     "exports",
+		body:    postExportRequest{},
+		status:  http.StatusAccepted,
+		data:    models.Export{},
+		errors:  []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+	},
+	"GET /exports/{exportID}": {
+		summary: "Get the status of an export",
+		tag:     "exports",
+		params:  []*parameter{exportIDParam},
+		data:    models.Export{},
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	"GET /exports/{exportID}/download": {
+		summary: "Download a completed export",
+		tag:     "exports",
+		params:  []*parameter{exportIDParam},
+		content: map[string]*schema{
+			exportContentTypes[transfer.FormatJSONL]:       {Type: schemaType{"string"}},
+			exportContentTypes[transfer.FormatCSV]:         {Type: schemaType{"string"}},
+			exportContentTypes[transfer.FormatMarkdownZip]: {Type: schemaType{"string"}, Format: "binary"},
+		},
+		errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusGone},
+	},
+	"GET /admin/jobs": {
+		summary: "List jobs, newest first",
+		tag:     "jobs",
+		params: []*parameter{
+			queryParam("status", "status of the jobs", models.JobPending, models.JobRunning, models.JobCompleted, models.JobDead, models.JobCancelled),
+			queryParam("kind", "kind of the jobs"),
+			limitParam("number of jobs, 50 by default"),
+		},
+		data:   []models.Job{},
+		errors: []int{http.StatusBadRequest, http.StatusUnprocessableEntity},
+	},
+	"GET /admin/jobs/{jobID}": {
+		summary: "Get a job",
+		tag:     "jobs",
+		params:  []*parameter{jobIDParam},
+		data:    models.Job{},
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	"POST /admin/jobs/{jobID}/retry": {
+		summary: "Run a job again now",
+		tag:     "jobs",
+		params:  []*parameter{jobIDParam},
+		data:    models.Job{},
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+	"POST /admin/jobs/{jobID}/cancel": {
+		summary: "Cancel a pending job",
+		tag:     "jobs",
+		params:  []*parameter{jobIDParam},
+		data:    models.Job{},
+		errors:  []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+	"GET /admin/cache": {
+		summary:     "Article cache statistics",
+		description: "Hits, misses, coalesced loads and invalidations of the article cache, and the memory it uses. A disabled cache reports `enabled: false`.",
+		tag:         "cache",
+		data:        CacheStats{},
+	},
+	"GET /openapi.json": {
+		summary: "OpenAPI document of the API",
+		tag:     "docs",
+		content: map[string]*schema{"application/json": {Type: schemaType{"object"}}},
+	},
+	"GET /docs": {
+		summary: "Swagger UI for the OpenAPI document",
+		tag:     "docs",
+		content: map[string]*schema{"text/html": {Type: schemaType{"string"}}},
+	},
+}
+
+var feedContent = map[string]*schema{
+	"application/atom+xml":  {Type: schemaType{"string"}},
+	"application/rss+xml":   {Type: schemaType{"string"}},
+	"application/feed+json": {Type: schemaType{"object"}},
+}
+
+var graphQLContent = map[string]*schema{
+	"application/json": {
+		Type: schemaType{"object"},
+		Properties: map[string]*schema{
+			"data":   {},
+			"errors": {Type: schemaType{"array"}, Items: &schema{Type: schemaType{"object"}}},
+		},
+	},
+}
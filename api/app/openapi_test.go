@@ -0,0 +1,132 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIArticleRoutes(t *testing.T) {
+	r := chi.NewRouter()
+	r.Mount("/articles", NewArticleResource(nil).router())
+
+	doc, err := newOpenAPIDoc(r)
+	if err != nil {
+		t.Fatalf("generate document failed: %v", err)
+	}
+
+	err = chi.Walk(r, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		path, _ := openAPIPath(route)
+		if _, ok := doc.Paths[path][strings.ToLower(method)]; !ok {
+			t.Errorf("%s %s is missing from the OpenAPI document, document it in routeDocs", method, path)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	tt := []struct {
+		pattern string
+		path    string
+		enums   map[string][]string
+	}{
+		{"/articles/*/", "/articles", map[string][]string{}},
+		{"/articles/*/{articleID}/*/", "/articles/{articleID}", map[string][]string{}},
+		{"/articles:batch", "/articles:batch", map[string][]string{}},
+		{"/feeds/*/tags/{tag}/articles.{format:atom|rss|json}", "/feeds/tags/{tag}/articles.{format}", map[string][]string{"format": {"atom", "rss", "json"}}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.pattern, func(t *testing.T) {
+			path, enums := openAPIPath(tc.pattern)
+			assert.Equal(t, tc.path, path)
+			assert.Equal(t, tc.enums, enums)
+		})
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	a := &API{
+		Article:     NewArticleResource(nil),
+		Feed:        &FeedResource{},
+		GraphQL:     &GraphQLResource{},
+		Stream:      &StreamResource{},
+		Webhook:     &WebhookResource{},
+		Job:         &JobResource{},
+		Export:      &ExportResource{},
+		Idempotency: NewIdempotency(nil, 0),
+		OpenAPI:     &OpenAPIResource{},
+	}
+	r := a.Router()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var doc struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+				Required   []string                          `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("unmarshal document failed: %v", err)
+	}
+
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+
+	err := chi.Walk(r, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		path, _ := openAPIPath(route)
+		assert.Contains(t, doc.Paths[path], strings.ToLower(method), "%s %s", method, path)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NotContains(t, doc.Paths, "/docs", "Swagger UI is disabled")
+
+	article := doc.Components.Schemas["Article"]
+	assert.Equal(t, []string{"id", "title", "content", "author"}, article.Required)
+	assert.Equal(t, true, article.Properties["id"]["readOnly"])
+	assert.Equal(t, []interface{}{"plain", "markdown", "html"}, article.Properties["content_format"]["enum"])
+	assert.Equal(t, "array", article.Properties["tags"]["type"])
+	assert.Equal(t, "date-time", article.Properties["published_at"]["format"])
+
+	webhook := doc.Components.Schemas["Webhook"]
+	assert.Equal(t, []interface{}{"array", "null"}, webhook.Properties["events"]["type"])
+	assert.Equal(t, true, webhook.Properties["secret"]["writeOnly"])
+
+	assert.Equal(t, []string{"index", "op", "status", "mesage"}, doc.Components.Schemas["BatchResult"].Required)
+	assert.Contains(t, string(doc.Paths["/articles"]["get"]), `"mesage"`)
+	assert.Contains(t, string(doc.Paths["/articles"]["get"]), `"next"`)
+	assert.NotContains(t, string(doc.Paths["/articles"]["get"]), `"message"`)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/docs", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestSwaggerUI(t *testing.T) {
+	rs := &OpenAPIResource{SwaggerUI: true}
+	r := chi.NewRouter()
+	r.Get("/openapi.json", rs.spec(r))
+	r.Get("/docs", rs.swaggerUI)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/docs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `url: "/openapi.json"`)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Contains(t, rec.Body.String(), `"/docs"`)
+}
@@ -0,0 +1,500 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+
+	"github.com/ykaseng/articles-library/codec"
+)
+
+// violation is a part of a request or response which does not match the OpenAPI document.
+type violation struct {
+	// In is path, query or header for parameters, and body or status otherwise.
+	In string `json:"in"`
+	// Name is the name of a parameter or the location of a value in the body, such as
+	// operations[0].op.
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message"`
+}
+
+func (v violation) String() string {
+	if v.Name == "" {
+		return v.In + " " + v.Message
+	}
+	return v.In + " " + v.Name + " " + v.Message
+}
+
+// errViolations returns an error response with the given status listing violations in data.
+func errViolations(code int, message string, violations []violation) render.Renderer {
+	msgs := make([]string, len(violations))
+	for i, v := range violations {
+		msgs[i] = v.String()
+	}
+
+	var data interface{} = violations
+	return &ErrResponse{
+		Status: Status{Code: code, Message: message + ": " + strings.Join(msgs, "; ")},
+		Data:   &data,
+	}
+}
+
+// validatedRoute matches requests to an operation of the OpenAPI document.
+type validatedRoute struct {
+	method  string
+	pattern *regexp.Regexp
+	params  []string
+	op      *openAPIOperation
+}
+
+// openAPIValidator validates requests and responses against the operations of an OpenAPI document.
+type openAPIValidator struct {
+	doc    *openAPIDoc
+	routes []*validatedRoute
+}
+
+func newOpenAPIValidator(doc *openAPIDoc) *openAPIValidator {
+	v := &openAPIValidator{doc: doc}
+	for path, item := range doc.Paths {
+		var params []string
+		pattern, last := "^", 0
+		for _, m := range routeParam.FindAllStringSubmatchIndex(path, -1) {
+			pattern += regexp.QuoteMeta(path[last:m[0]]) + "([^/]+)"
+			params = append(params, path[m[2]:m[3]])
+			last = m[1]
+		}
+		pattern += regexp.QuoteMeta(path[last:]) + "$"
+
+		for method, op := range item {
+			v.routes = append(v.routes, &validatedRoute{
+				method:  strings.ToUpper(method),
+				pattern: regexp.MustCompile(pattern),
+				params:  params,
+				op:      op,
+			})
+		}
+	}
+
+	// like the router, static paths take precedence over parameters
+	sort.Slice(v.routes, func(i, j int) bool {
+		if len(v.routes[i].params) != len(v.routes[j].params) {
+			return len(v.routes[i].params) < len(v.routes[j].params)
+		}
+		return v.routes[i].pattern.String() > v.routes[j].pattern.String()
+	})
+	return v
+}
+
+// match returns the operation of a request and its path parameters, or nil for undocumented routes.
+func (v *openAPIValidator) match(r *http.Request) (*openAPIOperation, map[string]string) {
+	path := r.URL.Path
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+		path = rctx.RoutePath
+	}
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	for _, route := range v.routes {
+		if route.method != r.Method {
+			continue
+		}
+		m := route.pattern.FindStringSubmatch(path)
+		if m == nil {
+			continue
+		}
+		params := map[string]string{}
+		for i, name := range route.params {
+			params[name] = m[i+1]
+		}
+		return route.op, params
+	}
+	return nil, nil
+}
+
+// validateRequest validates the parameters and body of a request, leaving the body to be read
+// again by the handler. It returns the status of the error response with the violations found.
+func (v *openAPIValidator) validateRequest(op *openAPIOperation, params map[string]string, r *http.Request) (int, []violation) {
+	var violations []violation
+	for _, p := range op.Parameters {
+		var value string
+		var ok bool
+		switch p.In {
+		case "path":
+			value, ok = params[p.Name]
+		case "query":
+			var values []string
+			values, ok = r.URL.Query()[p.Name]
+			if ok {
+				value = values[0]
+			}
+		case "header":
+			value = r.Header.Get(p.Name)
+			ok = value != ""
+		}
+
+		if !ok {
+			if p.Required {
+				violations = append(violations, violation{In: p.In, Name: p.Name, Message: "is required"})
+			}
+			continue
+		}
+		for _, msg := range v.checkParam(p.Schema, value) {
+			violations = append(violations, violation{In: p.In, Name: p.Name, Message: msg})
+		}
+	}
+
+	if op.RequestBody == nil {
+		return http.StatusBadRequest, violations
+	}
+
+	// requests without a content type are taken to be JSON
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		var err error
+		mediaType, _, err = mime.ParseMediaType(ct)
+		if _, ok := op.RequestBody.Content[mediaType]; err != nil || !ok {
+			return http.StatusUnsupportedMediaType, []violation{{In: "header", Name: "Content-Type", Message: "must be application/json, application/xml or application/msgpack"}}
+		}
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return http.StatusBadRequest, append(violations, violation{In: "body", Message: err.Error()})
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			violations = append(violations, violation{In: "body", Message: "is required"})
+		}
+		return http.StatusBadRequest, violations
+	}
+
+	// other media types are decoded into JSON by Negotiate before they are validated
+	if mediaType != "application/json" {
+		return http.StatusBadRequest, violations
+	}
+
+	value, err := decodeJSON(body)
+	if err != nil {
+		return http.StatusBadRequest, append(violations, violation{In: "body", Message: "must be valid JSON: " + err.Error()})
+	}
+	return http.StatusBadRequest, append(violations, v.checkBody(op.RequestBody.Content[mediaType].Schema, value, true)...)
+}
+
+// validateResponse validates the status, content type and JSON body of a response.
+func (v *openAPIValidator) validateResponse(op *openAPIOperation, status int, header http.Header, body []byte) []violation {
+	res, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		return []violation{{In: "status", Message: fmt.Sprintf("%d is not documented", status)}}
+	}
+	if res.Ref != "" {
+		res = v.doc.Components.Responses[strings.TrimPrefix(res.Ref, "#/components/responses/")]
+	}
+	if len(res.Content) == 0 {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	content, ok := res.Content[mediaType]
+	if !ok {
+		return []violation{{In: "header", Name: "Content-Type", Message: fmt.Sprintf("%q is not documented", mediaType)}}
+	}
+	if mediaType != "application/json" {
+		return nil
+	}
+
+	value, err := decodeJSON(body)
+	if err != nil {
+		return []violation{{In: "body", Message: "must be valid JSON: " + err.Error()}}
+	}
+	return v.checkBody(content.Schema, value, false)
+}
+
+// jsonResponses reports whether an operation responds with JSON, which is the case for all
+// operations but streams, feeds and downloads.
+func jsonResponses(op *openAPIOperation) bool {
+	for _, res := range op.Responses {
+		if _, ok := res.Content["application/json"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *openAPIValidator) checkBody(s *schema, value interface{}, request bool) []violation {
+	var violations []violation
+	for _, e := range v.check(s, value, "", request) {
+		violations = append(violations, violation{In: "body", Name: e.name, Message: e.message})
+	}
+	return violations
+}
+
+// checkParam validates the value of a parameter, which is parsed as the type of its schema.
+func (v *openAPIValidator) checkParam(s *schema, value string) []string {
+	var parsed interface{} = value
+	switch {
+	case s.Type.is("integer"):
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return []string{"must be an integer"}
+		}
+		parsed = json.Number(value)
+	case s.Type.is("boolean"):
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return []string{"must be a boolean"}
+		}
+		parsed = b
+	}
+
+	var msgs []string
+	for _, e := range v.check(s, parsed, "", true) {
+		msgs = append(msgs, e.message)
+	}
+	return msgs
+}
+
+// schemaError is a value which does not match a schema.
+type schemaError struct {
+	name    string
+	message string
+}
+
+// check validates a value decoded by decodeJSON against a schema. Read-only properties are not
+// required in requests, and write-only properties are neither required nor allowed in responses.
+func (v *openAPIValidator) check(s *schema, value interface{}, name string, request bool) []schemaError {
+	if s.Ref != "" {
+		return v.check(v.doc.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")], value, name, request)
+	}
+
+	if len(s.AnyOf) > 0 {
+		var first []schemaError
+		for i, alt := range s.AnyOf {
+			errs := v.check(alt, value, name, request)
+			if len(errs) == 0 {
+				return nil
+			}
+			if i == 0 {
+				first = errs
+			}
+		}
+		return first
+	}
+
+	t := jsonType(value)
+	if len(s.Type) > 0 && !s.Type.is(t) && !(t == "integer" && s.Type.is("number")) {
+		return []schemaError{{name, "must be of type " + strings.Join(s.Type, " or ")}}
+	}
+
+	switch value := value.(type) {
+	case string:
+		if len(s.Enum) > 0 && !contains(s.Enum, value) {
+			return []schemaError{{name, "must be one of " + strings.Join(s.Enum, ", ")}}
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				return []schemaError{{name, "must be an RFC 3339 date-time"}}
+			}
+		}
+	case json.Number:
+		n, err := value.Float64()
+		if err != nil {
+			return []schemaError{{name, "must be a number"}}
+		}
+		if s.Minimum != nil && n < float64(*s.Minimum) {
+			return []schemaError{{name, fmt.Sprintf("must be at least %d", *s.Minimum)}}
+		}
+		if s.Maximum != nil && n > float64(*s.Maximum) {
+			return []schemaError{{name, fmt.Sprintf("must be at most %d", *s.Maximum)}}
+		}
+	case []interface{}:
+		if s.Items == nil {
+			return nil
+		}
+		var errs []schemaError
+		for i, item := range value {
+			errs = append(errs, v.check(s.Items, item, fmt.Sprintf("%s[%d]", name, i), request)...)
+		}
+		return errs
+	case map[string]interface{}:
+		return v.checkObject(s, value, name, request)
+	}
+	return nil
+}
+
+func (v *openAPIValidator) checkObject(s *schema, value map[string]interface{}, name string, request bool) []schemaError {
+	prefix := name
+	if prefix != "" {
+		prefix += "."
+	}
+
+	var errs []schemaError
+	for _, key := range s.Required {
+		p := s.Properties[key]
+		if _, ok := value[key]; ok || (p != nil && ((request && p.ReadOnly) || (!request && p.WriteOnly))) {
+			continue
+		}
+		errs = append(errs, schemaError{prefix + key, "is required"})
+	}
+
+	for _, key := range sortedKeys(value) {
+		p, ok := s.Properties[key]
+		if !ok {
+			p = s.AdditionalProperties
+		}
+		if p == nil {
+			continue
+		}
+		if !request && p.WriteOnly {
+			errs = append(errs, schemaError{prefix + key, "must not be returned"})
+			continue
+		}
+		errs = append(errs, v.check(p, value[key], prefix+key, request)...)
+	}
+	return errs
+}
+
+// is reports whether t includes a type.
+func (t schemaType) is(name string) bool {
+	return contains(t, name)
+}
+
+// jsonType returns the JSON Schema type of a value decoded by decodeJSON.
+func jsonType(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if strings.ContainsAny(string(value), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
+
+// decodeJSON decodes a JSON document keeping numbers as json.Number, so that integers can be told
+// apart from other numbers.
+func decodeJSON(b []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var value interface{}
+	if err := d.Decode(&value); err != nil {
+		return nil, err
+	}
+	if d.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON value")
+	}
+	return value, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// responseBuffer holds a response until it is validated.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+// validate returns a middleware validating requests against the OpenAPI document of routes before
+// they reach their handlers. Requests which do not match the document are rejected with 400 Bad
+// Request, or 415 Unsupported Media Type, listing the violations in data. With ValidateResponses
+// JSON responses are validated too, and replaced with 500 Internal Server Error when they drift
+// from the document.
+func (rs *OpenAPIResource) validate(routes chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !rs.ValidateRequests && !rs.ValidateResponses {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			v, err := rs.validator(routes)
+			if err != nil {
+				log(r).Error(err)
+				render.Render(w, r, ErrInternalServerError)
+				return
+			}
+
+			op, params := v.match(r)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if rs.ValidateRequests {
+				if status, violations := v.validateRequest(op, params, r); len(violations) > 0 {
+					render.Render(w, r, errViolations(status, "invalid request", violations))
+					return
+				}
+			}
+
+			// streamed lists are written as they are read and cannot be held for validation
+			if !rs.ValidateResponses || !jsonResponses(op) || codec.Prefers(r.Header.Get("Accept"), codec.NDJSON) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			buf := &responseBuffer{header: http.Header{}}
+			next.ServeHTTP(buf, r)
+			if buf.status == 0 {
+				buf.status = http.StatusOK
+			}
+
+			if violations := v.validateResponse(op, buf.status, buf.header, buf.body.Bytes()); len(violations) > 0 {
+				log(r).WithField("violations", violations).Error("response does not match the OpenAPI document")
+				render.Render(w, r, errViolations(http.StatusInternalServerError, "invalid response", violations))
+				return
+			}
+
+			for k, values := range buf.header {
+				w.Header()[k] = values
+			}
+			w.WriteHeader(buf.status)
+			w.Write(buf.body.Bytes())
+		})
+	}
+}
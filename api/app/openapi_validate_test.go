@@ -0,0 +1,187 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/logging"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// validatedRouter returns the routes of an API validating requests and responses against its
+// OpenAPI document.
+func validatedRouter(articles ArticleStore, webhooks WebhookStore) http.Handler {
+	a := &API{
+		Article:     NewArticleResource(articles),
+		Feed:        &FeedResource{},
+		GraphQL:     &GraphQLResource{},
+		Stream:      &StreamResource{},
+		Webhook:     NewWebhookResource(webhooks),
+		Job:         NewJobResource(&memoryJobStore{jobs: []models.Job{{ID: 1, Kind: "export", Status: models.JobPending}}}),
+		Export:      &ExportResource{},
+		Idempotency: NewIdempotency(nil, 0),
+		OpenAPI:     &OpenAPIResource{ValidateRequests: true, ValidateResponses: true},
+	}
+	return a.Router()
+}
+
+func TestValidateRequest(t *testing.T) {
+	tt := []struct {
+		name        string
+		method      string
+		endpoint    string
+		contentType string
+		body        string
+		code        int
+		violations  []violation
+	}{
+		{"create", "POST", "/articles", "application/json", `{"title":"New Title","content":"New Content","author":"Test Author"}`, http.StatusOK, nil},
+		{"create without content type", "POST", "/articles", "", `{"id":"","title":"New Title","content":"New Content","author":"Test Author"}`, http.StatusOK, nil},
+		{"missing properties", "POST", "/articles", "application/json", `{"title":"New Title"}`, http.StatusBadRequest, []violation{
+			{In: "body", Name: "content", Message: "is required"},
+			{In: "body", Name: "author", Message: "is required"},
+		}},
+		{"wrong type", "POST", "/articles", "application/json", `{"title":1,"content":"New Content","author":"Test Author","tags":"go"}`, http.StatusBadRequest, []violation{
+			{In: "body", Name: "tags", Message: "must be of type array"},
+			{In: "body", Name: "title", Message: "must be of type string"},
+		}},
+		{"enum", "POST", "/articles", "application/json", `{"title":"New Title","content":"New Content","author":"Test Author","content_format":"pdf"}`, http.StatusBadRequest, []violation{
+			{In: "body", Name: "content_format", Message: "must be one of plain, markdown, html"},
+		}},
+		{"date-time", "POST", "/articles", "application/json", `{"title":"New Title","content":"New Content","author":"Test Author","published_at":"yesterday"}`, http.StatusBadRequest, []violation{
+			{In: "body", Name: "published_at", Message: "must be an RFC 3339 date-time"},
+		}},
+		{"empty body", "POST", "/articles", "application/json", ``, http.StatusBadRequest, []violation{
+			{In: "body", Message: "is required"},
+		}},
+		{"unsupported content type", "POST", "/articles", "text/xml", `<article/>`, http.StatusUnsupportedMediaType, []violation{
+			{In: "header", Name: "Content-Type", Message: "must be application/json, application/xml or application/msgpack"},
+		}},
+		{"nested", "POST", "/articles:batch", "application/json", `{"operations":[{"op":"create","article":{"title":"New Title"}},{"op":"rename"}]}`, http.StatusBadRequest, []violation{
+			{In: "body", Name: "operations[0].article.content", Message: "is required"},
+			{In: "body", Name: "operations[0].article.author", Message: "is required"},
+			{In: "body", Name: "operations[1].op", Message: "must be one of create, update, delete"},
+		}},
+		{"boolean query", "POST", "/articles:batch?atomic=maybe", "application/json", `{"operations":[]}`, http.StatusBadRequest, []violation{
+			{In: "query", Name: "atomic", Message: "must be a boolean"},
+		}},
+		{"integer query", "GET", "/articles?limit=ten", "", ``, http.StatusBadRequest, []violation{
+			{In: "query", Name: "limit", Message: "must be an integer"},
+		}},
+		{"maximum", "GET", "/articles?limit=500&render=pdf", "", ``, http.StatusBadRequest, []violation{
+			{In: "query", Name: "limit", Message: "must be at most 100"},
+			{In: "query", Name: "render", Message: "must be one of html"},
+		}},
+		{"integer path", "GET", "/webhooks/first", "", ``, http.StatusBadRequest, []violation{
+			{In: "path", Name: "webhookID", Message: "must be an integer"},
+		}},
+		{"undocumented route", "GET", "/unknown", "", ``, http.StatusNotFound, nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &publicArticleStore{articles: []models.Article{{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "Test Title", Content: "Test Content", Author: "Test Author"}}}
+			r := validatedRouter(store, &memoryWebhookStore{})
+
+			req := httptest.NewRequest(tc.method, tc.endpoint, strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+			if tc.violations == nil {
+				return
+			}
+
+			var res struct {
+				Data []violation `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+				t.Fatalf("unmarshal response failed: %v", err)
+			}
+			assert.Equal(t, tc.violations, res.Data)
+			assert.Len(t, store.articles, 1, "invalid requests must not reach the handler")
+		})
+	}
+}
+
+func TestValidateResponses(t *testing.T) {
+	tt := []struct {
+		name     string
+		method   string
+		endpoint string
+		body     string
+		code     int
+	}{
+		{"list articles", "GET", "/articles?limit=1", ``, http.StatusOK},
+		{"search articles", "GET", "/articles?q=Test", ``, http.StatusOK},
+		{"get article", "GET", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", ``, http.StatusOK},
+		{"get missing article", "GET", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F02", ``, http.StatusNotFound},
+		{"get serial id", "GET", "/articles/1", ``, http.StatusBadRequest},
+		{"create article", "POST", "/articles", `{"title":"New Title","content":"New Content","author":"Test Author"}`, http.StatusOK},
+		{"invalid article", "POST", "/articles", `{"title":"New Title","content":"","author":"Test Author"}`, http.StatusBadRequest},
+		{"update article", "PUT", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", `{"title":"New Title","content":"New Content","author":"Test Author"}`, http.StatusOK},
+		{"delete article", "DELETE", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", ``, http.StatusOK},
+		{"create webhook", "POST", "/webhooks", `{"url":"https://example.com/hook","secret":"0123456789abcdef","events":["created"]}`, http.StatusCreated},
+		{"list webhooks", "GET", "/webhooks", ``, http.StatusOK},
+		{"get webhook", "GET", "/webhooks/1", ``, http.StatusOK},
+		{"delete webhook", "DELETE", "/webhooks/1", ``, http.StatusOK},
+		{"list jobs", "GET", "/admin/jobs", ``, http.StatusOK},
+		{"cancel job", "POST", "/admin/jobs/1/cancel", ``, http.StatusOK},
+		{"openapi", "GET", "/openapi.json", ``, http.StatusOK},
+	}
+
+	store := &publicArticleStore{articles: []models.Article{{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "Test Title", Content: "Test Content", Author: "Test Author", Tags: []string{"go"}}}}
+	webhooks := &memoryWebhookStore{webhooks: []models.Webhook{{ID: 1, URL: "https://example.com/existing", Events: []string{models.EventCreated}}}}
+	r := validatedRouter(store, webhooks)
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.endpoint, strings.NewReader(tc.body)))
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestValidateResponseDrift(t *testing.T) {
+	rs := &OpenAPIResource{ValidateResponses: true}
+	r := chi.NewRouter()
+	r.Use(logging.NewStructuredLogger(logging.NewLogger()))
+	r.Use(rs.validate(r))
+	r.Get("/articles/{articleID}", func(w http.ResponseWriter, r *http.Request) {
+		render.Respond(w, r, map[string]interface{}{"status": 200, "mesage": "SUCCESS", "data": []map[string]interface{}{{"id": 1, "title": "Test Title"}}})
+	})
+	r.Delete("/webhooks/{webhookID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/articles/01ARZ3NDEKTSV4RRFFQ69G5F01", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	var res struct {
+		Data []violation `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("unmarshal response failed: %v", err)
+	}
+	assert.Equal(t, []violation{
+		{In: "body", Name: "data[0].content", Message: "is required"},
+		{In: "body", Name: "data[0].author", Message: "is required"},
+		{In: "body", Name: "data[0].id", Message: "must be of type string"},
+	}, res.Data)
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("DELETE", "/webhooks/1", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "status 204 is not documented")
+}
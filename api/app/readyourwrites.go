@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ykaseng/articles-library/database"
+)
+
+// primaryCtxKey marks requests whose reads must see the earlier writes of their client.
+const primaryCtxKey ctxKey = 2
+
+// ReadPrimaryCookie is the cookie holding the Unix time until which the reads of a client are
+// served by the primary database.
+const ReadPrimaryCookie = "read_primary_until"
+
+// ReadYourWrites sends the reads of a client to the primary database for Window after each of its
+// writes, so that the client sees its writes before they reached the read replicas. A zero Window,
+// as without replicas, disables it.
+type ReadYourWrites struct {
+	Window time.Duration
+}
+
+// NewReadYourWrites returns a ReadYourWrites covering the lag of the configured read replicas.
+func NewReadYourWrites() *ReadYourWrites {
+	return &ReadYourWrites{Window: database.ReplicaMaxLag()}
+}
+
+// Handler sets ReadPrimaryCookie on the responses of writes, and marks requests carrying an
+// unexpired cookie to read from the primary, see readsPrimary. A nil ReadYourWrites is disabled.
+func (rw *ReadYourWrites) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rw == nil || rw.Window <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+		if hasReadPrimaryCookie(r, now) {
+			r = r.WithContext(context.WithValue(r.Context(), primaryCtxKey, true))
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			until := now.Add(rw.Window)
+			http.SetCookie(w, &http.Cookie{
+				Name:     ReadPrimaryCookie,
+				Value:    strconv.FormatInt(until.Unix(), 10),
+				Path:     "/",
+				MaxAge:   int((rw.Window + time.Second - 1) / time.Second),
+				HttpOnly: true,
+			})
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasReadPrimaryCookie reports whether a request carries a ReadPrimaryCookie which is unexpired at now.
+func hasReadPrimaryCookie(r *http.Request, now time.Time) bool {
+	c, err := r.Cookie(ReadPrimaryCookie)
+	if err != nil {
+		return false
+	}
+	until, err := strconv.ParseInt(c.Value, 10, 64)
+	return err == nil && now.Unix() <= until
+}
+
+// readsPrimary reports whether the reads of a request must be served by the primary database.
+func readsPrimary(r *http.Request) bool {
+	primary, _ := r.Context().Value(primaryCtxKey).(bool)
+	return primary
+}
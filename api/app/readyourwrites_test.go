@@ -0,0 +1,113 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	future := strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+	past := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+
+	tt := []struct {
+		name      string
+		window    time.Duration
+		method    string
+		cookie    string
+		lagging   bool
+		code      int
+		title     string
+		setsUntil bool
+	}{
+		{name: "replica", window: 5 * time.Second, method: "GET", title: "Replica Title"},
+		{name: "after write", window: 5 * time.Second, method: "GET", cookie: future, title: "Primary Title"},
+		{name: "window passed", window: 5 * time.Second, method: "GET", cookie: past, title: "Replica Title"},
+		{name: "invalid cookie", window: 5 * time.Second, method: "GET", cookie: "soon", title: "Replica Title"},
+		{name: "without replicas", method: "GET", cookie: future, title: "Replica Title"},
+		{name: "not replicated yet", window: 5 * time.Second, method: "GET", lagging: true, code: http.StatusNotFound},
+		{name: "not replicated yet after write", window: 5 * time.Second, method: "GET", cookie: future, lagging: true, title: "Primary Title"},
+		{name: "write", window: 5 * time.Second, method: "DELETE", setsUntil: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			article := func(title string) *publicArticleStore {
+				return &publicArticleStore{articles: []models.Article{{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: title}}}
+			}
+			replica := article("Replica Title")
+			if tc.lagging {
+				replica.articles = nil
+			}
+			rs := NewArticleResource(replica)
+			rs.Primary = article("Primary Title")
+
+			r := chi.NewRouter()
+			r.Use((&ReadYourWrites{Window: tc.window}).Handler)
+			r.Mount("/articles", rs.router())
+
+			req := httptest.NewRequest(tc.method, "/articles/"+string(testPublicID(1)), nil)
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: ReadPrimaryCookie, Value: tc.cookie})
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if tc.code != 0 {
+				assert.Equal(t, tc.code, rec.Code, "articles must be loaded from the database which resolved them")
+			}
+			if tc.title != "" {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Contains(t, rec.Body.String(), tc.title)
+			}
+
+			var cookie *http.Cookie
+			for _, c := range rec.Result().Cookies() {
+				if c.Name == ReadPrimaryCookie {
+					cookie = c
+				}
+			}
+			if !tc.setsUntil {
+				assert.Nil(t, cookie, "reads must not extend the window")
+				return
+			}
+			if assert.NotNil(t, cookie) {
+				until, err := strconv.ParseInt(cookie.Value, 10, 64)
+				assert.NoError(t, err)
+				assert.InDelta(t, time.Now().Add(tc.window).Unix(), until, 1)
+				assert.Equal(t, 5, cookie.MaxAge)
+			}
+		})
+	}
+}
+
+func TestReadYourWritesInclude(t *testing.T) {
+	replica := &publicArticleStore{articles: []models.Article{
+		{ArticleID: models.ArticleID{ID: 1, PublicID: testPublicID(1)}, Title: "First Title", Author: "Test Author"},
+	}}
+	primary := &publicArticleStore{articles: append(replica.articles,
+		models.Article{ArticleID: models.ArticleID{ID: 2, PublicID: testPublicID(2)}, Title: "Second Title", Author: "Test Author"},
+	)}
+	rs := NewArticleResource(replica)
+	rs.Primary = primary
+
+	r := chi.NewRouter()
+	r.Use((&ReadYourWrites{Window: 5 * time.Second}).Handler)
+	r.Mount("/articles", rs.router())
+
+	req := httptest.NewRequest("GET", "/articles?limit=10&include=author", nil)
+	req.AddCookie(&http.Cookie{Name: ReadPrimaryCookie, Value: strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)})
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "Second Title")
+	assert.Contains(t, rec.Body.String(), `"articles":2`, "included authors must be loaded from the database which listed the articles")
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/spf13/viper"
+
+	"github.com/ykaseng/articles-library/events"
+)
+
+// ErrInvalidLastEventID is returned for a Last-Event-ID header which was not sent as an event ID.
+var ErrInvalidLastEventID = errors.New("Last-Event-ID must be an event id")
+
+// StreamResource implements the Server-Sent Events stream of article changes.
+type StreamResource struct {
+	Broker *events.Broker
+	// Heartbeat is the interval of comments sent to keep idle connections open through proxies.
+	Heartbeat time.Duration
+	// Done is closed when the server shuts down, which ends every stream so that clients reconnect
+	// to another instance. http.Server.Shutdown waits for streams, but does not cancel them.
+	Done <-chan struct{}
+}
+
+// NewStreamResource creates and returns a stream resource configured from viper.
+func NewStreamResource(broker *events.Broker) *StreamResource {
+	heartbeat := viper.GetDuration("stream_heartbeat")
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+
+	return &StreamResource{
+		Broker:    broker,
+		Heartbeat: heartbeat,
+	}
+}
+
+func (rs *StreamResource) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		render.Render(w, r, ErrInternalServerError)
+		return
+	}
+
+	var lastID int64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			render.Render(w, r, ErrBadRequest(ErrInvalidLastEventID))
+			return
+		}
+		lastID = id
+	}
+
+	missed, ch := rs.Broker.Subscribe(lastID)
+	defer rs.Broker.Unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// disable response buffering of nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range missed {
+		writeEvent(w, e)
+	}
+	flusher.Flush()
+
+	t := time.NewTicker(rs.Heartbeat)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-rs.Done:
+			return
+		case e, ok := <-ch:
+			if !ok {
+				// dropped for falling behind, the client reconnects and resumes from Last-Event-ID
+				return
+			}
+			writeEvent(w, e)
+		case <-t.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes an event in the text/event-stream format. Data holds JSON, which has no newlines.
+func writeEvent(w http.ResponseWriter, e events.Event) {
+	if e.ID > 0 {
+		fmt.Fprintf(w, "id: %d\n", e.ID)
+	}
+	fmt.Fprintf(w, "event: %s\n", e.Type)
+	if e.Data != nil {
+		fmt.Fprintf(w, "data: %s\n\n", e.Data)
+	} else {
+		fmt.Fprint(w, "data: {}\n\n")
+	}
+}
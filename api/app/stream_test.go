@@ -0,0 +1,159 @@
+package app
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/events"
+)
+
+// readEvent reads the lines of the next event or comment from a stream.
+func readEvent(t *testing.T, r *bufio.Reader) []string {
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event failed: %v", err)
+		}
+		if line == "\n" {
+			return lines
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+}
+
+func TestStream(t *testing.T) {
+	rs := NewStreamResource(events.NewBroker(10))
+	rs.Heartbeat = 50 * time.Millisecond
+	srv := httptest.NewServer(http.HandlerFunc(rs.stream))
+	defer srv.Close()
+
+	tt := []struct {
+		name        string
+		lastEventID string
+		expected    [][]string
+	}{
+		{
+			name:     "new subscriber",
+			expected: [][]string{{"id: 3", "event: deleted", `data: {"id":1}`}},
+		},
+		{
+			name:        "resume",
+			lastEventID: "1",
+			expected: [][]string{
+				{"id: 2", "event: updated", `data: {"id":1}`},
+				{"id: 3", "event: deleted", `data: {"id":1}`},
+			},
+		},
+		{
+			name:        "resume evicted",
+			lastEventID: "100",
+			expected: [][]string{
+				{"event: reset", "data: {}"},
+				{"id: 3", "event: deleted", `data: {"id":1}`},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			b := events.NewBroker(10)
+			b.Publish(events.Event{ID: 1, Type: events.TypeCreated, Data: []byte(`{"id":1}`)})
+			b.Publish(events.Event{ID: 2, Type: events.TypeUpdated, Data: []byte(`{"id":1}`)})
+			rs.Broker = b
+
+			req, _ := http.NewRequest("GET", srv.URL, nil)
+			if tc.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tc.lastEventID)
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusOK, res.StatusCode)
+			assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+			r := bufio.NewReader(res.Body)
+			var actual [][]string
+			for i := 0; i < len(tc.expected)-1; i++ {
+				actual = append(actual, readEvent(t, r))
+			}
+
+			// headers are sent after subscribing, so the event is not missed
+			b.Publish(events.Event{ID: 3, Type: events.TypeDeleted, Data: []byte(`{"id":1}`)})
+			for {
+				e := readEvent(t, r)
+				if e[0] == ": heartbeat" {
+					continue
+				}
+				actual = append(actual, e)
+				break
+			}
+
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestStreamHeartbeat(t *testing.T) {
+	rs := NewStreamResource(events.NewBroker(10))
+	rs.Heartbeat = 10 * time.Millisecond
+	srv := httptest.NewServer(http.HandlerFunc(rs.stream))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer res.Body.Close()
+
+	assert.Equal(t, []string{": heartbeat"}, readEvent(t, bufio.NewReader(res.Body)))
+}
+
+func TestStreamShutdown(t *testing.T) {
+	done := make(chan struct{})
+	rs := NewStreamResource(events.NewBroker(10))
+	rs.Done = done
+	srv := httptest.NewServer(http.HandlerFunc(rs.stream))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer res.Body.Close()
+
+	close(done)
+	ended := make(chan error)
+	go func() {
+		_, err := ioutil.ReadAll(res.Body)
+		ended <- err
+	}()
+
+	select {
+	case err := <-ended:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("streams must end when the server shuts down")
+	}
+}
+
+func TestStreamInvalidLastEventID(t *testing.T) {
+	rs := NewStreamResource(events.NewBroker(10))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Last-Event-ID", "abc")
+	rec := httptest.NewRecorder()
+	rs.stream(rec, r)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,241 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// The list of error types returned from webhook resource.
+var (
+	ErrInvalidDeliveryStatus = errors.New("status must be pending, delivered or dead")
+	ErrInvalidLimit          = errors.New("limit must be a number between 1 and 100")
+)
+
+// deliveryLogLimit is the default number of deliveries returned by the delivery log.
+const deliveryLogLimit = 50
+
+// WebhookStore defines database operations for webhooks.
+type WebhookStore interface {
+	Create(*models.Webhook) error
+	Get(id int) (*models.Webhook, error)
+	List() (*[]models.Webhook, error)
+	Delete(id int) error
+	Deliveries(webhookID int, status string, limit int) (*[]models.Delivery, error)
+	Redeliver(webhookID int, deliveryID int64) error
+}
+
+// WebhookResource implements webhook management handler.
+type WebhookResource struct {
+	Store WebhookStore
+}
+
+// NewWebhookResource creates and returns a webhook resource.
+func NewWebhookResource(store WebhookStore) *WebhookResource {
+	return &WebhookResource{
+		Store: store,
+	}
+}
+
+func (rs *WebhookResource) router() *chi.Mux {
+	r := chi.NewRouter()
+	r.Post("/", rs.post)
+	r.Get("/", rs.list)
+	r.Route("/{webhookID}", func(r chi.Router) {
+		r.Get("/", rs.get)
+		r.Delete("/", rs.delete)
+		r.Get("/deliveries", rs.deliveries)
+		r.Post("/deliveries/{deliveryID}/redeliver", rs.redeliver)
+	})
+	return r
+}
+
+func (rs *WebhookResource) post(w http.ResponseWriter, r *http.Request) {
+	type postWebhookResponse struct {
+		Status
+		Data *models.Webhook `json:"data"`
+	}
+
+	data := &models.Webhook{}
+	if err := render.DecodeJSON(r.Body, data); err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	if err := data.Validate(); err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	if err := rs.Store.Create(data); err != nil {
+		render.Render(w, r, ErrUnprocessableEntity(err))
+		return
+	}
+
+	data.Secret = ""
+	render.Status(r, http.StatusCreated)
+	render.Respond(w, r, &postWebhookResponse{
+		Status: Status{
+			Code:    http.StatusCreated,
+			Message: "SUCCESS",
+		},
+		Data: data,
+	})
+}
+
+func (rs *WebhookResource) list(w http.ResponseWriter, r *http.Request) {
+	type listWebhooksResponse struct {
+		Status
+		Data *[]models.Webhook `json:"data"`
+	}
+
+	webhooks, err := rs.Store.List()
+	if err != nil {
+		render.Render(w, r, ErrUnprocessableEntity(err))
+		return
+	}
+
+	render.Respond(w, r, &listWebhooksResponse{
+		Status: Status{
+			Code:    http.StatusOK,
+			Message: "SUCCESS",
+		},
+		Data: webhooks,
+	})
+}
+
+func (rs *WebhookResource) get(w http.ResponseWriter, r *http.Request) {
+	type getWebhookResponse struct {
+		Status
+		Data *models.Webhook `json:"data"`
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	webhook, err := rs.Store.Get(id)
+	if err != nil {
+		rs.renderError(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, &getWebhookResponse{
+		Status: Status{
+			Code:    http.StatusOK,
+			Message: "SUCCESS",
+		},
+		Data: webhook,
+	})
+}
+
+func (rs *WebhookResource) delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	if err := rs.Store.Delete(id); err != nil {
+		rs.renderError(w, r, err)
+		return
+	}
+
+	render.Respond(w, r, &Status{
+		Code:    http.StatusOK,
+		Message: "SUCCESS",
+	})
+}
+
+// deliveries responds with the delivery log of a webhook, newest first. The log is filtered by
+// ?status= and holds up to ?limit= deliveries.
+func (rs *WebhookResource) deliveries(w http.ResponseWriter, r *http.Request) {
+	type deliveriesResponse struct {
+		Status
+		Data *[]models.Delivery `json:"data"`
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		render.Render(w, r, ErrBadRequest(ErrInvalidDeliveryStatus))
+		return
+	}
+
+	limit := deliveryLogLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > 100 {
+			render.Render(w, r, ErrBadRequest(ErrInvalidLimit))
+			return
+		}
+	}
+
+	if _, err := rs.Store.Get(id); err != nil {
+		rs.renderError(w, r, err)
+		return
+	}
+
+	deliveries, err := rs.Store.Deliveries(id, status, limit)
+	if err != nil {
+		render.Render(w, r, ErrUnprocessableEntity(err))
+		return
+	}
+
+	render.Respond(w, r, &deliveriesResponse{
+		Status: Status{
+			Code:    http.StatusOK,
+			Message: "SUCCESS",
+		},
+		Data: deliveries,
+	})
+}
+
+// redeliver schedules a delivery to be sent again, such as a dead delivery once its receiver is fixed.
+func (rs *WebhookResource) redeliver(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(chi.URLParam(r, "deliveryID"), 10, 64)
+	if err != nil {
+		render.Render(w, r, ErrBadRequest(err))
+		return
+	}
+
+	if err := rs.Store.Redeliver(id, deliveryID); err != nil {
+		rs.renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.Respond(w, r, &Status{
+		Code:    http.StatusAccepted,
+		Message: "SUCCESS",
+	})
+}
+
+func (rs *WebhookResource) renderError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == database.ErrWebhookNotFound || err == database.ErrDeliveryNotFound {
+		render.Render(w, r, &ErrResponse{Status: Status{Code: http.StatusNotFound, Message: err.Error()}})
+		return
+	}
+	render.Render(w, r, ErrUnprocessableEntity(err))
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/models"
+)
+
+type memoryWebhookStore struct {
+	webhooks   []models.Webhook
+	deliveries []models.Delivery
+}
+
+func (s *memoryWebhookStore) Create(w *models.Webhook) error {
+	w.ID = len(s.webhooks) + 1
+	s.webhooks = append(s.webhooks, *w)
+	return nil
+}
+
+func (s *memoryWebhookStore) Get(id int) (*models.Webhook, error) {
+	for _, w := range s.webhooks {
+		if w.ID == id {
+			w.Secret = ""
+			return &w, nil
+		}
+	}
+	return nil, database.ErrWebhookNotFound
+}
+
+func (s *memoryWebhookStore) List() (*[]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+	for _, w := range s.webhooks {
+		w.Secret = ""
+		webhooks = append(webhooks, w)
+	}
+	return &webhooks, nil
+}
+
+func (s *memoryWebhookStore) Delete(id int) error {
+	for i, w := range s.webhooks {
+		if w.ID == id {
+			s.webhooks = append(s.webhooks[:i], s.webhooks[i+1:]...)
+			return nil
+		}
+	}
+	return database.ErrWebhookNotFound
+}
+
+func (s *memoryWebhookStore) Deliveries(webhookID int, status string, limit int) (*[]models.Delivery, error) {
+	deliveries := []models.Delivery{}
+	for _, d := range s.deliveries {
+		if d.WebhookID == webhookID && (status == "" || d.Status == status) && len(deliveries) < limit {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return &deliveries, nil
+}
+
+func (s *memoryWebhookStore) Redeliver(webhookID int, deliveryID int64) error {
+	for i, d := range s.deliveries {
+		if d.WebhookID == webhookID && d.ID == deliveryID {
+			s.deliveries[i].Status = models.DeliveryPending
+			s.deliveries[i].Attempts = 0
+			return nil
+		}
+	}
+	return database.ErrDeliveryNotFound
+}
+
+func TestWebhook(t *testing.T) {
+	tt := []struct {
+		name     string
+		method   string
+		endpoint string
+		body     string
+		code     int
+		contains []string
+	}{
+		{
+			name:     "create",
+			method:   "POST",
+			endpoint: "/",
+			body:     `{"url": "https://example.com/hook", "secret": "0123456789abcdef", "events": ["created", "deleted"]}`,
+			code:     http.StatusCreated,
+			contains: []string{`"id":2`, `"events":["created","deleted"]`},
+		},
+		{
+			name:     "create invalid",
+			method:   "POST",
+			endpoint: "/",
+			body:     `{"url": "https://example.com/hook", "secret": "0123456789abcdef", "events": ["published"]}`,
+			code:     http.StatusBadRequest,
+			contains: []string{"events: must be created, updated or deleted."},
+		},
+		{
+			name:     "list",
+			method:   "GET",
+			endpoint: "/",
+			code:     http.StatusOK,
+			contains: []string{`"url":"https://example.com/existing"`},
+		},
+		{
+			name:     "get missing",
+			method:   "GET",
+			endpoint: "/9",
+			code:     http.StatusNotFound,
+			contains: []string{"webhook not found"},
+		},
+		{
+			name:     "delete",
+			method:   "DELETE",
+			endpoint: "/1",
+			code:     http.StatusOK,
+		},
+		{
+			name:     "delivery log",
+			method:   "GET",
+			endpoint: "/1/deliveries?status=dead",
+			code:     http.StatusOK,
+			contains: []string{`"id":2`, `"status":"dead"`, `"last_error":"unexpected status 500"`},
+		},
+		{
+			name:     "delivery log invalid status",
+			method:   "GET",
+			endpoint: "/1/deliveries?status=lost",
+			code:     http.StatusBadRequest,
+			contains: []string{ErrInvalidDeliveryStatus.Error()},
+		},
+		{
+			name:     "delivery log invalid limit",
+			method:   "GET",
+			endpoint: "/1/deliveries?limit=1000",
+			code:     http.StatusBadRequest,
+			contains: []string{ErrInvalidLimit.Error()},
+		},
+		{
+			name:     "delivery log of missing webhook",
+			method:   "GET",
+			endpoint: "/9/deliveries",
+			code:     http.StatusNotFound,
+		},
+		{
+			name:     "redeliver",
+			method:   "POST",
+			endpoint: "/1/deliveries/2/redeliver",
+			code:     http.StatusAccepted,
+		},
+		{
+			name:     "redeliver missing",
+			method:   "POST",
+			endpoint: "/1/deliveries/9/redeliver",
+			code:     http.StatusNotFound,
+			contains: []string{"delivery not found"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &memoryWebhookStore{
+				webhooks: []models.Webhook{{ID: 1, URL: "https://example.com/existing", Secret: "0123456789abcdef", Events: []string{models.EventCreated}}},
+				deliveries: []models.Delivery{
+					{ID: 1, WebhookID: 1, Event: models.EventCreated, Payload: json.RawMessage(`{}`), Status: models.DeliveryDelivered, Attempts: 1},
+					{ID: 2, WebhookID: 1, Event: models.EventCreated, Payload: json.RawMessage(`{}`), Status: models.DeliveryDead, Attempts: 10, LastError: "unexpected status 500"},
+				},
+			}
+			rs := NewWebhookResource(store)
+
+			r := httptest.NewRequest(tc.method, tc.endpoint, strings.NewReader(tc.body))
+			rec := httptest.NewRecorder()
+			rs.router().ServeHTTP(rec, r)
+
+			assert.Equal(t, tc.code, rec.Code, rec.Body.String())
+			assert.NotContains(t, rec.Body.String(), "0123456789abcdef")
+			for _, s := range tc.contains {
+				assert.Contains(t, rec.Body.String(), s)
+			}
+		})
+	}
+}
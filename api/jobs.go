@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+
+	"github.com/go-pg/pg"
+	"github.com/spf13/viper"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/jobs"
+	"github.com/ykaseng/articles-library/logging"
+	"github.com/ykaseng/articles-library/transfer"
+)
+
+// CleanupIdempotencyKeys deletes expired idempotency keys.
+type CleanupIdempotencyKeys struct{}
+
+// Kind implements jobs.Args.
+func (CleanupIdempotencyKeys) Kind() string { return "idempotency.cleanup" }
+
+// NewWorker creates a job worker running the background jobs of the application.
+func NewWorker(db *pg.DB) (*jobs.Worker, error) {
+	w := jobs.NewWorker(database.NewJobStore(db), logging.Logger)
+
+	idempotency := database.NewIdempotencyStore(db)
+	w.Register(CleanupIdempotencyKeys{}, func(ctx context.Context, args jobs.Args) error {
+		n, err := idempotency.DeleteExpired()
+		if err != nil {
+			return err
+		}
+		logging.Logger.WithField("module", "jobs").Infof("deleted %d expired idempotency keys", n)
+		return nil
+	})
+	if err := w.Schedule("@hourly", CleanupIdempotencyKeys{}); err != nil {
+		return nil, err
+	}
+
+	exporter := transfer.NewExporter(database.NewExportStore(db), database.NewArticleStore(db))
+	w.RegisterTimeout(transfer.ExportArticles{}, viper.GetDuration("export_timeout"), func(ctx context.Context, args jobs.Args) error {
+		return exporter.Run(ctx, args.(*transfer.ExportArticles).ExportID)
+	})
+	w.Register(transfer.CleanupExports{}, func(ctx context.Context, args jobs.Args) error {
+		n, err := exporter.Cleanup()
+		if n > 0 {
+			logging.Logger.WithField("module", "jobs").Infof("removed %d expired exports", n)
+		}
+		return err
+	})
+	if err := w.Schedule("@every 10m", transfer.CleanupExports{}); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
@@ -3,63 +3,244 @@ package api
 import (
 	"context"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/go-pg/pg"
+	"github.com/soheilhy/cmux"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+
+	"github.com/ykaseng/articles-library/api/app"
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/events"
+	"github.com/ykaseng/articles-library/ingest"
+	"github.com/ykaseng/articles-library/jobs"
+	"github.com/ykaseng/articles-library/logging"
+	"github.com/ykaseng/articles-library/rpc"
+	"github.com/ykaseng/articles-library/webhook"
 )
 
 // Server provides an http.Server.
 type Server struct {
 	*http.Server
+
+	// GRPC serves the article service on GRPCAddr, or on Addr next to HTTP when GRPCAddr is empty.
+	GRPC     *grpc.Server
+	GRPCAddr string
+
+	// Poller imports entries of subscribed feeds in the background while the server runs.
+	Poller *ingest.Poller
+
+	// Dispatcher sends pending webhook deliveries in the background while the server runs.
+	Dispatcher *webhook.Dispatcher
+
+	// Worker runs background jobs while the server runs.
+	Worker *jobs.Worker
+
+	// Listener publishes article changes made by any server instance to the article stream.
+	Listener *events.Listener
+
+	// Cache holds article reads, which are invalidated by the article changes published by Listener.
+	Cache *app.ArticleCache
+
+	// DB and Replicas are the connection pools shared by all parts of the server, closed on shutdown.
+	DB       *pg.DB
+	Replicas *database.Replicas
 }
 
 // NewServer creates and configures an APIServer serving all application routes.
 func NewServer() (*Server, error) {
 	log.Println("configuring server...")
-	api, err := New()
+	db, err := database.DBConn()
 	if err != nil {
 		return nil, err
 	}
 
-	var addr string
-	port := viper.GetString("port")
+	if viper.GetBool("database_migrate") {
+		applied, err := database.Migrate(db)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		log.Printf("applied %d migrations\n", applied)
+	}
 
-	// allow port to be set in env during development to avoid "accept incoming network connection" request on restarts
-	if strings.Contains(port, ":") {
-		addr = port
-	} else {
-		addr = ":" + port
+	replicas, err := database.DBReplicas(logging.Logger.WithField("module", "database"))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	broker := events.NewBroker(viper.GetInt("stream_buffer_size"))
+	articleCache := app.NewArticleCache(broker)
+	// closed by Shutdown, which does not cancel the requests of open streams
+	shutdown := make(chan struct{})
+	api, err := New(db, replicas, broker, articleCache, shutdown)
+	if err != nil {
+		replicas.Close()
+		db.Close()
+		return nil, err
 	}
 
 	srv := http.Server{
-		Addr:    addr,
+		Addr:    listenAddr(viper.GetString("port")),
 		Handler: api,
 	}
+	srv.RegisterOnShutdown(func() { close(shutdown) })
+
+	server := &Server{
+		Server:   &srv,
+		Listener: events.NewListener(db, database.NewArticleStore(db), broker, logging.Logger),
+		Cache:    articleCache,
+		DB:       db,
+		Replicas: replicas,
+	}
+
+	if viper.GetBool("grpc_enabled") {
+		articleStore := database.NewReplicatedArticleStore(db, replicas)
+		server.GRPC = rpc.NewServer(articleCache.Wrap(articleStore), articleStore.Primary(), logging.Logger)
+		if port := viper.GetString("grpc_port"); port != "" {
+			server.GRPCAddr = listenAddr(port)
+		}
+	}
+
+	if viper.GetBool("source_poll_enabled") {
+		server.Poller = ingest.NewPoller(database.NewSourceStore(db), logging.Logger)
+	}
+
+	if viper.GetBool("webhook_dispatch_enabled") {
+		server.Dispatcher = webhook.NewDispatcher(database.NewWebhookStore(db), logging.Logger)
+	}
+
+	if viper.GetBool("jobs_worker_enabled") {
+		if server.Worker, err = NewWorker(db); err != nil {
+			replicas.Close()
+			db.Close()
+			return nil, err
+		}
+	}
 
-	return &Server{&srv}, nil
+	return server, nil
+}
+
+// listenAddr returns the listen address of a port, which may already be a host:port address.
+func listenAddr(port string) string {
+	// allow port to be set in env during development to avoid "accept incoming network connection" request on restarts
+	if strings.Contains(port, ":") {
+		return port
+	}
+	return ":" + port
 }
 
 // Start runs ListenAndServe on the http.Server with graceful shutdown.
 func (srv *Server) Start() {
 	log.Println("starting server...")
+	l, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		panic(err)
+	}
+
+	httpL := l
+	if srv.GRPC != nil {
+		if srv.GRPCAddr == "" {
+			// gRPC clients send a content-type of application/grpc in the first HTTP/2 headers frame
+			m := cmux.New(l)
+			grpcL := m.MatchWithWriters(cmux.HTTP2MatchHeaderFieldSendSettings("content-type", "application/grpc"))
+			httpL = m.Match(cmux.Any())
+			go srv.serveGRPC(grpcL)
+			go m.Serve()
+			log.Printf("Serving gRPC on %s\n", srv.Addr)
+		} else {
+			grpcL, err := net.Listen("tcp", srv.GRPCAddr)
+			if err != nil {
+				panic(err)
+			}
+			go srv.serveGRPC(grpcL)
+			log.Printf("Serving gRPC on %s\n", srv.GRPCAddr)
+		}
+	}
+
 	go func() {
-		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		if err := srv.Serve(httpL); err != http.ErrServerClosed {
 			panic(err)
 		}
 	}()
 	log.Printf("Listening on %s\n", srv.Addr)
 
-	quit := make(chan os.Signal)
+	// background work is waited for on shutdown, so that running jobs and deliveries record their
+	// results before the pools are closed
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	run := func(fn func(context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(ctx)
+		}()
+	}
+	run(srv.Listener.Run)
+	if srv.Cache != nil {
+		run(srv.Cache.Run)
+	}
+	if srv.Poller != nil {
+		run(srv.Poller.Run)
+	}
+	if srv.Dispatcher != nil {
+		run(srv.Dispatcher.Run)
+	}
+	if srv.Worker != nil {
+		run(srv.Worker.Run)
+	}
+
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	sig := <-quit
 	log.Println("Shutting down server... Reason:", sig)
 	// teardown logic...
+	cancel()
 
-	if err := srv.Shutdown(context.Background()); err != nil {
-		panic(err)
+	timeout := viper.GetDuration("shutdown_timeout")
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	shutdownCtx, stop := context.WithTimeout(context.Background(), timeout)
+	defer stop()
+
+	if srv.GRPC != nil {
+		stopped := make(chan struct{})
+		go func() {
+			srv.GRPC.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			srv.GRPC.Stop()
+		}
+	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("shutting down http server failed:", err)
+	}
+	l.Close()
+	wg.Wait()
+
+	if err := srv.Replicas.Close(); err != nil {
+		log.Println("closing read replicas failed:", err)
+	}
+	if err := srv.DB.Close(); err != nil {
+		log.Println("closing database failed:", err)
 	}
 	log.Println("Server gracefully stopped")
 }
+
+func (srv *Server) serveGRPC(l net.Listener) {
+	if err := srv.GRPC.Serve(l); err != nil && err != grpc.ErrServerStopped {
+		log.Println("gRPC server stopped:", err)
+	}
+}
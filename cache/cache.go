@@ -0,0 +1,196 @@
+// Package cache stores encoded values by key, either in memory or in a cache shared by every server
+// instance, and coalesces concurrent loads of the same key.
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend stores values by key for up to a time to live. A shared backend, such as Redis or
+// memcached, implements it to share cached values between server instances.
+type Backend interface {
+	// Get returns the value of key, unless it is missing or expired.
+	Get(key string) ([]byte, bool)
+	// Set stores the value of key for ttl.
+	Set(key string, value []byte, ttl time.Duration)
+	// Delete removes the given keys.
+	Delete(keys ...string)
+	// DeletePrefix removes every key starting with prefix, an empty prefix removes every key.
+	DeletePrefix(prefix string)
+}
+
+// Usage is the memory used by a backend.
+type Usage struct {
+	Entries   int    `json:"entries"`
+	Bytes     int64  `json:"bytes"`
+	MaxBytes  int64  `json:"max_bytes"`
+	Evictions uint64 `json:"evictions"`
+}
+
+// Measured is implemented by backends reporting their usage.
+type Measured interface {
+	Usage() Usage
+}
+
+// LRU is an in-process Backend holding up to maxBytes of keys and values. The least recently used
+// entries are evicted to make room for new ones, and expired entries are dropped when they are read.
+type LRU struct {
+	maxBytes int64
+
+	mu        sync.Mutex
+	size      int64
+	evictions uint64
+	items     map[string]*list.Element
+	order     *list.List
+
+	// now returns the current time, it is replaced by tests.
+	now func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func (e *lruEntry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
+
+// NewLRU returns an LRU holding up to maxBytes.
+func NewLRU(maxBytes int64) *LRU {
+	return &LRU{
+		maxBytes: maxBytes,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get returns the value of key, unless it is missing or expired.
+func (c *LRU) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	if entry := e.Value.(*lruEntry); c.now().After(entry.expires) {
+		c.remove(e)
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry).value, true
+}
+
+// Set stores the value of key for ttl, evicting the least recently used entries when the cache is
+// full. Values larger than the cache are not stored.
+func (c *LRU) Set(key string, value []byte, ttl time.Duration) {
+	entry := &lruEntry{key: key, value: value, expires: c.now().Add(ttl)}
+	if entry.size() > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+	c.items[key] = c.order.PushFront(entry)
+	c.size += entry.size()
+
+	for c.size > c.maxBytes {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+// Delete removes the given keys.
+func (c *LRU) Delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if e, ok := c.items[key]; ok {
+			c.remove(e)
+		}
+	}
+}
+
+// DeletePrefix removes every key starting with prefix.
+func (c *LRU) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, e := range c.items {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(e)
+		}
+	}
+}
+
+// Usage returns the number of entries and bytes held, and the number of entries evicted so far.
+func (c *LRU) Usage() Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Usage{Entries: len(c.items), Bytes: c.size, MaxBytes: c.maxBytes, Evictions: c.evictions}
+}
+
+func (c *LRU) remove(e *list.Element) {
+	entry := c.order.Remove(e).(*lruEntry)
+	delete(c.items, entry.key)
+	c.size -= entry.size()
+}
+
+// ErrLoadPanicked is returned to the callers waiting for a load which panicked.
+var ErrLoadPanicked = errors.New("cache: load panicked")
+
+// Group coalesces concurrent loads of the same key, so that a hot key missing from the cache is
+// loaded once rather than by every request asking for it.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	wg    sync.WaitGroup
+	value []byte
+	err   error
+}
+
+// Do calls fn and returns its results, unless a call for key is in flight, whose results are
+// returned once it completes. shared reports whether the results came from another call. A panic
+// of fn is propagated to the caller, the waiting callers get ErrLoadPanicked.
+func (g *Group) Do(key string, fn func() ([]byte, error)) (value []byte, err error, shared bool) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.value, c.err, true
+	}
+	c := &call{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		c.wg.Done()
+	}()
+
+	c.err = ErrLoadPanicked
+	c.value, c.err = fn()
+	return c.value, c.err, false
+}
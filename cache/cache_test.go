@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLRU(12)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", []byte("1111"), time.Minute)
+	c.Set("b", []byte("2222"), time.Minute)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	// a was used more recently than b, which is evicted
+	c.Set("c", []byte("3333"), time.Minute)
+	_, ok = c.Get("b")
+	assert.False(t, ok, "the least recently used entry must be evicted")
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1111"), value)
+	assert.Equal(t, Usage{Entries: 2, Bytes: 10, MaxBytes: 12, Evictions: 1}, c.Usage())
+
+	c.Set("d", []byte("larger than the cache"), time.Minute)
+	_, ok = c.Get("d")
+	assert.False(t, ok, "values larger than the cache must not be stored")
+
+	now = now.Add(2 * time.Minute)
+	_, ok = c.Get("a")
+	assert.False(t, ok, "expired entries must not be returned")
+	assert.Equal(t, 1, c.Usage().Entries)
+}
+
+func TestLRUDelete(t *testing.T) {
+	c := NewLRU(1024)
+	for _, key := range []string{"list:a", "list:b", "article:1", "article:2"} {
+		c.Set(key, []byte("value"), time.Minute)
+	}
+
+	c.Delete("article:1", "article:3")
+	c.DeletePrefix("list:")
+
+	var kept []string
+	for _, key := range []string{"list:a", "list:b", "article:1", "article:2"} {
+		if _, ok := c.Get(key); ok {
+			kept = append(kept, key)
+		}
+	}
+	assert.Equal(t, []string{"article:2"}, kept)
+	assert.Equal(t, int64(len("article:2")+len("value")), c.Usage().Bytes)
+
+	c.DeletePrefix("")
+	assert.Equal(t, Usage{MaxBytes: 1024}, c.Usage())
+}
+
+func TestGroup(t *testing.T) {
+	var g Group
+	var calls, shared, started int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			atomic.AddInt32(&started, 1)
+			value, err, s := g.Do("key", func() ([]byte, error) {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return []byte("value"), nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("value"), value)
+			if s {
+				atomic.AddInt32(&shared, 1)
+			}
+		}()
+	}
+
+	// wait for every goroutine to join the call in flight
+	for atomic.LoadInt32(&started) < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls, "concurrent loads of a key must be coalesced")
+	assert.Equal(t, int32(9), shared)
+
+	_, _, s := g.Do("key", func() ([]byte, error) { return nil, nil })
+	assert.False(t, s, "completed calls must not be shared")
+}
+
+func TestGroupPanic(t *testing.T) {
+	var g Group
+	started := make(chan struct{})
+	waiting := make(chan error)
+
+	go func() {
+		defer func() { recover() }()
+		g.Do("key", func() ([]byte, error) {
+			close(started)
+			// wait for the second call to join this one
+			time.Sleep(20 * time.Millisecond)
+			panic("load failed")
+		})
+	}()
+
+	<-started
+	go func() {
+		_, err, _ := g.Do("key", func() ([]byte, error) { return []byte("value"), nil })
+		waiting <- err
+	}()
+
+	select {
+	case err := <-waiting:
+		assert.Equal(t, ErrLoadPanicked, err)
+	case <-time.After(time.Second):
+		t.Fatal("callers waiting for a panicked load must be released")
+	}
+
+	value, err, _ := g.Do("key", func() ([]byte, error) { return []byte("value"), nil })
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), value, "a panicked load must not stay in flight")
+}
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// defaultPageSize is the number of articles fetched per request by List without a page size.
+const defaultPageSize = 100
+
+// Get returns an article by its public ID.
+func (c *Client) Get(ctx context.Context, id models.PublicID) (*models.Article, error) {
+	env, err := c.do(ctx, http.MethodGet, articlePath(id), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var articles []models.Article
+	if err := json.Unmarshal(env.Data, &articles); err != nil {
+		return nil, err
+	}
+	if len(articles) == 0 {
+		return nil, &Error{StatusCode: http.StatusNotFound, Message: "article not found"}
+	}
+
+	return &articles[0], nil
+}
+
+// Create creates an article and returns its ID.
+func (c *Client) Create(ctx context.Context, article *models.Article) (*models.ArticleID, error) {
+	env, err := c.do(ctx, http.MethodPost, "/articles", nil, article)
+	if err != nil {
+		return nil, err
+	}
+
+	id := &models.ArticleID{}
+	if err := json.Unmarshal(env.Data, id); err != nil {
+		return nil, err
+	}
+
+	return id, nil
+}
+
+// Update replaces the fields of an article and returns the updated article.
+func (c *Client) Update(ctx context.Context, id models.PublicID, article *models.Article) (*models.Article, error) {
+	env, err := c.do(ctx, http.MethodPut, articlePath(id), nil, article)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := &models.Article{}
+	if err := json.Unmarshal(env.Data, updated); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// Delete removes an article by its public ID.
+func (c *Client) Delete(ctx context.Context, id models.PublicID) error {
+	_, err := c.do(ctx, http.MethodDelete, articlePath(id), nil, nil)
+	return err
+}
+
+// Search returns up to limit articles matching a full text query, best matches first. A limit of
+// zero returns the default number of matches of the API.
+func (c *Client) Search(ctx context.Context, query string, limit int) ([]models.Article, error) {
+	q := url.Values{"q": {query}}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+
+	env, err := c.do(ctx, http.MethodGet, "/articles", q, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var articles []models.Article
+	if err := json.Unmarshal(env.Data, &articles); err != nil {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// ListOptions restricts the articles returned by List.
+type ListOptions struct {
+	models.ArticleFilter
+	// PageSize is the number of articles fetched per request, up to 100.
+	PageSize int
+}
+
+// List returns an iterator over the articles matching opts, ordered by ID. Pages of articles are
+// fetched as the iterator advances.
+func (c *Client) List(ctx context.Context, opts ListOptions) *Iterator {
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultPageSize
+	}
+	return &Iterator{client: c, ctx: ctx, opts: opts}
+}
+
+// Iterator iterates over a list of articles. Iteration stops at the end of the list or at the first
+// error, which is returned by Err:
+//
+//	it := c.List(ctx, client.ListOptions{})
+//	for it.Next() {
+//		article := it.Article()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type Iterator struct {
+	client *Client
+	ctx    context.Context
+	opts   ListOptions
+
+	page []models.Article
+	i    int
+	next string
+	last bool
+	err  error
+}
+
+// Next advances the iterator to the next article, fetching the next page when needed, and reports
+// whether there is one.
+func (it *Iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.i++
+	for it.i >= len(it.page) {
+		if it.last {
+			return false
+		}
+		if it.err = it.fetch(); it.err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Article returns the current article.
+func (it *Iterator) Article() *models.Article {
+	if it.i >= len(it.page) {
+		return nil
+	}
+	return &it.page[it.i]
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+func (it *Iterator) fetch() error {
+	q := url.Values{"limit": {strconv.Itoa(it.opts.PageSize)}}
+	if it.next != "" {
+		q.Set("after", it.next)
+	}
+	if it.opts.Author != "" {
+		q.Set("author", it.opts.Author)
+	}
+	if it.opts.Tag != "" {
+		q.Set("tag", it.opts.Tag)
+	}
+
+	env, err := it.client.do(it.ctx, http.MethodGet, "/articles", q, nil)
+	if err != nil {
+		return err
+	}
+
+	it.page = nil
+	if err := json.Unmarshal(env.Data, &it.page); err != nil {
+		return err
+	}
+	it.i = 0
+	it.next = env.Next
+	it.last = env.Next == ""
+
+	return nil
+}
+
+func articlePath(id models.PublicID) string {
+	return "/articles/" + url.PathEscape(string(id))
+}
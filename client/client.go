@@ -0,0 +1,186 @@
+// Package client is a Go client of the articles API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	mathrand "math/rand"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// idempotencyKeyHeader is the request header carrying the idempotency key of POST requests.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// Client makes requests to the articles API. Idempotent requests failing with a network error, 429
+// Too Many Requests or a 5xx status are retried with exponential backoff and full jitter. POST
+// requests carry a generated Idempotency-Key, so that they are retried without creating articles twice,
+// and are also retried on a 409 Conflict while an earlier attempt is in progress.
+type Client struct {
+	BaseURL string
+	// HTTPClient keeps the cookies of the API in a jar, so that reads following a write are served
+	// by the primary database and see the write.
+	HTTPClient *http.Client
+	// APIKey is sent as a bearer token when set, for proxies authenticating requests to the API,
+	// which does not check it.
+	APIKey string
+
+	// MaxRetries is the number of times a failed request is retried.
+	MaxRetries int
+	// MinBackoff is the maximum wait before the first retry, which doubles on every retry up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// New creates and returns a client of the API served at baseURL.
+func New(baseURL string) *Client {
+	// cookiejar.New only fails on options with an invalid public suffix list
+	jar, _ := cookiejar.New(nil)
+
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{Jar: jar},
+		MaxRetries: 3,
+		MinBackoff: 100 * time.Millisecond,
+		MaxBackoff: 5 * time.Second,
+	}
+}
+
+// envelope is the body of every API response.
+type envelope struct {
+	Status  int             `json:"status"`
+	Message string          `json:"mesage"`
+	Data    json.RawMessage `json:"data"`
+	Next    string          `json:"next"`
+}
+
+// do sends a request with body encoded as JSON, retrying it until it succeeds, fails permanently,
+// runs out of retries or ctx is done, and returns the response envelope.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}) (*envelope, error) {
+	var b []byte
+	if body != nil {
+		var err error
+		if b, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	header := http.Header{"Accept": {"application/json"}}
+	if body != nil {
+		header.Set("Content-Type", "application/json")
+	}
+	if c.APIKey != "" {
+		header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	if method == http.MethodPost {
+		key, err := newIdempotencyKey()
+		if err != nil {
+			return nil, err
+		}
+		header.Set(idempotencyKeyHeader, key)
+	}
+
+	idempotent := header.Get(idempotencyKeyHeader) != ""
+	for attempt := 0; ; attempt++ {
+		env, err := c.send(ctx, method, u, header, b)
+		if err == nil || attempt >= c.MaxRetries || !temporary(ctx, err, idempotent) {
+			return env, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(c.backoff(attempt)):
+		}
+	}
+}
+
+// send makes a single attempt of a request.
+func (c *Client) send(ctx context.Context, method, u string, header http.Header, body []byte) (*envelope, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &envelope{}
+	if err := json.Unmarshal(data, env); err != nil || env.Status == 0 {
+		// Responses of proxies and the request timeout have no envelope.
+		if res.StatusCode >= http.StatusBadRequest {
+			return nil, &Error{StatusCode: res.StatusCode, Message: http.StatusText(res.StatusCode)}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return nil, &Error{StatusCode: res.StatusCode, Message: env.Message}
+	}
+
+	return env, nil
+}
+
+// backoff returns a random wait of up to MinBackoff doubled attempt times, capped at MaxBackoff.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.MaxBackoff
+	if attempt < 32 && c.MinBackoff<<uint(attempt) < d {
+		d = c.MinBackoff << uint(attempt)
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(mathrand.Int63n(int64(d)))
+}
+
+// temporary reports whether a failed request, which carries an idempotency key when idempotent is
+// set, may succeed when retried. Requests are not retried once ctx is done.
+func temporary(ctx context.Context, err error, idempotent bool) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if e, ok := err.(*Error); ok {
+		return e.temporary(idempotent)
+	}
+	_, ok := err.(*url.Error)
+	return ok
+}
+
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,297 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/api"
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/events"
+	"github.com/ykaseng/articles-library/models"
+)
+
+func init() {
+	viper.AutomaticEnv()
+
+	viper.SetDefault("database_dsn", fmt.Sprintf("%s://%s:%s@%s:%d/%s?sslmode=%s", viper.GetString("DATABASE_URI_SCHEME"), viper.GetString("DATABASE_USER"), viper.GetString("DATABASE_PASSWORD"), viper.GetString("DATABASE_HOST"), viper.GetInt("DATABASE_PORT"), viper.GetString("DATABASE_NAME"), viper.GetString("DATABASE_SSL")))
+}
+
+func TestClient(t *testing.T) {
+	db, err := database.DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+	defer db.Close()
+
+	router, err := api.New(db, nil, events.NewBroker(0), nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create api : %v", err)
+	}
+
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	c := New(srv.URL)
+	ctx := context.Background()
+
+	id, err := c.Create(ctx, &models.Article{Title: "Client Title", Content: "Client Content", Author: "Client Author"})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	defer c.Delete(ctx, id.PublicID)
+
+	article, err := c.Get(ctx, id.PublicID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Client Title", article.Title)
+		assert.Equal(t, "client-title", article.Slug)
+	}
+
+	updated, err := c.Update(ctx, id.PublicID, &models.Article{Title: "Updated Client Title", Content: "Client Content", Author: "Client Author"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, id.PublicID, updated.PublicID)
+		assert.Equal(t, "updated-client-title", updated.Slug)
+	}
+
+	it := c.List(ctx, ListOptions{ArticleFilter: models.ArticleFilter{Author: "Client Author"}, PageSize: 1})
+	var listed []models.PublicID
+	for it.Next() {
+		listed = append(listed, it.Article().PublicID)
+	}
+	assert.NoError(t, it.Err())
+	assert.Contains(t, listed, id.PublicID)
+
+	found, err := c.Search(ctx, "updated", 10)
+	if assert.NoError(t, err) && assert.NotEmpty(t, found) {
+		assert.Equal(t, id.PublicID, found[0].PublicID)
+	}
+
+	_, err = c.Update(ctx, id.PublicID, &models.Article{Title: "Updated Client Title"})
+	assert.True(t, errors.Is(err, ErrBadRequest), "update invalid article: %v", err)
+
+	assert.NoError(t, c.Delete(ctx, id.PublicID))
+
+	_, err = c.Get(ctx, id.PublicID)
+	assert.True(t, errors.Is(err, ErrNotFound), "get deleted article: %v", err)
+
+	_, err = c.Get(ctx, "1")
+	assert.True(t, errors.Is(err, ErrBadRequest), "get serial id: %v", err)
+}
+
+func TestErrors(t *testing.T) {
+	tt := []struct {
+		name    string
+		code    int
+		body    string
+		kind    error
+		message string
+	}{
+		{"bad request", http.StatusBadRequest, `{"status":400,"mesage":"article id must be a ULID","data":null}`, ErrBadRequest, "article id must be a ULID"},
+		{"not found", http.StatusNotFound, `{"status":404,"mesage":"article not found","data":null}`, ErrNotFound, "article not found"},
+		{"unprocessable entity", http.StatusUnprocessableEntity, `{"status":422,"mesage":"slug is already used by another article","data":null}`, ErrUnprocessableEntity, "slug is already used by another article"},
+		{"no envelope", http.StatusGatewayTimeout, ``, ErrServer, "Gateway Timeout"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.code)
+				w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			c := New(srv.URL)
+			c.MaxRetries = 0
+
+			_, err := c.Get(context.Background(), "01ARZ3NDEKTSV4RRFFQ69G5FAV")
+			assert.True(t, errors.Is(err, tc.kind), "unexpected error: %v", err)
+
+			var e *Error
+			if assert.True(t, errors.As(err, &e)) {
+				assert.Equal(t, tc.code, e.StatusCode)
+				assert.Equal(t, tc.message, e.Message)
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	tt := []struct {
+		name     string
+		method   string
+		failures int
+		code     int
+		attempts int
+		kind     error
+	}{
+		{"get recovers", http.MethodGet, 2, http.StatusServiceUnavailable, 3, nil},
+		{"create recovers", http.MethodPost, 1, http.StatusBadGateway, 2, nil},
+		{"too many requests", http.MethodDelete, 1, http.StatusTooManyRequests, 2, nil},
+		{"retries exhausted", http.MethodGet, 5, http.StatusServiceUnavailable, 4, ErrServer},
+		{"not retried", http.MethodGet, 1, http.StatusBadRequest, 1, ErrBadRequest},
+		{"create in progress", http.MethodPost, 2, http.StatusConflict, 3, nil},
+		{"conflict without idempotency key", http.MethodDelete, 1, http.StatusConflict, 1, ErrConflict},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var attempts int
+			keys := map[string]bool{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				attempts++
+				keys[r.Header.Get(idempotencyKeyHeader)] = true
+				if attempts <= tc.failures {
+					w.WriteHeader(tc.code)
+					return
+				}
+
+				switch r.Method {
+				case http.MethodPost:
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte(`{"status":201,"mesage":"SUCCESS","data":{"id":"01ARZ3NDEKTSV4RRFFQ69G5FAV"}}`))
+				case http.MethodDelete:
+					w.Write([]byte(`{"status":200,"mesage":"SUCCESS","data":{"id":"01ARZ3NDEKTSV4RRFFQ69G5FAV"}}`))
+				default:
+					w.Write([]byte(`{"status":200,"mesage":"SUCCESS","data":[{"id":"01ARZ3NDEKTSV4RRFFQ69G5FAV","title":"Test Title"}]}`))
+				}
+			}))
+			defer srv.Close()
+
+			c := New(srv.URL)
+			c.MinBackoff = time.Millisecond
+			c.MaxBackoff = 10 * time.Millisecond
+
+			var err error
+			switch tc.method {
+			case http.MethodPost:
+				_, err = c.Create(context.Background(), &models.Article{Title: "Test Title", Content: "Test Content", Author: "Test Author"})
+				assert.Len(t, keys, 1, "attempts must share one idempotency key")
+				assert.NotContains(t, keys, "")
+			case http.MethodDelete:
+				err = c.Delete(context.Background(), "01ARZ3NDEKTSV4RRFFQ69G5FAV")
+			default:
+				_, err = c.Get(context.Background(), "01ARZ3NDEKTSV4RRFFQ69G5FAV")
+			}
+
+			if tc.kind == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tc.kind), "unexpected error: %v", err)
+			}
+			assert.Equal(t, tc.attempts, attempts)
+		})
+	}
+}
+
+func TestRetryContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL)
+	c.MinBackoff = time.Hour
+	c.MaxBackoff = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.Get(ctx, "01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) < time.Second, "retries must stop when the context is done")
+}
+
+func TestAPIKey(t *testing.T) {
+	var authorization []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = append(authorization, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"status":200,"mesage":"SUCCESS","data":[]}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL)
+	c.Search(context.Background(), "test", 0)
+	c.APIKey = "secret"
+	c.Search(context.Background(), "test", 0)
+
+	assert.Equal(t, []string{"", "Bearer secret"}, authorization)
+}
+
+func TestReadYourWrites(t *testing.T) {
+	var cookies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookies = append(cookies, r.Header.Get("Cookie"))
+		if r.Method == http.MethodPost {
+			http.SetCookie(w, &http.Cookie{Name: "read_primary_until", Value: "1700000000", Path: "/", MaxAge: 5})
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"status":201,"mesage":"SUCCESS","data":{"id":"01ARZ3NDEKTSV4RRFFQ69G5F01"}}`))
+			return
+		}
+		w.Write([]byte(`{"status":200,"mesage":"SUCCESS","data":[{"id":"01ARZ3NDEKTSV4RRFFQ69G5F01"}]}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL)
+	id, err := c.Create(context.Background(), &models.Article{Title: "Client Title", Content: "Client Content", Author: "Client Author"})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	_, err = c.Get(context.Background(), id.PublicID)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"", "read_primary_until=1700000000"}, cookies, "reads must carry the cookies set by writes")
+}
+
+func TestList(t *testing.T) {
+	pages := map[string]string{
+		"":      `{"status":200,"mesage":"SUCCESS","data":[{"id":"01ARZ3NDEKTSV4RRFFQ69G5F01"},{"id":"01ARZ3NDEKTSV4RRFFQ69G5F02"}],"next":"page2"}`,
+		"page2": `{"status":200,"mesage":"SUCCESS","data":[{"id":"01ARZ3NDEKTSV4RRFFQ69G5F03"}]}`,
+	}
+
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		w.Write([]byte(pages[r.URL.Query().Get("after")]))
+	}))
+	defer srv.Close()
+
+	it := New(srv.URL).List(context.Background(), ListOptions{ArticleFilter: models.ArticleFilter{Tag: "go"}, PageSize: 2})
+
+	var ids []string
+	for it.Next() {
+		ids = append(ids, string(it.Article().PublicID))
+	}
+
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"01ARZ3NDEKTSV4RRFFQ69G5F01", "01ARZ3NDEKTSV4RRFFQ69G5F02", "01ARZ3NDEKTSV4RRFFQ69G5F03"}, ids)
+	assert.Equal(t, []string{"limit=2&tag=go", "after=page2&limit=2&tag=go"}, queries)
+	assert.False(t, it.Next())
+}
+
+func TestListError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":400,"mesage":"invalid cursor","data":null}`))
+	}))
+	defer srv.Close()
+
+	it := New(srv.URL).List(context.Background(), ListOptions{})
+
+	assert.False(t, it.Next())
+	assert.True(t, errors.Is(it.Err(), ErrBadRequest))
+	assert.True(t, strings.Contains(it.Err().Error(), "invalid cursor"))
+}
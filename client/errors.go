@@ -0,0 +1,61 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// The list of errors an Error wraps, depending on its status code.
+var (
+	ErrBadRequest          = errors.New("bad request")
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrUnprocessableEntity = errors.New("unprocessable entity")
+	ErrTooManyRequests     = errors.New("too many requests")
+	ErrServer              = errors.New("server error")
+)
+
+// Error is returned for error responses of the API. It wraps one of the errors above, so that
+// callers can test for kinds of errors with errors.Is.
+type Error struct {
+	StatusCode int
+	// Message is the message of the response envelope, or the status text of responses without one.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("articles: %d %s", e.StatusCode, e.Message)
+}
+
+// Unwrap returns the kind of error of the status code, or nil for unexpected status codes.
+func (e *Error) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrBadRequest
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode == http.StatusUnprocessableEntity:
+		return ErrUnprocessableEntity
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServer
+	}
+	return nil
+}
+
+// temporary reports whether a request failing with the error may succeed when retried. A request
+// carrying an idempotency key is rejected with a conflict while an earlier attempt with the same
+// key is in progress, and is answered with the response of that attempt once it completed.
+func (e *Error) temporary(idempotent bool) bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusConflict:
+		return idempotent
+	}
+	return false
+}
@@ -0,0 +1,401 @@
+/*
+Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ykaseng/articles-library/client"
+	"github.com/ykaseng/articles-library/content"
+	"github.com/ykaseng/articles-library/models"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// The list of output formats of article commands.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// articleCmd represents the article command
+var articleCmd = &cobra.Command{
+	Use:   "article",
+	Short: "article manages articles of a running server",
+	Long: `Article gets, lists, searches, creates, updates and deletes articles through the HTTP API of a
+running server at api_url. Articles are printed as a table, JSON or YAML with --output. The API does
+not authenticate requests, api_key is sent as a bearer token for proxies in front of it which do.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		switch output(cmd) {
+		case outputTable, outputJSON, outputYAML:
+		default:
+			log.Fatal("output must be one of table, json or yaml")
+		}
+	},
+}
+
+// articleGetCmd represents the article get command
+var articleGetCmd = &cobra.Command{
+	Use:   "get <id>...",
+	Short: "get prints articles by id",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c := newClient()
+
+		var articles []models.Article
+		for _, id := range args {
+			article, err := c.Get(context.Background(), models.PublicID(id))
+			if err != nil {
+				log.Fatalf("%s: %v", id, err)
+			}
+			articles = append(articles, *article)
+		}
+
+		printArticles(cmd, articles)
+	},
+}
+
+// articleListCmd represents the article list command
+var articleListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list prints articles in id order",
+	Run: func(cmd *cobra.Command, args []string) {
+		author, _ := cmd.Flags().GetString("author")
+		tag, _ := cmd.Flags().GetString("tag")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		it := newClient().List(context.Background(), client.ListOptions{ArticleFilter: models.ArticleFilter{Author: author, Tag: tag}})
+
+		articles := []models.Article{}
+		for (limit <= 0 || len(articles) < limit) && it.Next() {
+			articles = append(articles, *it.Article())
+		}
+		if err := it.Err(); err != nil {
+			log.Fatal(err)
+		}
+
+		printArticles(cmd, articles)
+	},
+}
+
+// articleSearchCmd represents the article search command
+var articleSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "search prints articles matching a full text query, best matches first",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		articles, err := newClient().Search(context.Background(), strings.Join(args, " "), limit)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		printArticles(cmd, articles)
+	},
+}
+
+// articleCreateCmd represents the article create command
+var articleCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "create creates an article",
+	Long: `Create creates an article with content read from --file, or from stdin when it is not a terminal.
+Otherwise $EDITOR is opened to write the content.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		article := &models.Article{}
+		setArticleFlags(cmd, article)
+
+		file, _ := cmd.Flags().GetString("file")
+		switch {
+		case file != "":
+			article.Content = readContent(file)
+		case !terminal(os.Stdin):
+			article.Content = readContent("-")
+		default:
+			article.Content = edit("", article.ContentFormat)
+		}
+
+		c := newClient()
+		id, err := c.Create(context.Background(), article)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		created, err := c.Get(context.Background(), id.PublicID)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		printArticle(cmd, created)
+	},
+}
+
+// articleUpdateCmd represents the article update command
+var articleUpdateCmd = &cobra.Command{
+	Use:   "update <id>",
+	Short: "update changes fields of an article",
+	Long: `Update changes the fields of an article given by flags and keeps the others. Content is replaced
+from --file, where - reads stdin, or edited in $EDITOR with --edit.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c := newClient()
+		id := models.PublicID(args[0])
+
+		article, err := c.Get(context.Background(), id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// The slug is only sent when given, so that a new title gets a new slug.
+		article.Slug = ""
+		setArticleFlags(cmd, article)
+
+		file, _ := cmd.Flags().GetString("file")
+		if file != "" {
+			article.Content = readContent(file)
+		}
+		if editContent, _ := cmd.Flags().GetBool("edit"); editContent {
+			article.Content = edit(article.Content, article.ContentFormat)
+		}
+
+		updated, err := c.Update(context.Background(), id, article)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		printArticle(cmd, updated)
+	},
+}
+
+// articleDeleteCmd represents the article delete command
+var articleDeleteCmd = &cobra.Command{
+	Use:   "delete <id>...",
+	Short: "delete deletes articles by id",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c := newClient()
+		for _, id := range args {
+			if err := c.Delete(context.Background(), models.PublicID(id)); err != nil {
+				log.Fatalf("%s: %v", id, err)
+			}
+			log.Printf("deleted article %s\n", id)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(articleCmd)
+	articleCmd.AddCommand(articleGetCmd, articleListCmd, articleSearchCmd, articleCreateCmd, articleUpdateCmd, articleDeleteCmd)
+
+	articleCmd.PersistentFlags().String("api_url", "http://localhost:8080", "URL of the server")
+	viper.BindPFlag("api_url", articleCmd.PersistentFlags().Lookup("api_url"))
+	articleCmd.PersistentFlags().String("api_key", "", "API key sent as a bearer token to an authenticating proxy")
+	viper.BindPFlag("api_key", articleCmd.PersistentFlags().Lookup("api_key"))
+	articleCmd.PersistentFlags().StringP("output", "o", outputTable, "output format, one of table, json or yaml")
+
+	articleListCmd.Flags().String("author", "", "list articles of an author")
+	articleListCmd.Flags().String("tag", "", "list articles with a tag")
+	articleListCmd.Flags().Int("limit", 0, "maximum number of articles, 0 lists all articles")
+
+	articleSearchCmd.Flags().Int("limit", 20, "maximum number of articles, at most 100")
+
+	for _, c := range []*cobra.Command{articleCreateCmd, articleUpdateCmd} {
+		c.Flags().String("title", "", "title of the article")
+		c.Flags().String("author", "", "author of the article")
+		c.Flags().StringSlice("tags", nil, "comma separated tags of the article")
+		c.Flags().String("slug", "", "slug of the article, derived from the title when empty")
+		c.Flags().String("content-format", "", "format of the content, one of plain, markdown or html")
+		c.Flags().String("published-at", "", "publish date of the article in RFC 3339 format")
+		c.Flags().String("file", "", "file to read the content from, - reads stdin")
+	}
+	articleUpdateCmd.Flags().Bool("edit", false, "edit the content in $EDITOR")
+}
+
+func newClient() *client.Client {
+	c := client.New(viper.GetString("api_url"))
+	c.APIKey = viper.GetString("api_key")
+	return c
+}
+
+func output(cmd *cobra.Command) string {
+	o, _ := cmd.Flags().GetString("output")
+	return o
+}
+
+// setArticleFlags sets the fields of article given by flags.
+func setArticleFlags(cmd *cobra.Command, article *models.Article) {
+	flags := cmd.Flags()
+	if flags.Changed("title") {
+		article.Title, _ = flags.GetString("title")
+	}
+	if flags.Changed("author") {
+		article.Author, _ = flags.GetString("author")
+	}
+	if flags.Changed("tags") {
+		article.Tags, _ = flags.GetStringSlice("tags")
+	}
+	if flags.Changed("slug") {
+		article.Slug, _ = flags.GetString("slug")
+	}
+	if flags.Changed("content-format") {
+		article.ContentFormat, _ = flags.GetString("content-format")
+	}
+	if flags.Changed("published-at") {
+		v, _ := flags.GetString("published-at")
+		publishedAt, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			log.Fatalf("invalid published-at %q: %v", v, err)
+		}
+		article.PublishedAt = &publishedAt
+	}
+}
+
+// readContent returns the content of a file, - reads stdin.
+func readContent(file string) string {
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(b)
+}
+
+// edit opens $EDITOR, falling back to vi, on a temporary file holding text and returns the saved text.
+// Empty text aborts the command.
+func edit(text, format string) string {
+	ext := ".txt"
+	if format == content.FormatMarkdown {
+		ext = ".md"
+	} else if format == content.FormatHTML {
+		ext = ".html"
+	}
+
+	f, err := ioutil.TempFile("", "article-*"+ext)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(text); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+
+	e := exec.Command(editor[0], append(editor[1:], f.Name())...)
+	e.Stdin, e.Stdout, e.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := e.Run(); err != nil {
+		log.Fatalf("editor: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if strings.TrimSpace(string(b)) == "" {
+		log.Fatal("aborting due to empty content")
+	}
+	return string(b)
+}
+
+// terminal reports whether f is a terminal rather than a pipe or file.
+func terminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// printArticles writes a list of articles to stdout in the output format of cmd.
+func printArticles(cmd *cobra.Command, articles []models.Article) {
+	write(cmd, articles, articles)
+}
+
+// printArticle writes a single article to stdout, as an object rather than a list in JSON and YAML.
+func printArticle(cmd *cobra.Command, article *models.Article) {
+	write(cmd, article, []models.Article{*article})
+}
+
+func write(cmd *cobra.Command, v interface{}, rows []models.Article) {
+	switch output(cmd) {
+	case outputJSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(v); err != nil {
+			log.Fatal(err)
+		}
+	case outputYAML:
+		b, err := toYAML(v)
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Stdout.Write(b)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSLUG\tTITLE\tAUTHOR\tTAGS\tPUBLISHED")
+		for _, a := range rows {
+			published := ""
+			if a.PublishedAt != nil {
+				published = a.PublishedAt.Local().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", a.PublicID, a.Slug, a.Title, a.Author, strings.Join(a.Tags, ","), published)
+		}
+		w.Flush()
+	}
+}
+
+// toYAML encodes v as YAML with the field names and order of its JSON encoding. JSON is valid
+// YAML, so it is decoded into ordered YAML mappings and encoded again.
+func toYAML(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{} = &yaml.MapSlice{}
+	if len(b) > 0 && b[0] == '[' {
+		doc = &[]yaml.MapSlice{}
+	}
+	if err := yaml.Unmarshal(b, doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(doc)
+}
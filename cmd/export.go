@@ -0,0 +1,98 @@
+/*
+Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/transfer"
+
+	"github.com/spf13/cobra"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "export writes all articles to a file",
+	Long: `Export streams all articles from the database to a JSONL or CSV file without loading them into memory.
+The markdown format writes one Markdown file with YAML front matter per article into the --out directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runExport(cmd); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// runExport exports all articles. It returns errors rather than exiting, so that the deferred
+// closing of the output file and the database runs before the command exits.
+func runExport(cmd *cobra.Command) (err error) {
+	format, _ := cmd.Flags().GetString("format")
+	out, _ := cmd.Flags().GetString("out")
+
+	db, err := database.DBConn()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var enc transfer.Encoder
+	if format == transfer.FormatMarkdown {
+		if out == "-" {
+			return errors.New("markdown export requires an --out directory")
+		}
+
+		enc, err = transfer.NewMarkdownEncoder(out)
+	} else {
+		var w io.Writer = os.Stdout
+		if out != "-" {
+			f, err := os.Create(out)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if cerr := f.Close(); err == nil {
+					err = cerr
+				}
+			}()
+			w = f
+		}
+
+		enc, err = transfer.NewEncoder(format, w)
+	}
+	if err != nil {
+		return err
+	}
+
+	n, err := transfer.Export(database.NewArticleStore(db), enc, func(n int) {
+		log.Printf("exported %d articles\n", n)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("export complete: %d articles\n", n)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().String("format", transfer.FormatJSONL, "output format, one of jsonl, csv or markdown")
+	exportCmd.Flags().String("out", "-", "output file or markdown directory, - writes to stdout")
+}
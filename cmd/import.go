@@ -0,0 +1,103 @@
+/*
+Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/transfer"
+
+	"github.com/spf13/cobra"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "import reads articles from a file",
+	Long: `Import streams articles from a JSONL or CSV file into the database, resolving authors by name.
+Articles are committed in batches and a checkpoint file records the last committed record,
+so rerunning a failed import resumes after it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runImport(cmd, args); err != nil {
+			log.Fatal(err)
+		}
+	},
+}
+
+// runImport imports the file of args. It returns errors rather than exiting, so that the
+// deferred closing of the file and the database runs before the command exits.
+func runImport(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	batchSize, _ := cmd.Flags().GetInt("batch-size")
+	checkpoint, _ := cmd.Flags().GetString("checkpoint")
+	if checkpoint == "" {
+		checkpoint = args[0] + ".checkpoint"
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec, err := transfer.NewDecoder(format, f)
+	if err != nil {
+		return err
+	}
+
+	importer := &transfer.Importer{
+		BatchSize:  batchSize,
+		Checkpoint: checkpoint,
+		DryRun:     dryRun,
+		Progress: func(n int) {
+			log.Printf("processed %d records\n", n)
+		},
+		Invalid: func(record int, err error) {
+			log.Printf("record %d: %v\n", record, err)
+		},
+	}
+
+	if !dryRun {
+		db, err := database.DBConn()
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		importer.Store = database.NewArticleStore(db)
+	}
+
+	n, err := importer.Import(dec)
+	if err != nil {
+		return fmt.Errorf("import stopped after %d records: %v", n, err)
+	}
+	log.Printf("import complete: %d records\n", n)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.Flags().String("format", transfer.FormatJSONL, "input format, one of jsonl or csv")
+	importCmd.Flags().Bool("dry-run", false, "validate every record without writing to the database")
+	importCmd.Flags().Int("batch-size", 500, "number of records committed per transaction")
+	importCmd.Flags().String("checkpoint", "", "checkpoint file (default is <file>.checkpoint)")
+}
@@ -0,0 +1,50 @@
+/*
+Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"log"
+
+	"github.com/ykaseng/articles-library/database"
+
+	"github.com/spf13/cobra"
+)
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "migrate upgrades the database schema",
+	Long: `Migrate applies the schema migrations which were not applied to the database yet. start.sh
+only runs when the database is created, so existing databases are upgraded by migrations. serve
+runs them on startup unless database_migrate is false.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := database.DBConn()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+
+		applied, err := database.Migrate(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("applied %d migrations\n", applied)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+}
@@ -45,6 +45,58 @@ func init() {
 	// Here you will define your flags and configuration settings.
 	viper.SetDefault("port", ":8080")
 	viper.SetDefault("log_level", "debug")
+	viper.SetDefault("shutdown_timeout", "30s")
+	viper.SetDefault("idempotency_ttl", "24h")
+	viper.SetDefault("batch_max_operations", 100)
+	viper.SetDefault("render_cache_size", 1000)
+	viper.SetDefault("feed_title", "Articles Library")
+	viper.SetDefault("feed_length", 20)
+	viper.SetDefault("feed_full_content", false)
+	viper.SetDefault("feed_excerpt_length", 280)
+	viper.SetDefault("grpc_enabled", true)
+	viper.SetDefault("grpc_port", "")
+	viper.SetDefault("source_poll_enabled", false)
+	viper.SetDefault("source_poll_interval", "30m")
+	viper.SetDefault("source_max_backoff", "24h")
+	viper.SetDefault("source_fetch_timeout", "30s")
+	viper.SetDefault("graphql_max_depth", 10)
+	viper.SetDefault("graphql_max_complexity", 1000)
+	viper.SetDefault("graphql_page_size", 20)
+	viper.SetDefault("graphql_max_page_size", 100)
+	viper.SetDefault("graphql_graphiql", false)
+	viper.SetDefault("openapi_swagger_ui", false)
+	viper.SetDefault("openapi_validate_requests", true)
+	viper.SetDefault("openapi_validate_responses", false)
+	viper.SetDefault("database_migrate", true)
+	viper.SetDefault("database_replica_max_lag", "5s")
+	viper.SetDefault("database_replica_check_interval", "5s")
+	viper.SetDefault("database_replica_check_timeout", "1s")
+	viper.SetDefault("cache_enabled", true)
+	viper.SetDefault("cache_ttl", "1m")
+	viper.SetDefault("cache_max_bytes", 64<<20)
+	viper.SetDefault("cache_control_lists", "public, max-age=0, s-maxage=60")
+	viper.SetDefault("cache_control_articles", "public, max-age=0, s-maxage=300")
+	viper.SetDefault("cache_control_feeds", "public, max-age=300")
+	viper.SetDefault("cache_control_docs", "public, max-age=3600")
+	viper.SetDefault("cache_control_default", "private, no-cache")
+	viper.SetDefault("stream_buffer_size", 1000)
+	viper.SetDefault("stream_heartbeat", "15s")
+	viper.SetDefault("webhook_dispatch_enabled", true)
+	viper.SetDefault("webhook_timeout", "10s")
+	viper.SetDefault("webhook_allow_private_addresses", false)
+	viper.SetDefault("webhook_max_attempts", 10)
+	viper.SetDefault("webhook_backoff", "30s")
+	viper.SetDefault("webhook_max_backoff", "6h")
+	viper.SetDefault("jobs_worker_enabled", true)
+	viper.SetDefault("jobs_concurrency", 4)
+	viper.SetDefault("jobs_poll_interval", "1s")
+	viper.SetDefault("jobs_lease", "1m")
+	viper.SetDefault("jobs_timeout", "5m")
+	viper.SetDefault("jobs_backoff", "10s")
+	viper.SetDefault("jobs_max_backoff", "1h")
+	viper.SetDefault("export_dir", "./exports")
+	viper.SetDefault("export_timeout", "1h")
+	viper.SetDefault("export_ttl", "24h")
 
 	// Cobra supports Persistent Flags which will work for this command
 	// and all subcommands, e.g.:
@@ -0,0 +1,153 @@
+/*
+Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/ingest"
+	"github.com/ykaseng/articles-library/logging"
+	"github.com/ykaseng/articles-library/models"
+
+	"github.com/spf13/cobra"
+)
+
+// sourceCmd represents the source command
+var sourceCmd = &cobra.Command{
+	Use:   "source",
+	Short: "source manages subscribed RSS, Atom and JSON feeds",
+	Long: `Source manages the external feeds whose entries are imported as articles. Subscribed feeds
+are polled in the background by serve when source_poll_enabled is set, or on demand with poll.`,
+}
+
+// sourceAddCmd represents the source add command
+var sourceAddCmd = &cobra.Command{
+	Use:   "add <url>...",
+	Short: "add subscribes to feed URLs",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := database.DBConn()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+
+		store := database.NewSourceStore(db)
+		for _, url := range args {
+			if err := (&models.Source{URL: url}).Validate(); err != nil {
+				log.Fatalf("%s: %v", url, err)
+			}
+
+			src, err := store.Add(url)
+			if err != nil {
+				log.Fatalf("%s: %v", url, err)
+			}
+			log.Printf("added source %d %s\n", src.ID, src.URL)
+		}
+	},
+}
+
+// sourceListCmd represents the source list command
+var sourceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list prints subscribed feeds and their polling state",
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := database.DBConn()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+
+		sources, err := database.NewSourceStore(db).List()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tURL\tTITLE\tLAST POLLED\tNEXT POLL\tFAILURES\tLAST ERROR")
+		for _, src := range *sources {
+			polled := "never"
+			if src.LastPolledAt != nil {
+				polled = src.LastPolledAt.Local().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%s\n", src.ID, src.URL, src.Title, polled, src.NextPollAt.Local().Format(time.RFC3339), src.Failures, src.LastError)
+		}
+		w.Flush()
+	},
+}
+
+// sourcePollCmd represents the source poll command
+var sourcePollCmd = &cobra.Command{
+	Use:   "poll [id]...",
+	Short: "poll fetches feeds and imports new entries",
+	Long: `Poll fetches the given sources immediately, or every source which is due when no id is given,
+and imports entries not seen before as articles.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := database.DBConn()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+
+		store := database.NewSourceStore(db)
+		poller := ingest.NewPoller(store, logging.NewLogger())
+
+		if len(args) == 0 {
+			created, err := poller.PollDue()
+			if err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("poll complete: %d articles created\n", created)
+			return
+		}
+
+		var failed bool
+		for _, arg := range args {
+			id, err := strconv.Atoi(arg)
+			if err != nil {
+				log.Fatalf("invalid source id %q", arg)
+			}
+
+			src, err := store.Get(id)
+			if err != nil {
+				log.Fatalf("source %d: %v", id, err)
+			}
+
+			created, err := poller.Poll(src)
+			if err != nil {
+				log.Printf("source %d: %v\n", id, err)
+				failed = true
+				continue
+			}
+			log.Printf("source %d: %d articles created\n", id, created)
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sourceCmd)
+	sourceCmd.AddCommand(sourceAddCmd, sourceListCmd, sourcePollCmd)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"log"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/transfer"
+
+	"github.com/spf13/cobra"
+)
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "sync imports and updates articles from a Markdown directory",
+	Long: `Sync walks a directory tree of Markdown files with YAML front matter (title, author, tags, date)
+and imports or updates the matching articles, matched by the public ID in the front matter or else by
+the slug of the title. New files and files matched by slug get their id written back. Articles which changed in both the file and the database since the last sync
+are reported as conflicts and left untouched.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, _ := cmd.Flags().GetString("dir")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		db, err := database.DBConn()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+
+		syncer := &transfer.Syncer{
+			Store:  database.NewArticleStore(db),
+			Dir:    dir,
+			DryRun: dryRun,
+		}
+
+		report, err := syncer.Sync()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, path := range report.Created {
+			log.Println("created", path)
+		}
+		for _, path := range report.Updated {
+			log.Println("updated", path)
+		}
+		for _, path := range report.Pulled {
+			log.Println("pulled", path)
+		}
+		for _, path := range report.Conflicts {
+			log.Println("conflict", path)
+		}
+		log.Printf("sync complete: %d created, %d updated, %d pulled, %d unchanged, %d conflicts\n",
+			len(report.Created), len(report.Updated), len(report.Pulled), len(report.Unchanged), len(report.Conflicts))
+
+		if len(report.Conflicts) > 0 {
+			log.Fatal("resolve conflicts by editing either the file or the article and sync again")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+
+	syncCmd.Flags().String("dir", "./content", "directory of Markdown files")
+	syncCmd.Flags().Bool("dry-run", false, "report changes without writing files or the database")
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2019 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/ykaseng/articles-library/api"
+	"github.com/ykaseng/articles-library/database"
+
+	"github.com/spf13/cobra"
+)
+
+// workerCmd represents the worker command
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "worker runs background jobs without serving the API",
+	Long: `Worker claims and runs background jobs from the job queue until interrupted. Any number of
+workers and servers share the queue, so jobs can be moved off the API servers by disabling
+jobs_worker_enabled there and running workers instead.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := database.DBConn()
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer db.Close()
+
+		worker, err := api.NewWorker(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			quit := make(chan os.Signal, 1)
+			signal.Notify(quit, os.Interrupt)
+			sig := <-quit
+			log.Println("Stopping worker... Reason:", sig)
+			cancel()
+		}()
+
+		log.Println("starting worker...")
+		worker.Run(ctx)
+		log.Println("Worker stopped")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(workerCmd)
+}
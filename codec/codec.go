@@ -0,0 +1,274 @@
+// Package codec encodes JSON documents as XML, CSV and MessagePack and decodes them back, so that
+// the JSON encoding of a type is the only one it has to define.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned by codecs which cannot encode or decode a value, such as CSV for a
+// value which is not a list.
+var ErrUnsupported = errors.New("codec: unsupported value")
+
+// Codec encodes and decodes JSON documents in a media type. Documents are held as the values
+// returned by Parse: nil, bool, json.Number, string, []interface{} and Object.
+type Codec interface {
+	// ContentType returns the Content-Type header of encoded documents.
+	ContentType() string
+	// MediaTypes lists the media types of the encoding, the preferred one first.
+	MediaTypes() []string
+	Encode(w io.Writer, value interface{}) error
+	Decode(r io.Reader) (interface{}, error)
+}
+
+// The codecs of the package, in order of preference.
+var (
+	JSON        Codec = jsonCodec{}
+	XML         Codec = xmlCodec{}
+	MessagePack Codec = msgpackCodec{}
+	CSV         Codec = csvCodec{}
+
+	codecs = []Codec{JSON, XML, MessagePack, CSV}
+)
+
+// Object is a JSON object which keeps its members in order.
+type Object []Member
+
+// Member is a member of an Object.
+type Member struct {
+	Key   string
+	Value interface{}
+}
+
+// Get returns the value of a member.
+func (o Object) Get(key string) (interface{}, bool) {
+	for _, m := range o {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return nil, false
+}
+
+// MarshalJSON encodes the object with its members in order.
+func (o Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Parse decodes a JSON document, keeping the members of objects in order and numbers as
+// json.Number.
+func Parse(data []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	value, err := parse(d)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.Token(); err != io.EOF {
+		return nil, errors.New("codec: unexpected data after the JSON document")
+	}
+	return value, nil
+}
+
+func parse(d *json.Decoder) (interface{}, error) {
+	t, err := d.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t {
+	case json.Delim('{'):
+		o := Object{}
+		for d.More() {
+			key, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := parse(d)
+			if err != nil {
+				return nil, err
+			}
+			o = append(o, Member{Key: key.(string), Value: value})
+		}
+		_, err := d.Token()
+		return o, err
+	case json.Delim('['):
+		a := []interface{}{}
+		for d.More() {
+			value, err := parse(d)
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, value)
+		}
+		_, err := d.Token()
+		return a, err
+	}
+	return t, nil
+}
+
+// Value returns the JSON document of v.
+func Value(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// ByMediaType returns the codec of a media type, or nil for unknown media types.
+func ByMediaType(mediaType string) Codec {
+	for _, c := range codecs {
+		for _, t := range c.MediaTypes() {
+			if t == mediaType {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// NDJSON is the media type of newline delimited JSON, which lists one value per line. Handlers
+// stream lists in it themselves rather than encoding a whole response.
+const NDJSON = "application/x-ndjson"
+
+// Accepted returns the codecs accepted by an Accept header, in order of preference. Every codec
+// is accepted without the header, and JSON is preferred among codecs of the same quality.
+func Accepted(accept string) []Codec {
+	if strings.TrimSpace(accept) == "" {
+		return append([]Codec{}, codecs...)
+	}
+
+	ranges := parseAccept(accept)
+	q := map[Codec]float64{}
+	var accepted []Codec
+	for _, c := range codecs {
+		if q[c] = quality(ranges, c.MediaTypes()); q[c] > 0 {
+			accepted = append(accepted, c)
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return q[accepted[i]] > q[accepted[j]]
+	})
+	return accepted
+}
+
+// Prefers reports whether an Accept header names mediaType itself, rather than through a wildcard,
+// with a higher quality than that of every codec. Media types which handlers write themselves, such
+// as NDJSON, are only used when they are asked for.
+func Prefers(accept, mediaType string) bool {
+	ranges := parseAccept(accept)
+	q := 0.0
+	for _, mr := range ranges {
+		if mr.mediaType == mediaType {
+			q = mr.q
+		}
+	}
+	if q == 0 {
+		return false
+	}
+
+	for _, c := range codecs {
+		if quality(ranges, c.MediaTypes()) >= q {
+			return false
+		}
+	}
+	return true
+}
+
+// mediaRange is a media range of an Accept header and its quality.
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, field := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(field))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType, q})
+	}
+	return ranges
+}
+
+// quality returns the quality of the most specific range matching one of mediaTypes.
+func quality(ranges []mediaRange, mediaTypes []string) float64 {
+	q, specificity := 0.0, 0
+	for _, mr := range ranges {
+		s := 0
+		for _, t := range mediaTypes {
+			switch {
+			case mr.mediaType == t:
+				s = 3
+			case s < 2 && mr.mediaType == t[:strings.Index(t, "/")]+"/*":
+				s = 2
+			case s < 1 && mr.mediaType == "*/*":
+				s = 1
+			}
+		}
+		if s > specificity {
+			specificity = s
+			q = mr.q
+		}
+	}
+	return q
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+func (jsonCodec) MediaTypes() []string {
+	return []string{"application/json"}
+}
+
+func (jsonCodec) Encode(w io.Writer, value interface{}) error {
+	return json.NewEncoder(w).Encode(value)
+}
+
+func (jsonCodec) Decode(r io.Reader) (interface{}, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
@@ -0,0 +1,160 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testDocument = `{"status":200,"mesage":"SUCCESS","data":[{"id":"01ARZ3NDEKTSV4RRFFQ69G5F01","title":"Test \"Title\"","tags":["go","web"],"published_at":null,"score":-1.5,"count":70000,"draft":false}]}`
+
+func TestAccepted(t *testing.T) {
+	tt := []struct {
+		name     string
+		accept   string
+		accepted []Codec
+	}{
+		{"no header", "", []Codec{JSON, XML, MessagePack, CSV}},
+		{"any", "*/*", []Codec{JSON, XML, MessagePack, CSV}},
+		{"exact", "text/csv", []Codec{CSV}},
+		{"alias", "application/x-msgpack", []Codec{MessagePack}},
+		{"quality", "application/json;q=0.5, application/xml", []Codec{XML, JSON}},
+		{"subtype wildcard", "application/*", []Codec{JSON, XML, MessagePack}},
+		{"most specific range", "text/csv;q=0, */*;q=0.1", []Codec{JSON, XML, MessagePack}},
+		{"browser", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", []Codec{XML, JSON, MessagePack, CSV}},
+		{"unsupported", "image/png", nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.accepted, Accepted(tc.accept))
+		})
+	}
+}
+
+func TestPrefers(t *testing.T) {
+	tt := []struct {
+		name    string
+		accept  string
+		prefers bool
+	}{
+		{"no header", "", false},
+		{"any", "*/*", false},
+		{"exact", "application/x-ndjson", true},
+		{"preferred to json", "application/x-ndjson, application/json;q=0.9", true},
+		{"same quality as json", "application/json, application/x-ndjson", false},
+		{"wildcard", "application/*", false},
+		{"refused", "application/x-ndjson;q=0", false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.prefers, Prefers(tc.accept, "application/x-ndjson"))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	value, err := Parse([]byte(testDocument))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	b, err := json.Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, testDocument, string(b), "members must keep their order")
+
+	_, err = Parse([]byte(`{} {}`))
+	assert.Error(t, err)
+}
+
+func TestXML(t *testing.T) {
+	value, _ := Parse([]byte(`{"status":200,"mesage":"SUCCESS","data":[{"title":"A & B","tags":["go"],"published_at":null,"1st":true}]}`))
+
+	var buf bytes.Buffer
+	if err := XML.Encode(&buf, value); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response><status>200</status><mesage>SUCCESS</mesage><data><item><title>A &amp; B</title><tags><item>go</item></tags><published_at></published_at><member name="1st">true</member></item></data></response>`, buf.String())
+
+	decoded, err := XML.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	b, _ := json.Marshal(decoded)
+	assert.Equal(t, `{"status":"200","mesage":"SUCCESS","data":[{"title":"A \u0026 B","tags":["go"],"published_at":null,"1st":"true"}]}`, string(b))
+}
+
+func TestCSV(t *testing.T) {
+	value, _ := Parse([]byte(`{"status":200,"mesage":"SUCCESS","data":[{"id":"1","title":"Hello, World","tags":["go","web"]},{"id":"2","title":"Second","author":{"name":"John"}}]}`))
+
+	var buf bytes.Buffer
+	if err := CSV.Encode(&buf, value); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+	assert.Equal(t, "id,title,tags,author\n1,\"Hello, World\",\"go,web\",\n2,Second,,\"{\"\"name\"\":\"\"John\"\"}\"\n", buf.String())
+
+	notList, _ := Parse([]byte(`{"status":200,"mesage":"SUCCESS","data":{"id":"1"}}`))
+	assert.Equal(t, ErrUnsupported, CSV.Encode(&buf, notList))
+
+	_, err := CSV.Decode(strings.NewReader("id\n1\n"))
+	assert.Equal(t, ErrUnsupported, err)
+}
+
+func TestMessagePack(t *testing.T) {
+	tt := []struct {
+		name  string
+		json  string
+		bytes []byte
+	}{
+		{"positive fixint", `7`, []byte{0x07}},
+		{"negative fixint", `-3`, []byte{0xfd}},
+		{"uint 16", `300`, []byte{0xcd, 0x01, 0x2c}},
+		{"int 8", `-100`, []byte{0xd0, 0x9c}},
+		{"float 64", `1.5`, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"nil and bools", `[null,true,false]`, []byte{0x93, 0xc0, 0xc3, 0xc2}},
+		{"fixstr", `"go"`, []byte{0xa2, 'g', 'o'}},
+		{"str 8", `"` + strings.Repeat("a", 40) + `"`, append([]byte{0xd9, 40}, bytes.Repeat([]byte("a"), 40)...)},
+		{"fixmap", `{"a":1,"b":[]}`, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x90}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := Parse([]byte(tc.json))
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+
+			var buf bytes.Buffer
+			assert.NoError(t, MessagePack.Encode(&buf, value))
+			assert.Equal(t, tc.bytes, buf.Bytes())
+
+			decoded, err := MessagePack.Decode(bytes.NewReader(tc.bytes))
+			assert.NoError(t, err)
+			assert.Equal(t, value, decoded)
+		})
+	}
+}
+
+func TestMessagePackRoundTrip(t *testing.T) {
+	value, _ := Parse([]byte(testDocument))
+
+	var buf bytes.Buffer
+	if err := MessagePack.Encode(&buf, value); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	decoded, err := MessagePack.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	b, _ := json.Marshal(decoded)
+	assert.Equal(t, testDocument, string(b))
+
+	_, err = MessagePack.Decode(bytes.NewReader([]byte{0xdb, 0x7f, 0xff, 0xff, 0xff, 'a'}))
+	assert.Error(t, err, "truncated strings must fail without allocating their length")
+}
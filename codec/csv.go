@@ -0,0 +1,97 @@
+package codec
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvCodec encodes lists of objects as CSV, one record per object preceded by a header of their
+// keys. Lists are either the document or the data of a response envelope. Arrays of strings and
+// numbers are joined with commas and other arrays and objects are written as JSON. Documents
+// which are not lists are unsupported, as are CSV requests.
+type csvCodec struct{}
+
+func (csvCodec) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (csvCodec) MediaTypes() []string {
+	return []string{"text/csv"}
+}
+
+func (csvCodec) Encode(w io.Writer, value interface{}) error {
+	if o, ok := value.(Object); ok {
+		value, _ = o.Get("data")
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return ErrUnsupported
+	}
+
+	var header []string
+	columns := map[string]int{}
+	for _, item := range list {
+		o, ok := item.(Object)
+		if !ok {
+			return ErrUnsupported
+		}
+		for _, m := range o {
+			if _, ok := columns[m.Key]; !ok {
+				columns[m.Key] = len(header)
+				header = append(header, m.Key)
+			}
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, item := range list {
+		record := make([]string, len(header))
+		for _, m := range item.(Object) {
+			cell, err := csvCell(m.Value)
+			if err != nil {
+				return err
+			}
+			record[columns[m.Key]] = cell
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvCell(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case json.Number, bool:
+		return fmt.Sprint(value), nil
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			switch item.(type) {
+			case string, json.Number, bool:
+				items[i] = fmt.Sprint(item)
+			default:
+				b, err := json.Marshal(value)
+				return string(b), err
+			}
+		}
+		return strings.Join(items, ","), nil
+	}
+	b, err := json.Marshal(value)
+	return string(b), err
+}
+
+func (csvCodec) Decode(r io.Reader) (interface{}, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,296 @@
+package codec
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// msgpackCodec encodes documents as MessagePack. Integers use the smallest integer format holding
+// them, other numbers are float 64, and binary data is decoded as strings. Extension types are not
+// supported.
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+func (msgpackCodec) MediaTypes() []string {
+	return []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+}
+
+func (msgpackCodec) Encode(w io.Writer, value interface{}) error {
+	bw := bufio.NewWriter(w)
+	if err := encodeMsgpack(bw, value); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func encodeMsgpack(w *bufio.Writer, value interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		return w.WriteByte(0xc0)
+	case bool:
+		if value {
+			return w.WriteByte(0xc3)
+		}
+		return w.WriteByte(0xc2)
+	case json.Number:
+		return encodeMsgpackNumber(w, value)
+	case string:
+		writeMsgpackLength(w, len(value), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		_, err := w.WriteString(value)
+		return err
+	case []interface{}:
+		writeMsgpackLength(w, len(value), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range value {
+			if err := encodeMsgpack(w, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Object:
+		writeMsgpackLength(w, len(value), 0x80, 16, 0, 0xde, 0xdf)
+		for _, m := range value {
+			if err := encodeMsgpack(w, m.Key); err != nil {
+				return err
+			}
+			if err := encodeMsgpack(w, m.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return ErrUnsupported
+}
+
+func encodeMsgpackNumber(w *bufio.Writer, n json.Number) error {
+	if !strings.ContainsAny(string(n), ".eE") {
+		if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+			writeMsgpackInt(w, i)
+			return nil
+		}
+		if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+			w.WriteByte(0xcf)
+			return binary.Write(w, binary.BigEndian, u)
+		}
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	w.WriteByte(0xcb)
+	return binary.Write(w, binary.BigEndian, math.Float64bits(f))
+}
+
+func writeMsgpackInt(w *bufio.Writer, i int64) {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		w.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		w.WriteByte(byte(int8(i)))
+	case i >= 0 && i <= math.MaxUint8:
+		w.WriteByte(0xcc)
+		w.WriteByte(byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		w.WriteByte(0xcd)
+		binary.Write(w, binary.BigEndian, uint16(i))
+	case i >= 0 && i <= math.MaxUint32:
+		w.WriteByte(0xce)
+		binary.Write(w, binary.BigEndian, uint32(i))
+	case i >= 0:
+		w.WriteByte(0xcf)
+		binary.Write(w, binary.BigEndian, uint64(i))
+	case i >= math.MinInt8:
+		w.WriteByte(0xd0)
+		w.WriteByte(byte(int8(i)))
+	case i >= math.MinInt16:
+		w.WriteByte(0xd1)
+		binary.Write(w, binary.BigEndian, int16(i))
+	case i >= math.MinInt32:
+		w.WriteByte(0xd2)
+		binary.Write(w, binary.BigEndian, int32(i))
+	default:
+		w.WriteByte(0xd3)
+		binary.Write(w, binary.BigEndian, i)
+	}
+}
+
+// writeMsgpackLength writes the header of a string, array or map of n elements, using the fix
+// format for lengths below fixMax. Arrays and maps have no 8 bit format, which is 0.
+func writeMsgpackLength(w *bufio.Writer, n int, fix byte, fixMax int, f8, f16, f32 byte) {
+	switch {
+	case n < fixMax:
+		w.WriteByte(fix | byte(n))
+	case f8 != 0 && n <= math.MaxUint8:
+		w.WriteByte(f8)
+		w.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		w.WriteByte(f16)
+		binary.Write(w, binary.BigEndian, uint16(n))
+	default:
+		w.WriteByte(f32)
+		binary.Write(w, binary.BigEndian, uint32(n))
+	}
+}
+
+func (msgpackCodec) Decode(r io.Reader) (interface{}, error) {
+	br := bufio.NewReader(r)
+	value, err := decodeMsgpack(br)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, errors.New("codec: unexpected data after the MessagePack document")
+	}
+	return value, nil
+}
+
+func decodeMsgpack(r *bufio.Reader) (interface{}, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return json.Number(strconv.Itoa(int(b))), nil
+	case b >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(b)))), nil
+	case b&0xf0 == 0x80:
+		return decodeMsgpackMap(r, uint64(b&0x0f))
+	case b&0xf0 == 0x90:
+		return decodeMsgpackArray(r, uint64(b&0x0f))
+	case b&0xe0 == 0xa0:
+		return readMsgpackString(r, uint64(b&0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	}
+
+	// the other formats are followed by a length or a number
+	size, ok := msgpackSizes[b]
+	if !ok {
+		return nil, fmt.Errorf("codec: unsupported MessagePack format 0x%x", b)
+	}
+	u, err := readMsgpackUint(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b {
+	case 0xc4, 0xc5, 0xc6, 0xd9, 0xda, 0xdb:
+		return readMsgpackString(r, u)
+	case 0xca:
+		f := float64(math.Float32frombits(uint32(u)))
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 32)), nil
+	case 0xcb:
+		return json.Number(strconv.FormatFloat(math.Float64frombits(u), 'g', -1, 64)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return json.Number(strconv.FormatUint(u, 10)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		// sign extend the integer from its size
+		shift := uint(64 - 8*size)
+		return json.Number(strconv.FormatInt(int64(u<<shift)>>shift, 10)), nil
+	case 0xdc, 0xdd:
+		return decodeMsgpackArray(r, u)
+	default:
+		return decodeMsgpackMap(r, u)
+	}
+}
+
+// msgpackSizes holds the size in bytes of the length or number following a format.
+var msgpackSizes = map[byte]int{
+	0xc4: 1, 0xc5: 2, 0xc6: 4,
+	0xca: 4, 0xcb: 8,
+	0xcc: 1, 0xcd: 2, 0xce: 4, 0xcf: 8,
+	0xd0: 1, 0xd1: 2, 0xd2: 4, 0xd3: 8,
+	0xd9: 1, 0xda: 2, 0xdb: 4,
+	0xdc: 2, 0xdd: 4,
+	0xde: 2, 0xdf: 4,
+}
+
+// readMsgpackUint reads a big endian unsigned integer of size bytes.
+func readMsgpackUint(r *bufio.Reader, size int) (uint64, error) {
+	var u uint64
+	for i := 0; i < size; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		u = u<<8 | uint64(b)
+	}
+	return u, nil
+}
+
+// msgpackCapacity limits the memory allocated up front for the elements of arrays and maps, whose
+// lengths are read from the request.
+func msgpackCapacity(n uint64) int {
+	if n > 1024 {
+		return 1024
+	}
+	return int(n)
+}
+
+// readMsgpackString reads a string of n bytes, growing the string as it is read rather than
+// trusting the length read from the request.
+func readMsgpackString(r *bufio.Reader, n uint64) (interface{}, error) {
+	var b strings.Builder
+	if _, err := io.CopyN(&b, r, int64(n)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b.String(), nil
+}
+
+func decodeMsgpackArray(r *bufio.Reader, n uint64) (interface{}, error) {
+	a := make([]interface{}, 0, msgpackCapacity(n))
+	for i := uint64(0); i < n; i++ {
+		value, err := decodeMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, value)
+	}
+	return a, nil
+}
+
+func decodeMsgpackMap(r *bufio.Reader, n uint64) (interface{}, error) {
+	o := make(Object, 0, msgpackCapacity(n))
+	for i := uint64(0); i < n; i++ {
+		key, err := decodeMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		value, err := decodeMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+
+		switch k := key.(type) {
+		case string:
+			o = append(o, Member{Key: k, Value: value})
+		case json.Number:
+			o = append(o, Member{Key: string(k), Value: value})
+		default:
+			return nil, errors.New("codec: MessagePack map keys must be strings")
+		}
+	}
+	return o, nil
+}
@@ -0,0 +1,157 @@
+package codec
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// xmlName matches the keys which are written as element names. Other keys are written as member
+// elements with a name attribute.
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// xmlCodec encodes documents as XML with a response root element. Members of objects are elements
+// named by their keys, items of arrays are item elements and null is an empty element. Decoded
+// values are strings, and empty elements are null.
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+func (xmlCodec) MediaTypes() []string {
+	return []string{"application/xml", "text/xml"}
+}
+
+func (xmlCodec) Encode(w io.Writer, value interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	e := xml.NewEncoder(w)
+	if err := encodeXML(e, xml.StartElement{Name: xml.Name{Local: "response"}}, value); err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+func encodeXML(e *xml.Encoder, start xml.StartElement, value interface{}) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case nil:
+	case Object:
+		for _, m := range value {
+			child := xml.StartElement{Name: xml.Name{Local: m.Key}}
+			if !xmlName.MatchString(m.Key) || strings.HasPrefix(strings.ToLower(m.Key), "xml") {
+				child = xml.StartElement{Name: xml.Name{Local: "member"}, Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: m.Key}}}
+			}
+			if err := encodeXML(e, child, m.Value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if err := encodeXML(e, xml.StartElement{Name: xml.Name{Local: "item"}}, item); err != nil {
+				return err
+			}
+		}
+	case string:
+		if err := e.EncodeToken(xml.CharData(value)); err != nil {
+			return err
+		}
+	case json.Number, bool:
+		if err := e.EncodeToken(xml.CharData(fmt.Sprint(value))); err != nil {
+			return err
+		}
+	default:
+		return ErrUnsupported
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+func (xmlCodec) Decode(r io.Reader) (interface{}, error) {
+	d := xml.NewDecoder(r)
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := t.(xml.StartElement); ok {
+			return decodeXML(d, start)
+		}
+	}
+}
+
+// xmlElement is a decoded element with its name.
+type xmlElement struct {
+	name  string
+	value interface{}
+}
+
+// decodeXML decodes the content of an element. Elements holding only item elements are arrays,
+// elements holding other elements are objects and elements holding text are strings.
+func decodeXML(d *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	var children []xmlElement
+	var text strings.Builder
+	for {
+		t, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := t.(type) {
+		case xml.StartElement:
+			value, err := decodeXML(d, t)
+			if err != nil {
+				return nil, err
+			}
+			name := t.Name.Local
+			if name == "member" {
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "name" {
+						name = attr.Value
+					}
+				}
+			}
+			children = append(children, xmlElement{name, value})
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			return xmlValue(children, text.String()), nil
+		}
+	}
+}
+
+func xmlValue(children []xmlElement, text string) interface{} {
+	if len(children) == 0 {
+		if text == "" {
+			return nil
+		}
+		return text
+	}
+
+	array := true
+	for _, c := range children {
+		array = array && c.name == "item"
+	}
+	if array {
+		a := make([]interface{}, len(children))
+		for i, c := range children {
+			a[i] = c.value
+		}
+		return a
+	}
+
+	o := make(Object, len(children))
+	for i, c := range children {
+		o[i] = Member{Key: c.name, Value: c.value}
+	}
+	return o
+}
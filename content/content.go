@@ -0,0 +1,115 @@
+// Package content renders and sanitizes article content.
+package content
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"errors"
+	"html"
+	"strings"
+	"sync"
+
+	"github.com/yuin/goldmark"
+)
+
+// The list of supported content formats, an empty format is plain text.
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// The list of error types returned from content.
+var (
+	ErrUnknownFormat = errors.New("unknown content format, must be one of plain, markdown or html")
+)
+
+// Render converts content of format to sanitized HTML.
+func Render(format, s string) (string, error) {
+	switch format {
+	case "", FormatPlain:
+		return renderPlain(s), nil
+	case FormatMarkdown:
+		var buf bytes.Buffer
+		if err := goldmark.Convert([]byte(s), &buf); err != nil {
+			return "", err
+		}
+		return Sanitize(buf.String()), nil
+	case FormatHTML:
+		return Sanitize(s), nil
+	}
+
+	return "", ErrUnknownFormat
+}
+
+// renderPlain escapes text and wraps blank line separated paragraphs in <p> elements.
+func renderPlain(s string) string {
+	var b strings.Builder
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	for _, p := range strings.Split(s, "\n\n") {
+		p = strings.Trim(p, "\n")
+		if p == "" {
+			continue
+		}
+
+		b.WriteString("<p>")
+		b.WriteString(strings.Replace(html.EscapeString(p), "\n", "<br>\n", -1))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
+
+// Renderer renders content to HTML and caches the output by content hash.
+type Renderer struct {
+	size int
+
+	mu    sync.Mutex
+	items map[[sha256.Size]byte]*list.Element
+	order *list.List
+}
+
+type renderEntry struct {
+	key  [sha256.Size]byte
+	html string
+}
+
+// NewRenderer returns a Renderer caching the most recently rendered size outputs.
+func NewRenderer(size int) *Renderer {
+	return &Renderer{
+		size:  size,
+		items: make(map[[sha256.Size]byte]*list.Element),
+		order: list.New(),
+	}
+}
+
+// Render converts content of format to sanitized HTML, reusing cached output for identical content.
+func (r *Renderer) Render(format, s string) (string, error) {
+	key := sha256.Sum256([]byte(format + "\x00" + s))
+
+	r.mu.Lock()
+	if e, ok := r.items[key]; ok {
+		r.order.MoveToFront(e)
+		r.mu.Unlock()
+		return e.Value.(*renderEntry).html, nil
+	}
+	r.mu.Unlock()
+
+	out, err := Render(format, s)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.items[key]; !ok && r.size > 0 {
+		r.items[key] = r.order.PushFront(&renderEntry{key: key, html: out})
+		if r.order.Len() > r.size {
+			oldest := r.order.Back()
+			r.order.Remove(oldest)
+			delete(r.items, oldest.Value.(*renderEntry).key)
+		}
+	}
+
+	return out, nil
+}
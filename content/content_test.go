@@ -0,0 +1,102 @@
+package content
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitize(t *testing.T) {
+	tt := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"allowed markup", `<p>Hello <strong>World</strong></p>`, `<p>Hello <strong>World</strong></p>`},
+		{"script element", `<p>Hello</p><script>alert(1)</script>`, `<p>Hello</p>`},
+		{"raw text of dropped element", `<iframe><script>x</script></iframe>y`, `y`},
+		{"nested dropped element", `<object><object>x</object>y</object>z`, `z`},
+		{"event handler", `<img src="a.png" onerror="alert(1)">`, `<img src="a.png">`},
+		{"javascript link", `<a href="javascript:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"entity encoded javascript link", `<a href="&#106;avascript:alert(1)">x</a>`, `<a rel="nofollow noopener">x</a>`},
+		{"data image", `<img src="data:image/svg+xml;base64,PHN2Zz4=">`, `<img>`},
+		{"http link", `<a href="https://example.com/?a=1&b=2" title="Example">x</a>`, `<a href="https://example.com/?a=1&amp;b=2" title="Example" rel="nofollow noopener">x</a>`},
+		{"relative link", `<a href="/articles/1">x</a>`, `<a href="/articles/1" rel="nofollow noopener">x</a>`},
+		{"unknown element keeps text", `<marquee>Hello</marquee>`, `Hello`},
+		{"style attribute", `<p style="background:url(javascript:alert(1))">x</p>`, `<p>x</p>`},
+		{"code language class", `<code class="language-go">x</code><span class="evil">y</span>`, `<code class="language-go">x</code><span>y</span>`},
+		{"unclosed elements", `<ul><li>one<li>two`, `<ul><li>one<li>two</li></li></ul>`},
+		{"stray end tag", `x</div>`, `x`},
+		{"comment", `<!-- <script>alert(1)</script> -->x`, `x`},
+		{"escaped text", `1 &lt; 2 &amp;&amp; <b>3 > 2</b>`, `1 &lt; 2 &amp;&amp; <b>3 &gt; 2</b>`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Sanitize(tc.input))
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	tt := []struct {
+		name     string
+		format   string
+		input    string
+		expected string
+		err      error
+	}{
+		{"empty format is plain", "", "Hello <World>\nline\n\nParagraph", "<p>Hello &lt;World&gt;<br>\nline</p>\n<p>Paragraph</p>\n", nil},
+		{"markdown", FormatMarkdown, "# Title\n\n*Hello*", "<h1>Title</h1>\n<p><em>Hello</em></p>\n", nil},
+		{"markdown raw html", FormatMarkdown, "Hello <script>alert(1)</script>", "<p>Hello alert(1)</p>\n", nil},
+		{"markdown javascript link", FormatMarkdown, "[x](javascript:alert(1))", "<p><a href=\"\" rel=\"nofollow noopener\">x</a></p>\n", nil},
+		{"html", FormatHTML, "<p onclick=\"x()\">Hello</p>", "<p>Hello</p>", nil},
+		{"unknown format", "rst", "Hello", "", ErrUnknownFormat},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := Render(tc.format, tc.input)
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestRenderer(t *testing.T) {
+	r := NewRenderer(2)
+
+	for _, s := range []string{"one", "two", "one", "three"} {
+		if _, err := r.Render(FormatPlain, s); err != nil {
+			t.Errorf("render failed: %v", err)
+		}
+	}
+
+	assert.Equal(t, 2, r.order.Len())
+
+	var cached []string
+	for e := r.order.Front(); e != nil; e = e.Next() {
+		cached = append(cached, e.Value.(*renderEntry).html)
+	}
+	assert.Equal(t, []string{"<p>three</p>\n", "<p>one</p>\n"}, cached)
+}
+
+func TestExcerpt(t *testing.T) {
+	tt := []struct {
+		name     string
+		input    string
+		n        int
+		expected string
+	}{
+		{"short text", "<p>Hello <em>World</em></p>", 20, "Hello World"},
+		{"cut at word boundary", "<p>Lorem ipsum dolor sit amet.</p>", 14, "Lorem ipsum…"},
+		{"entities are decoded", "<p>Fish &amp; Chips</p>", 20, "Fish & Chips"},
+		{"block elements are separated", "<h1>Title</h1><p>Body</p>", 20, "Title Body"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Excerpt(tc.input, tc.n))
+		})
+	}
+}
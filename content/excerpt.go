@@ -0,0 +1,45 @@
+package content
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// Text returns the text of rendered HTML with markup removed and whitespace collapsed.
+func Text(s string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+
+		switch tt {
+		case html.TextToken:
+			b.Write(z.Text())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			b.WriteString(" ")
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Excerpt returns the text of rendered HTML cut to at most n characters at a word boundary.
+func Excerpt(s string, n int) string {
+	text := Text(s)
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:n])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+
+	return strings.TrimRight(cut, " ,.;:") + "…"
+}
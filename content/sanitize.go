@@ -0,0 +1,167 @@
+package content
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedElements maps every element kept by Sanitize to the attributes kept on it.
+var allowedElements = map[string][]string{
+	"a": {"href", "title"}, "abbr": {"title"}, "b": nil, "blockquote": nil, "br": nil, "caption": nil,
+	"cite": nil, "code": {"class"}, "dd": nil, "del": nil, "div": nil, "dl": nil, "dt": nil, "em": nil,
+	"figcaption": nil, "figure": nil, "h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+	"hr": nil, "i": nil, "img": {"src", "alt", "title", "width", "height"}, "ins": nil, "kbd": nil,
+	"li": nil, "mark": nil, "ol": {"start"}, "p": nil, "pre": nil, "q": nil, "s": nil, "small": nil,
+	"span": nil, "strong": nil, "sub": nil, "sup": nil, "table": nil, "tbody": nil,
+	"td": {"colspan", "rowspan"}, "tfoot": nil, "th": {"colspan", "rowspan"}, "thead": nil, "tr": nil,
+	"u": nil, "ul": nil,
+}
+
+// droppedElements are removed together with everything they contain.
+var droppedElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true, "noscript": true,
+	"template": true, "textarea": true, "title": true, "select": true, "svg": true, "math": true,
+}
+
+var voidElements = map[string]bool{"br": true, "hr": true, "img": true}
+
+var (
+	codeClass = regexp.MustCompile(`^language-[\w-]+$`)
+	number    = regexp.MustCompile(`^[0-9]{1,4}$`)
+)
+
+// Sanitize returns html reduced to an allowlist of formatting elements and attributes. Scripts,
+// styles, event handlers, comments and links to anything but http, https and mailto URLs are
+// removed, unknown elements are replaced by their text and unclosed elements are closed.
+func Sanitize(s string) string {
+	var b strings.Builder
+	var open []string
+	dropped := ""
+	depth := 0
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			// io.EOF or a read error, the input is a string so only io.EOF is expected
+			break
+		}
+
+		tok := z.Token()
+		if dropped != "" {
+			switch {
+			case tt == html.StartTagToken && tok.Data == dropped:
+				depth++
+			case tt == html.EndTagToken && tok.Data == dropped:
+				depth--
+				if depth == 0 {
+					dropped = ""
+				}
+			}
+			continue
+		}
+
+		switch tt {
+		case html.TextToken:
+			b.WriteString(html.EscapeString(tok.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedElements[tok.Data] {
+				if tt == html.StartTagToken {
+					dropped, depth = tok.Data, 1
+				}
+				continue
+			}
+
+			attrs, ok := allowedElements[tok.Data]
+			if !ok {
+				continue
+			}
+
+			b.WriteString("<" + tok.Data)
+			for _, attr := range tok.Attr {
+				if value, ok := sanitizeAttr(tok.Data, attr, attrs); ok {
+					b.WriteString(" " + attr.Key + `="` + html.EscapeString(value) + `"`)
+				}
+			}
+			if tok.Data == "a" {
+				b.WriteString(` rel="nofollow noopener"`)
+			}
+			b.WriteString(">")
+
+			if !voidElements[tok.Data] && tt == html.StartTagToken {
+				open = append(open, tok.Data)
+			}
+		case html.EndTagToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == tok.Data {
+					for j := len(open) - 1; j >= i; j-- {
+						b.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+
+	return b.String()
+}
+
+func sanitizeAttr(element string, attr html.Attribute, allowed []string) (string, bool) {
+	if attr.Namespace != "" {
+		return "", false
+	}
+
+	found := false
+	for _, key := range allowed {
+		if attr.Key == key {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	switch attr.Key {
+	case "href":
+		return attr.Val, safeURL(attr.Val, "http", "https", "mailto")
+	case "src":
+		return attr.Val, safeURL(attr.Val, "http", "https")
+	case "class":
+		return attr.Val, element == "code" && codeClass.MatchString(attr.Val)
+	case "width", "height", "colspan", "rowspan", "start":
+		return attr.Val, number.MatchString(attr.Val)
+	}
+
+	return attr.Val, true
+}
+
+// safeURL reports whether s is a relative URL or an absolute URL with one of schemes.
+func safeURL(s string, schemes ...string) bool {
+	s = strings.TrimSpace(s)
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+
+	if u.Scheme == "" {
+		// reject scheme-like prefixes the url parser does not recognise, e.g. "java\tscript:"
+		return !strings.ContainsAny(strings.SplitN(s, "/", 2)[0], ":\\")
+	}
+
+	for _, scheme := range schemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,15 +1,30 @@
 package database
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
+	"github.com/go-pg/pg/types"
 
 	"github.com/ykaseng/articles-library/models"
 )
 
+// The list of error types returned from article store.
+var (
+	ErrArticleNotFound = errors.New("article not found")
+	ErrSlugTaken       = errors.New("slug is already used by another article")
+)
+
 // ArticleStore implements database operations for article management.
 type ArticleStore struct {
 	db orm.DB
+	// replicas serve the reads of articles while one of them is healthy, otherwise db serves them.
+	replicas *Replicas
 }
 
 // NewArticleStore returns an ArticleStore.
@@ -19,14 +34,194 @@ func NewArticleStore(db orm.DB) *ArticleStore {
 	}
 }
 
+// NewReplicatedArticleStore returns an ArticleStore writing to db and reading from replicas, which
+// may be nil. Reads within writes read from db, the articles to write must be resolved on Primary.
+func NewReplicatedArticleStore(db orm.DB, replicas *Replicas) *ArticleStore {
+	return &ArticleStore{
+		db:       db,
+		replicas: replicas,
+	}
+}
+
+// Primary returns a store reading from the primary database only, for clients which must see
+// their own writes before they reach the replicas.
+func (s *ArticleStore) Primary() *ArticleStore {
+	return &ArticleStore{db: s.db}
+}
+
+// reader returns a healthy replica, or the primary database when there is none.
+func (s *ArticleStore) reader() orm.DB {
+	if db := s.replicas.DB(); db != nil {
+		return db
+	}
+	return s.db
+}
+
 // Get an article by ID.
 func (s *ArticleStore) Get(id int) (*[]models.Article, error) {
 	q := `
-	SELECT ar.id, ar.title, ar.content, au.name AS author FROM articles ar INNER JOIN authors au ON ar.author_id = au.id WHERE ar.id = ?
+	SELECT ar.id, ar.public_id, ar.title, ar.content, ar.content_format, au.name AS author, ar.tags, ar.published_at, ar.slug FROM articles ar INNER JOIN authors au ON ar.author_id = au.id WHERE ar.id = ?
+	`
+
+	var a []models.Article
+	if _, err := s.reader().QueryOne(&a, q, id); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return &a, nil
+}
+
+// articleColumns holds the select list expression of each article field.
+var articleColumns = map[string]string{
+	"id":             "ar.public_id",
+	"title":          "ar.title",
+	"content":        "ar.content",
+	"author":         "au.name AS author",
+	"slug":           "ar.slug",
+	"content_format": "ar.content_format",
+	"tags":           "ar.tags",
+	"published_at":   "ar.published_at",
+}
+
+// columns returns the select list of the given article fields. The serial and public IDs are always
+// selected, since pages are continued after the public ID.
+func columns(fields models.ArticleFields) types.ValueAppender {
+	list := []string{"ar.id", "ar.public_id"}
+	for _, name := range models.ArticleFieldNames {
+		if name != "id" && fields.Has(name) {
+			list = append(list, articleColumns[name])
+		}
+	}
+	return pg.Q(strings.Join(list, ", "))
+}
+
+// GetAll gets all articles, loading only the given fields.
+func (s *ArticleStore) GetAll(fields models.ArticleFields) (*[]models.Article, error) {
+	q := `
+	SELECT ? FROM articles ar INNER JOIN authors au ON ar.author_id = au.id
+	`
+
+	var a []models.Article
+	if _, err := s.reader().Query(&a, q, columns(fields)); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return &a, nil
+}
+
+// GetMany gets the articles with the given public IDs in no particular order, missing IDs are skipped.
+func (s *ArticleStore) GetMany(ids []models.PublicID) (*[]models.Article, error) {
+	q := `
+	SELECT ar.id, ar.public_id, ar.title, ar.content, ar.content_format, au.name AS author, ar.tags, ar.published_at, ar.slug FROM articles ar INNER JOIN authors au ON ar.author_id = au.id WHERE ar.public_id = ANY(?)
+	`
+
+	var a []models.Article
+	if _, err := s.reader().Query(&a, q, pg.Array(ids)); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return &a, nil
+}
+
+// Page returns up to limit articles matching filter with a public ID greater than after, ordered
+// by public ID, which is the order of creation, loading only the given fields.
+func (s *ArticleStore) Page(filter models.ArticleFilter, fields models.ArticleFields, after models.PublicID, limit int) (*[]models.Article, error) {
+	q := `
+	SELECT ? FROM articles ar INNER JOIN authors au ON ar.author_id = au.id
+	WHERE (? = '' OR au.name = ?) AND (? = '' OR ? = ANY(ar.tags)) AND ar.public_id > ? ORDER BY ar.public_id LIMIT ?
+	`
+
+	var a []models.Article
+	if _, err := s.reader().Query(&a, q, columns(fields), filter.Author, filter.Author, filter.Tag, filter.Tag, after, limit); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return &a, nil
+}
+
+// ByAuthors returns up to limit articles of each of the named authors with a public ID greater
+// than after, ordered by author and public ID.
+func (s *ArticleStore) ByAuthors(names []string, after models.PublicID, limit int) (*[]models.Article, error) {
+	q := `
+	SELECT id, public_id, title, content, content_format, author, tags, published_at, slug FROM (
+		SELECT ar.id, ar.public_id, ar.title, ar.content, ar.content_format, au.name AS author, ar.tags, ar.published_at, ar.slug, row_number() OVER (PARTITION BY au.name ORDER BY ar.public_id) AS n
+		FROM articles ar INNER JOIN authors au ON ar.author_id = au.id WHERE au.name = ANY(?) AND ar.public_id > ?
+	) a WHERE n <= ? ORDER BY author, public_id
+	`
+
+	var a []models.Article
+	if _, err := s.reader().Query(&a, q, pg.Array(names), after, limit); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return &a, nil
+}
+
+// Authors returns which of the given author names exist.
+func (s *ArticleStore) Authors(names []string) ([]string, error) {
+	var existing []string
+	if _, err := s.reader().Query(&existing, `SELECT DISTINCT name FROM authors WHERE name = ANY(?)`, pg.Array(names)); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return existing, nil
+}
+
+// GetAuthors returns the authors with the given names and the number of articles of each, unknown
+// names are skipped.
+func (s *ArticleStore) GetAuthors(names []string) ([]models.Author, error) {
+	q := `
+	SELECT au.name, count(ar.id) AS articles FROM authors au LEFT JOIN articles ar ON ar.author_id = au.id WHERE au.name = ANY(?) GROUP BY au.name ORDER BY au.name
+	`
+
+	var authors []models.Author
+	if _, err := s.reader().Query(&authors, q, pg.Array(names)); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return authors, nil
+}
+
+// GetTags returns the tags with the given names and the number of articles tagged with each, tags
+// of no article are skipped.
+func (s *ArticleStore) GetTags(names []string) ([]models.Tag, error) {
+	q := `
+	SELECT tag AS name, count(*) AS articles FROM articles ar, unnest(ar.tags) tag WHERE tag = ANY(?) GROUP BY tag ORDER BY tag
+	`
+
+	var tags []models.Tag
+	if _, err := s.reader().Query(&tags, q, pg.Array(names)); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return tags, nil
+}
+
+// Recent returns up to limit articles matching filter, most recently updated first.
+func (s *ArticleStore) Recent(filter models.ArticleFilter, limit int) (*[]models.Article, error) {
+	q := `
+	SELECT ar.id, ar.public_id, ar.title, ar.content, ar.content_format, au.name AS author, ar.tags, ar.published_at, ar.slug, ar.created_at, ar.updated_at FROM articles ar INNER JOIN authors au ON ar.author_id = au.id
+	WHERE (? = '' OR au.name = ?) AND (? = '' OR ? = ANY(ar.tags)) ORDER BY ar.updated_at DESC, ar.id DESC LIMIT ?
 	`
 
 	var a []models.Article
-	if _, err := s.db.QueryOne(&a, q, id); err != nil {
+	if _, err := s.reader().Query(&a, q, filter.Author, filter.Author, filter.Tag, filter.Tag, limit); err != nil {
 		if err != pg.ErrNoRows {
 			return nil, err
 		}
@@ -35,14 +230,16 @@ func (s *ArticleStore) Get(id int) (*[]models.Article, error) {
 	return &a, nil
 }
 
-// GetAll gets all articles.
-func (s *ArticleStore) GetAll() (*[]models.Article, error) {
+// Search returns up to limit articles whose title or content match a full text query, best matches
+// first, loading only the given fields.
+func (s *ArticleStore) Search(query string, fields models.ArticleFields, limit int) (*[]models.Article, error) {
 	q := `
-	SELECT ar.id, ar.title, ar.content, au.name AS author FROM articles ar INNER JOIN authors au ON ar.author_id = au.id
+	SELECT ? FROM articles ar INNER JOIN authors au ON ar.author_id = au.id, plainto_tsquery('english', ?) query
+	WHERE to_tsvector('english', ar.title || ' ' || ar.content) @@ query ORDER BY ts_rank(to_tsvector('english', ar.title || ' ' || ar.content), query) DESC, ar.id LIMIT ?
 	`
 
 	var a []models.Article
-	if _, err := s.db.Query(&a, q); err != nil {
+	if _, err := s.reader().Query(&a, q, columns(fields), query, limit); err != nil {
 		if err != pg.ErrNoRows {
 			return nil, err
 		}
@@ -51,16 +248,471 @@ func (s *ArticleStore) GetAll() (*[]models.Article, error) {
 	return &a, nil
 }
 
-// Post inserts an article into the database and returns the last insert id.
+// Each streams all articles ordered by ID to fn without loading them into memory and stops at the
+// first error returned by fn.
+func (s *ArticleStore) Each(fn func(*models.Article) error) error {
+	return s.EachMatching(models.ArticleFilter{}, fn)
+}
+
+// EachMatching calls fn for every article matching filter, ordered by ID, like Each. The query runs
+// in a transaction of its own without the statement timeout of database_statement_timeout, as it
+// lasts as long as fn takes for the whole library, such as during exports and syncs.
+func (s *ArticleStore) EachMatching(filter models.ArticleFilter, fn func(*models.Article) error) error {
+	q := `
+	SELECT ar.id, ar.public_id, ar.title, ar.content, ar.content_format, au.name AS author, ar.tags, ar.published_at, ar.slug FROM articles ar INNER JOIN authors au ON ar.author_id = au.id
+	WHERE (? = '' OR au.name = ?) AND (? = '' OR ? = ANY(ar.tags)) ORDER BY ar.id
+	`
+
+	return s.RunInTransaction(func(s *ArticleStore) error {
+		if _, err := s.db.Exec(`SET LOCAL statement_timeout = 0`); err != nil {
+			return err
+		}
+
+		_, err := s.db.Query(newArticleRows(fn), q, filter.Author, filter.Author, filter.Tag, filter.Tag)
+		return err
+	})
+}
+
+// streamBatch is the number of rows fetched at a time by Stream.
+const streamBatch = 100
+
+// Stream calls fn for every article matching filter with a public ID greater than after, ordered
+// by public ID, loading only the given fields. Rows are fetched from a server side cursor in batches, so memory
+// does not grow with the number of articles, and the query stops once ctx is done or fn returns an
+// error.
+func (s *ArticleStore) Stream(ctx context.Context, filter models.ArticleFilter, fields models.ArticleFields, after models.PublicID, fn func(*models.Article) error) error {
+	q := `
+	DECLARE article_stream NO SCROLL CURSOR FOR SELECT ? FROM articles ar INNER JOIN authors au ON ar.author_id = au.id
+	WHERE (? = '' OR au.name = ?) AND (? = '' OR ? = ANY(ar.tags)) AND ar.public_id > ? ORDER BY ar.public_id
+	`
+
+	// the cursor needs a transaction of its own, on a replica when one is healthy
+	reader := &ArticleStore{db: s.reader()}
+	return reader.RunInTransaction(func(s *ArticleStore) error {
+		if _, err := s.db.Exec(q, columns(fields), filter.Author, filter.Author, filter.Tag, filter.Tag, after); err != nil {
+			return err
+		}
+
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			res, err := s.db.Query(newArticleRows(fn), `FETCH ? FROM article_stream`, streamBatch)
+			if err != nil {
+				return err
+			}
+			if res.RowsReturned() < streamBatch {
+				break
+			}
+		}
+
+		_, err := s.db.Exec(`CLOSE article_stream`)
+		return err
+	})
+}
+
+// Count returns the number of articles matching filter.
+func (s *ArticleStore) Count(filter models.ArticleFilter) (int, error) {
+	q := `
+	SELECT count(*) FROM articles ar INNER JOIN authors au ON ar.author_id = au.id WHERE (? = '' OR au.name = ?) AND (? = '' OR ? = ANY(ar.tags))
+	`
+
+	var n int
+	if _, err := s.reader().QueryOne(pg.Scan(&n), q, filter.Author, filter.Author, filter.Tag, filter.Tag); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// Post inserts an article into the database with a new public ID and returns its ids.
 func (s *ArticleStore) Post(article *models.Article) (*models.ArticleID, error) {
 	q := `
-		WITH author AS (INSERT INTO authors(name) VALUES (?) RETURNING id) INSERT INTO articles(title, content, author_id) VALUES(?, ?, (SELECT author.id FROM author)) RETURNING id
+		WITH author AS (INSERT INTO authors(name) VALUES (?) RETURNING id) INSERT INTO articles(title, content, content_format, author_id, tags, published_at, slug, public_id) VALUES(?, ?, NULLIF(?, ''), (SELECT author.id FROM author), ?, ?, ?, ?) RETURNING id, public_id
+	`
+
+	var articleID models.ArticleID
+	err := s.RunInTransaction(func(s *ArticleStore) error {
+		slug, err := s.slug(0, "", article, nil)
+		if err != nil {
+			return err
+		}
+		article.Slug = slug
+		article.PublicID = models.NewPublicID()
+
+		if _, err := s.db.QueryOne(&articleID, q, article.Author, article.Title, article.Content, article.ContentFormat, pg.Array(article.Tags), article.PublishedAt, article.Slug, article.PublicID); err != nil {
+			return err
+		}
+		return s.created(articleID.ID, article)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &articleID, nil
+}
+
+// Import inserts an article, reusing an existing author with the same name, and returns its ids.
+// The public ID of an exported article is kept, so that its links keep working, articles without
+// one get a new public ID.
+func (s *ArticleStore) Import(article *models.Article) (*models.ArticleID, error) {
+	q := `
+	WITH existing AS (SELECT id FROM authors WHERE name = ? ORDER BY id LIMIT 1),
+	created AS (INSERT INTO authors(name) SELECT ? WHERE NOT EXISTS (SELECT 1 FROM existing) RETURNING id)
+	INSERT INTO articles(title, content, content_format, author_id, tags, published_at, slug, public_id) VALUES (?, ?, NULLIF(?, ''), (SELECT id FROM existing UNION ALL SELECT id FROM created LIMIT 1), ?, ?, ?, ?) RETURNING id, public_id
 	`
 
 	var articleID models.ArticleID
-	if _, err := s.db.QueryOne(&articleID.ID, q, article.Author, article.Title, article.Content); err != nil {
+	err := s.RunInTransaction(func(s *ArticleStore) error {
+		slug, err := s.slug(0, "", article, nil)
+		if err != nil {
+			return err
+		}
+		article.Slug = slug
+
+		if article.PublicID == "" {
+			article.PublicID = models.NewPublicID()
+		} else if article.PublicID, err = models.ParsePublicID(string(article.PublicID)); err != nil {
+			return err
+		}
+
+		if _, err := s.db.QueryOne(&articleID, q, article.Author, article.Author, article.Title, article.Content, article.ContentFormat, pg.Array(article.Tags), article.PublishedAt, article.Slug, article.PublicID); err != nil {
+			return err
+		}
+		return s.created(articleID.ID, article)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return &articleID, nil
 }
+
+// Update replaces the title, content, content format, author, tags, publish date and slug of an
+// article. The author is looked up by name like on Import rather than renamed, as other articles
+// may share it. Without a custom slug the current slug is kept until the title changes, when a new
+// slug is derived from it. Replaced slugs are kept in the slug history of the article.
+func (s *ArticleStore) Update(id int, article *models.Article) error {
+	q := `
+	WITH existing AS (SELECT id FROM authors WHERE name = ? ORDER BY id LIMIT 1),
+	created AS (INSERT INTO authors(name) SELECT ? WHERE NOT EXISTS (SELECT 1 FROM existing) RETURNING id)
+	UPDATE articles SET title = ?, content = ?, content_format = NULLIF(?, ''), author_id = (SELECT id FROM existing UNION ALL SELECT id FROM created LIMIT 1), tags = ?, published_at = ?, slug = ?, updated_at = now() WHERE id = ?
+	`
+
+	return s.RunInTransaction(func(s *ArticleStore) error {
+		var current struct {
+			Title    string
+			Slug     string
+			PublicID models.PublicID
+		}
+		if _, err := s.db.QueryOne(&current, `SELECT title, slug, public_id FROM articles WHERE id = ? FOR UPDATE`, id); err != nil {
+			if err == pg.ErrNoRows {
+				return ErrArticleNotFound
+			}
+			return err
+		}
+
+		slug := current.Slug
+		if article.Slug != "" || article.Title != current.Title {
+			var err error
+			if slug, err = s.slug(id, current.Slug, article, nil); err != nil {
+				return err
+			}
+		}
+		if err := s.moveSlug(id, current.Slug, slug); err != nil {
+			return err
+		}
+		article.Slug = slug
+
+		res, err := s.db.Exec(q, article.Author, article.Author, article.Title, article.Content, article.ContentFormat, pg.Array(article.Tags), article.PublishedAt, article.Slug, id)
+		if err != nil {
+			return err
+		}
+
+		if res.RowsAffected() == 0 {
+			return ErrArticleNotFound
+		}
+
+		a := *article
+		a.ArticleID = models.ArticleID{ID: id, PublicID: current.PublicID}
+		return enqueueDeliveries(s.db, models.EventUpdated, a)
+	})
+}
+
+// Delete removes an article by ID.
+func (s *ArticleStore) Delete(id int) error {
+	return s.RunInTransaction(func(s *ArticleStore) error {
+		var publicID models.PublicID
+		if _, err := s.db.QueryOne(pg.Scan(&publicID), `DELETE FROM articles WHERE id = ? RETURNING public_id`, id); err != nil {
+			if err == pg.ErrNoRows {
+				return ErrArticleNotFound
+			}
+			return err
+		}
+
+		return enqueueDeliveries(s.db, models.EventDeleted, models.Article{ArticleID: models.ArticleID{ID: id, PublicID: publicID}})
+	})
+}
+
+// created enqueues the webhook deliveries of a created article, which carries its public ID.
+func (s *ArticleStore) created(id int, article *models.Article) error {
+	a := *article
+	a.ID = id
+	return enqueueDeliveries(s.db, models.EventCreated, a)
+}
+
+// Batch runs create, update and delete operations in order and returns a result for each operation.
+// When atomic is set all operations run in a single transaction which is rolled back on the first
+// failure, reported as a *models.BatchError, and consecutive creates are written with multi-row
+// inserts. Otherwise each operation runs in its own transaction, so that a failing operation, a
+// create included, fails alone.
+func (s *ArticleStore) Batch(ops []models.BatchOperation, atomic bool) ([]models.BatchResult, error) {
+	results := make([]models.BatchResult, len(ops))
+
+	run := func(store *ArticleStore) error {
+		exec := func(fn func(*ArticleStore) error) error {
+			if atomic {
+				return fn(store)
+			}
+			return store.RunInTransaction(fn)
+		}
+
+		for i := 0; i < len(ops); {
+			if ops[i].Op == models.BatchCreate {
+				j := i + 1
+				for atomic && j < len(ops) && ops[j].Op == models.BatchCreate {
+					j++
+				}
+
+				var ids []models.ArticleID
+				err := exec(func(store *ArticleStore) (err error) {
+					ids, err = store.postMany(ops[i:j])
+					return err
+				})
+				for k := i; k < j; k++ {
+					if err != nil {
+						results[k].Err = err
+						continue
+					}
+					results[k].ArticleID = ids[k-i]
+				}
+				if err != nil && atomic {
+					return &models.BatchError{Index: i, Err: err}
+				}
+
+				i = j
+				continue
+			}
+
+			op := ops[i]
+			results[i].PublicID = op.ID
+			err := exec(func(store *ArticleStore) error {
+				id, err := store.Resolve(op.ID)
+				if err != nil {
+					return err
+				}
+				results[i].ID = id
+
+				if op.Op == models.BatchDelete {
+					return store.Delete(id)
+				}
+				return store.Update(id, op.Article)
+			})
+			if err != nil {
+				results[i].Err = err
+				if atomic {
+					return &models.BatchError{Index: i, Err: err}
+				}
+			}
+			i++
+		}
+
+		return nil
+	}
+
+	if atomic {
+		return results, s.RunInTransaction(run)
+	}
+
+	return results, run(s)
+}
+
+// postMany inserts the articles of create operations with one multi-row insert per table and
+// returns the article ids in the order of the operations.
+func (s *ArticleStore) postMany(ops []models.BatchOperation) ([]models.ArticleID, error) {
+	rows := make([]string, len(ops))
+	authors := make([]interface{}, len(ops))
+	for i, op := range ops {
+		rows[i] = "(?)"
+		authors[i] = op.Article.Author
+	}
+
+	// postgres returns inserted rows in the order of the VALUES list
+	var authorIDs []int
+	q := `INSERT INTO authors(name) VALUES ` + strings.Join(rows, ", ") + ` RETURNING id`
+	if _, err := s.db.Query(&authorIDs, q, authors...); err != nil {
+		return nil, err
+	}
+
+	params := make([]interface{}, 0, len(ops)*8)
+	reserved := make(map[string]bool, len(ops))
+	for i, op := range ops {
+		slug, err := s.slug(0, "", op.Article, reserved)
+		if err != nil {
+			return nil, err
+		}
+		op.Article.Slug = slug
+		reserved[slug] = true
+		op.Article.PublicID = models.NewPublicID()
+
+		rows[i] = "(?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?)"
+		params = append(params, op.Article.Title, op.Article.Content, op.Article.ContentFormat, authorIDs[i], pg.Array(op.Article.Tags), op.Article.PublishedAt, op.Article.Slug, op.Article.PublicID)
+	}
+
+	var ids []models.ArticleID
+	q = `INSERT INTO articles(title, content, content_format, author_id, tags, published_at, slug, public_id) VALUES ` + strings.Join(rows, ", ") + ` RETURNING id, public_id`
+	if _, err := s.db.Query(&ids, q, params...); err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		if err := s.created(ids[i].ID, op.Article); err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
+}
+
+// Resolve returns the internal ID of the article with a public ID. Like the other reads it may be
+// served by a replica, so that the article is loaded from the database which resolved it.
+func (s *ArticleStore) Resolve(id models.PublicID) (int, error) {
+	var articleID int
+	if _, err := s.reader().QueryOne(pg.Scan(&articleID), `SELECT id FROM articles WHERE public_id = ?`, strings.ToUpper(string(id))); err != nil {
+		if err == pg.ErrNoRows {
+			return 0, ErrArticleNotFound
+		}
+		return 0, err
+	}
+
+	return articleID, nil
+}
+
+// GetBySlug gets an article by its current slug or by a slug it had before. The returned article
+// carries its current slug, which differs from slug for a replaced slug.
+func (s *ArticleStore) GetBySlug(slug string) (*models.Article, error) {
+	q := `
+	SELECT ar.id, ar.public_id, ar.title, ar.content, ar.content_format, au.name AS author, ar.tags, ar.published_at, ar.slug FROM articles ar INNER JOIN authors au ON ar.author_id = au.id
+	WHERE ar.slug = ? OR ar.id = (SELECT article_id FROM article_slugs WHERE slug = ?)
+	ORDER BY ar.slug = ? DESC LIMIT 1
+	`
+
+	var a models.Article
+	if _, err := s.reader().QueryOne(&a, q, slug, slug, slug); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, ErrArticleNotFound
+		}
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// slug returns a unique slug for an article with the given ID and current slug, or 0 and "" for a
+// new article. A custom slug is used as is and fails with ErrSlugTaken when another article uses
+// it now or used it before. Otherwise the slug is derived from the title, with a -2, -3, ...
+// suffix on collision, and the current slug is kept when the title derives it. Slugs in reserved
+// are taken by articles not inserted yet.
+func (s *ArticleStore) slug(id int, current string, article *models.Article, reserved map[string]bool) (string, error) {
+	base := article.Slug
+	if base == "" {
+		base = models.Slugify(article.Title)
+		if current == base || (strings.HasPrefix(current, base+"-") && isNumber(current[len(base)+1:])) {
+			return current, nil
+		}
+	}
+
+	// concurrent writers of articles with the same base slug pick suffixes one after another
+	if _, err := s.db.Exec(`SELECT pg_advisory_xact_lock(hashtext(?))`, "slug:"+base); err != nil {
+		return "", err
+	}
+
+	q := `
+	SELECT slug FROM articles WHERE (slug = ? OR slug LIKE ?) AND id <> ?
+	UNION SELECT slug FROM article_slugs WHERE (slug = ? OR slug LIKE ?) AND article_id <> ?
+	`
+
+	var taken []string
+	if _, err := s.db.Query(&taken, q, base, base+"-%", id, base, base+"-%", id); err != nil && err != pg.ErrNoRows {
+		return "", err
+	}
+
+	used := make(map[string]bool, len(taken))
+	for _, slug := range taken {
+		used[slug] = true
+	}
+
+	if article.Slug != "" {
+		if used[base] || reserved[base] {
+			return "", ErrSlugTaken
+		}
+		return base, nil
+	}
+
+	slug := base
+	for n := 2; used[slug] || reserved[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug, nil
+}
+
+// moveSlug records the replaced slug of an article in its slug history, so that it keeps resolving
+// to the article. A slug taken back from the history is removed from it.
+func (s *ArticleStore) moveSlug(id int, from, to string) error {
+	if from == to {
+		return nil
+	}
+
+	if from != "" {
+		q := `
+		INSERT INTO article_slugs(slug, article_id) VALUES (?, ?) ON CONFLICT (slug) DO UPDATE SET article_id = EXCLUDED.article_id, created_at = now()
+		`
+		if _, err := s.db.Exec(q, from, id); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.db.Exec(`DELETE FROM article_slugs WHERE slug = ?`, to)
+	return err
+}
+
+// isNumber reports whether s is a decimal number, such as the suffix of a colliding slug.
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil && !strings.HasPrefix(s, "+") && !strings.HasPrefix(s, "-")
+}
+
+// RunInTransaction calls fn with a store bound to a transaction which is committed when fn returns
+// nil and rolled back otherwise. A store already bound to a transaction uses a savepoint instead.
+func (s *ArticleStore) RunInTransaction(fn func(*ArticleStore) error) error {
+	if db, ok := s.db.(*pg.DB); ok {
+		return db.RunInTransaction(func(tx *pg.Tx) error {
+			return fn(&ArticleStore{db: tx})
+		})
+	}
+
+	if _, err := s.db.Exec(`SAVEPOINT article_store`); err != nil {
+		return err
+	}
+
+	if err := fn(s); err != nil {
+		if _, rerr := s.db.Exec(`ROLLBACK TO SAVEPOINT article_store`); rerr != nil {
+			return rerr
+		}
+		return err
+	}
+
+	_, err := s.db.Exec(`RELEASE SAVEPOINT article_store`)
+	return err
+}
@@ -1,9 +1,13 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/go-pg/pg"
 	"github.com/go-pg/pg/orm"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -45,6 +49,7 @@ func TestGet(t *testing.T) {
 					Title:   "Test Title",
 					Content: "Test Content",
 					Author:  "Test Author",
+					Slug:    "test-title",
 				},
 			},
 		},
@@ -60,6 +65,7 @@ func TestGet(t *testing.T) {
 					Title:   "Another Test Title",
 					Content: "Another Test Content",
 					Author:  "Another Test Author",
+					Slug:    "another-test-title",
 				},
 			},
 		},
@@ -99,7 +105,7 @@ func TestGet(t *testing.T) {
 				t.Errorf("getAll failed: %v", err)
 			}
 
-			assert.Equal(t, tc.expected, *actual)
+			assert.Equal(t, tc.expected, withoutPublicIDs(t, *actual))
 		})
 	}
 }
@@ -127,6 +133,7 @@ func TestGetAll(t *testing.T) {
 					Title:   "Test Title",
 					Content: "Test Content",
 					Author:  "Test Author",
+					Slug:    "test-title",
 				},
 			},
 		},
@@ -141,6 +148,7 @@ func TestGetAll(t *testing.T) {
 					Title:   "Test Title",
 					Content: "Test Content",
 					Author:  "Test Author",
+					Slug:    "test-title",
 				},
 				{
 					ArticleID: models.ArticleID{
@@ -149,6 +157,7 @@ func TestGetAll(t *testing.T) {
 					Title:   "Another Test Title",
 					Content: "Another Test Content",
 					Author:  "Another Test Author",
+					Slug:    "another-test-title",
 				},
 			},
 		},
@@ -177,12 +186,12 @@ func TestGetAll(t *testing.T) {
 				}
 			}
 
-			actual, err := (&ArticleStore{db: tx}).GetAll()
+			actual, err := (&ArticleStore{db: tx}).GetAll(nil)
 			if err != nil {
 				t.Errorf("getAll failed: %v", err)
 			}
 
-			assert.Equal(t, tc.expected, *actual)
+			assert.Equal(t, tc.expected, withoutPublicIDs(t, *actual))
 		})
 	}
 }
@@ -206,6 +215,7 @@ func TestPost(t *testing.T) {
 				},
 				Title:   "Test Title",
 				Author:  "Test Author",
+				Slug:    "test-title",
 				Content: "Test Content",
 			},
 		},
@@ -239,14 +249,846 @@ func TestPost(t *testing.T) {
 				t.Errorf("get failed: %v", err)
 			}
 
-			assert.Equal(t, []models.Article{tc.expected}, *actual)
+			assert.Equal(t, []models.Article{tc.expected}, withoutPublicIDs(t, *actual))
 		})
 	}
 }
 
+// withoutPublicIDs checks that articles have a public ID and returns them without it, since public
+// IDs are random.
+func withoutPublicIDs(t *testing.T, articles []models.Article) []models.Article {
+	if articles == nil {
+		return nil
+	}
+
+	stripped := make([]models.Article, len(articles))
+	for i, a := range articles {
+		if _, err := models.ParsePublicID(string(a.PublicID)); err != nil {
+			t.Errorf("article %d has invalid public id %q", a.ID, a.PublicID)
+		}
+		a.PublicID = ""
+		stripped[i] = a
+	}
+	return stripped
+}
+
 func restartSerial(t *testing.T, db orm.DB) {
 	_, err := db.Exec(`TRUNCATE articles RESTART IDENTITY CASCADE`)
 	if err != nil {
 		t.Errorf("could not restart serial: %v", err)
 	}
 }
+
+func TestUpdate(t *testing.T) {
+	seed := "WITH author AS (INSERT INTO authors(name) VALUES ('Test Author') RETURNING id) INSERT INTO articles(title, content, author_id) VALUES('Test Title', 'Test Content', (SELECT author.id FROM author))"
+
+	tt := []struct {
+		name     string
+		id       int
+		article  models.Article
+		expected struct {
+			err      error
+			articles []models.Article
+		}
+	}{
+		{
+			name:    "update record",
+			id:      1,
+			article: models.Article{Title: "New Title", Content: "New Content", Author: "New Author"},
+			expected: struct {
+				err      error
+				articles []models.Article
+			}{
+				articles: []models.Article{{ArticleID: models.ArticleID{ID: 1}, Title: "New Title", Content: "New Content", Author: "New Author", Slug: "new-title"}},
+			},
+		},
+		{
+			name:    "record does not exist",
+			id:      2,
+			article: models.Article{Title: "New Title", Content: "New Content", Author: "New Author"},
+			expected: struct {
+				err      error
+				articles []models.Article
+			}{
+				err:      ErrArticleNotFound,
+				articles: []models.Article{{ArticleID: models.ArticleID{ID: 1}, Title: "Test Title", Content: "Test Content", Author: "Test Author", Slug: "test-title"}},
+			},
+		},
+	}
+
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Errorf("failed to begin transaction: %v", err)
+			}
+
+			defer func() {
+				tx.Rollback()
+				restartSerial(t, db)
+			}()
+
+			if _, err := tx.Exec(seed); err != nil {
+				t.Errorf("failed to seed: %v", err)
+			}
+
+			articleStore := &ArticleStore{db: tx}
+			assert.Equal(t, tc.expected.err, articleStore.Update(tc.id, &tc.article))
+
+			actual, err := articleStore.GetAll(nil)
+			if err != nil {
+				t.Errorf("getAll failed: %v", err)
+			}
+
+			assert.Equal(t, tc.expected.articles, withoutPublicIDs(t, *actual))
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	seed := "WITH author AS (INSERT INTO authors(name) VALUES ('Test Author') RETURNING id) INSERT INTO articles(title, content, author_id) VALUES('Test Title', 'Test Content', (SELECT author.id FROM author))"
+
+	tt := []struct {
+		name     string
+		id       int
+		expected struct {
+			err      error
+			articles []models.Article
+		}
+	}{
+		{
+			name: "delete record",
+			id:   1,
+			expected: struct {
+				err      error
+				articles []models.Article
+			}{
+				articles: []models.Article(nil),
+			},
+		},
+		{
+			name: "record does not exist",
+			id:   2,
+			expected: struct {
+				err      error
+				articles []models.Article
+			}{
+				err:      ErrArticleNotFound,
+				articles: []models.Article{{ArticleID: models.ArticleID{ID: 1}, Title: "Test Title", Content: "Test Content", Author: "Test Author", Slug: "test-title"}},
+			},
+		},
+	}
+
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Errorf("failed to begin transaction: %v", err)
+			}
+
+			defer func() {
+				tx.Rollback()
+				restartSerial(t, db)
+			}()
+
+			if _, err := tx.Exec(seed); err != nil {
+				t.Errorf("failed to seed: %v", err)
+			}
+
+			articleStore := &ArticleStore{db: tx}
+			assert.Equal(t, tc.expected.err, articleStore.Delete(tc.id))
+
+			actual, err := articleStore.GetAll(nil)
+			if err != nil {
+				t.Errorf("getAll failed: %v", err)
+			}
+
+			assert.Equal(t, tc.expected.articles, withoutPublicIDs(t, *actual))
+		})
+	}
+}
+
+func TestBatch(t *testing.T) {
+	seed := "WITH author AS (INSERT INTO authors(name) VALUES ('Test Author') RETURNING id) INSERT INTO articles(title, content, author_id, public_id) VALUES('Test Title', 'Test Content', (SELECT author.id FROM author), '01ARZ3NDEKTSV4RRFFQ69G5FAV')"
+	ops := []models.BatchOperation{
+		{Op: models.BatchCreate, Article: &models.Article{Title: "Second Title", Content: "Second Content", Author: "Second Author"}},
+		{Op: models.BatchCreate, Article: &models.Article{Title: "Third Title", Content: "Third Content", Author: "Third Author"}},
+		{Op: models.BatchDelete, ID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"},
+		{Op: models.BatchUpdate, ID: "01ARZ3NDEKTSV4RRFFQ69G5FAW", Article: &models.Article{Title: "New Title", Content: "New Content", Author: "New Author"}},
+	}
+
+	tt := []struct {
+		name     string
+		atomic   bool
+		expected struct {
+			err      error
+			results  []models.BatchResult
+			articles []models.Article
+		}
+	}{
+		{
+			name:   "best effort",
+			atomic: false,
+			expected: struct {
+				err      error
+				results  []models.BatchResult
+				articles []models.Article
+			}{
+				results: []models.BatchResult{
+					{ArticleID: models.ArticleID{ID: 2}},
+					{ArticleID: models.ArticleID{ID: 3}},
+					{ArticleID: models.ArticleID{ID: 1, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"}},
+					{ArticleID: models.ArticleID{PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAW"}, Err: ErrArticleNotFound},
+				},
+				articles: []models.Article{
+					{ArticleID: models.ArticleID{ID: 2}, Title: "Second Title", Content: "Second Content", Author: "Second Author", Slug: "second-title"},
+					{ArticleID: models.ArticleID{ID: 3}, Title: "Third Title", Content: "Third Content", Author: "Third Author", Slug: "third-title"},
+				},
+			},
+		},
+		{
+			name:   "atomic",
+			atomic: true,
+			expected: struct {
+				err      error
+				results  []models.BatchResult
+				articles []models.Article
+			}{
+				err: &models.BatchError{Index: 3, Err: ErrArticleNotFound},
+				results: []models.BatchResult{
+					{ArticleID: models.ArticleID{ID: 2}},
+					{ArticleID: models.ArticleID{ID: 3}},
+					{ArticleID: models.ArticleID{ID: 1, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"}},
+					{ArticleID: models.ArticleID{PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAW"}, Err: ErrArticleNotFound},
+				},
+				articles: []models.Article{
+					{ArticleID: models.ArticleID{ID: 1}, Title: "Test Title", Content: "Test Content", Author: "Test Author", Slug: "test-title"},
+				},
+			},
+		},
+	}
+
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Errorf("failed to begin transaction: %v", err)
+			}
+
+			defer func() {
+				tx.Rollback()
+				restartSerial(t, db)
+			}()
+
+			if _, err := tx.Exec(seed); err != nil {
+				t.Errorf("failed to seed: %v", err)
+			}
+
+			articleStore := &ArticleStore{db: tx}
+			results, err := articleStore.Batch(ops, tc.atomic)
+			for i := range results {
+				if ops[i].Op == models.BatchCreate {
+					assert.NotEmpty(t, results[i].PublicID)
+					results[i].PublicID = ""
+				}
+			}
+			assert.Equal(t, tc.expected.err, err)
+			assert.Equal(t, tc.expected.results, results)
+
+			actual, err := articleStore.GetAll(nil)
+			if err != nil {
+				t.Errorf("getAll failed: %v", err)
+			}
+
+			assert.Equal(t, tc.expected.articles, withoutPublicIDs(t, *actual))
+		})
+	}
+}
+
+func TestEach(t *testing.T) {
+	seed := "WITH author AS (INSERT INTO authors(name) VALUES ('Test Author') RETURNING id) INSERT INTO articles(title, content, author_id) VALUES('Test Title', 'Test Content', (SELECT author.id FROM author));WITH author AS (INSERT INTO authors(name) VALUES ('Another Test Author') RETURNING id) INSERT INTO articles(title, content, author_id) VALUES('Another Test Title', 'Another Test Content', (SELECT author.id FROM author))"
+	stop := errors.New("stop")
+
+	tt := []struct {
+		name     string
+		limit    int
+		expected struct {
+			err      error
+			articles []models.Article
+		}
+	}{
+		{
+			name:  "stream all records",
+			limit: 2,
+			expected: struct {
+				err      error
+				articles []models.Article
+			}{
+				articles: []models.Article{
+					{ArticleID: models.ArticleID{ID: 1}, Title: "Test Title", Content: "Test Content", Author: "Test Author", Slug: "test-title"},
+					{ArticleID: models.ArticleID{ID: 2}, Title: "Another Test Title", Content: "Another Test Content", Author: "Another Test Author", Slug: "another-test-title"},
+				},
+			},
+		},
+		{
+			name:  "stop on error",
+			limit: 1,
+			expected: struct {
+				err      error
+				articles []models.Article
+			}{
+				err: stop,
+				articles: []models.Article{
+					{ArticleID: models.ArticleID{ID: 1}, Title: "Test Title", Content: "Test Content", Author: "Test Author", Slug: "test-title"},
+				},
+			},
+		},
+	}
+
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Errorf("failed to begin transaction: %v", err)
+			}
+
+			defer func() {
+				tx.Rollback()
+				restartSerial(t, db)
+			}()
+
+			if _, err := tx.Exec(seed); err != nil {
+				t.Errorf("failed to seed: %v", err)
+			}
+
+			var actual []models.Article
+			err = (&ArticleStore{db: tx}).Each(func(a *models.Article) error {
+				if len(actual) == tc.limit {
+					return stop
+				}
+				actual = append(actual, *a)
+				return nil
+			})
+
+			assert.Equal(t, tc.expected.err, err)
+			assert.Equal(t, tc.expected.articles, withoutPublicIDs(t, actual))
+		})
+	}
+}
+
+func TestUpdateSharedAuthor(t *testing.T) {
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Errorf("failed to begin transaction: %v", err)
+	}
+
+	defer func() {
+		tx.Rollback()
+		restartSerial(t, db)
+	}()
+
+	articleStore := &ArticleStore{db: tx}
+	var ids []int
+	for _, a := range []models.Article{
+		{Title: "Test Title", Content: "Test Content", Author: "Test Author"},
+		{Title: "Another Test Title", Content: "Another Test Content", Author: "Test Author"},
+	} {
+		id, err := articleStore.Import(&a)
+		if err != nil {
+			t.Fatalf("import failed: %v", err)
+		}
+		ids = append(ids, id.ID)
+	}
+
+	assert.NoError(t, articleStore.Update(ids[0], &models.Article{Title: "Test Title", Content: "Test Content", Author: "New Author"}))
+	assert.NoError(t, articleStore.Update(ids[1], &models.Article{Title: "Another Test Title", Content: "Another Test Content", Author: "New Author"}))
+	assert.NoError(t, articleStore.Update(ids[0], &models.Article{Title: "Test Title", Content: "Test Content", Author: "Test Author"}))
+
+	for i, expected := range []string{"Test Author", "New Author"} {
+		articles, err := articleStore.Get(ids[i])
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		assert.Equal(t, expected, (*articles)[0].Author, "updating an article must not rename the authors of others")
+	}
+
+	var authors int
+	if _, err := tx.QueryOne(pg.Scan(&authors), `SELECT count(*) FROM authors WHERE name = 'New Author'`); err != nil {
+		t.Errorf("count authors failed: %v", err)
+	}
+	assert.Equal(t, 1, authors, "existing authors must be reused")
+}
+
+func TestImport(t *testing.T) {
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Errorf("failed to begin transaction: %v", err)
+	}
+
+	defer func() {
+		tx.Rollback()
+		restartSerial(t, db)
+	}()
+
+	articleStore := &ArticleStore{db: tx}
+	for _, a := range []models.Article{
+		{Title: "Test Title", Content: "Test Content", Author: "Test Author"},
+		{Title: "Another Test Title", Content: "Another Test Content", Author: "Test Author"},
+	} {
+		if _, err := articleStore.Import(&a); err != nil {
+			t.Errorf("import failed: %v", err)
+		}
+	}
+
+	var authors int
+	if _, err := tx.QueryOne(pg.Scan(&authors), `SELECT count(*) FROM authors WHERE name = 'Test Author'`); err != nil {
+		t.Errorf("count authors failed: %v", err)
+	}
+
+	assert.Equal(t, 1, authors)
+
+	// exported public ids are kept
+	imported := &models.Article{ArticleID: models.ArticleID{PublicID: "01arz3ndektsv4rrffq69g5fav"}, Title: "Third Test Title", Content: "Test Content", Author: "Test Author"}
+	id, err := articleStore.Import(imported)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	assert.Equal(t, models.PublicID("01ARZ3NDEKTSV4RRFFQ69G5FAV"), id.PublicID)
+}
+
+func TestResolve(t *testing.T) {
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	defer func() {
+		tx.Rollback()
+		restartSerial(t, db)
+	}()
+
+	s := &ArticleStore{db: tx}
+	articleID, err := s.Post(&models.Article{Title: "Test Title", Content: "Test Content", Author: "Test Author"})
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+
+	id, err := s.Resolve(articleID.PublicID)
+	if err != nil {
+		t.Errorf("resolve failed: %v", err)
+	}
+	assert.Equal(t, articleID.ID, id)
+
+	// public ids are case insensitive like ULIDs
+	id, err = s.Resolve(models.PublicID(strings.ToLower(string(articleID.PublicID))))
+	if err != nil {
+		t.Errorf("resolve failed: %v", err)
+	}
+	assert.Equal(t, articleID.ID, id)
+
+	_, err = s.Resolve("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	assert.Equal(t, ErrArticleNotFound, err)
+}
+
+func TestSearch(t *testing.T) {
+	seed := "WITH author AS (INSERT INTO authors(name) VALUES ('Test Author') RETURNING id) INSERT INTO articles(title, content, author_id) VALUES('Baking Bread', 'Flour, water and salt', (SELECT author.id FROM author));WITH author AS (INSERT INTO authors(name) VALUES ('Another Test Author') RETURNING id) INSERT INTO articles(title, content, author_id) VALUES('Sourdough', 'Bread baked from a starter', (SELECT author.id FROM author))"
+
+	tt := []struct {
+		name     string
+		query    string
+		limit    int
+		expected []models.Article
+	}{
+		{
+			name:  "match title and content",
+			query: "bread",
+			limit: 10,
+			expected: []models.Article{
+				{ArticleID: models.ArticleID{ID: 1}, Title: "Baking Bread", Content: "Flour, water and salt", Author: "Test Author", Slug: "baking-bread"},
+				{ArticleID: models.ArticleID{ID: 2}, Title: "Sourdough", Content: "Bread baked from a starter", Author: "Another Test Author", Slug: "sourdough"},
+			},
+		},
+		{
+			name:  "stemmed match",
+			query: "starters",
+			limit: 10,
+			expected: []models.Article{
+				{ArticleID: models.ArticleID{ID: 2}, Title: "Sourdough", Content: "Bread baked from a starter", Author: "Another Test Author", Slug: "sourdough"},
+			},
+		},
+		{
+			name:     "no match",
+			query:    "pasta",
+			limit:    10,
+			expected: []models.Article(nil),
+		},
+		{
+			name:  "limit",
+			query: "bread",
+			limit: 1,
+			expected: []models.Article{
+				{ArticleID: models.ArticleID{ID: 1}, Title: "Baking Bread", Content: "Flour, water and salt", Author: "Test Author", Slug: "baking-bread"},
+			},
+		},
+	}
+
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Errorf("failed to begin transaction: %v", err)
+			}
+
+			defer func() {
+				tx.Rollback()
+				restartSerial(t, db)
+			}()
+
+			if _, err := tx.Exec(seed); err != nil {
+				t.Errorf("failed to seed: %v", err)
+			}
+
+			actual, err := (&ArticleStore{db: tx}).Search(tc.query, nil, tc.limit)
+			if err != nil {
+				t.Errorf("search failed: %v", err)
+			}
+
+			assert.Equal(t, tc.expected, withoutPublicIDs(t, *actual))
+		})
+	}
+}
+
+func TestPage(t *testing.T) {
+	seed := "WITH author AS (INSERT INTO authors(name) VALUES ('Test Author') RETURNING id) INSERT INTO articles(title, content, author_id, tags, public_id) VALUES('Test Title 1', 'Test Content 1', (SELECT author.id FROM author), '{go}', '01ARZ3NDEKTSV4RRFFQ69G5F01'), ('Test Title 2', 'Test Content 2', (SELECT author.id FROM author), '{}', '01ARZ3NDEKTSV4RRFFQ69G5F03');WITH author AS (INSERT INTO authors(name) VALUES ('Another Test Author') RETURNING id) INSERT INTO articles(title, content, author_id, tags, public_id) VALUES('Test Title 3', 'Test Content 3', (SELECT author.id FROM author), '{go}', '01ARZ3NDEKTSV4RRFFQ69G5F02')"
+
+	tt := []struct {
+		name     string
+		filter   models.ArticleFilter
+		after    models.PublicID
+		limit    int
+		expected []int
+	}{
+		{name: "first page", limit: 2, expected: []int{1, 3}},
+		{name: "after cursor", after: "01ARZ3NDEKTSV4RRFFQ69G5F01", limit: 2, expected: []int{3, 2}},
+		{name: "by author", filter: models.ArticleFilter{Author: "Test Author"}, limit: 10, expected: []int{1, 2}},
+		{name: "by tag", filter: models.ArticleFilter{Tag: "go"}, limit: 10, expected: []int{1, 3}},
+		{name: "past the end", after: "01ARZ3NDEKTSV4RRFFQ69G5F03", limit: 10, expected: nil},
+	}
+
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Errorf("failed to begin transaction: %v", err)
+			}
+
+			defer func() {
+				tx.Rollback()
+				restartSerial(t, db)
+			}()
+
+			if _, err := tx.Exec(seed); err != nil {
+				t.Errorf("failed to seed: %v", err)
+			}
+
+			articles, err := (&ArticleStore{db: tx}).Page(tc.filter, nil, tc.after, tc.limit)
+			if err != nil {
+				t.Errorf("page failed: %v", err)
+			}
+
+			var actual []int
+			for _, a := range *articles {
+				actual = append(actual, a.ID)
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestPageFields(t *testing.T) {
+	seed := "WITH author AS (INSERT INTO authors(name) VALUES ('Test Author') RETURNING id) INSERT INTO articles(title, content, author_id, tags, slug, public_id) VALUES('Test Title', 'Test Content', (SELECT author.id FROM author), '{go}', 'test-title', '01ARZ3NDEKTSV4RRFFQ69G5F01')"
+
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	defer func() {
+		tx.Rollback()
+		restartSerial(t, db)
+	}()
+
+	if _, err := tx.Exec(seed); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	articles, err := (&ArticleStore{db: tx}).Page(models.ArticleFilter{}, models.ArticleFields{"title", "author"}, "", 10)
+	if err != nil {
+		t.Fatalf("page failed: %v", err)
+	}
+
+	assert.Equal(t, []models.Article{{ArticleID: models.ArticleID{ID: 1, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5F01"}, Title: "Test Title", Author: "Test Author"}}, *articles, "columns which are not selected must not be loaded")
+}
+
+func TestStream(t *testing.T) {
+	seed := "WITH author AS (INSERT INTO authors(name) VALUES ('Test Author') RETURNING id) INSERT INTO articles(title, content, author_id, slug, public_id) SELECT 'Test Title ' || n, 'Test Content', (SELECT author.id FROM author), 'test-title-' || n, '01ARZ3NDEKTSV4RRFFQ69G5' || lpad(n::text, 3, '0') FROM generate_series(1, 250) n"
+
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	defer func() {
+		tx.Rollback()
+		restartSerial(t, db)
+	}()
+
+	if _, err := tx.Exec(seed); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	s := &ArticleStore{db: tx}
+	var ids []int
+	err = s.Stream(context.Background(), models.ArticleFilter{}, models.ArticleFields{"title"}, "01ARZ3NDEKTSV4RRFFQ69G5010", func(a *models.Article) error {
+		ids = append(ids, a.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	assert.Equal(t, 240, len(ids), "every batch of the cursor must be fetched")
+	assert.Equal(t, 11, ids[0])
+	assert.Equal(t, 250, ids[len(ids)-1])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := 0
+	err = s.Stream(ctx, models.ArticleFilter{}, nil, "", func(a *models.Article) error {
+		if n++; n == 1 {
+			cancel()
+		}
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, streamBatch, n, "no batch must be fetched once the context is done")
+}
+
+func TestGetAuthorsAndTags(t *testing.T) {
+	seed := "WITH author AS (INSERT INTO authors(name) VALUES ('Test Author') RETURNING id) INSERT INTO articles(title, content, author_id, tags) VALUES('Test Title 1', 'Test Content 1', (SELECT author.id FROM author), '{go,web}');WITH author AS (INSERT INTO authors(name) VALUES ('Test Author') RETURNING id) INSERT INTO articles(title, content, author_id, tags) VALUES('Test Title 2', 'Test Content 2', (SELECT author.id FROM author), '{go}')"
+
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	defer func() {
+		tx.Rollback()
+		restartSerial(t, db)
+	}()
+
+	if _, err := tx.Exec(seed); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	s := &ArticleStore{db: tx}
+	authors, err := s.GetAuthors([]string{"Test Author", "Unknown Author"})
+	if err != nil {
+		t.Fatalf("get authors failed: %v", err)
+	}
+	assert.Equal(t, []models.Author{{Name: "Test Author", Articles: 2}}, authors)
+
+	tags, err := s.GetTags([]string{"go", "web", "rust"})
+	if err != nil {
+		t.Fatalf("get tags failed: %v", err)
+	}
+	assert.Equal(t, []models.Tag{{Name: "go", Articles: 2}, {Name: "web", Articles: 1}}, tags)
+}
+
+func TestByAuthors(t *testing.T) {
+	seed := "WITH author AS (INSERT INTO authors(name) VALUES ('Test Author') RETURNING id) INSERT INTO articles(title, content, author_id, public_id) VALUES('Test Title 1', 'Test Content 1', (SELECT author.id FROM author), '01ARZ3NDEKTSV4RRFFQ69G5F02'), ('Test Title 2', 'Test Content 2', (SELECT author.id FROM author), '01ARZ3NDEKTSV4RRFFQ69G5F01');WITH author AS (INSERT INTO authors(name) VALUES ('Another Test Author') RETURNING id) INSERT INTO articles(title, content, author_id, public_id) VALUES('Test Title 3', 'Test Content 3', (SELECT author.id FROM author), '01ARZ3NDEKTSV4RRFFQ69G5F03')"
+
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	defer func() {
+		tx.Rollback()
+		restartSerial(t, db)
+	}()
+
+	if _, err := tx.Exec(seed); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	s := &ArticleStore{db: tx}
+	articles, err := s.ByAuthors([]string{"Test Author", "Another Test Author", "Unknown Author"}, "", 1)
+	if err != nil {
+		t.Fatalf("by authors failed: %v", err)
+	}
+
+	var actual []int
+	for _, a := range *articles {
+		actual = append(actual, a.ID)
+	}
+	assert.Equal(t, []int{3, 2}, actual, "the first article of an author is the one with the lowest public ID")
+
+	many, err := s.GetMany([]models.PublicID{(*articles)[0].PublicID, (*articles)[1].PublicID, "01ARZ3NDEKTSV4RRFFQ69G5FAV"})
+	if err != nil {
+		t.Fatalf("get many failed: %v", err)
+	}
+	assert.Len(t, *many, 2)
+
+	authors, err := s.Authors([]string{"Test Author", "Unknown Author"})
+	if err != nil {
+		t.Fatalf("authors failed: %v", err)
+	}
+	assert.Equal(t, []string{"Test Author"}, authors)
+}
+
+func TestSlugs(t *testing.T) {
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	defer func() {
+		tx.Rollback()
+		restartSerial(t, db)
+	}()
+
+	s := &ArticleStore{db: tx}
+	first := &models.Article{Title: "Test Title", Content: "Test Content", Author: "Test Author"}
+	second := &models.Article{Title: "Test Title!", Content: "Test Content", Author: "Test Author"}
+	for _, a := range []*models.Article{first, second} {
+		if _, err := s.Post(a); err != nil {
+			t.Fatalf("post failed: %v", err)
+		}
+	}
+	assert.Equal(t, "test-title", first.Slug)
+	assert.Equal(t, "test-title-2", second.Slug)
+
+	_, err = s.Post(&models.Article{Title: "Custom", Content: "Test Content", Author: "Test Author", Slug: "test-title"})
+	assert.Equal(t, ErrSlugTaken, err)
+
+	// changing the title moves the old slug into the history, other changes keep the slug
+	if err := s.Update(1, &models.Article{Title: "New Title", Content: "Test Content", Author: "Test Author"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if err := s.Update(1, &models.Article{Title: "New Title", Content: "New Content", Author: "Test Author"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+
+	for _, slug := range []string{"new-title", "test-title"} {
+		a, err := s.GetBySlug(slug)
+		if err != nil {
+			t.Fatalf("get by slug failed: %v", err)
+		}
+		assert.Equal(t, 1, a.ID)
+		assert.Equal(t, "new-title", a.Slug)
+	}
+
+	// replaced slugs stay with their article
+	third := &models.Article{Title: "Test Title", Content: "Test Content", Author: "Test Author"}
+	if _, err := s.Post(third); err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	assert.Equal(t, "test-title-3", third.Slug)
+
+	// an article takes back its own replaced slug
+	if err := s.Update(1, &models.Article{Title: "New Title", Content: "New Content", Author: "Test Author", Slug: "test-title"}); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	a, err := s.GetBySlug("new-title")
+	if err != nil {
+		t.Fatalf("get by slug failed: %v", err)
+	}
+	assert.Equal(t, "test-title", a.Slug)
+
+	_, err = s.GetBySlug("unknown")
+	assert.Equal(t, ErrArticleNotFound, err)
+
+	results, err := s.Batch([]models.BatchOperation{
+		{Op: models.BatchCreate, Article: &models.Article{Title: "Batch Title", Content: "Test Content", Author: "Test Author"}},
+		{Op: models.BatchCreate, Article: &models.Article{Title: "Batch Title", Content: "Test Content", Author: "Test Author"}},
+	}, true)
+	if err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	for i, slug := range []string{"batch-title", "batch-title-2"} {
+		a, err := s.GetBySlug(slug)
+		if err != nil {
+			t.Fatalf("get by slug failed: %v", err)
+		}
+		assert.Equal(t, results[i].ID, a.ID)
+	}
+}
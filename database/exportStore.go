@@ -0,0 +1,117 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+
+	"github.com/ykaseng/articles-library/jobs"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// The list of error types returned from export store.
+var (
+	ErrExportNotFound = errors.New("export not found")
+)
+
+// ExportStore implements database operations for bulk exports.
+type ExportStore struct {
+	db orm.DB
+}
+
+// NewExportStore returns an ExportStore.
+func NewExportStore(db orm.DB) *ExportStore {
+	return &ExportStore{
+		db: db,
+	}
+}
+
+const exportColumns = `id, format, author, tag, status, job_id, exported, total, size, error, created_at, finished_at, expires_at`
+
+// Create inserts a pending export and enqueues the job writing it in the same transaction. args
+// returns the arguments of the job for the ID of the inserted export.
+func (s *ExportStore) Create(e *models.Export, args func(id int64) jobs.Args, opts jobs.Options) error {
+	return runInTransaction(s.db, func(db orm.DB) error {
+		q := `
+		INSERT INTO exports(format, author, tag, status) VALUES (?, ?, ?, ?) RETURNING ` + exportColumns
+
+		if _, err := db.QueryOne(e, q, e.Format, e.Author, e.Tag, models.ExportPending); err != nil {
+			return err
+		}
+
+		job, err := jobs.NewJob(args(e.ID), opts)
+		if err != nil {
+			return err
+		}
+		if _, err := NewJobStore(db).Enqueue(job); err != nil {
+			return err
+		}
+
+		e.JobID = job.ID
+		_, err = db.Exec(`UPDATE exports SET job_id = ? WHERE id = ?`, job.ID, e.ID)
+		return err
+	})
+}
+
+// Get an export by ID.
+func (s *ExportStore) Get(id int64) (*models.Export, error) {
+	q := `
+	SELECT ` + exportColumns + ` FROM exports WHERE id = ?
+	`
+
+	var e models.Export
+	if _, err := s.db.QueryOne(&e, q, id); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, ErrExportNotFound
+		}
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+// UpdateState stores the status, progress and result of an export.
+func (s *ExportStore) UpdateState(e *models.Export) error {
+	q := `
+	UPDATE exports SET status = ?, exported = ?, total = ?, size = ?, error = ?, finished_at = ?, expires_at = ? WHERE id = ?
+	`
+
+	res, err := s.db.Exec(q, e.Status, e.Exported, e.Total, e.Size, e.Error, e.FinishedAt, e.ExpiresAt, e.ID)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrExportNotFound
+	}
+
+	return nil
+}
+
+// Expire marks completed exports whose expiry has passed as expired and returns them, so their
+// files can be removed.
+func (s *ExportStore) Expire() (*[]models.Export, error) {
+	q := `
+	UPDATE exports SET status = ? WHERE status = ? AND expires_at <= now() RETURNING ` + exportColumns
+
+	var exports []models.Export
+	if _, err := s.db.Query(&exports, q, models.ExportExpired, models.ExportCompleted); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return &exports, nil
+}
+
+// runInTransaction calls fn within a transaction which is committed when fn returns nil and rolled
+// back otherwise. A db already bound to a transaction is passed to fn as is.
+func runInTransaction(db orm.DB, fn func(orm.DB) error) error {
+	if pdb, ok := db.(*pg.DB); ok {
+		return pdb.RunInTransaction(func(tx *pg.Tx) error {
+			return fn(tx)
+		})
+	}
+	return fn(db)
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/jobs"
+	"github.com/ykaseng/articles-library/models"
+)
+
+type testExportArgs struct {
+	ExportID int64 `json:"export_id"`
+}
+
+func (testExportArgs) Kind() string { return "test.export" }
+
+func TestExportStore(t *testing.T) {
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Errorf("failed to begin transaction: %v", err)
+	}
+
+	defer func() {
+		tx.Rollback()
+		restartSerial(t, db)
+	}()
+
+	store := NewExportStore(tx)
+	export := &models.Export{Format: "csv", Author: "Test Author"}
+	args := func(id int64) jobs.Args { return testExportArgs{ExportID: id} }
+	if err := store.Create(export, args, jobs.Options{MaxAttempts: 1}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	assert.Equal(t, models.ExportPending, export.Status)
+
+	// the job is enqueued with the export
+	job, err := NewJobStore(tx).Get(export.JobID)
+	if err != nil {
+		t.Fatalf("get job failed: %v", err)
+	}
+	assert.Equal(t, "test.export", job.Kind)
+	assert.JSONEq(t, `{"export_id":1}`, string(job.Args))
+	assert.Equal(t, 1, job.MaxAttempts)
+
+	expired := time.Now().Add(-time.Minute)
+	export.Status, export.Exported, export.Total, export.Size = models.ExportCompleted, 2, 2, 128
+	export.FinishedAt, export.ExpiresAt = &expired, &expired
+	if err := store.UpdateState(export); err != nil {
+		t.Fatalf("update state failed: %v", err)
+	}
+
+	e, err := store.Get(export.ID)
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	assert.Equal(t, models.ExportCompleted, e.Status)
+	assert.Equal(t, int64(128), e.Size)
+	assert.Equal(t, export.JobID, e.JobID)
+
+	exports, err := store.Expire()
+	if err != nil {
+		t.Fatalf("expire failed: %v", err)
+	}
+	assert.Len(t, *exports, 1)
+	assert.Equal(t, models.ExportExpired, (*exports)[0].Status)
+
+	_, err = store.Get(999)
+	assert.Equal(t, ErrExportNotFound, err)
+}
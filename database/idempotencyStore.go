@@ -0,0 +1,94 @@
+package database
+
+import (
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// IdempotencyStore implements database operations for idempotency keys.
+type IdempotencyStore struct {
+	db orm.DB
+}
+
+// NewIdempotencyStore returns an IdempotencyStore.
+func NewIdempotencyStore(db orm.DB) *IdempotencyStore {
+	return &IdempotencyStore{
+		db: db,
+	}
+}
+
+// Get an unexpired idempotency key, returns nil if the key does not exist.
+func (s *IdempotencyStore) Get(key string) (*models.IdempotencyKey, error) {
+	q := `
+	SELECT key, fingerprint, status_code, content_type, body, expires_at FROM idempotency_keys WHERE key = ? AND expires_at > now()
+	`
+
+	var k models.IdempotencyKey
+	if _, err := s.db.QueryOne(&k, q, key); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &k, nil
+}
+
+// Claim marks an idempotency key as in progress for the request with fingerprint until
+// expiresAt. Returns nil if the key was claimed, or the unexpired entry of the key, with a zero
+// status code while the request holding it is in progress.
+func (s *IdempotencyStore) Claim(key, fingerprint string, expiresAt time.Time) (*models.IdempotencyKey, error) {
+	q := `
+	INSERT INTO idempotency_keys(key, fingerprint, expires_at) VALUES (?, ?, ?)
+	ON CONFLICT (key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, content_type = NULL, body = NULL, expires_at = EXCLUDED.expires_at
+	WHERE idempotency_keys.expires_at <= now()
+	`
+
+	for {
+		res, err := s.db.Exec(q, key, fingerprint, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		if res.RowsAffected() > 0 {
+			return nil, nil
+		}
+
+		// the entry may have expired since, in which case it is claimed again
+		k, err := s.Get(key)
+		if k != nil || err != nil {
+			return k, err
+		}
+	}
+}
+
+// Save stores the response of an idempotency key, replacing its claim or any expired entry.
+func (s *IdempotencyStore) Save(k *models.IdempotencyKey) error {
+	q := `
+	INSERT INTO idempotency_keys(key, fingerprint, status_code, content_type, body, expires_at) VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT (key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, status_code = EXCLUDED.status_code, content_type = EXCLUDED.content_type, body = EXCLUDED.body, expires_at = EXCLUDED.expires_at
+	WHERE idempotency_keys.expires_at <= now() OR (idempotency_keys.status_code IS NULL AND idempotency_keys.fingerprint = EXCLUDED.fingerprint)
+	`
+
+	_, err := s.db.Exec(q, k.Key, k.Fingerprint, k.StatusCode, k.ContentType, k.Body, k.ExpiresAt)
+	return err
+}
+
+// Release removes the claim on an idempotency key whose response is not stored.
+func (s *IdempotencyStore) Release(key string) error {
+	_, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE key = ? AND status_code IS NULL`, key)
+	return err
+}
+
+// DeleteExpired removes expired idempotency keys and returns the number of deleted keys.
+func (s *IdempotencyStore) DeleteExpired() (int, error) {
+	res, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
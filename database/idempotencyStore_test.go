@@ -0,0 +1,126 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	tt := []struct {
+		name     string
+		saves    []models.IdempotencyKey
+		key      string
+		expected *models.IdempotencyKey
+	}{
+		{
+			name:     "key does not exist",
+			key:      "abc",
+			expected: nil,
+		},
+		{
+			name: "key exists",
+			saves: []models.IdempotencyKey{
+				{Key: "abc", Fingerprint: "fp", StatusCode: 201, ContentType: "application/json", Body: []byte(`{"status":201}`), ExpiresAt: time.Now().Add(time.Hour)},
+			},
+			key:      "abc",
+			expected: &models.IdempotencyKey{Key: "abc", Fingerprint: "fp", StatusCode: 201, ContentType: "application/json", Body: []byte(`{"status":201}`)},
+		},
+		{
+			name: "key expired",
+			saves: []models.IdempotencyKey{
+				{Key: "abc", Fingerprint: "fp", StatusCode: 201, ContentType: "application/json", Body: []byte(`{"status":201}`), ExpiresAt: time.Now().Add(-time.Hour)},
+			},
+			key:      "abc",
+			expected: nil,
+		},
+		{
+			name: "unexpired key is not overwritten",
+			saves: []models.IdempotencyKey{
+				{Key: "abc", Fingerprint: "fp", StatusCode: 201, ContentType: "application/json", Body: []byte(`{"status":201}`), ExpiresAt: time.Now().Add(time.Hour)},
+				{Key: "abc", Fingerprint: "other", StatusCode: 400, ContentType: "application/json", Body: []byte(`{"status":400}`), ExpiresAt: time.Now().Add(time.Hour)},
+			},
+			key:      "abc",
+			expected: &models.IdempotencyKey{Key: "abc", Fingerprint: "fp", StatusCode: 201, ContentType: "application/json", Body: []byte(`{"status":201}`)},
+		},
+	}
+
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Errorf("failed to begin transaction: %v", err)
+			}
+			defer tx.Rollback()
+
+			store := NewIdempotencyStore(tx)
+			for _, k := range tc.saves {
+				if err := store.Save(&k); err != nil {
+					t.Errorf("save failed: %v", err)
+				}
+			}
+
+			actual, err := store.Get(tc.key)
+			if err != nil {
+				t.Errorf("get failed: %v", err)
+			}
+
+			if actual != nil {
+				actual.ExpiresAt = time.Time{}
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestIdempotencyStoreClaim(t *testing.T) {
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	store := NewIdempotencyStore(tx)
+
+	claimed, err := store.Claim("abc", "fp", time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Nil(t, claimed, "unused keys must be claimed")
+
+	claimed, err = store.Claim("abc", "fp", time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	if assert.NotNil(t, claimed, "claimed keys must not be claimed again") {
+		assert.Equal(t, 0, claimed.StatusCode)
+	}
+
+	assert.NoError(t, store.Save(&models.IdempotencyKey{Key: "abc", Fingerprint: "fp", StatusCode: 201, ContentType: "application/json", Body: []byte(`{"status":201}`), ExpiresAt: time.Now().Add(time.Hour)}))
+	claimed, err = store.Claim("abc", "fp", time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	if assert.NotNil(t, claimed) {
+		assert.Equal(t, 201, claimed.StatusCode, "saved responses must replace the claim")
+	}
+
+	assert.NoError(t, store.Release("abc"))
+	stored, err := store.Get("abc")
+	assert.NoError(t, err)
+	assert.NotNil(t, stored, "stored responses must not be released")
+
+	_, err = store.Claim("def", "fp", time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.NoError(t, store.Release("def"))
+	claimed, err = store.Claim("def", "fp", time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Nil(t, claimed, "released keys must be claimed again")
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// The list of error types returned from job store.
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobStatus   = errors.New("job cannot be changed in its current status")
+)
+
+// JobStore implements database operations for the background job queue.
+type JobStore struct {
+	db orm.DB
+}
+
+// NewJobStore returns a JobStore.
+func NewJobStore(db orm.DB) *JobStore {
+	return &JobStore{
+		db: db,
+	}
+}
+
+const jobColumns = `id, kind, args, status, attempts, max_attempts, run_at, locked_until, last_error, unique_key, created_at, finished_at`
+
+// Enqueue inserts a pending job, sets its ID and reports whether it was inserted. A job with a
+// unique key is not inserted while another job with the same key is pending or running.
+func (s *JobStore) Enqueue(job *models.Job) (bool, error) {
+	q := `
+	INSERT INTO jobs(kind, args, status, max_attempts, run_at, unique_key) VALUES (?, ?::jsonb, ?, ?, ?, NULLIF(?, ''))
+	ON CONFLICT (unique_key) WHERE status IN ('pending', 'running') DO NOTHING
+	RETURNING ` + jobColumns
+
+	args := string(job.Args)
+	if args == "" {
+		args = "{}"
+	}
+
+	if _, err := s.db.QueryOne(job, q, job.Kind, args, models.JobPending, job.MaxAttempts, job.RunAt, job.UniqueKey); err != nil {
+		if err == pg.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Get a job by ID.
+func (s *JobStore) Get(id int64) (*models.Job, error) {
+	q := `
+	SELECT ` + jobColumns + ` FROM jobs WHERE id = ?
+	`
+
+	var job models.Job
+	if _, err := s.db.QueryOne(&job, q, id); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// List returns up to limit jobs, newest first, optionally only those with the given status and kind.
+func (s *JobStore) List(status, kind string, limit int) (*[]models.Job, error) {
+	q := `
+	SELECT ` + jobColumns + ` FROM jobs WHERE (? = '' OR status = ?) AND (? = '' OR kind = ?) ORDER BY id DESC LIMIT ?
+	`
+
+	var jobs []models.Job
+	if _, err := s.db.Query(&jobs, q, status, status, kind, kind, limit); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return &jobs, nil
+}
+
+// Claim marks the next due job of one of the given kinds as running for lease and returns it, or
+// nil when no job is due. Running jobs whose lease has expired, because their worker stopped, are
+// claimed again.
+func (s *JobStore) Claim(kinds []string, lease time.Duration) (*models.Job, error) {
+	q := `
+	UPDATE jobs SET status = ?, attempts = attempts + 1, locked_until = now() + ? * interval '1 millisecond' WHERE id = (
+		SELECT id FROM jobs WHERE kind = ANY(?) AND ((status = ? AND run_at <= now()) OR (status = ? AND locked_until < now()))
+		ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED
+	) RETURNING ` + jobColumns
+
+	var job models.Job
+	if _, err := s.db.QueryOne(&job, q, models.JobRunning, lease.Milliseconds(), pg.Array(kinds), models.JobPending, models.JobRunning); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Extend extends the lease of a running job and reports whether the job is still held by the
+// claim it was returned from, which is false once it has been claimed again.
+func (s *JobStore) Extend(job *models.Job, lease time.Duration) (bool, error) {
+	q := `
+	UPDATE jobs SET locked_until = now() + ? * interval '1 millisecond' WHERE id = ? AND status = ? AND attempts = ?
+	`
+
+	res, err := s.db.Exec(q, lease.Milliseconds(), job.ID, models.JobRunning, job.Attempts)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+// UpdateState stores the status, schedule and last error of a job after an attempt.
+func (s *JobStore) UpdateState(job *models.Job) error {
+	q := `
+	UPDATE jobs SET status = ?, run_at = ?, locked_until = ?, last_error = ?, finished_at = ? WHERE id = ?
+	`
+
+	res, err := s.db.Exec(q, job.Status, job.RunAt, job.LockedUntil, job.LastError, job.FinishedAt, job.ID)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrJobNotFound
+	}
+
+	return nil
+}
+
+// Retry makes a job which is not running due immediately with its attempts reset, which revives
+// dead and cancelled jobs and runs scheduled jobs early.
+func (s *JobStore) Retry(id int64) (*models.Job, error) {
+	q := `
+	UPDATE jobs SET status = ?, attempts = 0, run_at = now(), locked_until = NULL, finished_at = NULL WHERE id = ? AND status <> ? RETURNING ` + jobColumns
+
+	var job models.Job
+	if _, err := s.db.QueryOne(&job, q, models.JobPending, id, models.JobRunning); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, s.missing(id)
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Cancel stops a pending job from running.
+func (s *JobStore) Cancel(id int64) (*models.Job, error) {
+	q := `
+	UPDATE jobs SET status = ?, finished_at = now() WHERE id = ? AND status = ? RETURNING ` + jobColumns
+
+	var job models.Job
+	if _, err := s.db.QueryOne(&job, q, models.JobCancelled, id, models.JobPending); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, s.missing(id)
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// missing returns ErrJobNotFound when a job does not exist, and ErrJobStatus when it exists but is
+// not in a status the operation applies to.
+func (s *JobStore) missing(id int64) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	return ErrJobStatus
+}
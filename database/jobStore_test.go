@@ -0,0 +1,113 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+func TestJobStore(t *testing.T) {
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Errorf("failed to begin transaction: %v", err)
+	}
+
+	defer func() {
+		tx.Rollback()
+		restartSerial(t, db)
+	}()
+
+	store := NewJobStore(tx)
+	due := &models.Job{Kind: "test", Args: []byte(`{"name":"Test"}`), MaxAttempts: 3, RunAt: time.Now().Add(-time.Second), UniqueKey: "test:1"}
+	later := &models.Job{Kind: "test", MaxAttempts: 3, RunAt: time.Now().Add(time.Hour)}
+	for _, j := range []*models.Job{due, later} {
+		ok, err := store.Enqueue(j)
+		if err != nil {
+			t.Fatalf("enqueue failed: %v", err)
+		}
+		assert.True(t, ok)
+	}
+	assert.Equal(t, models.JobPending, due.Status)
+	assert.JSONEq(t, `{}`, string(later.Args))
+
+	// the unique key is taken while the first job is pending
+	ok, err := store.Enqueue(&models.Job{Kind: "test", MaxAttempts: 3, RunAt: time.Now(), UniqueKey: "test:1"})
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	assert.False(t, ok)
+
+	job, err := store.Claim([]string{"test"}, time.Minute)
+	if err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	assert.Equal(t, due.ID, job.ID)
+	assert.Equal(t, models.JobRunning, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.NotNil(t, job.LockedUntil)
+
+	ok, err = store.Extend(job, time.Hour)
+	if err != nil {
+		t.Fatalf("extend failed: %v", err)
+	}
+	assert.True(t, ok)
+
+	// an earlier claim of the job no longer holds it
+	ok, err = store.Extend(&models.Job{ID: job.ID, Attempts: job.Attempts - 1}, time.Hour)
+	if err != nil {
+		t.Fatalf("extend failed: %v", err)
+	}
+	assert.False(t, ok)
+
+	// nothing else is due, and other kinds are not claimed
+	none, err := store.Claim([]string{"test"}, time.Minute)
+	if err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	assert.Nil(t, none)
+
+	_, err = store.Retry(job.ID)
+	assert.Equal(t, ErrJobStatus, err)
+
+	now := time.Now()
+	job.Status, job.LockedUntil, job.FinishedAt = models.JobDead, nil, &now
+	job.LastError = "connection refused"
+	if err := store.UpdateState(job); err != nil {
+		t.Fatalf("update state failed: %v", err)
+	}
+
+	jobs, err := store.List(models.JobDead, "", 10)
+	if err != nil {
+		t.Errorf("list failed: %v", err)
+	}
+	assert.Len(t, *jobs, 1)
+	assert.Equal(t, "connection refused", (*jobs)[0].LastError)
+
+	job, err = store.Retry(job.ID)
+	if err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	assert.Equal(t, models.JobPending, job.Status)
+	assert.Equal(t, 0, job.Attempts)
+	assert.Nil(t, job.FinishedAt)
+
+	job, err = store.Cancel(later.ID)
+	if err != nil {
+		t.Fatalf("cancel failed: %v", err)
+	}
+	assert.Equal(t, models.JobCancelled, job.Status)
+
+	_, err = store.Cancel(later.ID)
+	assert.Equal(t, ErrJobStatus, err)
+
+	_, err = store.Get(999)
+	assert.Equal(t, ErrJobNotFound, err)
+}
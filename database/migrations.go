@@ -0,0 +1,198 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/go-pg/pg"
+)
+
+// migration upgrades the schema of databases created by an earlier start.sh, which only runs when
+// the database is created. start.sh creates the current schema and records every migration as
+// applied. Migrations are idempotent, so that they also run on databases created by a start.sh of
+// any version in between.
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// migrations lists the migrations in the order they are applied. They bring a database created by
+// the first start.sh, with only the authors and articles tables, to the current schema.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "public_ids",
+		sql: `
+		-- public IDs of existing articles are backfilled from their creation time
+		ALTER TABLE articles ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(), ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+		CREATE EXTENSION IF NOT EXISTS pgcrypto;
+		CREATE OR REPLACE FUNCTION gen_ulid(ts TIMESTAMPTZ DEFAULT clock_timestamp()) RETURNS TEXT AS $$
+		DECLARE
+			alphabet TEXT := '0123456789ABCDEFGHJKMNPQRSTVWXYZ';
+			ms BIGINT := floor(extract(epoch FROM ts) * 1000);
+			entropy BYTEA := gen_random_bytes(10);
+			id TEXT := '';
+			c INT;
+		BEGIN
+			FOR i IN REVERSE 9..0 LOOP
+				id := id || substr(alphabet, ((ms >> (5 * i)) & 31)::INT + 1, 1);
+			END LOOP;
+			FOR i IN 0..15 LOOP
+				c := 0;
+				FOR j IN 0..4 LOOP
+					c := c * 2 + get_bit(entropy, 5 * i + j);
+				END LOOP;
+				id := id || substr(alphabet, c + 1, 1);
+			END LOOP;
+			RETURN id;
+		END;
+		$$ LANGUAGE plpgsql VOLATILE;
+		ALTER TABLE articles ADD COLUMN IF NOT EXISTS public_id CHAR(26);
+		UPDATE articles SET public_id = gen_ulid(created_at) WHERE public_id IS NULL;
+		ALTER TABLE articles ALTER COLUMN public_id SET DEFAULT gen_ulid(), ALTER COLUMN public_id SET NOT NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS articles_public_id ON articles (public_id);
+		`,
+	},
+	{
+		version: 2,
+		name:    "article_fields",
+		sql: `
+		ALTER TABLE articles ADD COLUMN IF NOT EXISTS tags TEXT[], ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ, ADD COLUMN IF NOT EXISTS content_format VARCHAR(16);
+		`,
+	},
+	{
+		version: 3,
+		name:    "idempotency_keys",
+		sql: `
+		CREATE TABLE IF NOT EXISTS idempotency_keys (key VARCHAR(255), fingerprint CHAR(64), status_code INT, content_type TEXT, body BYTEA, expires_at TIMESTAMPTZ, PRIMARY KEY(key));
+		`,
+	},
+	{
+		version: 4,
+		name:    "sources",
+		sql: `
+		CREATE TABLE IF NOT EXISTS sources (id SERIAL, url TEXT NOT NULL UNIQUE, title TEXT NOT NULL DEFAULT '', etag TEXT NOT NULL DEFAULT '', last_modified TEXT NOT NULL DEFAULT '', failures INT NOT NULL DEFAULT 0, last_error TEXT NOT NULL DEFAULT '', last_polled_at TIMESTAMPTZ, next_poll_at TIMESTAMPTZ NOT NULL DEFAULT now(), PRIMARY KEY(id));
+		CREATE TABLE IF NOT EXISTS source_entries (source_id INT REFERENCES sources(id) ON DELETE CASCADE, guid TEXT, article_id INT REFERENCES articles(id) ON DELETE SET NULL, PRIMARY KEY(source_id, guid));
+		`,
+	},
+	{
+		version: 5,
+		name:    "article_changes",
+		sql: `
+		CREATE SEQUENCE IF NOT EXISTS article_events_id_seq;
+		CREATE OR REPLACE FUNCTION notify_article_change() RETURNS trigger AS $$
+		DECLARE
+			article_id INT;
+			public_id TEXT;
+		BEGIN
+			IF TG_OP = 'DELETE' THEN article_id := OLD.id; public_id := OLD.public_id; ELSE article_id := NEW.id; public_id := NEW.public_id; END IF;
+			PERFORM pg_notify('article_changes', json_build_object('id', nextval('article_events_id_seq'), 'op', TG_OP, 'article_id', article_id, 'public_id', public_id)::text);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql;
+		DROP TRIGGER IF EXISTS article_changes ON articles;
+		CREATE TRIGGER article_changes AFTER INSERT OR UPDATE OR DELETE ON articles FOR EACH ROW EXECUTE PROCEDURE notify_article_change();
+		`,
+	},
+	{
+		version: 6,
+		name:    "webhooks",
+		sql: `
+		CREATE TABLE IF NOT EXISTS webhooks (id SERIAL, url TEXT NOT NULL, secret TEXT NOT NULL, events TEXT[] NOT NULL, created_at TIMESTAMPTZ NOT NULL DEFAULT now(), PRIMARY KEY(id));
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (id BIGSERIAL, webhook_id INT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE, event VARCHAR(16) NOT NULL, article_id INT NOT NULL, payload JSONB NOT NULL, status VARCHAR(16) NOT NULL DEFAULT 'pending', attempts INT NOT NULL DEFAULT 0, next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(), last_error TEXT NOT NULL DEFAULT '', response_status INT NOT NULL DEFAULT 0, created_at TIMESTAMPTZ NOT NULL DEFAULT now(), delivered_at TIMESTAMPTZ, PRIMARY KEY(id));
+		CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+		CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id);
+		`,
+	},
+	{
+		version: 7,
+		name:    "jobs",
+		sql: `
+		CREATE TABLE IF NOT EXISTS jobs (id BIGSERIAL, kind VARCHAR(64) NOT NULL, args JSONB NOT NULL DEFAULT '{}', status VARCHAR(16) NOT NULL DEFAULT 'pending', attempts INT NOT NULL DEFAULT 0, max_attempts INT NOT NULL DEFAULT 5, run_at TIMESTAMPTZ NOT NULL DEFAULT now(), locked_until TIMESTAMPTZ, last_error TEXT NOT NULL DEFAULT '', unique_key TEXT, created_at TIMESTAMPTZ NOT NULL DEFAULT now(), finished_at TIMESTAMPTZ, PRIMARY KEY(id));
+		CREATE INDEX IF NOT EXISTS jobs_due ON jobs (run_at, id) WHERE status IN ('pending', 'running');
+		CREATE UNIQUE INDEX IF NOT EXISTS jobs_unique_key ON jobs (unique_key) WHERE status IN ('pending', 'running');
+		`,
+	},
+	{
+		version: 8,
+		name:    "exports",
+		sql: `
+		CREATE TABLE IF NOT EXISTS exports (id BIGSERIAL, format VARCHAR(16) NOT NULL, author TEXT NOT NULL DEFAULT '', tag TEXT NOT NULL DEFAULT '', status VARCHAR(16) NOT NULL DEFAULT 'pending', job_id BIGINT REFERENCES jobs(id) ON DELETE SET NULL, exported INT NOT NULL DEFAULT 0, total INT NOT NULL DEFAULT 0, size BIGINT NOT NULL DEFAULT 0, error TEXT NOT NULL DEFAULT '', created_at TIMESTAMPTZ NOT NULL DEFAULT now(), finished_at TIMESTAMPTZ, expires_at TIMESTAMPTZ, PRIMARY KEY(id));
+		CREATE INDEX IF NOT EXISTS exports_expires_at ON exports (expires_at) WHERE status = 'completed';
+		`,
+	},
+	{
+		version: 9,
+		name:    "slugs",
+		sql: `
+		ALTER TABLE articles ADD COLUMN IF NOT EXISTS slug TEXT;
+		UPDATE articles a SET slug = CASE WHEN b.n = 1 THEN b.base ELSE b.base || '-' || a.id END FROM (
+			SELECT id, base, row_number() OVER (PARTITION BY base ORDER BY id) AS n FROM (
+				SELECT id, COALESCE(NULLIF(trim(both '-' from left(regexp_replace(lower(title), '[^[:alnum:]]+', '-', 'g'), 80)), ''), 'article') AS base FROM articles WHERE slug IS NULL
+			) s
+		) b WHERE a.id = b.id;
+		CREATE OR REPLACE FUNCTION default_article_slug() RETURNS trigger AS $$
+		BEGIN
+			IF NEW.slug IS NULL THEN
+				NEW.slug := COALESCE(NULLIF(trim(both '-' from left(regexp_replace(lower(NEW.title), '[^[:alnum:]]+', '-', 'g'), 80)), ''), 'article');
+				IF EXISTS (SELECT 1 FROM articles WHERE slug = NEW.slug) OR EXISTS (SELECT 1 FROM article_slugs WHERE slug = NEW.slug) THEN
+					NEW.slug := NEW.slug || '-' || NEW.id;
+				END IF;
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql;
+		ALTER TABLE articles ALTER COLUMN slug SET NOT NULL;
+		CREATE UNIQUE INDEX IF NOT EXISTS articles_slug ON articles (slug);
+		CREATE TABLE IF NOT EXISTS article_slugs (slug TEXT, article_id INT NOT NULL REFERENCES articles(id) ON DELETE CASCADE, created_at TIMESTAMPTZ NOT NULL DEFAULT now(), PRIMARY KEY(slug));
+		DROP TRIGGER IF EXISTS article_slug ON articles;
+		CREATE TRIGGER article_slug BEFORE INSERT ON articles FOR EACH ROW EXECUTE PROCEDURE default_article_slug();
+		`,
+	},
+}
+
+// Migrate applies the migrations which were not applied to db yet and returns their number. All
+// pending migrations run in a single transaction without statement timeout, holding an advisory
+// lock so that servers starting at the same time migrate one after another.
+func Migrate(db *pg.DB) (int, error) {
+	var applied int
+	err := db.RunInTransaction(func(tx *pg.Tx) (err error) {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext('schema_migrations'))`); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`SET LOCAL statement_timeout = 0`); err != nil {
+			return err
+		}
+		applied, err = migrate(tx)
+		return err
+	})
+
+	return applied, err
+}
+
+// migrate applies the pending migrations in tx and returns their number.
+func migrate(tx *pg.Tx) (int, error) {
+	if _, err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INT, name TEXT NOT NULL, applied_at TIMESTAMPTZ NOT NULL DEFAULT now(), PRIMARY KEY(version))`); err != nil {
+		return 0, err
+	}
+
+	var current int
+	if _, err := tx.QueryOne(pg.Scan(&current), `SELECT COALESCE(max(version), 0) FROM schema_migrations`); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if _, err := tx.Exec(m.sql); err != nil {
+			return applied, fmt.Errorf("migration %d %s: %v", m.version, m.name, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations(version, name) VALUES (?, ?)`, m.version, m.name); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/go-pg/pg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+func TestMigrate(t *testing.T) {
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := Migrate(db); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	applied, err := Migrate(db)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, applied, "migrations must be applied once")
+
+	var ids []string
+	if _, err := db.Query(pg.Scan(pg.Array(&ids)), `SELECT array_agg(gen_ulid('2016-07-30 23:54:10.259+00')) FROM generate_series(1, 100)`); err != nil {
+		t.Fatalf("gen_ulid failed: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for _, id := range ids {
+		assert.Regexp(t, regexp.MustCompile(`^01ARZ3NDEK[0-9A-HJKMNP-TV-Z]{16}$`), id)
+		assert.False(t, seen[id], "ulids of the same millisecond must differ")
+		seen[id] = true
+	}
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// the schema created by the first start.sh, with an article written before any migration
+	seed := `
+	CREATE SCHEMA baseline;
+	SET LOCAL search_path = baseline, public;
+	CREATE TABLE authors (id SERIAL, name VARCHAR(255), PRIMARY KEY(id));
+	CREATE TABLE articles (id SERIAL, title TEXT, content TEXT, author_id INT, PRIMARY KEY(id), FOREIGN KEY(author_id) REFERENCES authors(id));
+	WITH author AS (INSERT INTO authors(name) VALUES ('Test Author') RETURNING id) INSERT INTO articles(title, content, author_id) VALUES('Test Title', 'Test Content', (SELECT author.id FROM author));
+	`
+	if _, err := tx.Exec(seed); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+
+	applied, err := migrate(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations), applied)
+
+	store := &ArticleStore{db: tx}
+	articles, err := store.GetAll(nil)
+	if err != nil {
+		t.Fatalf("getAll failed: %v", err)
+	}
+	assert.Len(t, *articles, 1)
+	assert.Equal(t, "test-title", (*articles)[0].Slug)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`), string((*articles)[0].PublicID))
+
+	id, err := store.Post(&models.Article{Title: "Test Title", Content: "Test Content", Author: "Test Author"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, id.PublicID)
+}
@@ -3,6 +3,7 @@ package database
 
 import (
 	"log"
+	"time"
 
 	"github.com/spf13/viper"
 
@@ -11,16 +12,27 @@ import (
 
 // DBConn returns a postgres connection pool.
 func DBConn() (*pg.DB, error) {
-	opts, err := pg.ParseURL(viper.GetString("database_dsn"))
+	db, err := connect(viper.GetString("database_dsn"))
 	if err != nil {
 		return nil, err
 	}
 
-	db := pg.Connect(opts)
 	if err := checkConn(db); err != nil {
 		return nil, err
 	}
 
+	return db, nil
+}
+
+// connect returns a connection pool of dsn configured by viper, without connecting yet.
+func connect(dsn string) (*pg.DB, error) {
+	opts, err := pg.ParseURL(dsn)
+	if err != nil {
+		return nil, err
+	}
+	poolOptions(opts)
+
+	db := pg.Connect(opts)
 	if viper.GetBool("db_debug") {
 		db.AddQueryHook(&logSQL{})
 	}
@@ -28,6 +40,33 @@ func DBConn() (*pg.DB, error) {
 	return db, nil
 }
 
+// poolOptions sets the pool options configured by viper, options which are not set keep the
+// defaults of go-pg. A statement timeout is set on every new connection.
+func poolOptions(opts *pg.Options) {
+	if n := viper.GetInt("database_pool_size"); n > 0 {
+		opts.PoolSize = n
+	}
+	if n := viper.GetInt("database_min_idle_conns"); n > 0 {
+		opts.MinIdleConns = n
+	}
+	if d := viper.GetDuration("database_idle_timeout"); d != 0 {
+		opts.IdleTimeout = d
+	}
+	if d := viper.GetDuration("database_max_conn_age"); d > 0 {
+		opts.MaxConnAge = d
+	}
+	if d := viper.GetDuration("database_pool_timeout"); d > 0 {
+		opts.PoolTimeout = d
+	}
+
+	if d := viper.GetDuration("database_statement_timeout"); d > 0 {
+		opts.OnConnect = func(conn *pg.Conn) error {
+			_, err := conn.Exec(`SET statement_timeout = ?`, int64(d/time.Millisecond))
+			return err
+		}
+	}
+}
+
 type logSQL struct{}
 
 func (l *logSQL) BeforeQuery(e *pg.QueryEvent) {}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/go-pg/pg"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
@@ -68,6 +70,37 @@ func TestDBConn(t *testing.T) {
 	}
 }
 
+func TestPoolOptions(t *testing.T) {
+	settings := map[string]interface{}{
+		"database_pool_size":         20,
+		"database_min_idle_conns":    2,
+		"database_idle_timeout":      "1m",
+		"database_max_conn_age":      "30m",
+		"database_pool_timeout":      "10s",
+		"database_statement_timeout": "5s",
+	}
+	for key, value := range settings {
+		viper.Set(key, value)
+		defer viper.Set(key, nil)
+	}
+
+	opts := &pg.Options{}
+	poolOptions(opts)
+
+	assert.Equal(t, 20, opts.PoolSize)
+	assert.Equal(t, 2, opts.MinIdleConns)
+	assert.Equal(t, time.Minute, opts.IdleTimeout)
+	assert.Equal(t, 30*time.Minute, opts.MaxConnAge)
+	assert.Equal(t, 10*time.Second, opts.PoolTimeout)
+	assert.NotNil(t, opts.OnConnect, "the statement timeout must be set on new connections")
+}
+
+func TestPoolOptionsDefaults(t *testing.T) {
+	opts := &pg.Options{}
+	poolOptions(opts)
+	assert.Equal(t, &pg.Options{}, opts, "unset options must keep the defaults of go-pg")
+}
+
 func resetEnv() {
 	viper.SetDefault("database_dsn", fmt.Sprintf("%s://%s:%s@%s:%d/%s?sslmode=%s", viper.GetString("DATABASE_URI_SCHEME"), viper.GetString("DATABASE_USER"), viper.GetString("DATABASE_PASSWORD"), viper.GetString("DATABASE_HOST"), viper.GetInt("DATABASE_PORT"), viper.GetString("DATABASE_NAME"), viper.GetString("DATABASE_SSL")))
 }
@@ -0,0 +1,197 @@
+package database
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+var (
+	errReplicaLagging      = errors.New("replica lags behind the primary")
+	errReplicaNotStreaming = errors.New("replica does not receive WAL from the primary")
+)
+
+// replicaLagQuery returns whether a replica streams WAL from the primary, and the seconds it lags
+// behind the primary, 0 when it replayed all it received. The time of the last replayed transaction
+// alone would grow while the primary is idle. A server which is not a standby, or whose WAL receiver
+// stopped, does not stream and would report no lag while it falls behind.
+const replicaLagQuery = `
+SELECT pg_last_wal_receive_lsn() IS NOT NULL AND EXISTS (SELECT 1 FROM pg_stat_wal_receiver),
+COALESCE(CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()) END, 0)
+`
+
+// Replicas balances reads across the read replicas of the primary database. Every replica is
+// checked each Interval, and replicas which fail the check, do not answer within Timeout or lag
+// behind the primary by more than MaxLag are skipped until they pass again.
+type Replicas struct {
+	MaxLag   time.Duration
+	Interval time.Duration
+	Timeout  time.Duration
+	Logger   logrus.FieldLogger
+
+	replicas []*replica
+	next     uint32
+	done     chan struct{}
+}
+
+type replica struct {
+	db *pg.DB
+	// healthy is 1 when the replica passed its last check, 0 when it failed and -1 before the first.
+	healthy int32
+}
+
+// ReplicaMaxLag returns the longest time the configured read replicas may lag behind the primary,
+// or 0 without replicas.
+func ReplicaMaxLag() time.Duration {
+	if len(viper.GetStringSlice("database_replica_dsns")) == 0 {
+		return 0
+	}
+	if d := viper.GetDuration("database_replica_max_lag"); d > 0 {
+		return d
+	}
+	return 5 * time.Second
+}
+
+// DBReplicas returns the read replicas of database_replica_dsns, or nil without replicas. Replicas
+// which cannot be reached are skipped until they pass a health check, rather than failing startup.
+// Changes of their health are logged to logger. An invalid dsn fails, closing the replicas opened
+// before it.
+func DBReplicas(logger logrus.FieldLogger) (*Replicas, error) {
+	dsns := viper.GetStringSlice("database_replica_dsns")
+	if len(dsns) == 0 {
+		return nil, nil
+	}
+
+	var dbs []*pg.DB
+	for _, dsn := range dsns {
+		db, err := connect(dsn)
+		if err != nil {
+			for _, db := range dbs {
+				db.Close()
+			}
+			return nil, err
+		}
+		dbs = append(dbs, db)
+	}
+
+	interval := viper.GetDuration("database_replica_check_interval")
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	timeout := viper.GetDuration("database_replica_check_timeout")
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+
+	return NewReplicas(dbs, ReplicaMaxLag(), interval, timeout, logger), nil
+}
+
+// NewReplicas checks dbs once and returns Replicas checking them every interval until closed. A
+// check which does not complete within timeout fails.
+func NewReplicas(dbs []*pg.DB, maxLag, interval, timeout time.Duration, logger logrus.FieldLogger) *Replicas {
+	r := &Replicas{
+		MaxLag:   maxLag,
+		Interval: interval,
+		Timeout:  timeout,
+		Logger:   logger,
+		done:     make(chan struct{}),
+	}
+	for _, db := range dbs {
+		r.replicas = append(r.replicas, &replica{db: db, healthy: -1})
+	}
+
+	r.check()
+	go r.run()
+	return r
+}
+
+// DB returns the next healthy replica in turn, or nil when no replica is healthy.
+func (r *Replicas) DB() orm.DB {
+	if r == nil {
+		return nil
+	}
+
+	n := uint32(len(r.replicas))
+	start := atomic.AddUint32(&r.next, 1)
+	for i := uint32(0); i < n; i++ {
+		rep := r.replicas[(start+i)%n]
+		if atomic.LoadInt32(&rep.healthy) == 1 {
+			return rep.db
+		}
+	}
+	return nil
+}
+
+// Close stops the health checks and closes the connection pools of the replicas.
+func (r *Replicas) Close() error {
+	if r == nil {
+		return nil
+	}
+	close(r.done)
+
+	var err error
+	for _, rep := range r.replicas {
+		if cerr := rep.db.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (r *Replicas) run() {
+	t := time.NewTicker(r.Interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-t.C:
+			r.check()
+		}
+	}
+}
+
+// check marks the replicas which stream WAL and answer within their lag limit healthy, and the
+// others unhealthy.
+// go-pg does not cancel queries with their context, so the probe of a hanging replica is bounded by
+// the read and write deadlines of Timeout instead.
+func (r *Replicas) check() {
+	for _, rep := range r.replicas {
+		db := rep.db
+		if r.Timeout > 0 {
+			db = db.WithTimeout(r.Timeout)
+		}
+
+		var streaming bool
+		var lag float64
+		_, err := db.QueryOne(pg.Scan(&streaming, &lag), replicaLagQuery)
+		switch {
+		case err != nil:
+		case !streaming:
+			err = errReplicaNotStreaming
+		case r.MaxLag > 0 && time.Duration(lag*float64(time.Second)) > r.MaxLag:
+			err = errReplicaLagging
+		}
+
+		healthy := int32(0)
+		if err == nil {
+			healthy = 1
+		}
+		if atomic.SwapInt32(&rep.healthy, healthy) != healthy && r.Logger != nil {
+			logger := r.Logger.WithField("replica", rep.db.Options().Addr)
+			if err != nil {
+				logger.WithField("lag", lag).Warnf("replica unhealthy, reading from the others: %v", err)
+			} else {
+				logger.Info("replica healthy")
+			}
+		}
+	}
+}
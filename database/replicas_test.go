@@ -0,0 +1,108 @@
+package database
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicasDB(t *testing.T) {
+	a := pg.Connect(&pg.Options{Addr: "replica-a:5432"})
+	b := pg.Connect(&pg.Options{Addr: "replica-b:5432"})
+	c := pg.Connect(&pg.Options{Addr: "replica-c:5432"})
+
+	tt := []struct {
+		name     string
+		healthy  []int32
+		expected []orm.DB
+	}{
+		{name: "round robin", healthy: []int32{1, 1, 1}, expected: []orm.DB{b, c, a, b}},
+		{name: "unhealthy skipped", healthy: []int32{1, 0, 1}, expected: []orm.DB{c, c, a, c}},
+		{name: "unchecked skipped", healthy: []int32{-1, 1, 0}, expected: []orm.DB{b, b, b, b}},
+		{name: "none healthy", healthy: []int32{0, 0, -1}, expected: []orm.DB{nil, nil, nil, nil}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Replicas{}
+			for i, db := range []*pg.DB{a, b, c} {
+				r.replicas = append(r.replicas, &replica{db: db, healthy: tc.healthy[i]})
+			}
+
+			var picked []orm.DB
+			for range tc.expected {
+				picked = append(picked, r.DB())
+			}
+			assert.Equal(t, tc.expected, picked)
+		})
+	}
+
+	var none *Replicas
+	assert.Nil(t, none.DB(), "without replicas reads must fall back to the primary")
+}
+
+func TestReplicasCheck(t *testing.T) {
+	down := pg.Connect(&pg.Options{Addr: "127.0.0.1:1", DialTimeout: time.Second})
+	r := NewReplicas([]*pg.DB{down}, time.Second, time.Hour, time.Second, nil)
+	defer r.Close()
+
+	assert.Equal(t, int32(0), r.replicas[0].healthy, "unreachable replicas must be unhealthy")
+	assert.Nil(t, r.DB())
+}
+
+func TestReplicasCheckPrimary(t *testing.T) {
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	// the primary answers the probe, but does not stream WAL from anywhere
+	r := NewReplicas([]*pg.DB{db}, time.Second, time.Hour, time.Second, nil)
+	defer r.Close()
+
+	assert.Equal(t, int32(0), r.replicas[0].healthy, "servers which do not stream WAL must be unhealthy")
+}
+
+func TestReplicasCheckTimeout(t *testing.T) {
+	// a replica which accepts connections but never answers
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+
+	hanging := pg.Connect(&pg.Options{Addr: l.Addr().String()})
+	start := time.Now()
+	r := NewReplicas([]*pg.DB{hanging}, time.Second, time.Hour, 100*time.Millisecond, nil)
+	defer r.Close()
+
+	assert.Equal(t, int32(0), r.replicas[0].healthy, "hanging replicas must be unhealthy")
+	assert.True(t, time.Since(start) < 2*time.Second, "checks must not wait for hanging replicas")
+}
+
+func TestReplicaMaxLag(t *testing.T) {
+	defer viper.Set("database_replica_dsns", nil)
+	defer viper.Set("database_replica_max_lag", nil)
+
+	assert.Equal(t, time.Duration(0), ReplicaMaxLag(), "without replicas nothing lags")
+
+	viper.Set("database_replica_dsns", []string{"postgres://replica-a:5432/library"})
+	assert.Equal(t, 5*time.Second, ReplicaMaxLag())
+
+	viper.Set("database_replica_max_lag", "2s")
+	assert.Equal(t, 2*time.Second, ReplicaMaxLag())
+}
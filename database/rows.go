@@ -0,0 +1,42 @@
+package database
+
+import (
+	"github.com/go-pg/pg/orm"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// articleRows is a go-pg model handing every scanned row to fn instead of collecting rows in memory.
+// Once fn returns an error the remaining rows are discarded and the error is returned by the query.
+type articleRows struct {
+	fn      func(*models.Article) error
+	article models.Article
+	scanner orm.ColumnScanner
+	err     error
+}
+
+func newArticleRows(fn func(*models.Article) error) *articleRows {
+	m := &articleRows{fn: fn}
+	model, _ := orm.NewModel(&m.article)
+	m.scanner = model.NewModel()
+	return m
+}
+
+func (m *articleRows) Init() error {
+	return nil
+}
+
+func (m *articleRows) NewModel() orm.ColumnScanner {
+	m.article = models.Article{}
+	return m.scanner
+}
+
+func (m *articleRows) AddModel(_ orm.ColumnScanner) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	article := m.article
+	m.err = m.fn(&article)
+	return m.err
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// The list of error types returned from source store.
+var (
+	ErrSourceNotFound = errors.New("source not found")
+	ErrSourceExists   = errors.New("source already exists")
+)
+
+// SourceStore implements database operations for feed sources and their ingested entries.
+type SourceStore struct {
+	db orm.DB
+}
+
+// NewSourceStore returns a SourceStore.
+func NewSourceStore(db orm.DB) *SourceStore {
+	return &SourceStore{
+		db: db,
+	}
+}
+
+const sourceColumns = `id, url, title, etag, last_modified, failures, last_error, last_polled_at, next_poll_at`
+
+// Add subscribes to a feed URL, the source is due for polling immediately.
+func (s *SourceStore) Add(url string) (*models.Source, error) {
+	q := `
+	INSERT INTO sources(url) VALUES (?) ON CONFLICT (url) DO NOTHING RETURNING ` + sourceColumns
+
+	var src models.Source
+	if _, err := s.db.QueryOne(&src, q, url); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, ErrSourceExists
+		}
+		return nil, err
+	}
+
+	return &src, nil
+}
+
+// Get a source by ID.
+func (s *SourceStore) Get(id int) (*models.Source, error) {
+	q := `
+	SELECT ` + sourceColumns + ` FROM sources WHERE id = ?
+	`
+
+	var src models.Source
+	if _, err := s.db.QueryOne(&src, q, id); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, ErrSourceNotFound
+		}
+		return nil, err
+	}
+
+	return &src, nil
+}
+
+// List gets all sources.
+func (s *SourceStore) List() (*[]models.Source, error) {
+	q := `
+	SELECT ` + sourceColumns + ` FROM sources ORDER BY id
+	`
+
+	var src []models.Source
+	if _, err := s.db.Query(&src, q); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return &src, nil
+}
+
+// Due claims up to limit sources whose next poll is due by pushing their next poll back by lease,
+// so that concurrent pollers do not fetch the same source.
+func (s *SourceStore) Due(limit int, lease time.Duration) (*[]models.Source, error) {
+	q := `
+	UPDATE sources SET next_poll_at = now() + ? * interval '1 millisecond' WHERE id IN (
+		SELECT id FROM sources WHERE next_poll_at <= now() ORDER BY next_poll_at, id LIMIT ? FOR UPDATE SKIP LOCKED
+	) RETURNING ` + sourceColumns
+
+	var src []models.Source
+	if _, err := s.db.Query(&src, q, lease.Milliseconds(), limit); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return &src, nil
+}
+
+// UpdateState stores the title, cache validators, failure count and schedule of a polled source.
+func (s *SourceStore) UpdateState(src *models.Source) error {
+	q := `
+	UPDATE sources SET title = ?, etag = ?, last_modified = ?, failures = ?, last_error = ?, last_polled_at = ?, next_poll_at = ? WHERE id = ?
+	`
+
+	res, err := s.db.Exec(q, src.Title, src.ETag, src.LastModified, src.Failures, src.LastError, src.LastPolledAt, src.NextPollAt, src.ID)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrSourceNotFound
+	}
+
+	return nil
+}
+
+// Ingest creates an article for every entry whose GUID was not seen before for the source and
+// returns the number of created articles. Entries are recorded in the same transaction as their
+// articles, so a GUID is never imported twice even if its article is deleted later.
+func (s *SourceStore) Ingest(sourceID int, entries []models.SourceEntry) (int, error) {
+	var created int
+	err := NewArticleStore(s.db).RunInTransaction(func(store *ArticleStore) error {
+		created = 0
+		for _, e := range entries {
+			q := `
+			INSERT INTO source_entries(source_id, guid) VALUES (?, ?) ON CONFLICT DO NOTHING
+			`
+
+			res, err := store.db.Exec(q, sourceID, e.GUID)
+			if err != nil {
+				return err
+			}
+			if res.RowsAffected() == 0 {
+				continue
+			}
+
+			article := e.Article
+			id, err := store.Import(&article)
+			if err != nil {
+				return err
+			}
+
+			if _, err := store.db.Exec(`UPDATE source_entries SET article_id = ? WHERE source_id = ? AND guid = ?`, id.ID, sourceID, e.GUID); err != nil {
+				return err
+			}
+			created++
+		}
+		return nil
+	})
+
+	return created, err
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+func TestSourceStore(t *testing.T) {
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Errorf("failed to begin transaction: %v", err)
+	}
+
+	defer func() {
+		tx.Rollback()
+		restartSerial(t, db)
+	}()
+
+	store := NewSourceStore(tx)
+	src, err := store.Add("http://example.com/feed.xml")
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	_, err = store.Add("http://example.com/feed.xml")
+	assert.Equal(t, ErrSourceExists, err)
+
+	due, err := store.Due(10, time.Minute)
+	if err != nil {
+		t.Errorf("due failed: %v", err)
+	}
+	assert.Len(t, *due, 1)
+	assert.Equal(t, src.ID, (*due)[0].ID)
+
+	// claimed sources are not due again until the lease expires
+	due, err = store.Due(10, time.Minute)
+	if err != nil {
+		t.Errorf("due failed: %v", err)
+	}
+	assert.Len(t, *due, 0)
+
+	polled := time.Now().UTC().Truncate(time.Millisecond)
+	src.Title = "Test Feed"
+	src.ETag = `"abc"`
+	src.Failures = 2
+	src.LastError = "unexpected status 500"
+	src.LastPolledAt = &polled
+	src.NextPollAt = polled.Add(time.Hour)
+	if err := store.UpdateState(src); err != nil {
+		t.Errorf("update state failed: %v", err)
+	}
+
+	actual, err := store.Get(src.ID)
+	if err != nil {
+		t.Errorf("get failed: %v", err)
+	}
+	assert.Equal(t, "Test Feed", actual.Title)
+	assert.Equal(t, `"abc"`, actual.ETag)
+	assert.Equal(t, 2, actual.Failures)
+	assert.True(t, polled.Equal(*actual.LastPolledAt))
+
+	_, err = store.Get(src.ID + 1)
+	assert.Equal(t, ErrSourceNotFound, err)
+
+	entries := []models.SourceEntry{
+		{GUID: "1", Article: models.Article{Title: "Test Title", Content: "Test Content", Author: "Test Author"}},
+		{GUID: "2", Article: models.Article{Title: "Another Test Title", Content: "Another Test Content", Author: "Test Author"}},
+	}
+	created, err := store.Ingest(src.ID, entries)
+	if err != nil {
+		t.Errorf("ingest failed: %v", err)
+	}
+	assert.Equal(t, 2, created)
+
+	// entries already seen are skipped by GUID
+	created, err = store.Ingest(src.ID, append(entries, models.SourceEntry{GUID: "3", Article: models.Article{Title: "Third Test Title", Content: "Third Test Content", Author: "Test Author"}}))
+	if err != nil {
+		t.Errorf("ingest failed: %v", err)
+	}
+	assert.Equal(t, 1, created)
+
+	var articles int
+	if _, err := tx.QueryOne(pg.Scan(&articles), `SELECT count(*) FROM articles ar INNER JOIN source_entries se ON se.article_id = ar.id WHERE se.source_id = ?`, src.ID); err != nil {
+		t.Errorf("count articles failed: %v", err)
+	}
+	assert.Equal(t, 3, articles)
+
+	sources, err := store.List()
+	if err != nil {
+		t.Errorf("list failed: %v", err)
+	}
+	assert.Len(t, *sources, 1)
+}
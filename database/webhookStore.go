@@ -0,0 +1,208 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// The list of error types returned from webhook store.
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+)
+
+// WebhookStore implements database operations for webhooks and their deliveries.
+type WebhookStore struct {
+	db orm.DB
+}
+
+// NewWebhookStore returns a WebhookStore.
+func NewWebhookStore(db orm.DB) *WebhookStore {
+	return &WebhookStore{
+		db: db,
+	}
+}
+
+// webhookColumns leaves out the secret, which is only loaded to sign deliveries.
+const webhookColumns = `id, url, events, created_at`
+
+const deliveryColumns = `id, webhook_id, event, article_id, payload, status, attempts, next_attempt_at, last_error, response_status, created_at, delivered_at`
+
+// Create registers a webhook and sets its ID and creation time.
+func (s *WebhookStore) Create(w *models.Webhook) error {
+	q := `
+	INSERT INTO webhooks(url, secret, events) VALUES (?, ?, ?) RETURNING id, created_at
+	`
+
+	_, err := s.db.QueryOne(w, q, w.URL, w.Secret, pg.Array(w.Events))
+	return err
+}
+
+// Get a webhook by ID.
+func (s *WebhookStore) Get(id int) (*models.Webhook, error) {
+	q := `
+	SELECT ` + webhookColumns + ` FROM webhooks WHERE id = ?
+	`
+
+	var w models.Webhook
+	if _, err := s.db.QueryOne(&w, q, id); err != nil {
+		if err == pg.ErrNoRows {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// List gets all webhooks.
+func (s *WebhookStore) List() (*[]models.Webhook, error) {
+	q := `
+	SELECT ` + webhookColumns + ` FROM webhooks ORDER BY id
+	`
+
+	var w []models.Webhook
+	if _, err := s.db.Query(&w, q); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return &w, nil
+}
+
+// Delete removes a webhook and its deliveries.
+func (s *WebhookStore) Delete(id int) error {
+	res, err := s.db.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// Deliveries returns up to limit deliveries of a webhook, newest first, optionally only those with
+// the given status.
+func (s *WebhookStore) Deliveries(webhookID int, status string, limit int) (*[]models.Delivery, error) {
+	q := `
+	SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE webhook_id = ? AND (? = '' OR status = ?) ORDER BY id DESC LIMIT ?
+	`
+
+	var d []models.Delivery
+	if _, err := s.db.Query(&d, q, webhookID, status, status, limit); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	return &d, nil
+}
+
+// Redeliver schedules a delivery of a webhook to be attempted again immediately with its attempts
+// reset, which revives dead deliveries.
+func (s *WebhookStore) Redeliver(webhookID int, deliveryID int64) error {
+	q := `
+	UPDATE webhook_deliveries SET status = ?, attempts = 0, next_attempt_at = now() WHERE id = ? AND webhook_id = ?
+	`
+
+	res, err := s.db.Exec(q, models.DeliveryPending, deliveryID, webhookID)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+// Due claims up to limit pending deliveries whose next attempt is due by pushing the attempt back
+// by lease, so that concurrent dispatchers do not send the same delivery. Each delivery is returned
+// with its webhook.
+func (s *WebhookStore) Due(limit int, lease time.Duration) (*[]models.Delivery, error) {
+	q := `
+	UPDATE webhook_deliveries SET next_attempt_at = now() + ? * interval '1 millisecond' WHERE id IN (
+		SELECT id FROM webhook_deliveries WHERE status = ? AND next_attempt_at <= now() ORDER BY next_attempt_at, id LIMIT ? FOR UPDATE SKIP LOCKED
+	) RETURNING ` + deliveryColumns
+
+	var d []models.Delivery
+	if _, err := s.db.Query(&d, q, lease.Milliseconds(), models.DeliveryPending, limit); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+	if len(d) == 0 {
+		return &d, nil
+	}
+
+	ids := make([]int, len(d))
+	for i := range d {
+		ids[i] = d[i].WebhookID
+	}
+
+	var webhooks []models.Webhook
+	if _, err := s.db.Query(&webhooks, `SELECT id, url, secret, events, created_at FROM webhooks WHERE id = ANY(?)`, pg.Array(ids)); err != nil {
+		if err != pg.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	byID := make(map[int]*models.Webhook, len(webhooks))
+	for i := range webhooks {
+		byID[webhooks[i].ID] = &webhooks[i]
+	}
+	for i := range d {
+		d[i].Webhook = byID[d[i].WebhookID]
+	}
+
+	return &d, nil
+}
+
+// UpdateState stores the status, attempts, schedule and last response of an attempted delivery.
+func (s *WebhookStore) UpdateState(d *models.Delivery) error {
+	q := `
+	UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, response_status = ?, delivered_at = ? WHERE id = ?
+	`
+
+	res, err := s.db.Exec(q, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.ResponseStatus, d.DeliveredAt, d.ID)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		return ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+// enqueueDeliveries writes a delivery of an article event for every webhook subscribed to it. It is
+// called with the database handle of the article write, so that deliveries are part of its transaction.
+func enqueueDeliveries(db orm.DB, event string, article models.Article) error {
+	payload, err := json.Marshal(&models.DeliveryPayload{
+		Event:      event,
+		OccurredAt: time.Now().UTC(),
+		Article:    article,
+	})
+	if err != nil {
+		return err
+	}
+
+	q := `
+	INSERT INTO webhook_deliveries(webhook_id, event, article_id, payload) SELECT id, ?, ?, ?::jsonb FROM webhooks WHERE ? = ANY(events)
+	`
+
+	_, err = db.Exec(q, event, article.ID, string(payload), event)
+	return err
+}
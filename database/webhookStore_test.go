@@ -0,0 +1,135 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+func TestWebhookStore(t *testing.T) {
+	db, err := DBConn()
+	if err != nil {
+		t.Fatalf("open database connection: %v", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Errorf("failed to begin transaction: %v", err)
+	}
+
+	defer func() {
+		tx.Rollback()
+		restartSerial(t, db)
+	}()
+
+	store := NewWebhookStore(tx)
+	created := &models.Webhook{URL: "https://example.com/created", Secret: "0123456789abcdef", Events: []string{models.EventCreated}}
+	all := &models.Webhook{URL: "https://example.com/all", Secret: "fedcba9876543210", Events: []string{models.EventCreated, models.EventUpdated, models.EventDeleted}}
+	for _, w := range []*models.Webhook{created, all} {
+		if err := store.Create(w); err != nil {
+			t.Fatalf("create failed: %v", err)
+		}
+	}
+
+	w, err := store.Get(created.ID)
+	if err != nil {
+		t.Errorf("get failed: %v", err)
+	}
+	assert.Equal(t, "", w.Secret)
+	assert.Equal(t, []string{models.EventCreated}, w.Events)
+
+	// article writes enqueue deliveries in their transaction
+	articles := NewArticleStore(tx)
+	article := &models.Article{Title: "Test Title", Content: "Test Content", Author: "Test Author"}
+	id, err := articles.Post(article)
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	if err := articles.Update(id.ID, article); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if err := articles.Delete(id.ID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+
+	d, err := store.Deliveries(created.ID, "", 10)
+	if err != nil {
+		t.Errorf("deliveries failed: %v", err)
+	}
+	assert.Len(t, *d, 1)
+
+	d, err = store.Deliveries(all.ID, "", 10)
+	if err != nil {
+		t.Errorf("deliveries failed: %v", err)
+	}
+	assert.Len(t, *d, 3)
+	assert.Equal(t, models.EventDeleted, (*d)[0].Event)
+
+	var payload models.DeliveryPayload
+	if err := json.Unmarshal((*d)[2].Payload, &payload); err != nil {
+		t.Errorf("unmarshal payload failed: %v", err)
+	}
+	assert.Equal(t, models.EventCreated, payload.Event)
+	assert.Equal(t, id.PublicID, payload.Article.PublicID)
+	assert.Equal(t, "Test Title", payload.Article.Title)
+
+	due, err := store.Due(10, time.Minute)
+	if err != nil {
+		t.Errorf("due failed: %v", err)
+	}
+	assert.Len(t, *due, 4)
+	for _, dl := range *due {
+		assert.NotNil(t, dl.Webhook)
+		assert.NotEmpty(t, dl.Webhook.Secret)
+	}
+
+	// claimed deliveries are not due again until the lease expires
+	due2, err := store.Due(10, time.Minute)
+	if err != nil {
+		t.Errorf("due failed: %v", err)
+	}
+	assert.Len(t, *due2, 0)
+
+	dl := (*due)[0]
+	dl.Status = models.DeliveryDead
+	dl.Attempts = 10
+	dl.LastError = "unexpected status 500"
+	dl.ResponseStatus = 500
+	if err := store.UpdateState(&dl); err != nil {
+		t.Errorf("update state failed: %v", err)
+	}
+
+	d, err = store.Deliveries(dl.WebhookID, models.DeliveryDead, 10)
+	if err != nil {
+		t.Errorf("deliveries failed: %v", err)
+	}
+	assert.Len(t, *d, 1)
+	assert.Equal(t, "unexpected status 500", (*d)[0].LastError)
+
+	if err := store.Redeliver(dl.WebhookID, dl.ID); err != nil {
+		t.Errorf("redeliver failed: %v", err)
+	}
+	assert.Equal(t, ErrDeliveryNotFound, store.Redeliver(dl.WebhookID, dl.ID+100))
+
+	due, err = store.Due(10, time.Minute)
+	if err != nil {
+		t.Errorf("due failed: %v", err)
+	}
+	assert.Len(t, *due, 1)
+	assert.Equal(t, 0, (*due)[0].Attempts)
+
+	if err := store.Delete(all.ID); err != nil {
+		t.Errorf("delete failed: %v", err)
+	}
+	assert.Equal(t, ErrWebhookNotFound, store.Delete(all.ID))
+
+	list, err := store.List()
+	if err != nil {
+		t.Errorf("list failed: %v", err)
+	}
+	assert.Len(t, *list, 1)
+}
@@ -0,0 +1,125 @@
+// Package events distributes article changes to stream subscribers. Changes are announced by
+// Postgres NOTIFY so that subscribers of every server instance see writes made on any instance.
+package events
+
+import (
+	"sync"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// The list of event types.
+const (
+	TypeCreated = "created"
+	TypeUpdated = "updated"
+	TypeDeleted = "deleted"
+	// TypeReset tells a resuming subscriber that events were missed and it should reload its state.
+	TypeReset = "reset"
+)
+
+// subscriberBuffer is the number of events a subscriber may fall behind before it is dropped.
+const subscriberBuffer = 64
+
+// Event is a change of an article. IDs are assigned by the database and are the same on every
+// server instance.
+type Event struct {
+	ID   int64
+	Type string
+	Data []byte
+
+	// Article identifies the changed article, it is not part of the event sent to subscribers.
+	Article models.ArticleID
+}
+
+// Broker keeps the most recent events in a ring buffer and fans published events out to subscribers.
+type Broker struct {
+	mu   sync.Mutex
+	ring []Event
+	// next is the ring position of the next event, and full tells whether the ring has wrapped around
+	next int
+	full bool
+	subs map[chan Event]struct{}
+}
+
+// NewBroker creates and returns a broker buffering up to size events for resuming subscribers.
+func NewBroker(size int) *Broker {
+	if size <= 0 {
+		size = 1000
+	}
+
+	return &Broker{
+		ring: make([]Event, size),
+		subs: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish buffers an event and sends it to every subscriber. Subscribers which have fallen too far
+// behind are dropped, their channel is closed so that they reconnect and resume from the buffer.
+func (b *Broker) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.ring[b.next] = e
+	b.next = (b.next + 1) % len(b.ring)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns the buffered events published after the event lastID and a channel of the
+// events published from now on. A lastID of zero subscribes to new events only. When lastID is no
+// longer buffered the returned events start with a reset event. The channel is closed by
+// Unsubscribe or when the subscriber falls behind.
+func (b *Broker) Subscribe(lastID int64) ([]Event, <-chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var missed []Event
+	if lastID > 0 {
+		buffered := b.buffered()
+		missed = []Event{{Type: TypeReset}}
+		// events arrive in commit order, which may differ from ID order, so resume by position
+		for i := len(buffered) - 1; i >= 0; i-- {
+			if buffered[i].ID == lastID {
+				missed = append([]Event(nil), buffered[i+1:]...)
+				break
+			}
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	b.subs[ch] = struct{}{}
+
+	return missed, ch
+}
+
+// Unsubscribe stops sending events to a channel returned by Subscribe.
+func (b *Broker) Unsubscribe(events <-chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs {
+		if ch == events {
+			delete(b.subs, ch)
+			close(ch)
+			return
+		}
+	}
+}
+
+// buffered returns the buffered events, oldest first.
+func (b *Broker) buffered() []Event {
+	if !b.full {
+		return b.ring[:b.next]
+	}
+	return append(append([]Event(nil), b.ring[b.next:]...), b.ring[:b.next]...)
+}
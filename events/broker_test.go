@@ -0,0 +1,85 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func publish(b *Broker, ids ...int64) {
+	for _, id := range ids {
+		b.Publish(Event{ID: id, Type: TypeCreated})
+	}
+}
+
+func ids(events []Event) []int64 {
+	var ids []int64
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+	return ids
+}
+
+func TestBrokerSubscribe(t *testing.T) {
+	tt := []struct {
+		name      string
+		size      int
+		published []int64
+		lastID    int64
+		expected  []Event
+	}{
+		{name: "new subscriber", size: 3, published: []int64{1, 2}, lastID: 0, expected: nil},
+		{name: "resume", size: 3, published: []int64{1, 2, 3}, lastID: 1, expected: []Event{{ID: 2, Type: TypeCreated}, {ID: 3, Type: TypeCreated}}},
+		{name: "up to date", size: 3, published: []int64{1, 2}, lastID: 2, expected: nil},
+		{name: "resume after wrap around", size: 3, published: []int64{1, 2, 3, 4, 5}, lastID: 3, expected: []Event{{ID: 4, Type: TypeCreated}, {ID: 5, Type: TypeCreated}}},
+		{name: "resume in commit order", size: 3, published: []int64{2, 1, 3}, lastID: 2, expected: []Event{{ID: 1, Type: TypeCreated}, {ID: 3, Type: TypeCreated}}},
+		{name: "evicted", size: 3, published: []int64{1, 2, 3, 4}, lastID: 1, expected: []Event{{Type: TypeReset}}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewBroker(tc.size)
+			publish(b, tc.published...)
+
+			missed, ch := b.Subscribe(tc.lastID)
+			defer b.Unsubscribe(ch)
+
+			assert.Equal(t, tc.expected, missed)
+		})
+	}
+}
+
+func TestBrokerPublish(t *testing.T) {
+	b := NewBroker(10)
+	_, ch := b.Subscribe(0)
+	publish(b, 1, 2)
+
+	assert.Equal(t, []int64{1, 2}, ids([]Event{<-ch, <-ch}))
+
+	b.Unsubscribe(ch)
+	_, ok := <-ch
+	assert.False(t, ok, "expected channel to be closed by unsubscribe")
+}
+
+func TestBrokerDropsSlowSubscriber(t *testing.T) {
+	b := NewBroker(10)
+	_, slow := b.Subscribe(0)
+	_, fast := b.Subscribe(0)
+
+	for i := int64(1); i <= subscriberBuffer+1; i++ {
+		publish(b, i)
+		// the fast subscriber keeps up
+		<-fast
+	}
+
+	var received int
+	for range slow {
+		received++
+	}
+	assert.Equal(t, subscriberBuffer, received)
+
+	// unsubscribing a dropped subscriber is a no-op
+	b.Unsubscribe(slow)
+	publish(b, 100)
+	assert.Equal(t, int64(100), (<-fast).ID)
+}
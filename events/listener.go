@@ -0,0 +1,169 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/sirupsen/logrus"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// Channel is the Postgres notification channel the article triggers notify on writes.
+const Channel = "article_changes"
+
+// pingChannel is notified by the listener itself to check its connection when no change has been
+// notified for pingInterval.
+const pingChannel = "article_changes_ping"
+
+const (
+	pingInterval  = 5 * time.Second
+	retryInterval = time.Second
+)
+
+var (
+	// ErrUnknownOperation is returned for a notification of an unknown trigger operation.
+	ErrUnknownOperation = errors.New("unknown article change operation")
+	// ErrPingTimeout is returned when the connection of the listener did not answer its ping.
+	ErrPingTimeout = errors.New("listener ping timed out")
+)
+
+// ArticleStore defines database operations for loading changed articles.
+type ArticleStore interface {
+	Get(id int) (*[]models.Article, error)
+}
+
+// Listener publishes the article changes notified by Postgres to a broker.
+type Listener struct {
+	DB     *pg.DB
+	Store  ArticleStore
+	Broker *Broker
+	Logger logrus.FieldLogger
+}
+
+// NewListener creates and returns a listener.
+func NewListener(db *pg.DB, store ArticleStore, broker *Broker, logger logrus.FieldLogger) *Listener {
+	return &Listener{
+		DB:     db,
+		Store:  store,
+		Broker: broker,
+		Logger: logger,
+	}
+}
+
+// notification is the payload sent by the article trigger, op is the trigger operation.
+type notification struct {
+	ID        int64           `json:"id"`
+	Op        string          `json:"op"`
+	ArticleID int             `json:"article_id"`
+	PublicID  models.PublicID `json:"public_id"`
+}
+
+// Run publishes notifications until ctx is done. The connection is reestablished and the channel
+// listened to again when it fails. Changes notified in the meantime are lost, so a reset event is
+// published once listening again, which tells subscribers to reload their state.
+func (l *Listener) Run(ctx context.Context) {
+	connected := false
+	for {
+		ln := l.DB.Listen()
+		err := ln.Listen(Channel, pingChannel)
+		if err == nil {
+			if connected {
+				l.Broker.Publish(Event{Type: TypeReset})
+			}
+			connected = true
+			err = l.receive(ctx, ln)
+		}
+		ln.Close()
+
+		if ctx.Err() != nil {
+			return
+		}
+		l.Logger.WithField("module", "events").Error(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// receive handles the notifications of ln until ctx is done or the connection fails. The connection
+// is pinged when nothing was notified for pingInterval, and fails when the ping is not answered.
+func (l *Listener) receive(ctx context.Context, ln *pg.Listener) error {
+	pinged := false
+	for ctx.Err() == nil {
+		channel, payload, err := ln.ReceiveTimeout(pingInterval)
+		if err != nil {
+			if err, ok := err.(net.Error); !ok || !err.Timeout() {
+				return err
+			}
+			if pinged {
+				return ErrPingTimeout
+			}
+			if _, err := l.DB.Exec(`NOTIFY ` + pingChannel); err != nil {
+				return err
+			}
+			pinged = true
+			continue
+		}
+
+		// any notification shows that the connection is alive
+		pinged = false
+		if channel == pingChannel {
+			continue
+		}
+		if err := l.Handle(payload); err != nil {
+			l.Logger.WithField("module", "events").Error(err)
+		}
+	}
+	return nil
+}
+
+// Handle publishes the event of a notification payload. Created and updated articles are loaded,
+// since notification payloads are limited to 8000 bytes. Changes to articles which have been
+// deleted since are skipped as their deleted event follows.
+func (l *Listener) Handle(payload string) error {
+	var n notification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		return err
+	}
+
+	e := Event{ID: n.ID, Article: models.ArticleID{ID: n.ArticleID, PublicID: n.PublicID}}
+	switch n.Op {
+	case "INSERT":
+		e.Type = TypeCreated
+	case "UPDATE":
+		e.Type = TypeUpdated
+	case "DELETE":
+		e.Type = TypeDeleted
+	default:
+		return ErrUnknownOperation
+	}
+
+	var data interface{} = e.Article
+	if e.Type != TypeDeleted {
+		articles, err := l.Store.Get(n.ArticleID)
+		if err != nil {
+			return err
+		}
+		if len(*articles) == 0 {
+			return nil
+		}
+		data = (*articles)[0]
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	e.Data = b
+
+	l.Broker.Publish(e)
+	return nil
+}
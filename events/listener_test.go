@@ -0,0 +1,85 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+type memoryArticleStore struct {
+	articles map[int]models.Article
+}
+
+func (s *memoryArticleStore) Get(id int) (*[]models.Article, error) {
+	var a []models.Article
+	if article, ok := s.articles[id]; ok {
+		a = append(a, article)
+	}
+	return &a, nil
+}
+
+func TestListenerHandle(t *testing.T) {
+	store := &memoryArticleStore{
+		articles: map[int]models.Article{
+			1: {ArticleID: models.ArticleID{ID: 1, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"}, Title: "Test Title", Content: "Test Content", Author: "Test Author"},
+		},
+	}
+
+	tt := []struct {
+		name     string
+		payload  string
+		expected []Event
+		err      bool
+	}{
+		{
+			name:     "created",
+			payload:  `{"id": 7, "op": "INSERT", "article_id": 1, "public_id": "01ARZ3NDEKTSV4RRFFQ69G5FAV"}`,
+			expected: []Event{{ID: 7, Type: TypeCreated, Data: []byte(`{"id":"01ARZ3NDEKTSV4RRFFQ69G5FAV","title":"Test Title","content":"Test Content","author":"Test Author"}`), Article: models.ArticleID{ID: 1, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"}}},
+		},
+		{
+			name:     "updated",
+			payload:  `{"id": 8, "op": "UPDATE", "article_id": 1, "public_id": "01ARZ3NDEKTSV4RRFFQ69G5FAV"}`,
+			expected: []Event{{ID: 8, Type: TypeUpdated, Data: []byte(`{"id":"01ARZ3NDEKTSV4RRFFQ69G5FAV","title":"Test Title","content":"Test Content","author":"Test Author"}`), Article: models.ArticleID{ID: 1, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"}}},
+		},
+		{
+			name:     "deleted",
+			payload:  `{"id": 9, "op": "DELETE", "article_id": 2, "public_id": "01ARZ3NDEKTSV4RRFFQ69G5FAW"}`,
+			expected: []Event{{ID: 9, Type: TypeDeleted, Data: []byte(`{"id":"01ARZ3NDEKTSV4RRFFQ69G5FAW"}`), Article: models.ArticleID{ID: 2, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAW"}}},
+		},
+		{
+			name:    "updated since deleted",
+			payload: `{"id": 10, "op": "UPDATE", "article_id": 2, "public_id": "01ARZ3NDEKTSV4RRFFQ69G5FAW"}`,
+		},
+		{
+			name:    "unknown operation",
+			payload: `{"id": 11, "op": "TRUNCATE", "article_id": 1}`,
+			err:     true,
+		},
+		{
+			name:    "malformed payload",
+			payload: `not json`,
+			err:     true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewBroker(10)
+			_, ch := b.Subscribe(0)
+			l := NewListener(nil, store, b, logrus.New())
+
+			err := l.Handle(tc.payload)
+			assert.Equal(t, tc.err, err != nil, "unexpected error: %v", err)
+
+			b.Unsubscribe(ch)
+			var actual []Event
+			for e := range ch {
+				actual = append(actual, e)
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
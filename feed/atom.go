@@ -0,0 +1,85 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     atomAuthor     `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Summary    *atomText      `xml:"summary"`
+	Content    *atomText      `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func atom(f *Feed) *atomFeed {
+	a := &atomFeed{
+		Title:    f.Title,
+		Subtitle: f.Description,
+		ID:       f.FeedURL,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: f.FeedURL},
+			{Rel: "alternate", Href: f.Link},
+		},
+	}
+
+	for _, item := range f.Items {
+		e := atomEntry{
+			Title:     item.Title,
+			ID:        item.ID,
+			Link:      atomLink{Rel: "alternate", Href: item.URL},
+			Published: item.Published.UTC().Format(time.RFC3339),
+			Updated:   item.Updated.UTC().Format(time.RFC3339),
+			Author:    atomAuthor{Name: item.Author},
+		}
+
+		for _, tag := range item.Tags {
+			e.Categories = append(e.Categories, atomCategory{Term: tag})
+		}
+
+		if item.Content != "" {
+			e.Content = &atomText{Type: "html", Body: item.Content}
+		} else {
+			e.Summary = &atomText{Type: "text", Body: item.Summary}
+		}
+
+		a.Entries = append(a.Entries, e)
+	}
+
+	return a
+}
@@ -0,0 +1,82 @@
+// Package feed encodes and parses article feeds as Atom 1.0, RSS 2.0 and JSON Feed 1.1.
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"time"
+)
+
+// The list of supported feed formats, named after their file extension.
+const (
+	FormatAtom = "atom"
+	FormatRSS  = "rss"
+	FormatJSON = "json"
+)
+
+// The list of error types returned from feed.
+var (
+	ErrUnknownFormat = errors.New("unknown feed format, must be one of atom, rss or json")
+)
+
+// Feed holds the metadata and entries of a feed.
+type Feed struct {
+	Title       string
+	Description string
+	// Link is the URL of the HTML page the feed describes.
+	Link string
+	// FeedURL is the URL the feed itself is served from.
+	FeedURL string
+	Updated time.Time
+	Items   []Item
+}
+
+// Item holds a single feed entry. Either Content, rendered HTML, or Summary, plain text, is set.
+type Item struct {
+	ID        string
+	URL       string
+	Title     string
+	Author    string
+	Tags      []string
+	Published time.Time
+	Updated   time.Time
+	Content   string
+	Summary   string
+}
+
+// ContentType returns the media type of a feed format.
+func ContentType(format string) string {
+	switch format {
+	case FormatAtom:
+		return "application/atom+xml; charset=utf-8"
+	case FormatRSS:
+		return "application/rss+xml; charset=utf-8"
+	case FormatJSON:
+		return "application/feed+json; charset=utf-8"
+	}
+	return ""
+}
+
+// Encode returns the feed in format.
+func Encode(format string, f *Feed) ([]byte, error) {
+	switch format {
+	case FormatAtom:
+		return encodeXML(atom(f))
+	case FormatRSS:
+		return encodeXML(rss(f))
+	case FormatJSON:
+		return json.MarshalIndent(jsonFeed(f), "", "  ")
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+func encodeXML(v interface{}) ([]byte, error) {
+	b, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), b...), nil
+}
@@ -0,0 +1,265 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testFeed() *Feed {
+	published := time.Date(2019, 10, 1, 9, 30, 0, 0, time.UTC)
+	updated := time.Date(2019, 10, 2, 9, 30, 0, 0, time.UTC)
+
+	return &Feed{
+		Title:   "Articles & <News>",
+		Link:    "http://localhost:8080/articles",
+		FeedURL: "http://localhost:8080/feeds/articles.atom",
+		Updated: updated,
+		Items: []Item{
+			{
+				ID:        "http://localhost:8080/articles/1",
+				URL:       "http://localhost:8080/articles/1",
+				Title:     "Fish & Chips",
+				Author:    "Test Author",
+				Tags:      []string{"food"},
+				Published: published,
+				Updated:   updated,
+				Content:   "<p>Fish &amp; Chips</p>",
+			},
+			{
+				ID:        "http://localhost:8080/articles/2",
+				URL:       "http://localhost:8080/articles/2",
+				Title:     "Test Title",
+				Author:    "Test Author",
+				Published: published,
+				Updated:   published,
+				Summary:   "Test Content",
+			},
+		},
+	}
+}
+
+func TestAtom(t *testing.T) {
+	b, err := Encode(FormatAtom, testFeed())
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	var actual atomFeed
+	if err := xml.Unmarshal(b, &actual); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	assert.True(t, strings.HasPrefix(string(b), xml.Header))
+	assert.Contains(t, string(b), "<title>Articles &amp; &lt;News&gt;</title>")
+	assert.Equal(t, "Articles & <News>", actual.Title)
+	assert.Equal(t, "2019-10-02T09:30:00Z", actual.Updated)
+	assert.Len(t, actual.Entries, 2)
+	assert.Equal(t, &atomText{Type: "html", Body: "<p>Fish &amp; Chips</p>"}, actual.Entries[0].Content)
+	assert.Equal(t, []atomCategory{{Term: "food"}}, actual.Entries[0].Categories)
+	assert.Equal(t, &atomText{Type: "text", Body: "Test Content"}, actual.Entries[1].Summary)
+	assert.Nil(t, actual.Entries[1].Content)
+}
+
+func TestRSS(t *testing.T) {
+	b, err := Encode(FormatRSS, testFeed())
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	s := string(b)
+	assert.Contains(t, s, `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/">`)
+	assert.Contains(t, s, `<atom:link href="http://localhost:8080/feeds/articles.atom" rel="self" type="application/rss+xml"></atom:link>`)
+	assert.Contains(t, s, `<lastBuildDate>Wed, 02 Oct 2019 09:30:00 +0000</lastBuildDate>`)
+	assert.Contains(t, s, `<guid isPermaLink="true">http://localhost:8080/articles/1</guid>`)
+	assert.Contains(t, s, `<dc:creator>Test Author</dc:creator>`)
+	assert.Contains(t, s, `<description>&lt;p&gt;Fish &amp;amp; Chips&lt;/p&gt;</description>`)
+}
+
+func TestJSON(t *testing.T) {
+	b, err := Encode(FormatJSON, testFeed())
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	var actual jsonFeedDoc
+	if err := json.Unmarshal(b, &actual); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	assert.Equal(t, "https://jsonfeed.org/version/1.1", actual.Version)
+	assert.Equal(t, "<p>Fish &amp; Chips</p>", actual.Items[0].ContentHTML)
+	assert.Equal(t, "Test Content", actual.Items[1].ContentText)
+	assert.Equal(t, "2019-10-01T09:30:00Z", actual.Items[1].DatePublished)
+	assert.Equal(t, []jsonFeedAuthor{{Name: "Test Author"}}, actual.Items[1].Authors)
+}
+
+func TestEncodeUnknownFormat(t *testing.T) {
+	_, err := Encode("xml", testFeed())
+	assert.Equal(t, ErrUnknownFormat, err)
+}
+
+func TestParseRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatAtom, FormatRSS, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			expected := testFeed()
+			b, err := Encode(format, expected)
+			if err != nil {
+				t.Fatalf("encode failed: %v", err)
+			}
+
+			actual, err := Parse(b)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+
+			assert.Equal(t, expected.Title, actual.Title)
+			assert.Equal(t, expected.Link, actual.Link)
+			assert.True(t, expected.Updated.Equal(actual.Updated), "updated %v", actual.Updated)
+			assert.Len(t, actual.Items, len(expected.Items))
+			for i, item := range actual.Items {
+				assert.Equal(t, expected.Items[i].ID, item.ID)
+				assert.Equal(t, expected.Items[i].URL, item.URL)
+				assert.Equal(t, expected.Items[i].Title, item.Title)
+				assert.Equal(t, expected.Items[i].Author, item.Author)
+				assert.Equal(t, expected.Items[i].Tags, item.Tags)
+				assert.True(t, expected.Items[i].Published.Equal(item.Published), "published %v", item.Published)
+			}
+			assert.Equal(t, "<p>Fish &amp; Chips</p>", actual.Items[0].Content)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tt := []struct {
+		name     string
+		doc      string
+		expected []Item
+		err      error
+	}{
+		{
+			name: "rss with encoded content and rfc822 dates",
+			doc: `<?xml version="1.0"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+  <channel>
+    <title>Test Feed</title>
+    <item>
+      <title>Test Title</title>
+      <link>http://example.com/1</link>
+      <guid isPermaLink="false">tag:example.com,2019:1</guid>
+      <author>author@example.com (Test Author)</author>
+      <category> go </category>
+      <pubDate>Tue, 1 Oct 2019 09:30:00 GMT</pubDate>
+      <description>&lt;p&gt;Test Summary&lt;/p&gt;</description>
+      <content:encoded><![CDATA[<p>Test <em>Content</em></p>]]></content:encoded>
+    </item>
+  </channel>
+</rss>`,
+			expected: []Item{
+				{
+					ID:        "tag:example.com,2019:1",
+					URL:       "http://example.com/1",
+					Title:     "Test Title",
+					Author:    "author@example.com (Test Author)",
+					Tags:      []string{"go"},
+					Published: time.Date(2019, 10, 1, 9, 30, 0, 0, time.UTC),
+					Updated:   time.Date(2019, 10, 1, 9, 30, 0, 0, time.UTC),
+					Content:   "<p>Test <em>Content</em></p>",
+					Summary:   "Test Summary",
+				},
+			},
+		},
+		{
+			name: "rss in windows-1252",
+			doc: "<?xml version=\"1.0\" encoding=\"windows-1252\"?>\n" +
+				"<rss version=\"2.0\"><channel><title>Test Feed</title><item>" +
+				"<title>Caf\xe9 \x93Cr\xe8me\x94</title><guid>1</guid><description>Na\xefve \x80 prices</description>" +
+				"</item></channel></rss>",
+			expected: []Item{
+				{
+					ID:      "1",
+					Title:   "Café “Crème”",
+					Content: "Naïve € prices",
+				},
+			},
+		},
+		{
+			name: "atom in iso-8859-1",
+			doc: "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+				"<feed xmlns=\"http://www.w3.org/2005/Atom\"><title>Test Feed</title><entry>" +
+				"<id>urn:uuid:1</id><title>R\xe9sum\xe9</title><content>D\xe9j\xe0 vu</content>" +
+				"</entry></feed>",
+			expected: []Item{
+				{
+					ID:      "urn:uuid:1",
+					Title:   "Résumé",
+					Summary: "Déjà vu",
+				},
+			},
+		},
+		{
+			name: "atom with text content and feed author",
+			doc: `<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Test Feed</title>
+  <author><name>Test Author</name></author>
+  <entry>
+    <id>urn:uuid:1</id>
+    <title>Test Title</title>
+    <link href="http://example.com/1"/>
+    <updated>2019-10-01T09:30:00Z</updated>
+    <content>Test Content</content>
+  </entry>
+</feed>`,
+			expected: []Item{
+				{
+					ID:        "urn:uuid:1",
+					URL:       "http://example.com/1",
+					Title:     "Test Title",
+					Author:    "Test Author",
+					Published: time.Date(2019, 10, 1, 9, 30, 0, 0, time.UTC),
+					Updated:   time.Date(2019, 10, 1, 9, 30, 0, 0, time.UTC),
+					Summary:   "Test Content",
+				},
+			},
+		},
+		{
+			name: "json feed 1.0 with numeric id",
+			doc:  `{"version":"https://jsonfeed.org/version/1","title":"Test Feed","items":[{"id":1,"title":"Test Title","content_text":"Test Content","author":{"name":"Test Author"}}]}`,
+			expected: []Item{
+				{
+					ID:      "1",
+					Title:   "Test Title",
+					Author:  "Test Author",
+					Summary: "Test Content",
+				},
+			},
+		},
+		{
+			name: "unsupported document",
+			doc:  `<html><body>Not a feed</body></html>`,
+			err:  ErrUnrecognizedFeed,
+		},
+		{
+			name: "empty document",
+			doc:  ``,
+			err:  ErrUnrecognizedFeed,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := Parse([]byte(tc.doc))
+			assert.Equal(t, tc.err, err)
+			if err != nil {
+				return
+			}
+
+			assert.Equal(t, "Test Feed", actual.Title)
+			assert.Equal(t, tc.expected, actual.Items)
+		})
+	}
+}
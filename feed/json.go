@@ -0,0 +1,64 @@
+package feed
+
+import "time"
+
+type jsonFeedDoc struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html,omitempty"`
+	ContentText   string           `json:"content_text,omitempty"`
+	Summary       string           `json:"summary,omitempty"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+	Tags          []string         `json:"tags,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+func jsonFeed(f *Feed) *jsonFeedDoc {
+	j := &jsonFeedDoc{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		Description: f.Description,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedURL,
+		Items:       []jsonFeedItem{},
+	}
+
+	for _, item := range f.Items {
+		ji := jsonFeedItem{
+			ID:            item.ID,
+			URL:           item.URL,
+			Title:         item.Title,
+			DatePublished: item.Published.UTC().Format(time.RFC3339),
+			DateModified:  item.Updated.UTC().Format(time.RFC3339),
+			Authors:       []jsonFeedAuthor{{Name: item.Author}},
+			Tags:          item.Tags,
+		}
+
+		// JSON Feed requires content_html or content_text on every item
+		if item.Content != "" {
+			ji.ContentHTML = item.Content
+		} else {
+			ji.ContentText = item.Summary
+			ji.Summary = item.Summary
+		}
+
+		j.Items = append(j.Items, ji)
+	}
+
+	return j
+}
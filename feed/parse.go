@@ -0,0 +1,336 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+
+	"github.com/ykaseng/articles-library/content"
+)
+
+// The list of error types returned from parsing feeds.
+var (
+	ErrUnrecognizedFeed = errors.New("unrecognized feed document, must be RSS 2.0, Atom 1.0 or JSON Feed")
+)
+
+// Parse reads an RSS 2.0, Atom 1.0 or JSON Feed document. Parsed items carry HTML in Content and
+// plain text in Summary, feeds which only provide text leave Content empty.
+func Parse(b []byte) (*Feed, error) {
+	b = bytes.TrimPrefix(bytes.TrimSpace(b), []byte("\xef\xbb\xbf"))
+	if len(b) > 0 && b[0] == '{' {
+		return parseJSON(b)
+	}
+
+	d := newXMLDecoder(b)
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, ErrUnrecognizedFeed
+		}
+
+		if se, ok := tok.(xml.StartElement); ok {
+			switch se.Name.Local {
+			case "rss":
+				return parseRSS(b)
+			case "feed":
+				return parseAtom(b)
+			}
+			return nil, ErrUnrecognizedFeed
+		}
+	}
+}
+
+// newXMLDecoder returns a decoder of an XML document, which converts documents declaring another
+// encoding than UTF-8, such as ISO-8859-1 or windows-1252, to UTF-8.
+func newXMLDecoder(b []byte) *xml.Decoder {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	d.CharsetReader = charset.NewReaderLabel
+	return d
+}
+
+type rssDoc struct {
+	Channel struct {
+		Title string `xml:"title"`
+		// atom:link elements share the local name of the channel link
+		Links []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:"link"`
+		Description   string `xml:"description"`
+		LastBuildDate string `xml:"lastBuildDate"`
+		PubDate       string `xml:"pubDate"`
+		Items         []struct {
+			Title       string   `xml:"title"`
+			Link        string   `xml:"link"`
+			GUID        string   `xml:"guid"`
+			Author      string   `xml:"author"`
+			Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			Categories  []string `xml:"category"`
+			PubDate     string   `xml:"pubDate"`
+			Description string   `xml:"description"`
+			Encoded     string   `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+func parseRSS(b []byte) (*Feed, error) {
+	var doc rssDoc
+	if err := newXMLDecoder(b).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	f := &Feed{
+		Title:       strings.TrimSpace(doc.Channel.Title),
+		Description: strings.TrimSpace(doc.Channel.Description),
+		Updated:     parseTime(doc.Channel.LastBuildDate, doc.Channel.PubDate),
+	}
+	for _, l := range doc.Channel.Links {
+		if l.XMLName.Space == "" {
+			f.Link = strings.TrimSpace(l.Value)
+		}
+	}
+
+	for _, ri := range doc.Channel.Items {
+		item := Item{
+			ID:        strings.TrimSpace(ri.GUID),
+			URL:       strings.TrimSpace(ri.Link),
+			Title:     strings.TrimSpace(ri.Title),
+			Author:    strings.TrimSpace(ri.Creator),
+			Tags:      trimAll(ri.Categories),
+			Published: parseTime(ri.PubDate),
+			Content:   strings.TrimSpace(ri.Description),
+		}
+		if item.Author == "" {
+			item.Author = strings.TrimSpace(ri.Author)
+		}
+		if ri.Encoded != "" {
+			item.Content = strings.TrimSpace(ri.Encoded)
+			item.Summary = content.Text(ri.Description)
+		}
+		item.Updated = item.Published
+
+		f.Items = append(f.Items, item)
+	}
+
+	return f, nil
+}
+
+type atomDoc struct {
+	Title    string         `xml:"http://www.w3.org/2005/Atom title"`
+	Subtitle string         `xml:"http://www.w3.org/2005/Atom subtitle"`
+	Updated  string         `xml:"http://www.w3.org/2005/Atom updated"`
+	Links    []atomLink     `xml:"http://www.w3.org/2005/Atom link"`
+	Author   atomAuthor     `xml:"http://www.w3.org/2005/Atom author"`
+	Entries  []atomEntryDoc `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+type atomEntryDoc struct {
+	Title      string         `xml:"http://www.w3.org/2005/Atom title"`
+	ID         string         `xml:"http://www.w3.org/2005/Atom id"`
+	Links      []atomLink     `xml:"http://www.w3.org/2005/Atom link"`
+	Published  string         `xml:"http://www.w3.org/2005/Atom published"`
+	Updated    string         `xml:"http://www.w3.org/2005/Atom updated"`
+	Author     atomAuthor     `xml:"http://www.w3.org/2005/Atom author"`
+	Categories []atomCategory `xml:"http://www.w3.org/2005/Atom category"`
+	Summary    atomTextDoc    `xml:"http://www.w3.org/2005/Atom summary"`
+	Content    atomTextDoc    `xml:"http://www.w3.org/2005/Atom content"`
+}
+
+type atomTextDoc struct {
+	Type  string `xml:"type,attr"`
+	Body  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// html returns the text construct as HTML, or an empty string for plain text.
+func (t atomTextDoc) html() string {
+	switch t.Type {
+	case "html":
+		return strings.TrimSpace(t.Body)
+	case "xhtml":
+		return strings.TrimSpace(t.Inner)
+	}
+	return ""
+}
+
+// text returns the text construct as plain text.
+func (t atomTextDoc) text() string {
+	switch t.Type {
+	case "html", "xhtml":
+		return content.Text(t.html())
+	}
+	return strings.TrimSpace(t.Body)
+}
+
+func parseAtom(b []byte) (*Feed, error) {
+	var doc atomDoc
+	if err := newXMLDecoder(b).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	f := &Feed{
+		Title:       strings.TrimSpace(doc.Title),
+		Description: strings.TrimSpace(doc.Subtitle),
+		Link:        alternateLink(doc.Links),
+		Updated:     parseTime(doc.Updated),
+	}
+	for _, l := range doc.Links {
+		if l.Rel == "self" {
+			f.FeedURL = l.Href
+		}
+	}
+
+	for _, e := range doc.Entries {
+		item := Item{
+			ID:        strings.TrimSpace(e.ID),
+			URL:       alternateLink(e.Links),
+			Title:     strings.TrimSpace(e.Title),
+			Author:    strings.TrimSpace(e.Author.Name),
+			Published: parseTime(e.Published, e.Updated),
+			Updated:   parseTime(e.Updated, e.Published),
+			Content:   e.Content.html(),
+			Summary:   e.Summary.text(),
+		}
+		if item.Author == "" {
+			item.Author = strings.TrimSpace(doc.Author.Name)
+		}
+		if item.Content == "" && e.Content.Body != "" {
+			item.Summary = e.Content.text()
+		}
+		for _, c := range e.Categories {
+			item.Tags = append(item.Tags, c.Term)
+		}
+		item.Tags = trimAll(item.Tags)
+
+		f.Items = append(f.Items, item)
+	}
+
+	return f, nil
+}
+
+func alternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+type jsonFeedParseDoc struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	HomePageURL string `json:"home_page_url"`
+	FeedURL     string `json:"feed_url"`
+	Items       []struct {
+		ID            json.RawMessage  `json:"id"`
+		URL           string           `json:"url"`
+		Title         string           `json:"title"`
+		ContentHTML   string           `json:"content_html"`
+		ContentText   string           `json:"content_text"`
+		Summary       string           `json:"summary"`
+		DatePublished string           `json:"date_published"`
+		DateModified  string           `json:"date_modified"`
+		Author        *jsonFeedAuthor  `json:"author"`
+		Authors       []jsonFeedAuthor `json:"authors"`
+		Tags          []string         `json:"tags"`
+	} `json:"items"`
+}
+
+func parseJSON(b []byte) (*Feed, error) {
+	var doc jsonFeedParseDoc
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+
+	f := &Feed{
+		Title:       strings.TrimSpace(doc.Title),
+		Description: strings.TrimSpace(doc.Description),
+		Link:        doc.HomePageURL,
+		FeedURL:     doc.FeedURL,
+	}
+
+	for _, ji := range doc.Items {
+		item := Item{
+			ID:        jsonID(ji.ID),
+			URL:       ji.URL,
+			Title:     strings.TrimSpace(ji.Title),
+			Tags:      trimAll(ji.Tags),
+			Published: parseTime(ji.DatePublished, ji.DateModified),
+			Updated:   parseTime(ji.DateModified, ji.DatePublished),
+			Content:   strings.TrimSpace(ji.ContentHTML),
+			Summary:   strings.TrimSpace(ji.Summary),
+		}
+
+		// JSON Feed 1.1 replaced author with authors
+		switch {
+		case len(ji.Authors) > 0:
+			item.Author = ji.Authors[0].Name
+		case ji.Author != nil:
+			item.Author = ji.Author.Name
+		}
+		if item.Content == "" && ji.ContentText != "" {
+			item.Summary = strings.TrimSpace(ji.ContentText)
+		}
+		if item.Updated.After(f.Updated) {
+			f.Updated = item.Updated
+		}
+
+		f.Items = append(f.Items, item)
+	}
+
+	return f, nil
+}
+
+// jsonID returns a JSON Feed item id, which some feeds publish as a number instead of a string.
+func jsonID(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// parseTime returns the first of values which parses as a date, or the zero time.
+func parseTime(values ...string) time.Time {
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t.UTC()
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func trimAll(values []string) []string {
+	var out []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	DCNS    string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	AtomLink      rssLink   `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	Creator     string   `xml:"dc:creator"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func rss(f *Feed) *rssFeed {
+	r := &rssFeed{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Description,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			AtomLink:      rssLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+
+	for _, item := range f.Items {
+		description := item.Content
+		if description == "" {
+			description = item.Summary
+		}
+
+		r.Channel.Items = append(r.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        rssGUID{IsPermaLink: item.ID == item.URL, Value: item.ID},
+			Creator:     item.Author,
+			Categories:  item.Tags,
+			PubDate:     item.Published.UTC().Format(time.RFC1123Z),
+			Description: description,
+		})
+	}
+
+	return r
+}
@@ -8,14 +8,23 @@ require (
 	github.com/go-chi/render v1.0.1
 	github.com/go-ozzo/ozzo-validation v3.5.0+incompatible
 	github.com/go-pg/pg v7.1.5+incompatible
+	github.com/golang/protobuf v1.3.3
+	github.com/graphql-go/graphql v0.7.9
 	github.com/lib/pq v1.0.0
 	github.com/mitchellh/go-homedir v1.1.0
+	github.com/oklog/ulid v1.3.1
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/romanyx/polluter v1.2.2
 	github.com/sirupsen/logrus v1.3.0
+	github.com/soheilhy/cmux v0.1.4
 	github.com/spf13/cobra v0.0.5
 	github.com/spf13/viper v1.4.0
 	github.com/stretchr/testify v1.3.0
+	github.com/yuin/goldmark v1.2.1
+	golang.org/x/net v0.0.0-20190620200207-3b0461eec859
 	golang.org/x/tools/gopls v0.1.7 // indirect
+	google.golang.org/grpc v1.28.1
+	gopkg.in/yaml.v2 v2.2.2
 )
 
 go 1.13
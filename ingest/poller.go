@@ -0,0 +1,281 @@
+// Package ingest polls subscribed RSS, Atom and JSON feeds and imports their entries as articles.
+package ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	"github.com/ykaseng/articles-library/content"
+	"github.com/ykaseng/articles-library/feed"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// maxBodySize limits the size of a fetched feed document.
+const maxBodySize = 10 << 20
+
+// titleLength is the length of titles made up from the content of untitled entries.
+const titleLength = 80
+
+// The list of error types returned from poller.
+var (
+	ErrFeedTooLarge = errors.New("feed document exceeds 10MB")
+)
+
+// SourceStore defines database operations for feed ingestion.
+type SourceStore interface {
+	Due(limit int, lease time.Duration) (*[]models.Source, error)
+	UpdateState(*models.Source) error
+	Ingest(sourceID int, entries []models.SourceEntry) (int, error)
+}
+
+// Poller fetches due sources and creates articles from their new entries.
+type Poller struct {
+	Store  SourceStore
+	Client *http.Client
+	Logger logrus.FieldLogger
+
+	// Interval is the delay between polls of a healthy source.
+	Interval time.Duration
+	// MaxBackoff caps the exponentially growing delay after consecutive failed polls.
+	MaxBackoff time.Duration
+	// Tick is how often Run checks for due sources.
+	Tick time.Duration
+	// BatchSize is the number of due sources claimed at once.
+	BatchSize int
+}
+
+// NewPoller creates and returns a poller configured from viper.
+func NewPoller(store SourceStore, logger logrus.FieldLogger) *Poller {
+	p := &Poller{
+		Store:      store,
+		Client:     &http.Client{Timeout: viper.GetDuration("source_fetch_timeout")},
+		Logger:     logger,
+		Interval:   viper.GetDuration("source_poll_interval"),
+		MaxBackoff: viper.GetDuration("source_max_backoff"),
+		Tick:       time.Minute,
+		BatchSize:  10,
+	}
+
+	if p.Client.Timeout <= 0 {
+		p.Client.Timeout = 30 * time.Second
+	}
+	if p.Interval <= 0 {
+		p.Interval = 30 * time.Minute
+	}
+	if p.MaxBackoff < p.Interval {
+		p.MaxBackoff = 24 * time.Hour
+	}
+
+	return p
+}
+
+// Run polls due sources every Tick until ctx is done.
+func (p *Poller) Run(ctx context.Context) {
+	t := time.NewTicker(p.Tick)
+	defer t.Stop()
+
+	for {
+		if _, err := p.PollDue(); err != nil {
+			p.Logger.WithField("module", "ingest").Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// PollDue polls all sources which are due and returns the number of created articles. Failures of
+// single sources are logged and recorded on the source.
+func (p *Poller) PollDue() (int, error) {
+	// a claimed source is not handed to another poller until its fetch has timed out
+	lease := 2 * p.Client.Timeout
+
+	var created int
+	for {
+		sources, err := p.Store.Due(p.BatchSize, lease)
+		if err != nil {
+			return created, err
+		}
+		if len(*sources) == 0 {
+			return created, nil
+		}
+
+		for i := range *sources {
+			src := &(*sources)[i]
+			n, err := p.Poll(src)
+			created += n
+			if err != nil {
+				p.Logger.WithFields(logrus.Fields{"module": "ingest", "source_id": src.ID, "failures": src.Failures}).Warn(err)
+			}
+		}
+	}
+}
+
+// Poll fetches a source, creates articles from entries not seen before and schedules the next
+// poll. It returns the number of created articles.
+func (p *Poller) Poll(src *models.Source) (int, error) {
+	created, err := p.fetch(src)
+
+	now := time.Now()
+	src.LastPolledAt = &now
+	if err != nil {
+		src.Failures++
+		src.LastError = err.Error()
+		src.NextPollAt = now.Add(p.backoff(src.Failures))
+	} else {
+		src.Failures = 0
+		src.LastError = ""
+		src.NextPollAt = now.Add(p.Interval)
+	}
+
+	if uerr := p.Store.UpdateState(src); uerr != nil && err == nil {
+		err = uerr
+	}
+
+	return created, err
+}
+
+// backoff returns the delay before the next poll after failures consecutive failed polls.
+func (p *Poller) backoff(failures int) time.Duration {
+	d := p.Interval
+	for i := 0; i < failures && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+func (p *Poller) fetch(src *models.Source) (int, error) {
+	req, err := http.NewRequest(http.MethodGet, src.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/atom+xml, application/rss+xml, application/feed+json, application/json;q=0.9, application/xml;q=0.8, */*;q=0.5")
+	req.Header.Set("User-Agent", "articles-library")
+	if src.ETag != "" {
+		req.Header.Set("If-None-Match", src.ETag)
+	}
+	if src.LastModified != "" {
+		req.Header.Set("If-Modified-Since", src.LastModified)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return 0, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return 0, err
+	}
+	if len(b) > maxBodySize {
+		return 0, ErrFeedTooLarge
+	}
+
+	f, err := feed.Parse(b)
+	if err != nil {
+		return 0, err
+	}
+
+	created, err := p.Store.Ingest(src.ID, p.entries(src, f))
+	if err != nil {
+		return 0, err
+	}
+
+	// validators are only kept once the entries are stored, so a failed ingest refetches the feed
+	src.ETag = resp.Header.Get("ETag")
+	src.LastModified = resp.Header.Get("Last-Modified")
+	if f.Title != "" {
+		src.Title = f.Title
+	}
+
+	return created, nil
+}
+
+// entries converts feed items to articles, skipping items which do not make a valid article.
+func (p *Poller) entries(src *models.Source, f *feed.Feed) []models.SourceEntry {
+	var entries []models.SourceEntry
+	for _, item := range f.Items {
+		a := models.Article{
+			Title:         item.Title,
+			Content:       item.Content,
+			ContentFormat: content.FormatHTML,
+			Author:        item.Author,
+			Tags:          item.Tags,
+		}
+
+		if a.Content == "" {
+			a.Content = item.Summary
+			a.ContentFormat = content.FormatPlain
+		}
+		if a.Content == "" && item.URL != "" {
+			a.Content = item.URL
+			a.ContentFormat = content.FormatPlain
+		}
+
+		if a.Title == "" {
+			text := a.Content
+			if a.ContentFormat == content.FormatPlain {
+				text = html.EscapeString(text)
+			}
+			a.Title = content.Excerpt(text, titleLength)
+		}
+
+		if a.Author == "" {
+			a.Author = f.Title
+		}
+		if a.Author == "" {
+			if u, err := url.Parse(src.URL); err == nil {
+				a.Author = u.Host
+			}
+		}
+
+		if !item.Published.IsZero() {
+			published := item.Published
+			a.PublishedAt = &published
+		}
+
+		guid := item.ID
+		if guid == "" {
+			guid = item.URL
+		}
+		if guid == "" {
+			sum := sha256.Sum256([]byte(item.Title + "\n" + a.Content))
+			guid = hex.EncodeToString(sum[:])
+		}
+
+		if err := a.Validate(); err != nil {
+			p.Logger.WithFields(logrus.Fields{"module": "ingest", "source_id": src.ID, "guid": guid}).Warn(err)
+			continue
+		}
+
+		entries = append(entries, models.SourceEntry{GUID: guid, Article: a})
+	}
+
+	return entries
+}
@@ -0,0 +1,265 @@
+package ingest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/content"
+	"github.com/ykaseng/articles-library/models"
+)
+
+type memorySourceStore struct {
+	mu       sync.Mutex
+	sources  map[int]*models.Source
+	guids    map[string]bool
+	articles []models.Article
+}
+
+func newMemorySourceStore(sources ...models.Source) *memorySourceStore {
+	s := &memorySourceStore{sources: map[int]*models.Source{}, guids: map[string]bool{}}
+	for i := range sources {
+		s.sources[sources[i].ID] = &sources[i]
+	}
+	return s
+}
+
+func (s *memorySourceStore) Due(limit int, lease time.Duration) (*[]models.Source, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []models.Source
+	for _, src := range s.sources {
+		if len(due) < limit && !src.NextPollAt.After(time.Now()) {
+			src.NextPollAt = time.Now().Add(lease)
+			due = append(due, *src)
+		}
+	}
+	return &due, nil
+}
+
+func (s *memorySourceStore) UpdateState(src *models.Source) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *src
+	s.sources[src.ID] = &stored
+	return nil
+}
+
+func (s *memorySourceStore) Ingest(sourceID int, entries []models.SourceEntry) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var created int
+	for _, e := range entries {
+		if s.guids[e.GUID] {
+			continue
+		}
+		s.guids[e.GUID] = true
+		s.articles = append(s.articles, e.Article)
+		created++
+	}
+	return created, nil
+}
+
+// fixtureServer serves testdata files with a fixed ETag and Last-Modified and counts requests.
+func fixtureServer(t *testing.T, status *int) (*httptest.Server, *int) {
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if status != nil && *status != http.StatusOK {
+			w.WriteHeader(*status)
+			return
+		}
+
+		modified := time.Date(2019, 10, 2, 9, 30, 0, 0, time.UTC)
+		w.Header().Set("ETag", `"`+r.URL.Path+`"`)
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		if r.Header.Get("If-None-Match") == `"`+r.URL.Path+`"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		b, err := ioutil.ReadFile("testdata" + r.URL.Path)
+		if err != nil {
+			t.Errorf("read fixture failed: %v", err)
+		}
+		w.Write(b)
+	})
+
+	return httptest.NewServer(mux), &requests
+}
+
+func newTestPoller(store SourceStore) *Poller {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	p := NewPoller(store, logger)
+	p.Interval = time.Minute
+	p.MaxBackoff = 10 * time.Minute
+	return p
+}
+
+func TestPoll(t *testing.T) {
+	published := time.Date(2019, 10, 1, 9, 30, 0, 0, time.UTC)
+
+	tt := []struct {
+		name     string
+		fixture  string
+		title    string
+		expected []models.Article
+	}{
+		{
+			name:    "rss",
+			fixture: "/rss.xml",
+			title:   "Test RSS Feed",
+			expected: []models.Article{
+				{Title: "Test Title", Content: "<p>Test <em>Content</em></p>", ContentFormat: content.FormatHTML, Author: "Test Author", Tags: []string{"go", "feeds"}, PublishedAt: &published},
+				{Title: "Another Test Title", Content: "<p>Another Test Content</p>", ContentFormat: content.FormatHTML, Author: "Test RSS Feed", PublishedAt: timePtr(published.Add(24 * time.Hour))},
+			},
+		},
+		{
+			name:    "atom",
+			fixture: "/atom.xml",
+			title:   "Test Atom Feed",
+			expected: []models.Article{
+				{Title: "Test Title", Content: "<p>Test <em>Content</em></p>", ContentFormat: content.FormatHTML, Author: "Test Author", Tags: []string{"go"}, PublishedAt: &published},
+				{Title: "Another Test Title", Content: "Another Test Content", ContentFormat: content.FormatPlain, Author: "Another Test Author", PublishedAt: timePtr(published.Add(24 * time.Hour))},
+			},
+		},
+		{
+			name:    "json feed",
+			fixture: "/feed.json",
+			title:   "Test JSON Feed",
+			expected: []models.Article{
+				{Title: "Test Title", Content: "<p>Test <em>Content</em></p>", ContentFormat: content.FormatHTML, Author: "Test Author", Tags: []string{"go"}, PublishedAt: &published},
+				{Title: "Untitled Test Content", Content: "Untitled Test Content", ContentFormat: content.FormatPlain, Author: "Test JSON Feed", PublishedAt: timePtr(published.Add(24 * time.Hour))},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, requests := fixtureServer(t, nil)
+			defer srv.Close()
+			store := newMemorySourceStore()
+			p := newTestPoller(store)
+			src := &models.Source{ID: 1, URL: srv.URL + tc.fixture}
+
+			created, err := p.Poll(src)
+			if err != nil {
+				t.Fatalf("poll failed: %v", err)
+			}
+
+			assert.Equal(t, len(tc.expected), created)
+			assert.Equal(t, tc.expected, store.articles)
+			assert.Equal(t, tc.title, src.Title)
+			assert.Equal(t, `"`+tc.fixture+`"`, src.ETag)
+			assert.Equal(t, "Wed, 02 Oct 2019 09:30:00 GMT", src.LastModified)
+			assert.Equal(t, 0, src.Failures)
+			assert.WithinDuration(t, time.Now().Add(p.Interval), src.NextPollAt, time.Second)
+
+			// the second poll revalidates with the stored ETag and creates nothing
+			created, err = p.Poll(src)
+			if err != nil {
+				t.Fatalf("poll failed: %v", err)
+			}
+			assert.Equal(t, 0, created)
+			assert.Equal(t, 2, *requests)
+			assert.Len(t, store.articles, len(tc.expected))
+		})
+	}
+}
+
+func TestPollDeduplicatesByGUID(t *testing.T) {
+	srv, _ := fixtureServer(t, nil)
+	defer srv.Close()
+	store := newMemorySourceStore()
+	p := newTestPoller(store)
+	src := &models.Source{ID: 1, URL: srv.URL + "/rss.xml"}
+
+	for i := 0; i < 2; i++ {
+		// dropping the validators forces a full fetch of the unchanged feed
+		src.ETag, src.LastModified = "", ""
+		if _, err := p.Poll(src); err != nil {
+			t.Fatalf("poll failed: %v", err)
+		}
+	}
+
+	assert.Len(t, store.articles, 2)
+	assert.True(t, store.guids["rss-1"])
+	assert.True(t, store.guids["http://example.com/posts/2"])
+}
+
+func TestPollBackoff(t *testing.T) {
+	status := http.StatusInternalServerError
+	srv, _ := fixtureServer(t, &status)
+	defer srv.Close()
+	store := newMemorySourceStore()
+	p := newTestPoller(store)
+	src := &models.Source{ID: 1, URL: srv.URL + "/rss.xml"}
+
+	for _, expected := range []time.Duration{2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute} {
+		_, err := p.Poll(src)
+		assert.EqualError(t, err, "unexpected status 500 Internal Server Error")
+		assert.Equal(t, "unexpected status 500 Internal Server Error", src.LastError)
+		assert.WithinDuration(t, time.Now().Add(expected), src.NextPollAt, time.Second)
+	}
+	assert.Equal(t, 5, src.Failures)
+
+	status = http.StatusOK
+	if _, err := p.Poll(src); err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	assert.Equal(t, 0, src.Failures)
+	assert.Empty(t, src.LastError)
+	assert.Equal(t, src.Failures, store.sources[1].Failures)
+}
+
+func TestPollInvalidFeed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>Not a feed</body></html>"))
+	}))
+	defer srv.Close()
+
+	p := newTestPoller(newMemorySourceStore())
+	src := &models.Source{ID: 1, URL: srv.URL}
+
+	_, err := p.Poll(src)
+	assert.Error(t, err)
+	assert.Equal(t, 1, src.Failures)
+}
+
+func TestPollDue(t *testing.T) {
+	srv, requests := fixtureServer(t, nil)
+	defer srv.Close()
+	store := newMemorySourceStore(
+		models.Source{ID: 1, URL: srv.URL + "/rss.xml"},
+		models.Source{ID: 2, URL: srv.URL + "/feed.json"},
+		models.Source{ID: 3, URL: srv.URL + "/atom.xml", NextPollAt: time.Now().Add(time.Hour)},
+	)
+	p := newTestPoller(store)
+	p.BatchSize = 1
+
+	created, err := p.PollDue()
+	if err != nil {
+		t.Fatalf("poll due failed: %v", err)
+	}
+
+	assert.Equal(t, 4, created)
+	assert.Equal(t, 2, *requests)
+	assert.Equal(t, "Test RSS Feed", store.sources[1].Title)
+	assert.Equal(t, "Test JSON Feed", store.sources[2].Title)
+	assert.Empty(t, store.sources[3].Title)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
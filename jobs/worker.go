@@ -0,0 +1,381 @@
+// Package jobs runs background work from a Postgres backed queue. Jobs are claimed with
+// FOR UPDATE SKIP LOCKED, so any number of workers on any number of servers share one queue.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// defaultMaxAttempts is the number of attempts of a job enqueued without MaxAttempts.
+const defaultMaxAttempts = 5
+
+// Args holds the arguments of a job, which are stored as JSON. Kind names the handler of the job.
+type Args interface {
+	Kind() string
+}
+
+// Handler runs a job. args is a pointer to a new value of the type registered for the kind of the
+// job, decoded from the stored arguments. A returned error fails the attempt.
+type Handler func(ctx context.Context, args Args) error
+
+// Store defines database operations for the job queue.
+type Store interface {
+	Enqueue(*models.Job) (bool, error)
+	Claim(kinds []string, lease time.Duration) (*models.Job, error)
+	Extend(job *models.Job, lease time.Duration) (bool, error)
+	UpdateState(*models.Job) error
+}
+
+// Options controls when and how often an enqueued job runs.
+type Options struct {
+	// RunAt delays the job, the zero time runs it as soon as possible.
+	RunAt time.Time
+	// MaxAttempts is the number of failed attempts after which the job is dead, it defaults to 5.
+	MaxAttempts int
+	// UniqueKey skips enqueueing the job while another job with the same key is pending or running.
+	UniqueKey string
+}
+
+// NewJob returns a job with args which is not enqueued yet, for stores which enqueue jobs in a
+// transaction of their own.
+func NewJob(args Args, opts Options) (*models.Job, error) {
+	b, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.Job{
+		Kind:        args.Kind(),
+		Args:        b,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+		UniqueKey:   opts.UniqueKey,
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = defaultMaxAttempts
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+
+	return job, nil
+}
+
+// Enqueue adds a job to the queue and reports whether it was added, which is only false for a
+// job whose unique key is in use.
+func Enqueue(store Store, args Args, opts Options) (*models.Job, bool, error) {
+	job, err := NewJob(args, opts)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ok, err := store.Enqueue(job)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return job, ok, nil
+}
+
+type handler struct {
+	args    reflect.Type
+	fn      Handler
+	timeout time.Duration
+}
+
+type schedule struct {
+	spec     string
+	schedule cron.Schedule
+	args     Args
+	// next is the run time of the last enqueued job
+	next time.Time
+}
+
+// Worker runs the jobs of its registered kinds with a pool of goroutines.
+type Worker struct {
+	Store  Store
+	Logger logrus.FieldLogger
+
+	// Concurrency is the number of jobs run at the same time.
+	Concurrency int
+	// Poll is how often idle goroutines check for due jobs and scheduled jobs are enqueued.
+	Poll time.Duration
+	// Lease is how long a claimed job stays locked. It is extended every third of the lease while
+	// the job runs, so the jobs of a worker which stopped are claimed again once their lease expired.
+	Lease time.Duration
+	// Timeout is the maximum run time of an attempt of a job registered without a timeout.
+	Timeout time.Duration
+	// Backoff is the delay after the first failed attempt, it doubles with each further failure
+	// up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	handlers  map[string]handler
+	schedules []*schedule
+}
+
+// NewWorker creates and returns a worker configured from viper.
+func NewWorker(store Store, logger logrus.FieldLogger) *Worker {
+	w := &Worker{
+		Store:       store,
+		Logger:      logger,
+		Concurrency: viper.GetInt("jobs_concurrency"),
+		Poll:        viper.GetDuration("jobs_poll_interval"),
+		Lease:       viper.GetDuration("jobs_lease"),
+		Timeout:     viper.GetDuration("jobs_timeout"),
+		Backoff:     viper.GetDuration("jobs_backoff"),
+		MaxBackoff:  viper.GetDuration("jobs_max_backoff"),
+		handlers:    make(map[string]handler),
+	}
+
+	if w.Concurrency <= 0 {
+		w.Concurrency = 4
+	}
+	if w.Poll <= 0 {
+		w.Poll = time.Second
+	}
+	if w.Lease <= 0 {
+		w.Lease = time.Minute
+	}
+	if w.Timeout <= 0 {
+		w.Timeout = 5 * time.Minute
+	}
+	if w.Backoff <= 0 {
+		w.Backoff = 10 * time.Second
+	}
+	if w.MaxBackoff < w.Backoff {
+		w.MaxBackoff = time.Hour
+	}
+
+	return w
+}
+
+// Register sets the handler of the jobs of the kind of args. Registering a kind twice panics.
+func (w *Worker) Register(args Args, fn Handler) {
+	w.RegisterTimeout(args, 0, fn)
+}
+
+// RegisterTimeout sets the handler of the jobs of the kind of args like Register, with a maximum
+// run time of an attempt other than the Timeout of the worker.
+func (w *Worker) RegisterTimeout(args Args, timeout time.Duration, fn Handler) {
+	kind := args.Kind()
+	if _, ok := w.handlers[kind]; ok {
+		panic(fmt.Sprintf("jobs: handler of %s registered twice", kind))
+	}
+
+	t := reflect.TypeOf(args)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	w.handlers[kind] = handler{args: t, fn: fn, timeout: timeout}
+}
+
+// Schedule enqueues a job with args at the times of a cron spec, such as "0 3 * * *" or "@every 1h".
+// Every run is enqueued with a unique key, so workers on several servers enqueue it once.
+func (w *Worker) Schedule(spec string, args Args) error {
+	s, err := cron.ParseStandard(spec)
+	if err != nil {
+		return err
+	}
+
+	w.schedules = append(w.schedules, &schedule{spec: spec, schedule: s, args: args})
+	return nil
+}
+
+// Run claims and runs jobs until ctx is done, then waits for running jobs to finish.
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < w.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx, func() {
+				for {
+					ok, err := w.Work()
+					if err != nil {
+						w.Logger.WithField("module", "jobs").Error(err)
+					}
+					if !ok || ctx.Err() != nil {
+						return
+					}
+				}
+			})
+		}()
+	}
+
+	if len(w.schedules) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx, func() {
+				if err := w.EnqueueScheduled(time.Now()); err != nil {
+					w.Logger.WithField("module", "jobs").Error(err)
+				}
+			})
+		}()
+	}
+
+	wg.Wait()
+}
+
+// loop calls fn every Poll until ctx is done.
+func (w *Worker) loop(ctx context.Context, fn func()) {
+	t := time.NewTicker(w.Poll)
+	defer t.Stop()
+
+	for {
+		fn()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// EnqueueScheduled enqueues the next run after now of every schedule which is not enqueued yet.
+func (w *Worker) EnqueueScheduled(now time.Time) error {
+	for _, s := range w.schedules {
+		next := s.schedule.Next(now)
+		if next.Equal(s.next) {
+			continue
+		}
+
+		key := fmt.Sprintf("cron:%s:%s:%d", s.args.Kind(), s.spec, next.Unix())
+		if _, _, err := Enqueue(w.Store, s.args, Options{RunAt: next, UniqueKey: key}); err != nil {
+			return err
+		}
+		s.next = next
+	}
+
+	return nil
+}
+
+// Work claims and runs one due job and reports whether there was a job to run.
+func (w *Worker) Work() (bool, error) {
+	kinds := make([]string, 0, len(w.handlers))
+	for kind := range w.handlers {
+		kinds = append(kinds, kind)
+	}
+
+	job, err := w.Store.Claim(kinds, w.Lease)
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	err = w.run(job)
+
+	now := time.Now()
+	job.LockedUntil = nil
+	if err != nil {
+		job.LastError = err.Error()
+		if job.Attempts >= job.MaxAttempts {
+			job.Status = models.JobDead
+			job.FinishedAt = &now
+		} else {
+			job.Status = models.JobPending
+			job.RunAt = now.Add(w.backoff(job.Attempts))
+		}
+		w.Logger.WithFields(logrus.Fields{"module": "jobs", "job_id": job.ID, "kind": job.Kind, "attempts": job.Attempts, "status": job.Status}).Warn(err)
+	} else {
+		job.Status = models.JobCompleted
+		job.LastError = ""
+		job.FinishedAt = &now
+	}
+
+	return true, w.Store.UpdateState(job)
+}
+
+// run decodes the arguments of a job and calls its handler within its timeout, extending the lease
+// while it runs. Panics fail the attempt.
+func (w *Worker) run(job *models.Job) (err error) {
+	h, ok := w.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("no handler registered for %s", job.Kind)
+	}
+
+	args := reflect.New(h.args).Interface().(Args)
+	if err := json.Unmarshal(job.Args, args); err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	timeout := h.timeout
+	if timeout <= 0 {
+		timeout = w.Timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	stop := w.heartbeat(job, cancel)
+	defer stop()
+
+	return h.fn(ctx, args)
+}
+
+// heartbeat extends the lease of a running job until stop is called. It cancels the attempt once
+// the job has been claimed again, since it then runs on another worker.
+func (w *Worker) heartbeat(job *models.Job, cancel context.CancelFunc) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		t := time.NewTicker(w.Lease / 3)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+			}
+
+			ok, err := w.Store.Extend(job, w.Lease)
+			if err != nil {
+				w.Logger.WithFields(logrus.Fields{"module": "jobs", "job_id": job.ID, "kind": job.Kind}).Error(err)
+				continue
+			}
+			if !ok {
+				w.Logger.WithFields(logrus.Fields{"module": "jobs", "job_id": job.ID, "kind": job.Kind}).Warn("lease lost to another worker")
+				cancel()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// backoff returns the delay before the next attempt after attempts failed attempts.
+func (w *Worker) backoff(attempts int) time.Duration {
+	b := w.Backoff
+	for i := 1; i < attempts && b < w.MaxBackoff; i++ {
+		b *= 2
+	}
+	if b > w.MaxBackoff {
+		b = w.MaxBackoff
+	}
+	return b
+}
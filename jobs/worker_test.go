@@ -0,0 +1,342 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	jobs    []*models.Job
+	extends int
+}
+
+func (s *memoryStore) Enqueue(job *models.Job) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		if job.UniqueKey != "" && j.UniqueKey == job.UniqueKey && (j.Status == models.JobPending || j.Status == models.JobRunning) {
+			return false, nil
+		}
+	}
+
+	job.ID = int64(len(s.jobs) + 1)
+	job.Status = models.JobPending
+	stored := *job
+	s.jobs = append(s.jobs, &stored)
+	return true, nil
+}
+
+func (s *memoryStore) Claim(kinds []string, lease time.Duration) (*models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, j := range s.jobs {
+		if j.Status != models.JobPending || j.RunAt.After(time.Now()) {
+			continue
+		}
+		for _, kind := range kinds {
+			if j.Kind == kind {
+				locked := time.Now().Add(lease)
+				j.Status = models.JobRunning
+				j.Attempts++
+				j.LockedUntil = &locked
+				claimed := *j
+				return &claimed, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (s *memoryStore) Extend(job *models.Job, lease time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j := s.jobs[job.ID-1]
+	if j.Status != models.JobRunning || j.Attempts != job.Attempts {
+		return false, nil
+	}
+	locked := time.Now().Add(lease)
+	j.LockedUntil = &locked
+	s.extends++
+	return true, nil
+}
+
+func (s *memoryStore) UpdateState(job *models.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *job
+	s.jobs[job.ID-1] = &stored
+	return nil
+}
+
+type greet struct {
+	Name string `json:"name"`
+}
+
+func (greet) Kind() string { return "greet" }
+
+func newTestWorker(store Store) *Worker {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	w := NewWorker(store, logger)
+	w.Backoff = time.Minute
+	w.MaxBackoff = 3 * time.Minute
+	return w
+}
+
+func TestEnqueue(t *testing.T) {
+	store := &memoryStore{}
+
+	job, ok, err := Enqueue(store, greet{Name: "Test"}, Options{UniqueKey: "greet:test"})
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), job.ID)
+	assert.Equal(t, "greet", job.Kind)
+	assert.JSONEq(t, `{"name":"Test"}`, string(job.Args))
+	assert.Equal(t, defaultMaxAttempts, job.MaxAttempts)
+	assert.WithinDuration(t, time.Now(), job.RunAt, time.Second)
+
+	// a job with the same unique key is skipped while the first one is pending
+	_, ok, err = Enqueue(store, greet{Name: "Test"}, Options{UniqueKey: "greet:test"})
+	if err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	assert.False(t, ok)
+	assert.Len(t, store.jobs, 1)
+}
+
+func TestWork(t *testing.T) {
+	store := &memoryStore{}
+	w := newTestWorker(store)
+
+	var greeted []string
+	w.Register(greet{}, func(ctx context.Context, args Args) error {
+		greeted = append(greeted, args.(*greet).Name)
+		return nil
+	})
+
+	if _, _, err := Enqueue(store, greet{Name: "Test"}, Options{}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	if _, _, err := Enqueue(store, greet{Name: "Later"}, Options{RunAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	ok, err := w.Work()
+	if err != nil {
+		t.Fatalf("work failed: %v", err)
+	}
+	assert.True(t, ok)
+	assert.Equal(t, []string{"Test"}, greeted)
+	assert.Equal(t, models.JobCompleted, store.jobs[0].Status)
+	assert.Nil(t, store.jobs[0].LockedUntil)
+	assert.NotNil(t, store.jobs[0].FinishedAt)
+
+	// the second job is not due yet
+	ok, err = w.Work()
+	if err != nil {
+		t.Fatalf("work failed: %v", err)
+	}
+	assert.False(t, ok)
+	assert.Equal(t, models.JobPending, store.jobs[1].Status)
+}
+
+func TestWorkRetries(t *testing.T) {
+	tt := []struct {
+		name    string
+		handler Handler
+		err     string
+	}{
+		{
+			name:    "error",
+			handler: func(ctx context.Context, args Args) error { return errors.New("connection refused") },
+			err:     "connection refused",
+		},
+		{
+			name:    "panic",
+			handler: func(ctx context.Context, args Args) error { panic("nil map") },
+			err:     "panic: nil map",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &memoryStore{}
+			w := newTestWorker(store)
+			w.Register(greet{}, tc.handler)
+
+			if _, _, err := Enqueue(store, greet{}, Options{MaxAttempts: 4}); err != nil {
+				t.Fatalf("enqueue failed: %v", err)
+			}
+
+			for _, expected := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute} {
+				if _, err := w.Work(); err != nil {
+					t.Fatalf("work failed: %v", err)
+				}
+				assert.Equal(t, models.JobPending, store.jobs[0].Status)
+				assert.Equal(t, tc.err, store.jobs[0].LastError)
+				assert.WithinDuration(t, time.Now().Add(expected), store.jobs[0].RunAt, time.Second)
+
+				// make the retry due
+				store.jobs[0].RunAt = time.Now()
+			}
+
+			if _, err := w.Work(); err != nil {
+				t.Fatalf("work failed: %v", err)
+			}
+			assert.Equal(t, models.JobDead, store.jobs[0].Status)
+			assert.Equal(t, 4, store.jobs[0].Attempts)
+			assert.NotNil(t, store.jobs[0].FinishedAt)
+		})
+	}
+}
+
+func TestEnqueueScheduled(t *testing.T) {
+	store := &memoryStore{}
+	w := newTestWorker(store)
+	if err := w.Schedule("0 * * * *", greet{Name: "Hourly"}); err != nil {
+		t.Fatalf("schedule failed: %v", err)
+	}
+	assert.Error(t, w.Schedule("every hour", greet{}))
+
+	now := time.Date(2019, 10, 1, 9, 30, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		if err := w.EnqueueScheduled(now); err != nil {
+			t.Fatalf("enqueue scheduled failed: %v", err)
+		}
+	}
+
+	// another worker enqueues the same run once
+	other := newTestWorker(store)
+	other.Schedule("0 * * * *", greet{Name: "Hourly"})
+	if err := other.EnqueueScheduled(now); err != nil {
+		t.Fatalf("enqueue scheduled failed: %v", err)
+	}
+
+	assert.Len(t, store.jobs, 1)
+	assert.Equal(t, time.Date(2019, 10, 1, 10, 0, 0, 0, time.UTC), store.jobs[0].RunAt.UTC())
+	assert.JSONEq(t, `{"name":"Hourly"}`, string(store.jobs[0].Args))
+
+	if err := w.EnqueueScheduled(now.Add(time.Hour)); err != nil {
+		t.Fatalf("enqueue scheduled failed: %v", err)
+	}
+	assert.Len(t, store.jobs, 2)
+}
+
+func TestRun(t *testing.T) {
+	store := &memoryStore{}
+	w := newTestWorker(store)
+	w.Concurrency = 2
+	w.Poll = 10 * time.Millisecond
+
+	var mu sync.Mutex
+	ran := 0
+	w.Register(greet{}, func(ctx context.Context, args Args) error {
+		mu.Lock()
+		defer mu.Unlock()
+		ran++
+		return nil
+	})
+
+	for i := 0; i < 5; i++ {
+		Enqueue(store, greet{}, Options{})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w.Run(ctx)
+
+	assert.Equal(t, 5, ran)
+	for _, j := range store.jobs {
+		assert.Equal(t, models.JobCompleted, j.Status)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	tt := []struct {
+		name    string
+		claimed bool
+		err     string
+	}{
+		{
+			name: "lease is extended",
+			err:  "",
+		},
+		{
+			name:    "claimed again",
+			claimed: true,
+			err:     context.Canceled.Error(),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &memoryStore{}
+			w := newTestWorker(store)
+			w.Lease = 30 * time.Millisecond
+			w.RegisterTimeout(greet{}, time.Second, func(ctx context.Context, args Args) error {
+				if tc.claimed {
+					store.mu.Lock()
+					store.jobs[0].Attempts++
+					store.mu.Unlock()
+				}
+
+				// the attempt outlives the lease
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(100 * time.Millisecond):
+					return nil
+				}
+			})
+
+			if _, _, err := Enqueue(store, greet{}, Options{}); err != nil {
+				t.Fatalf("enqueue failed: %v", err)
+			}
+			if _, err := w.Work(); err != nil {
+				t.Fatalf("work failed: %v", err)
+			}
+
+			assert.Equal(t, tc.err, store.jobs[0].LastError)
+			if tc.claimed {
+				assert.Equal(t, 0, store.extends)
+			} else {
+				assert.Equal(t, models.JobCompleted, store.jobs[0].Status)
+				assert.True(t, store.extends >= 2, "extends: %d", store.extends)
+			}
+		})
+	}
+}
+
+func TestRegisterTimeout(t *testing.T) {
+	store := &memoryStore{}
+	w := newTestWorker(store)
+	w.RegisterTimeout(greet{}, 10*time.Millisecond, func(ctx context.Context, args Args) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if _, _, err := Enqueue(store, greet{}, Options{}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	if _, err := w.Work(); err != nil {
+		t.Fatalf("work failed: %v", err)
+	}
+	assert.Equal(t, context.DeadlineExceeded.Error(), store.jobs[0].LastError)
+	assert.Equal(t, models.JobPending, store.jobs[0].Status)
+}
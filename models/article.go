@@ -2,7 +2,13 @@
 package models
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	validation "github.com/go-ozzo/ozzo-validation"
+
+	"github.com/ykaseng/articles-library/content"
 )
 
 // Article holds specific application settings linked to an Article.
@@ -11,18 +17,118 @@ type Article struct {
 	Title   string `json:"title"`
 	Content string `json:"content"`
 	Author  string `json:"author"`
+
+	// Slug identifies the article in permalinks. It is derived from the title unless set.
+	Slug string `json:"slug,omitempty"`
+
+	// ContentFormat is one of plain, markdown or html, empty content is plain text.
+	ContentFormat string `json:"content_format,omitempty" openapi:"enum=plain|markdown|html"`
+
+	Tags        []string   `json:"tags,omitempty" pg:",array"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+
+	// CreatedAt and UpdatedAt are only loaded by queries which need them, such as feeds.
+	CreatedAt *time.Time `json:"created_at,omitempty" openapi:"readOnly"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty" openapi:"readOnly"`
 }
 
-// Validate validates Article struct and returns validation errors.
+// Validate validates Article struct and returns validation errors. HTML content is sanitized in
+// place so that only an allowlist of formatting markup is ever stored.
 func (a *Article) Validate() error {
+	if a.ContentFormat == content.FormatHTML {
+		a.Content = content.Sanitize(a.Content)
+	}
+
 	return validation.ValidateStruct(a,
 		validation.Field(&a.Title, validation.Required),
 		validation.Field(&a.Content, validation.Required),
+		validation.Field(&a.ContentFormat, validation.In(content.FormatPlain, content.FormatMarkdown, content.FormatHTML)),
 		validation.Field(&a.Author, validation.Required, validation.Length(1, 255)),
+		validation.Field(&a.Slug, validation.Length(1, 255), validation.By(slug)),
 	)
 }
 
-// ArticleID holds specific application settings linked to an ArticleID.
+// ArticleID holds specific application settings linked to an ArticleID. The serial ID is internal,
+// articles are identified by their PublicID outside the database.
 type ArticleID struct {
-	ID int `json:"id"`
+	ID       int      `json:"-"`
+	PublicID PublicID `json:"id" openapi:"readOnly"`
+}
+
+// ArticleFilter restricts a list of articles to an author name or a tag, empty fields match all articles.
+type ArticleFilter struct {
+	Author string `json:"author,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+}
+
+// ArticleFields lists the fields of articles which are loaded, in the order of ArticleFieldNames. A
+// nil list loads all fields.
+type ArticleFields []string
+
+// ArticleFieldNames are the fields of articles which can be selected.
+var ArticleFieldNames = []string{"id", "title", "content", "author", "slug", "content_format", "tags", "published_at"}
+
+// ParseArticleFields parses a comma separated list of field names, an empty list selects all fields.
+func ParseArticleFields(s string) (ArticleFields, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	selected := map[string]bool{}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if !contains(ArticleFieldNames, name) {
+			return nil, fmt.Errorf("unknown field %q, fields are %s", name, strings.Join(ArticleFieldNames, ", "))
+		}
+		selected[name] = true
+	}
+
+	fields := ArticleFields{}
+	for _, name := range ArticleFieldNames {
+		if selected[name] {
+			fields = append(fields, name)
+		}
+	}
+	return fields, nil
+}
+
+// Has reports whether a field is selected.
+func (f ArticleFields) Has(name string) bool {
+	return f == nil || contains(f, name)
+}
+
+// With returns the fields together with names, keeping the order of ArticleFieldNames.
+func (f ArticleFields) With(names ...string) ArticleFields {
+	if f == nil {
+		return nil
+	}
+
+	fields := ArticleFields{}
+	for _, name := range ArticleFieldNames {
+		if f.Has(name) || contains(names, name) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Author is an author together with the number of articles published under the name.
+type Author struct {
+	Name     string `json:"name"`
+	Articles int    `json:"articles"`
+}
+
+// Tag is a tag together with the number of articles tagged with it.
+type Tag struct {
+	Name     string `json:"name"`
+	Articles int    `json:"articles"`
 }
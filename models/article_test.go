@@ -18,6 +18,12 @@ func TestValidate(t *testing.T) {
 		{"article missing multiple fields", &Article{Title: "TestTitle"}, "author: cannot be blank; content: cannot be blank."},
 		{"article missing all fields", &Article{}, "author: cannot be blank; content: cannot be blank; title: cannot be blank."},
 		{"article invalid author field", &Article{Title: "TestTitle", Content: "TestContent", Author: "Test"}, "author: the length must be between 1 and 255."},
+		{"article markdown content format", &Article{Title: "TestTitle", Content: "*TestContent*", ContentFormat: "markdown", Author: "TestAuthor"}, ""},
+		{"article invalid content format", &Article{Title: "TestTitle", Content: "TestContent", ContentFormat: "rst", Author: "TestAuthor"}, "content_format: must be a valid value."},
+		{"article custom slug", &Article{Title: "TestTitle", Content: "TestContent", Author: "TestAuthor", Slug: "my-first-post-2"}, ""},
+		{"article invalid slug", &Article{Title: "TestTitle", Content: "TestContent", Author: "TestAuthor", Slug: "My First Post"}, "slug: must be lowercase letters and digits separated by single dashes."},
+		{"article slug with double dash", &Article{Title: "TestTitle", Content: "TestContent", Author: "TestAuthor", Slug: "my--post"}, "slug: must be lowercase letters and digits separated by single dashes."},
+		{"article html content only unsafe markup", &Article{Title: "TestTitle", Content: "<script>alert(1)</script>", ContentFormat: "html", Author: "TestAuthor"}, "content: cannot be blank."},
 	}
 
 	for _, tc := range tt {
@@ -30,3 +36,54 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSanitizesHTML(t *testing.T) {
+	a := &Article{Title: "TestTitle", Content: `<p onclick="alert(1)">TestContent</p><script>alert(1)</script>`, ContentFormat: "html", Author: "TestAuthor"}
+	if err := a.Validate(); err != nil {
+		t.Errorf("validate failed: %v", err)
+	}
+
+	if a.Content != "<p>TestContent</p>" {
+		t.Errorf("validate should sanitize content to %q; got %q", "<p>TestContent</p>", a.Content)
+	}
+}
+
+func TestParseArticleFields(t *testing.T) {
+	tt := []struct {
+		name   string
+		fields string
+		parsed ArticleFields
+		err    string
+	}{
+		{"all fields", "", nil, ""},
+		{"selected fields", "title,id", ArticleFields{"id", "title"}, ""},
+		{"spaces and duplicates", "author, tags,author", ArticleFields{"author", "tags"}, ""},
+		{"unknown field", "title,body", nil, `unknown field "body", fields are id, title, content, author, slug, content_format, tags, published_at`},
+		{"empty field", "title,", nil, `unknown field "", fields are id, title, content, author, slug, content_format, tags, published_at`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			fields, err := ParseArticleFields(tc.fields)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Errorf("parse of %q should fail with %v; got %v", tc.fields, tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse of %q failed: %v", tc.fields, err)
+			}
+			if strings.Join(fields, ",") != strings.Join(tc.parsed, ",") || (fields == nil) != (tc.parsed == nil) {
+				t.Errorf("parse of %q should be %v; got %v", tc.fields, tc.parsed, fields)
+			}
+		})
+	}
+
+	if !ArticleFields(nil).Has("content") {
+		t.Errorf("nil fields should select all fields")
+	}
+	if with := (ArticleFields{"title"}).With("author"); strings.Join(with, ",") != "title,author" {
+		t.Errorf("with should keep the order of the fields; got %v", with)
+	}
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// The list of batch operation types.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchOperation holds a single create, update or delete operation of a batch.
+type BatchOperation struct {
+	Op      string   `json:"op" openapi:"enum=create|update|delete"`
+	ID      PublicID `json:"id,omitempty"`
+	Article *Article `json:"article,omitempty"`
+}
+
+// Validate validates BatchOperation struct and returns validation errors.
+func (o *BatchOperation) Validate() error {
+	var idRules, articleRules []validation.Rule
+	switch o.Op {
+	case BatchCreate:
+		articleRules = append(articleRules, validation.Required)
+	case BatchUpdate:
+		idRules = append(idRules, validation.Required, validation.By(publicID))
+		articleRules = append(articleRules, validation.Required)
+	case BatchDelete:
+		idRules = append(idRules, validation.Required, validation.By(publicID))
+	}
+
+	return validation.ValidateStruct(o,
+		validation.Field(&o.Op, validation.Required, validation.In(BatchCreate, BatchUpdate, BatchDelete)),
+		validation.Field(&o.ID, idRules...),
+		validation.Field(&o.Article, articleRules...),
+	)
+}
+
+// BatchResult holds the outcome of a single batch operation.
+type BatchResult struct {
+	ArticleID
+	Err error `json:"-"`
+}
+
+// BatchError reports the operation which caused an atomic batch to roll back.
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestBatchOperationValidate(t *testing.T) {
+	article := &Article{Title: "TestTitle", Content: "TestContent", Author: "TestAuthor"}
+
+	tt := []struct {
+		name string
+		op   *BatchOperation
+		err  string
+	}{
+		{"create operation", &BatchOperation{Op: BatchCreate, Article: article}, ""},
+		{"update operation", &BatchOperation{Op: BatchUpdate, ID: "01ARZ3NDEKTSV4RRFFQ69G5FAV", Article: article}, ""},
+		{"delete operation", &BatchOperation{Op: BatchDelete, ID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"}, ""},
+		{"missing op", &BatchOperation{Article: article}, "op: cannot be blank."},
+		{"unknown op", &BatchOperation{Op: "upsert", Article: article}, "op: must be a valid value."},
+		{"create missing article", &BatchOperation{Op: BatchCreate}, "article: cannot be blank."},
+		{"create invalid article", &BatchOperation{Op: BatchCreate, Article: &Article{Title: "TestTitle", Content: "TestContent"}}, "article: (author: cannot be blank.)."},
+		{"update missing id", &BatchOperation{Op: BatchUpdate, Article: article}, "id: cannot be blank."},
+		{"delete missing id", &BatchOperation{Op: BatchDelete}, "id: cannot be blank."},
+		{"delete serial id", &BatchOperation{Op: BatchDelete, ID: "1"}, "id: must be a ULID."},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.op.Validate()
+			actual := ""
+			if err != nil {
+				actual = err.Error()
+			}
+			if tc.err != actual {
+				t.Errorf("validate of %v should be %v; got %v", tc.name, tc.err, actual)
+			}
+		})
+	}
+}
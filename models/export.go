@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// The list of export states. Completed exports can be downloaded until they expire.
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+	ExportExpired   = "expired"
+)
+
+// Export holds a bulk export of articles written to a file in the background by a job.
+type Export struct {
+	ID     int64  `json:"id"`
+	Format string `json:"format" openapi:"enum=jsonl|csv|markdown-zip"`
+	// Author and Tag restrict the export to matching articles, like an ArticleFilter.
+	Author string `json:"author,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+
+	Status string `json:"status" openapi:"enum=pending|running|completed|failed|expired"`
+	JobID  int64  `json:"job_id"`
+	// Exported is the number of articles written so far out of Total.
+	Exported int    `json:"exported"`
+	Total    int    `json:"total"`
+	Size     int64  `json:"size,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+// Validate validates Export struct and returns validation errors.
+func (e *Export) Validate() error {
+	return validation.ValidateStruct(e,
+		validation.Field(&e.Format, validation.Required, validation.In("jsonl", "csv", "markdown-zip").Error("must be jsonl, csv or markdown-zip")),
+		validation.Field(&e.Author, validation.Length(0, 255)),
+		validation.Field(&e.Tag, validation.Length(0, 255)),
+	)
+}
+
+// Filter returns the article filter of the export.
+func (e *Export) Filter() ArticleFilter {
+	return ArticleFilter{Author: e.Author, Tag: e.Tag}
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateExport(t *testing.T) {
+	tt := []struct {
+		name   string
+		export *Export
+		err    string
+	}{
+		{"export valid", &Export{Format: "markdown-zip", Author: "Test Author"}, ""},
+		{"export missing format", &Export{}, "format: cannot be blank."},
+		{"export unknown format", &Export{Format: "xml"}, "format: must be jsonl, csv or markdown-zip."},
+		{"export long tag", &Export{Format: "csv", Tag: strings.Repeat("t", 256)}, "tag: the length must be no more than 255."},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.export.Validate()
+			actual := ""
+			if err != nil {
+				actual = err.Error()
+			}
+			if strings.Compare(tc.err, actual) != 0 {
+				t.Errorf("validate of %v should be %v; got %v", tc.name, tc.err, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// IdempotencyKey holds the stored response linked to an Idempotency-Key header.
+type IdempotencyKey struct {
+	Key         string    `json:"key"`
+	Fingerprint string    `json:"fingerprint"`
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// The list of job states. Pending jobs run once RunAt has passed, failed jobs are pending again
+// until they run out of attempts.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobDead      = "dead"
+	JobCancelled = "cancelled"
+)
+
+// Job holds a unit of background work and the state of its attempts.
+type Job struct {
+	ID   int64           `json:"id"`
+	Kind string          `json:"kind"`
+	Args json.RawMessage `json:"args"`
+
+	Status      string    `json:"status" openapi:"enum=pending|running|completed|dead|cancelled"`
+	Attempts    int       `json:"attempts"`
+	MaxAttempts int       `json:"max_attempts"`
+	RunAt       time.Time `json:"run_at"`
+	// LockedUntil is the end of the lease of a running job, after which another worker may claim it.
+	LockedUntil *time.Time `json:"locked_until,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+
+	// UniqueKey prevents enqueueing a job while another job with the same key is pending or running.
+	UniqueKey  string     `json:"unique_key,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
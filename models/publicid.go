@@ -0,0 +1,74 @@
+package models
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/oklog/ulid"
+)
+
+// ErrInvalidPublicID is returned for an article ID which is not a ULID.
+var ErrInvalidPublicID = errors.New("article id must be a ULID")
+
+// PublicID identifies an article in the API. It is a ULID, which sorts by creation time like the
+// serial ID but cannot be guessed from the IDs of other articles.
+type PublicID string
+
+var (
+	entropyMu sync.Mutex
+	entropy   = ulid.Monotonic(rand.Reader, 0)
+)
+
+// NewPublicID returns a new ULID. IDs created within the same millisecond increase monotonically.
+func NewPublicID() PublicID {
+	entropyMu.Lock()
+	defer entropyMu.Unlock()
+
+	return PublicID(ulid.MustNew(ulid.Now(), entropy).String())
+}
+
+// ParsePublicID parses an article ID from a URL or request body, accepting lowercase letters, and
+// returns it in canonical form.
+func ParsePublicID(s string) (PublicID, error) {
+	id, err := ulid.ParseStrict(s)
+	if err != nil {
+		return "", ErrInvalidPublicID
+	}
+	return PublicID(id.String()), nil
+}
+
+// UnmarshalJSON decodes a string ID. Numeric IDs of articles exported before public IDs were
+// introduced are ignored, so that those articles get a new ID when imported.
+func (id *PublicID) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] != '"' && !bytes.Equal(data, []byte("null")) {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return err
+		}
+		*id = ""
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*id = PublicID(s)
+	return nil
+}
+
+// publicID validates an article ID given in a request.
+func publicID(value interface{}) error {
+	id, _ := value.(PublicID)
+	if id == "" {
+		return nil
+	}
+
+	if _, err := ParsePublicID(string(id)); err != nil {
+		return errors.New("must be a ULID")
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParsePublicID(t *testing.T) {
+	tt := []struct {
+		name     string
+		input    string
+		expected PublicID
+		err      string
+	}{
+		{"canonical", "01ARZ3NDEKTSV4RRFFQ69G5FAV", "01ARZ3NDEKTSV4RRFFQ69G5FAV", ""},
+		{"lowercase", "01arz3ndektsv4rrffq69g5fav", "01ARZ3NDEKTSV4RRFFQ69G5FAV", ""},
+		{"serial", "1", "", "article id must be a ULID"},
+		{"invalid character", "01ARZ3NDEKTSV4RRFFQ69G5FAU", "", "article id must be a ULID"},
+		{"overflow", "81ARZ3NDEKTSV4RRFFQ69G5FAV", "", "article id must be a ULID"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := ParsePublicID(tc.input)
+			errStr := ""
+			if err != nil {
+				errStr = err.Error()
+			}
+			if strings.Compare(tc.err, errStr) != 0 {
+				t.Errorf("parse of %v should fail with %v; got %v", tc.input, tc.err, errStr)
+			}
+			if actual != tc.expected {
+				t.Errorf("parse of %v should be %v; got %v", tc.input, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNewPublicID(t *testing.T) {
+	prev := NewPublicID()
+	for i := 0; i < 100; i++ {
+		id := NewPublicID()
+		if _, err := ParsePublicID(string(id)); err != nil {
+			t.Fatalf("new public id %v is invalid: %v", id, err)
+		}
+		if id <= prev {
+			t.Fatalf("new public id %v should sort after %v", id, prev)
+		}
+		prev = id
+	}
+}
+
+func TestPublicIDUnmarshalJSON(t *testing.T) {
+	tt := []struct {
+		name     string
+		input    string
+		expected PublicID
+	}{
+		{"public id", `{"id":"01ARZ3NDEKTSV4RRFFQ69G5FAV","title":"Test Title"}`, "01ARZ3NDEKTSV4RRFFQ69G5FAV"},
+		{"serial id", `{"id":1,"title":"Test Title"}`, ""},
+		{"null id", `{"id":null,"title":"Test Title"}`, ""},
+		{"no id", `{"title":"Test Title"}`, ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var a Article
+			if err := json.Unmarshal([]byte(tc.input), &a); err != nil {
+				t.Fatalf("unmarshal of %v failed: %v", tc.input, err)
+			}
+			if a.PublicID != tc.expected || a.ID != 0 || a.Title != "Test Title" {
+				t.Errorf("unmarshal of %v should have id %v; got %+v", tc.input, tc.expected, a)
+			}
+		})
+	}
+}
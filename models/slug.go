@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// slugLength caps the length of slugs derived from titles in runes.
+const slugLength = 80
+
+// Slugify derives a slug from a title: lowercase letters and digits, with every run of other
+// characters replaced by a single dash. Titles without letters or digits give "article".
+func Slugify(title string) string {
+	var b strings.Builder
+	n := 0
+	dash := false
+	for _, r := range strings.ToLower(title) {
+		if n == slugLength {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+			n++
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+			n++
+		}
+	}
+
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return "article"
+	}
+	return slug
+}
+
+// slug validates a custom slug, which must look like a slug derived from a title.
+func slug(value interface{}) error {
+	s, _ := value.(string)
+	if s == "" {
+		return nil
+	}
+
+	err := errors.New("must be lowercase letters and digits separated by single dashes")
+	if strings.HasPrefix(s, "-") || strings.HasSuffix(s, "-") || strings.Contains(s, "--") {
+		return err
+	}
+	for _, r := range s {
+		if r != '-' && !unicode.IsDigit(r) && (!unicode.IsLetter(r) || unicode.IsUpper(r)) {
+			return err
+		}
+	}
+	return nil
+}
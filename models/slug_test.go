@@ -0,0 +1,28 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tt := []struct {
+		name  string
+		title string
+		slug  string
+	}{
+		{"slug title", "Test Title", "test-title"},
+		{"slug punctuation", "  Hello, World! (Part 2)  ", "hello-world-part-2"},
+		{"slug unicode", "Über Straße", "über-straße"},
+		{"slug no letters", "!!!", "article"},
+		{"slug long title", strings.Repeat("word ", 20), strings.TrimSuffix(strings.Repeat("word-", 16), "-")},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := Slugify(tc.title); strings.Compare(tc.slug, actual) != 0 {
+				t.Errorf("slugify of %v should be %v; got %v", tc.name, tc.slug, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// Source holds a subscribed external feed and the state of its polling.
+type Source struct {
+	ID    int    `json:"id"`
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+
+	// ETag and LastModified are the validators of the last fetched response, sent on the next poll.
+	ETag         string `json:"etag,omitempty" sql:"etag"`
+	LastModified string `json:"last_modified,omitempty"`
+
+	// Failures counts consecutive failed polls, NextPollAt backs off exponentially with it.
+	Failures     int        `json:"failures"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastPolledAt *time.Time `json:"last_polled_at,omitempty"`
+	NextPollAt   time.Time  `json:"next_poll_at"`
+}
+
+// Validate validates Source struct and returns validation errors.
+func (s *Source) Validate() error {
+	return validation.ValidateStruct(s,
+		validation.Field(&s.URL, validation.Required, validation.Length(1, 2048), validation.By(httpURL)),
+	)
+}
+
+func httpURL(value interface{}) error {
+	s, _ := value.(string)
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// SourceEntry holds an article read from a source, identified by the GUID of its feed item.
+type SourceEntry struct {
+	GUID    string
+	Article Article
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSource(t *testing.T) {
+	tt := []struct {
+		name   string
+		source *Source
+		err    string
+	}{
+		{"source http url", &Source{URL: "http://example.com/feed.xml"}, ""},
+		{"source https url", &Source{URL: "https://example.com/feed.json"}, ""},
+		{"source missing url", &Source{}, "url: cannot be blank."},
+		{"source relative url", &Source{URL: "/feed.xml"}, "url: must be an absolute http or https URL."},
+		{"source ftp url", &Source{URL: "ftp://example.com/feed.xml"}, "url: must be an absolute http or https URL."},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.source.Validate()
+			actual := ""
+			if err != nil {
+				actual = err.Error()
+			}
+			if strings.Compare(tc.err, actual) != 0 {
+				t.Errorf("validate of %v should be %v; got %v", tc.name, tc.err, actual)
+			}
+		})
+	}
+}
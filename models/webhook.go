@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// The list of article events webhooks subscribe to.
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+)
+
+// The list of delivery states. Failed deliveries are retried until they are delivered or dead.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// Webhook holds a URL notified of article events. The secret signs deliveries and is never returned.
+type Webhook struct {
+	ID        int       `json:"id" openapi:"readOnly"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty" openapi:"writeOnly"`
+	Events    []string  `json:"events" pg:",array"`
+	CreatedAt time.Time `json:"created_at" openapi:"readOnly"`
+}
+
+// Validate validates Webhook struct and returns validation errors.
+func (w *Webhook) Validate() error {
+	return validation.ValidateStruct(w,
+		validation.Field(&w.URL, validation.Required, validation.Length(1, 2048), validation.By(httpURL)),
+		validation.Field(&w.Secret, validation.Required, validation.Length(16, 255)),
+		validation.Field(&w.Events, validation.Required, validation.By(webhookEvents)),
+	)
+}
+
+func webhookEvents(value interface{}) error {
+	events, _ := value.([]string)
+	for _, e := range events {
+		if e != EventCreated && e != EventUpdated && e != EventDeleted {
+			return errors.New("must be created, updated or deleted")
+		}
+	}
+	return nil
+}
+
+// Delivery holds an event sent to a webhook and the state of its delivery attempts. Deliveries are
+// written in the transaction of the article change, so no committed change goes unannounced.
+type Delivery struct {
+	ID        int64  `json:"id"`
+	WebhookID int    `json:"webhook_id"`
+	Event     string `json:"event"`
+
+	// ArticleID is internal, the payload carries the public ID of the article.
+	ArticleID int             `json:"-"`
+	Payload   json.RawMessage `json:"payload"`
+
+	// Status is pending until the delivery succeeds or runs out of attempts.
+	Status         string     `json:"status" openapi:"enum=pending|delivered|dead"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastError      string     `json:"last_error,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+
+	// Webhook is the recipient of a delivery which is due.
+	Webhook *Webhook `json:"-" sql:"-"`
+}
+
+// DeliveryPayload is the JSON body of a delivery.
+type DeliveryPayload struct {
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Article    Article   `json:"article"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateWebhook(t *testing.T) {
+	secret := "0123456789abcdef"
+
+	tt := []struct {
+		name    string
+		webhook *Webhook
+		err     string
+	}{
+		{"webhook valid", &Webhook{URL: "https://example.com/hook", Secret: secret, Events: []string{EventCreated, EventDeleted}}, ""},
+		{"webhook missing url", &Webhook{Secret: secret, Events: []string{EventCreated}}, "url: cannot be blank."},
+		{"webhook relative url", &Webhook{URL: "/hook", Secret: secret, Events: []string{EventCreated}}, "url: must be an absolute http or https URL."},
+		{"webhook short secret", &Webhook{URL: "https://example.com/hook", Secret: "secret", Events: []string{EventCreated}}, "secret: the length must be between 16 and 255."},
+		{"webhook missing events", &Webhook{URL: "https://example.com/hook", Secret: secret}, "events: cannot be blank."},
+		{"webhook unknown event", &Webhook{URL: "https://example.com/hook", Secret: secret, Events: []string{EventCreated, "published"}}, "events: must be created, updated or deleted."},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.webhook.Validate()
+			actual := ""
+			if err != nil {
+				actual = err.Error()
+			}
+			if strings.Compare(tc.err, actual) != 0 {
+				t.Errorf("validate of %v should be %v; got %v", tc.name, tc.err, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,746 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: article.proto
+
+package articlepb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	empty "github.com/golang/protobuf/ptypes/empty"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type Article struct {
+	// id is the public ULID of the article.
+	Id      string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title   string `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Content string `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Author  string `protobuf:"bytes,4,opt,name=author,proto3" json:"author,omitempty"`
+	// content_format is one of plain, markdown or html, empty content is plain text.
+	ContentFormat        string               `protobuf:"bytes,5,opt,name=content_format,json=contentFormat,proto3" json:"content_format,omitempty"`
+	Tags                 []string             `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
+	PublishedAt          *timestamp.Timestamp `protobuf:"bytes,7,opt,name=published_at,json=publishedAt,proto3" json:"published_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Article) Reset()         { *m = Article{} }
+func (m *Article) String() string { return proto.CompactTextString(m) }
+func (*Article) ProtoMessage()    {}
+func (*Article) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{0}
+}
+
+func (m *Article) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Article.Unmarshal(m, b)
+}
+func (m *Article) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Article.Marshal(b, m, deterministic)
+}
+func (m *Article) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Article.Merge(m, src)
+}
+func (m *Article) XXX_Size() int {
+	return xxx_messageInfo_Article.Size(m)
+}
+func (m *Article) XXX_DiscardUnknown() {
+	xxx_messageInfo_Article.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Article proto.InternalMessageInfo
+
+func (m *Article) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Article) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+func (m *Article) GetContent() string {
+	if m != nil {
+		return m.Content
+	}
+	return ""
+}
+
+func (m *Article) GetAuthor() string {
+	if m != nil {
+		return m.Author
+	}
+	return ""
+}
+
+func (m *Article) GetContentFormat() string {
+	if m != nil {
+		return m.ContentFormat
+	}
+	return ""
+}
+
+func (m *Article) GetTags() []string {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Article) GetPublishedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.PublishedAt
+	}
+	return nil
+}
+
+type GetRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetRequest) Reset()         { *m = GetRequest{} }
+func (m *GetRequest) String() string { return proto.CompactTextString(m) }
+func (*GetRequest) ProtoMessage()    {}
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{1}
+}
+
+func (m *GetRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRequest.Unmarshal(m, b)
+}
+func (m *GetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRequest.Marshal(b, m, deterministic)
+}
+func (m *GetRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRequest.Merge(m, src)
+}
+func (m *GetRequest) XXX_Size() int {
+	return xxx_messageInfo_GetRequest.Size(m)
+}
+func (m *GetRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRequest proto.InternalMessageInfo
+
+func (m *GetRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type ListRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListRequest) Reset()         { *m = ListRequest{} }
+func (m *ListRequest) String() string { return proto.CompactTextString(m) }
+func (*ListRequest) ProtoMessage()    {}
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{2}
+}
+
+func (m *ListRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListRequest.Unmarshal(m, b)
+}
+func (m *ListRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListRequest.Marshal(b, m, deterministic)
+}
+func (m *ListRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListRequest.Merge(m, src)
+}
+func (m *ListRequest) XXX_Size() int {
+	return xxx_messageInfo_ListRequest.Size(m)
+}
+func (m *ListRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListRequest proto.InternalMessageInfo
+
+type CreateRequest struct {
+	Article              *Article `protobuf:"bytes,1,opt,name=article,proto3" json:"article,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CreateRequest) Reset()         { *m = CreateRequest{} }
+func (m *CreateRequest) String() string { return proto.CompactTextString(m) }
+func (*CreateRequest) ProtoMessage()    {}
+func (*CreateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{3}
+}
+
+func (m *CreateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreateRequest.Unmarshal(m, b)
+}
+func (m *CreateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreateRequest.Marshal(b, m, deterministic)
+}
+func (m *CreateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreateRequest.Merge(m, src)
+}
+func (m *CreateRequest) XXX_Size() int {
+	return xxx_messageInfo_CreateRequest.Size(m)
+}
+func (m *CreateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateRequest proto.InternalMessageInfo
+
+func (m *CreateRequest) GetArticle() *Article {
+	if m != nil {
+		return m.Article
+	}
+	return nil
+}
+
+type UpdateRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Article              *Article `protobuf:"bytes,2,opt,name=article,proto3" json:"article,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdateRequest) Reset()         { *m = UpdateRequest{} }
+func (m *UpdateRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdateRequest) ProtoMessage()    {}
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{4}
+}
+
+func (m *UpdateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateRequest.Unmarshal(m, b)
+}
+func (m *UpdateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateRequest.Marshal(b, m, deterministic)
+}
+func (m *UpdateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateRequest.Merge(m, src)
+}
+func (m *UpdateRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdateRequest.Size(m)
+}
+func (m *UpdateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateRequest proto.InternalMessageInfo
+
+func (m *UpdateRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *UpdateRequest) GetArticle() *Article {
+	if m != nil {
+		return m.Article
+	}
+	return nil
+}
+
+type DeleteRequest struct {
+	Id                   string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteRequest) Reset()         { *m = DeleteRequest{} }
+func (m *DeleteRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteRequest) ProtoMessage()    {}
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{5}
+}
+
+func (m *DeleteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteRequest.Unmarshal(m, b)
+}
+func (m *DeleteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteRequest.Merge(m, src)
+}
+func (m *DeleteRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteRequest.Size(m)
+}
+func (m *DeleteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteRequest proto.InternalMessageInfo
+
+func (m *DeleteRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+type SearchRequest struct {
+	Query string `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// limit defaults to 20 and is capped at 100.
+	Limit                int32    `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SearchRequest) Reset()         { *m = SearchRequest{} }
+func (m *SearchRequest) String() string { return proto.CompactTextString(m) }
+func (*SearchRequest) ProtoMessage()    {}
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{6}
+}
+
+func (m *SearchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SearchRequest.Unmarshal(m, b)
+}
+func (m *SearchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SearchRequest.Marshal(b, m, deterministic)
+}
+func (m *SearchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SearchRequest.Merge(m, src)
+}
+func (m *SearchRequest) XXX_Size() int {
+	return xxx_messageInfo_SearchRequest.Size(m)
+}
+func (m *SearchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SearchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SearchRequest proto.InternalMessageInfo
+
+func (m *SearchRequest) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *SearchRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type SearchResponse struct {
+	Articles             []*Article `protobuf:"bytes,1,rep,name=articles,proto3" json:"articles,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *SearchResponse) Reset()         { *m = SearchResponse{} }
+func (m *SearchResponse) String() string { return proto.CompactTextString(m) }
+func (*SearchResponse) ProtoMessage()    {}
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5c593d380f9840a2, []int{7}
+}
+
+func (m *SearchResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SearchResponse.Unmarshal(m, b)
+}
+func (m *SearchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SearchResponse.Marshal(b, m, deterministic)
+}
+func (m *SearchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SearchResponse.Merge(m, src)
+}
+func (m *SearchResponse) XXX_Size() int {
+	return xxx_messageInfo_SearchResponse.Size(m)
+}
+func (m *SearchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SearchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SearchResponse proto.InternalMessageInfo
+
+func (m *SearchResponse) GetArticles() []*Article {
+	if m != nil {
+		return m.Articles
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*Article)(nil), "articles.Article")
+	proto.RegisterType((*GetRequest)(nil), "articles.GetRequest")
+	proto.RegisterType((*ListRequest)(nil), "articles.ListRequest")
+	proto.RegisterType((*CreateRequest)(nil), "articles.CreateRequest")
+	proto.RegisterType((*UpdateRequest)(nil), "articles.UpdateRequest")
+	proto.RegisterType((*DeleteRequest)(nil), "articles.DeleteRequest")
+	proto.RegisterType((*SearchRequest)(nil), "articles.SearchRequest")
+	proto.RegisterType((*SearchResponse)(nil), "articles.SearchResponse")
+}
+
+func init() { proto.RegisterFile("article.proto", fileDescriptor_5c593d380f9840a2) }
+
+var fileDescriptor_5c593d380f9840a2 = []byte{
+	// 488 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x53, 0x4d, 0x6f, 0xd3, 0x40,
+	0x10, 0x95, 0x93, 0xc6, 0xa1, 0x13, 0x1c, 0x89, 0x55, 0x28, 0x2b, 0x83, 0xd4, 0xc8, 0x12, 0x52,
+	0x24, 0x54, 0x07, 0xd2, 0x5e, 0x50, 0x41, 0xa8, 0x7c, 0xf5, 0xd2, 0x93, 0x0b, 0x17, 0x2e, 0xd5,
+	0xda, 0x99, 0x26, 0x2b, 0xec, 0xd8, 0x5d, 0x8f, 0x91, 0xf2, 0x4f, 0x91, 0xf8, 0x33, 0x28, 0xbb,
+	0xde, 0xc4, 0xf9, 0x40, 0xdc, 0x3c, 0x6f, 0xde, 0x9b, 0xdd, 0x79, 0x6f, 0x0d, 0x9e, 0x50, 0x24,
+	0x93, 0x14, 0xc3, 0x42, 0xe5, 0x94, 0xb3, 0x47, 0x75, 0x59, 0xfa, 0xcf, 0x67, 0x79, 0x3e, 0x4b,
+	0x71, 0xac, 0xf1, 0xb8, 0xba, 0x1f, 0x63, 0x56, 0xd0, 0xd2, 0xd0, 0xfc, 0xd3, 0xdd, 0x26, 0xc9,
+	0x0c, 0x4b, 0x12, 0x59, 0x61, 0x08, 0xc1, 0x1f, 0x07, 0xba, 0x57, 0x66, 0x14, 0xeb, 0x43, 0x4b,
+	0x4e, 0xb9, 0x33, 0x74, 0x46, 0xc7, 0x51, 0x4b, 0x4e, 0xd9, 0x00, 0x3a, 0x24, 0x29, 0x45, 0xde,
+	0xd2, 0x90, 0x29, 0x18, 0x87, 0x6e, 0x92, 0x2f, 0x08, 0x17, 0xc4, 0xdb, 0x1a, 0xb7, 0x25, 0x3b,
+	0x01, 0x57, 0x54, 0x34, 0xcf, 0x15, 0x3f, 0xd2, 0x8d, 0xba, 0x62, 0x2f, 0xa1, 0x5f, 0x53, 0xee,
+	0xee, 0x73, 0x95, 0x09, 0xe2, 0x1d, 0xdd, 0xf7, 0x6a, 0xf4, 0xab, 0x06, 0x19, 0x83, 0x23, 0x12,
+	0xb3, 0x92, 0xbb, 0xc3, 0xf6, 0xe8, 0x38, 0xd2, 0xdf, 0xec, 0x3d, 0x3c, 0x2e, 0xaa, 0x38, 0x95,
+	0xe5, 0x1c, 0xa7, 0x77, 0x82, 0x78, 0x77, 0xe8, 0x8c, 0x7a, 0x13, 0x3f, 0x34, 0x6b, 0x85, 0x76,
+	0xad, 0xf0, 0x9b, 0x5d, 0x2b, 0xea, 0xad, 0xf9, 0x57, 0x14, 0xbc, 0x00, 0xb8, 0x46, 0x8a, 0xf0,
+	0xa1, 0xc2, 0x92, 0x76, 0xf7, 0x0b, 0x3c, 0xe8, 0xdd, 0xc8, 0xd2, 0xb6, 0x83, 0x77, 0xe0, 0x7d,
+	0x52, 0x28, 0x08, 0x2d, 0xff, 0x15, 0x74, 0x6b, 0x97, 0xb5, 0xa8, 0x37, 0x79, 0x12, 0x5a, 0xd7,
+	0xc3, 0xda, 0xb3, 0xc8, 0x32, 0x82, 0x1b, 0xf0, 0xbe, 0x17, 0xd3, 0x86, 0x7a, 0xd7, 0xcd, 0xc6,
+	0xb4, 0xd6, 0x7f, 0xa7, 0x9d, 0x82, 0xf7, 0x19, 0x53, 0xfc, 0xe7, 0xb4, 0xe0, 0x12, 0xbc, 0x5b,
+	0x14, 0x2a, 0x99, 0x5b, 0xc2, 0x00, 0x3a, 0x0f, 0x15, 0xaa, 0x65, 0xcd, 0x31, 0xc5, 0x0a, 0x4d,
+	0x65, 0x26, 0x49, 0x1f, 0xd9, 0x89, 0x4c, 0x11, 0x7c, 0x80, 0xbe, 0x15, 0x97, 0x45, 0xbe, 0x28,
+	0x91, 0x9d, 0xc1, 0xfa, 0x41, 0x71, 0x67, 0xd8, 0x3e, 0x7c, 0xbb, 0x35, 0x65, 0xf2, 0xbb, 0x05,
+	0xfd, 0x1a, 0xbd, 0x45, 0xf5, 0x4b, 0x26, 0xc8, 0x42, 0x68, 0x5f, 0x23, 0xb1, 0xc1, 0x46, 0xb6,
+	0x71, 0xde, 0xdf, 0x1f, 0xc6, 0x26, 0x70, 0xb4, 0x32, 0x9f, 0x3d, 0xdd, 0xb4, 0x1a, 0x61, 0x1c,
+	0x50, 0xbc, 0x76, 0xd8, 0x05, 0xb8, 0x26, 0x21, 0xf6, 0x6c, 0xd3, 0xde, 0xca, 0xec, 0xd0, 0x49,
+	0x17, 0xe0, 0x9a, 0x64, 0x9a, 0xaa, 0xad, 0xac, 0x0e, 0xa9, 0xde, 0x82, 0x6b, 0x12, 0x68, 0xaa,
+	0xb6, 0x32, 0xf1, 0x4f, 0xf6, 0x9e, 0xe1, 0x97, 0xd5, 0xaf, 0xc7, 0x2e, 0xc1, 0x35, 0xf6, 0x36,
+	0xa5, 0x5b, 0x69, 0xf9, 0x7c, 0xbf, 0x61, 0x92, 0xf8, 0x78, 0xfe, 0xe3, 0xcd, 0x4c, 0xd2, 0xbc,
+	0x8a, 0xc3, 0x24, 0xcf, 0xc6, 0xcb, 0x9f, 0xa2, 0xc4, 0xc5, 0x6c, 0x6c, 0xd9, 0x67, 0xa9, 0x8c,
+	0x95, 0x50, 0xcb, 0xb1, 0x2a, 0x12, 0x0b, 0x16, 0x71, 0xec, 0xea, 0x1b, 0x9c, 0xff, 0x1d, 0x00,
+	0x58, 0x5e, 0x9f, 0x96, 0x25, 0x04, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// ArticleServiceClient is the client API for ArticleService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ArticleServiceClient interface {
+	// Get returns an article by id.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Article, error)
+	// List streams all articles ordered by id.
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (ArticleService_ListClient, error)
+	// Create validates and inserts an article.
+	Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*Article, error)
+	// Update replaces the fields of an article.
+	Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*Article, error)
+	// Delete removes an article by id.
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	// Search returns articles matching a full text query, best matches first.
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+}
+
+type articleServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewArticleServiceClient(cc grpc.ClientConnInterface) ArticleServiceClient {
+	return &articleServiceClient{cc}
+}
+
+func (c *articleServiceClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*Article, error) {
+	out := new(Article)
+	err := c.cc.Invoke(ctx, "/articles.ArticleService/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *articleServiceClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (ArticleService_ListClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ArticleService_serviceDesc.Streams[0], "/articles.ArticleService/List", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &articleServiceListClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ArticleService_ListClient interface {
+	Recv() (*Article, error)
+	grpc.ClientStream
+}
+
+type articleServiceListClient struct {
+	grpc.ClientStream
+}
+
+func (x *articleServiceListClient) Recv() (*Article, error) {
+	m := new(Article)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *articleServiceClient) Create(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*Article, error) {
+	out := new(Article)
+	err := c.cc.Invoke(ctx, "/articles.ArticleService/Create", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *articleServiceClient) Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*Article, error) {
+	out := new(Article)
+	err := c.cc.Invoke(ctx, "/articles.ArticleService/Update", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *articleServiceClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/articles.ArticleService/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *articleServiceClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, "/articles.ArticleService/Search", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ArticleServiceServer is the server API for ArticleService service.
+type ArticleServiceServer interface {
+	// Get returns an article by id.
+	Get(context.Context, *GetRequest) (*Article, error)
+	// List streams all articles ordered by id.
+	List(*ListRequest, ArticleService_ListServer) error
+	// Create validates and inserts an article.
+	Create(context.Context, *CreateRequest) (*Article, error)
+	// Update replaces the fields of an article.
+	Update(context.Context, *UpdateRequest) (*Article, error)
+	// Delete removes an article by id.
+	Delete(context.Context, *DeleteRequest) (*empty.Empty, error)
+	// Search returns articles matching a full text query, best matches first.
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+}
+
+// UnimplementedArticleServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedArticleServiceServer struct {
+}
+
+func (*UnimplementedArticleServiceServer) Get(ctx context.Context, req *GetRequest) (*Article, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (*UnimplementedArticleServiceServer) List(req *ListRequest, srv ArticleService_ListServer) error {
+	return status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (*UnimplementedArticleServiceServer) Create(ctx context.Context, req *CreateRequest) (*Article, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
+}
+func (*UnimplementedArticleServiceServer) Update(ctx context.Context, req *UpdateRequest) (*Article, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (*UnimplementedArticleServiceServer) Delete(ctx context.Context, req *DeleteRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (*UnimplementedArticleServiceServer) Search(ctx context.Context, req *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+
+func RegisterArticleServiceServer(s *grpc.Server, srv ArticleServiceServer) {
+	s.RegisterService(&_ArticleService_serviceDesc, srv)
+}
+
+func _ArticleService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticleServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/articles.ArticleService/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticleServiceServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArticleService_List_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ArticleServiceServer).List(m, &articleServiceListServer{stream})
+}
+
+type ArticleService_ListServer interface {
+	Send(*Article) error
+	grpc.ServerStream
+}
+
+type articleServiceListServer struct {
+	grpc.ServerStream
+}
+
+func (x *articleServiceListServer) Send(m *Article) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ArticleService_Create_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticleServiceServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/articles.ArticleService/Create",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticleServiceServer).Create(ctx, req.(*CreateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArticleService_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticleServiceServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/articles.ArticleService/Update",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticleServiceServer).Update(ctx, req.(*UpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArticleService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticleServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/articles.ArticleService/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticleServiceServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ArticleService_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ArticleServiceServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/articles.ArticleService/Search",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ArticleServiceServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ArticleService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "articles.ArticleService",
+	HandlerType: (*ArticleServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _ArticleService_Get_Handler,
+		},
+		{
+			MethodName: "Create",
+			Handler:    _ArticleService_Create_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _ArticleService_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _ArticleService_Delete_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _ArticleService_Search_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "List",
+			Handler:       _ArticleService_List_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "article.proto",
+}
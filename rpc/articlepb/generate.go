@@ -0,0 +1,4 @@
+// Package articlepb contains the protobuf messages and gRPC service of the article API.
+package articlepb
+
+//go:generate protoc --go_out=plugins=grpc,paths=source_relative:. article.proto
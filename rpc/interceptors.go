@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader is the metadata key carrying the request id, matching the HTTP header.
+const RequestIDHeader = "x-request-id"
+
+type ctxKey int
+
+const logEntryKey ctxKey = 0
+
+// UnaryLogger logs the start and completion of unary calls with the fields of the HTTP request logger.
+func UnaryLogger(logger *logrus.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, entry := newLogEntry(ctx, logger, info.FullMethod)
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+		logComplete(entry, err, start)
+		return resp, err
+	}
+}
+
+// StreamLogger logs the start and completion of streaming calls with the fields of the HTTP request logger.
+func StreamLogger(logger *logrus.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, entry := newLogEntry(ss.Context(), logger, info.FullMethod)
+		start := time.Now()
+
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		logComplete(entry, err, start)
+		return err
+	}
+}
+
+// UnaryRecoverer recovers from panics in unary handlers, logs the stack trace and returns an Internal status.
+func UnaryRecoverer(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer recoverer(ctx, &err)
+	return handler(ctx, req)
+}
+
+// StreamRecoverer recovers from panics in streaming handlers, logs the stack trace and returns an Internal status.
+func StreamRecoverer(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer recoverer(ss.Context(), &err)
+	return handler(srv, ss)
+}
+
+func recoverer(ctx context.Context, err *error) {
+	if rvr := recover(); rvr != nil {
+		LogEntry(ctx).WithFields(logrus.Fields{
+			"stack": string(debug.Stack()),
+			"panic": fmt.Sprintf("%+v", rvr),
+		}).Error("request panicked")
+		*err = status.Error(codes.Internal, codes.Internal.String())
+	}
+}
+
+// LogEntry returns the call scoped logrus.FieldLogger.
+func LogEntry(ctx context.Context) logrus.FieldLogger {
+	if entry, ok := ctx.Value(logEntryKey).(logrus.FieldLogger); ok {
+		return entry
+	}
+	return logrus.StandardLogger()
+}
+
+func newLogEntry(ctx context.Context, logger *logrus.Logger, method string) (context.Context, logrus.FieldLogger) {
+	logFields := logrus.Fields{}
+	logFields["ts"] = time.Now().UTC().Format(time.RFC1123)
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	reqID := first(md.Get(RequestIDHeader))
+	if reqID == "" {
+		reqID = fmt.Sprintf("%06d", middleware.NextRequestID())
+	}
+	logFields["req_id"] = reqID
+
+	logFields["grpc_method"] = method
+	if p, ok := peer.FromContext(ctx); ok {
+		logFields["remote_addr"] = p.Addr.String()
+	}
+	logFields["user_agent"] = first(md.Get("user-agent"))
+
+	entry := logger.WithFields(logFields)
+	entry.Infoln("request started")
+
+	return context.WithValue(ctx, logEntryKey, logrus.FieldLogger(entry)), entry
+}
+
+func logComplete(entry logrus.FieldLogger, err error, start time.Time) {
+	entry.WithFields(logrus.Fields{
+		"resp_status":     status.Code(err).String(),
+		"resp_elapsed_ms": float64(time.Since(start).Nanoseconds()) / 1000000.0,
+	}).Infoln("request complete")
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// serverStream overrides the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
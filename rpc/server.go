@@ -0,0 +1,299 @@
+// Package rpc serves the article API over gRPC.
+package rpc
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"github.com/ykaseng/articles-library/api/app"
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/models"
+	"github.com/ykaseng/articles-library/rpc/articlepb"
+)
+
+// The list of search limits.
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// ReadPrimaryHeader is the metadata key holding the Unix time until which the reads of a client are
+// served by the primary database, like the read_primary_until cookie of the HTTP API. It is sent in
+// the response header of writes, and clients pass it on in the metadata of their calls.
+const ReadPrimaryHeader = "read-primary-until"
+
+// NewServer creates a gRPC server with the article service, health checking and reflection
+// registered, logging and recovering from panics like the HTTP middleware stack. Reads are served by
+// store, and by primary for clients which must see their own writes.
+func NewServer(store, primary app.ArticleStore, logger *logrus.Logger) *grpc.Server {
+	return newServer(&ArticleServer{Store: store, Primary: primary, Window: database.ReplicaMaxLag()}, logger)
+}
+
+func newServer(as *ArticleServer, logger *logrus.Logger) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(UnaryLogger(logger), UnaryRecoverer),
+		grpc.ChainStreamInterceptor(StreamLogger(logger), StreamRecoverer),
+	)
+
+	articlepb.RegisterArticleServiceServer(s, as)
+
+	hs := health.NewServer()
+	hs.SetServingStatus("articles.ArticleService", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, hs)
+
+	reflection.Register(s)
+
+	return s
+}
+
+// ArticleServer implements articlepb.ArticleServiceServer on top of an app.ArticleStore.
+type ArticleServer struct {
+	Store app.ArticleStore
+	// Primary serves the reads of clients for Window after their writes, see ReadPrimaryHeader.
+	// Store serves them when it is nil.
+	Primary app.ArticleStore
+	Window  time.Duration
+}
+
+// reads returns the store serving the reads of a call.
+func (s *ArticleServer) reads(ctx context.Context) app.ArticleStore {
+	if s.Primary == nil || s.Window <= 0 {
+		return s.Store
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get(ReadPrimaryHeader) {
+		if until, err := strconv.ParseInt(v, 10, 64); err == nil && time.Now().Unix() <= until {
+			return s.Primary
+		}
+	}
+	return s.Store
+}
+
+// writes returns the store resolving the articles to write, which must not lag behind the primary.
+func (s *ArticleServer) writes() app.ArticleStore {
+	if s.Primary != nil {
+		return s.Primary
+	}
+	return s.Store
+}
+
+// wrote sends ReadPrimaryHeader in the response header of a write.
+func (s *ArticleServer) wrote(ctx context.Context) {
+	if s.Window <= 0 {
+		return
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(ReadPrimaryHeader, strconv.FormatInt(time.Now().Add(s.Window).Unix(), 10)))
+}
+
+// Get returns an article by id.
+func (s *ArticleServer) Get(ctx context.Context, req *articlepb.GetRequest) (*articlepb.Article, error) {
+	store := s.reads(ctx)
+	id, err := s.resolve(store, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	articles, err := store.Get(id.ID)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	if len(*articles) == 0 {
+		return nil, status.Error(codes.NotFound, database.ErrArticleNotFound.Error())
+	}
+
+	return toProto(&(*articles)[0])
+}
+
+// List streams all articles ordered by id.
+func (s *ArticleServer) List(req *articlepb.ListRequest, stream articlepb.ArticleService_ListServer) error {
+	err := s.reads(stream.Context()).Each(func(a *models.Article) error {
+		pb, err := toProto(a)
+		if err != nil {
+			return err
+		}
+		return stream.Send(pb)
+	})
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return storeError(err)
+}
+
+// Create validates and inserts an article.
+func (s *ArticleServer) Create(ctx context.Context, req *articlepb.CreateRequest) (*articlepb.Article, error) {
+	article, err := fromProto(req.Article)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := s.Store.Post(article)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	s.wrote(ctx)
+	article.ArticleID = *id
+
+	return toProto(article)
+}
+
+// Update replaces the fields of an article.
+func (s *ArticleServer) Update(ctx context.Context, req *articlepb.UpdateRequest) (*articlepb.Article, error) {
+	id, err := s.resolve(s.writes(), req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	article, err := fromProto(req.Article)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Store.Update(id.ID, article); err != nil {
+		return nil, storeError(err)
+	}
+	s.wrote(ctx)
+	article.ArticleID = id
+
+	return toProto(article)
+}
+
+// Delete removes an article by id.
+func (s *ArticleServer) Delete(ctx context.Context, req *articlepb.DeleteRequest) (*empty.Empty, error) {
+	id, err := s.resolve(s.writes(), req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Store.Delete(id.ID); err != nil {
+		return nil, storeError(err)
+	}
+	s.wrote(ctx)
+
+	return &empty.Empty{}, nil
+}
+
+// Search returns articles matching a full text query, best matches first.
+func (s *ArticleServer) Search(ctx context.Context, req *articlepb.SearchRequest) (*articlepb.SearchResponse, error) {
+	if req.Query == "" {
+		return nil, status.Error(codes.InvalidArgument, "query: cannot be blank.")
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	articles, err := s.reads(ctx).Search(req.Query, nil, limit)
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	resp := &articlepb.SearchResponse{}
+	for i := range *articles {
+		pb, err := toProto(&(*articles)[i])
+		if err != nil {
+			return nil, err
+		}
+		resp.Articles = append(resp.Articles, pb)
+	}
+
+	return resp, nil
+}
+
+// resolve returns the ids of an article by its public ID in store, returning an InvalidArgument
+// status for IDs which are not ULIDs and a NotFound status for unknown articles.
+func (s *ArticleServer) resolve(store app.ArticleStore, publicID string) (models.ArticleID, error) {
+	pid, err := models.ParsePublicID(publicID)
+	if err != nil {
+		return models.ArticleID{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	id, err := store.Resolve(pid)
+	if err != nil {
+		return models.ArticleID{}, storeError(err)
+	}
+	return models.ArticleID{ID: id, PublicID: pid}, nil
+}
+
+// storeError maps an error of the article store to a gRPC status.
+func storeError(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case database.ErrArticleNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func toProto(a *models.Article) (*articlepb.Article, error) {
+	pb := &articlepb.Article{
+		Id:            string(a.PublicID),
+		Title:         a.Title,
+		Content:       a.Content,
+		Author:        a.Author,
+		ContentFormat: a.ContentFormat,
+		Tags:          a.Tags,
+	}
+
+	if a.PublishedAt != nil {
+		ts, err := ptypes.TimestampProto(*a.PublishedAt)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		pb.PublishedAt = ts
+	}
+
+	return pb, nil
+}
+
+// fromProto converts and validates an article, returning an InvalidArgument status for invalid articles.
+func fromProto(pb *articlepb.Article) (*models.Article, error) {
+	if pb == nil {
+		return nil, status.Error(codes.InvalidArgument, app.ErrEmptyRequest.Error())
+	}
+
+	a := &models.Article{
+		Title:         pb.Title,
+		Content:       pb.Content,
+		Author:        pb.Author,
+		ContentFormat: pb.ContentFormat,
+		Tags:          pb.Tags,
+	}
+
+	if pb.PublishedAt != nil {
+		t, err := ptypes.Timestamp(pb.PublishedAt)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		a.PublishedAt = &t
+	}
+
+	if err := a.Validate(); err != nil {
+		if _, ok := err.(validation.Errors); ok {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return a, nil
+}
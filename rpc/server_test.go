@@ -0,0 +1,364 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/models"
+	"github.com/ykaseng/articles-library/rpc/articlepb"
+)
+
+type memoryArticleStore struct {
+	mu       sync.Mutex
+	articles map[int]models.Article
+	nextID   int
+}
+
+func newMemoryArticleStore() *memoryArticleStore {
+	return &memoryArticleStore{articles: map[int]models.Article{}, nextID: 1}
+}
+
+func (s *memoryArticleStore) Get(id int) (*[]models.Article, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var a []models.Article
+	if article, ok := s.articles[id]; ok {
+		a = append(a, article)
+	}
+	return &a, nil
+}
+
+func (s *memoryArticleStore) GetAll(fields models.ArticleFields) (*[]models.Article, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var a []models.Article
+	for _, id := range s.ids() {
+		a = append(a, s.articles[id])
+	}
+	return &a, nil
+}
+
+func (s *memoryArticleStore) Page(filter models.ArticleFilter, fields models.ArticleFields, after models.PublicID, limit int) (*[]models.Article, error) {
+	articles, _ := s.GetAll(nil)
+
+	var a []models.Article
+	for _, article := range *articles {
+		if len(a) < limit && article.PublicID > after && (filter.Author == "" || article.Author == filter.Author) {
+			a = append(a, article)
+		}
+	}
+	return &a, nil
+}
+
+func (s *memoryArticleStore) Post(article *models.Article) (*models.ArticleID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if article.Title == "panic" {
+		panic("store panicked")
+	}
+
+	a := *article
+	a.ArticleID = models.ArticleID{ID: s.nextID, PublicID: publicID(s.nextID)}
+	s.articles[a.ID] = a
+	s.nextID++
+	return &a.ArticleID, nil
+}
+
+func (s *memoryArticleStore) Resolve(id models.PublicID) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range s.articles {
+		if a.PublicID == id {
+			return a.ID, nil
+		}
+	}
+	return 0, database.ErrArticleNotFound
+}
+
+func (s *memoryArticleStore) Update(id int, article *models.Article) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.articles[id]; !ok {
+		return database.ErrArticleNotFound
+	}
+	a := *article
+	a.ArticleID = s.articles[id].ArticleID
+	s.articles[id] = a
+	return nil
+}
+
+func (s *memoryArticleStore) Delete(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.articles[id]; !ok {
+		return database.ErrArticleNotFound
+	}
+	delete(s.articles, id)
+	return nil
+}
+
+func (s *memoryArticleStore) Each(fn func(*models.Article) error) error {
+	articles, _ := s.GetAll(nil)
+	for i := range *articles {
+		if err := fn(&(*articles)[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memoryArticleStore) GetBySlug(slug string) (*models.Article, error) {
+	return nil, database.ErrArticleNotFound
+}
+
+func (s *memoryArticleStore) Stream(ctx context.Context, filter models.ArticleFilter, fields models.ArticleFields, after models.PublicID, fn func(*models.Article) error) error {
+	return nil
+}
+
+func (s *memoryArticleStore) GetAuthors(names []string) ([]models.Author, error) {
+	return nil, nil
+}
+
+func (s *memoryArticleStore) GetTags(names []string) ([]models.Tag, error) {
+	return nil, nil
+}
+
+func (s *memoryArticleStore) Search(query string, fields models.ArticleFields, limit int) (*[]models.Article, error) {
+	articles, _ := s.GetAll(nil)
+
+	var a []models.Article
+	for _, article := range *articles {
+		if len(a) < limit && strings.Contains(strings.ToLower(article.Title+" "+article.Content), strings.ToLower(query)) {
+			a = append(a, article)
+		}
+	}
+	return &a, nil
+}
+
+func (s *memoryArticleStore) Batch(ops []models.BatchOperation, atomic bool) ([]models.BatchResult, error) {
+	return nil, nil
+}
+
+func (s *memoryArticleStore) ids() []int {
+	var ids []int
+	for id := range s.articles {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// publicID returns the public ID the memory store gives to the article with a serial ID.
+func publicID(id int) models.PublicID {
+	return models.PublicID(fmt.Sprintf("01ARZ3NDEKTSV4RRFFQ69G5F%02d", id))
+}
+
+func dial(t *testing.T, store *memoryArticleStore) (*grpc.ClientConn, func()) {
+	return dialServer(t, &ArticleServer{Store: store})
+}
+
+func dialServer(t *testing.T, as *ArticleServer) (*grpc.ClientConn, func()) {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	l := bufconn.Listen(1 << 20)
+	s := newServer(as, logger)
+	go s.Serve(l)
+
+	conn, err := grpc.Dial("bufnet", grpc.WithInsecure(), grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		return l.Dial()
+	}))
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+
+	return conn, func() {
+		conn.Close()
+		s.Stop()
+	}
+}
+
+func TestArticleService(t *testing.T) {
+	store := newMemoryArticleStore()
+	conn, closeFn := dial(t, store)
+	defer closeFn()
+
+	client := articlepb.NewArticleServiceClient(conn)
+	ctx := context.Background()
+
+	created, err := client.Create(ctx, &articlepb.CreateRequest{Article: &articlepb.Article{Title: "Test Title", Content: "Test Content", Author: "Test Author", Tags: []string{"go"}}})
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	assert.Equal(t, string(publicID(1)), created.Id)
+
+	_, err = client.Create(ctx, &articlepb.CreateRequest{Article: &articlepb.Article{Title: "Test Title"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "author: cannot be blank; content: cannot be blank.", status.Convert(err).Message())
+
+	_, err = client.Create(ctx, &articlepb.CreateRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	got, err := client.Get(ctx, &articlepb.GetRequest{Id: created.Id})
+	if err != nil {
+		t.Fatalf("get failed: %v", err)
+	}
+	assert.Equal(t, "Test Title", got.Title)
+	assert.Equal(t, []string{"go"}, got.Tags)
+
+	_, err = client.Get(ctx, &articlepb.GetRequest{Id: string(publicID(2))})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Get(ctx, &articlepb.GetRequest{Id: "1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	updated, err := client.Update(ctx, &articlepb.UpdateRequest{Id: created.Id, Article: &articlepb.Article{Title: "Updated Title", Content: "Updated Content", Author: "Test Author"}})
+	if err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	assert.Equal(t, created.Id, updated.Id)
+	assert.Equal(t, "Updated Title", store.articles[1].Title)
+
+	_, err = client.Update(ctx, &articlepb.UpdateRequest{Id: string(publicID(2)), Article: &articlepb.Article{Title: "Updated Title", Content: "Updated Content", Author: "Test Author"}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	if _, err := client.Create(ctx, &articlepb.CreateRequest{Article: &articlepb.Article{Title: "Another Test Title", Content: "Another Test Content", Author: "Test Author"}}); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	stream, err := client.List(ctx, &articlepb.ListRequest{})
+	if err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	var titles []string
+	for {
+		a, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("receive failed: %v", err)
+		}
+		titles = append(titles, a.Title)
+	}
+	assert.Equal(t, []string{"Updated Title", "Another Test Title"}, titles)
+
+	found, err := client.Search(ctx, &articlepb.SearchRequest{Query: "another"})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	assert.Len(t, found.Articles, 1)
+	assert.Equal(t, string(publicID(2)), found.Articles[0].Id)
+
+	_, err = client.Search(ctx, &articlepb.SearchRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	if _, err := client.Delete(ctx, &articlepb.DeleteRequest{Id: created.Id}); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	_, err = client.Delete(ctx, &articlepb.DeleteRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestRecoverer(t *testing.T) {
+	conn, closeFn := dial(t, newMemoryArticleStore())
+	defer closeFn()
+
+	_, err := articlepb.NewArticleServiceClient(conn).Create(context.Background(), &articlepb.CreateRequest{Article: &articlepb.Article{Title: "panic", Content: "Test Content", Author: "Test Author"}})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestHealth(t *testing.T) {
+	conn, closeFn := dial(t, newMemoryArticleStore())
+	defer closeFn()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "articles.ArticleService"})
+	if err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+}
+
+func TestArticleServiceReadYourWrites(t *testing.T) {
+	replica, primary := newMemoryArticleStore(), newMemoryArticleStore()
+	conn, closeFn := dialServer(t, &ArticleServer{Store: replica, Primary: primary, Window: 5 * time.Second})
+	defer closeFn()
+
+	client := articlepb.NewArticleServiceClient(conn)
+	ctx := context.Background()
+
+	var header metadata.MD
+	created, err := client.Create(ctx, &articlepb.CreateRequest{Article: &articlepb.Article{Title: "Test Title", Content: "Test Content", Author: "Test Author"}}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	// the article reached the primary but not the replica yet
+	primary.Post(&models.Article{Title: "Test Title", Content: "Test Content", Author: "Test Author"})
+	replica.Delete(1)
+
+	until := header.Get(ReadPrimaryHeader)
+	if assert.Len(t, until, 1, "writes must send the time until which reads go to the primary") {
+		v, err := strconv.ParseInt(until[0], 10, 64)
+		assert.NoError(t, err)
+		assert.InDelta(t, time.Now().Add(5*time.Second).Unix(), v, 1)
+	}
+
+	_, err = client.Get(ctx, &articlepb.GetRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err), "reads without the header are served by the replica")
+
+	got, err := client.Get(metadata.AppendToOutgoingContext(ctx, ReadPrimaryHeader, until[0]), &articlepb.GetRequest{Id: created.Id})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Test Title", got.Title)
+	}
+
+	expired := strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10)
+	_, err = client.Get(metadata.AppendToOutgoingContext(ctx, ReadPrimaryHeader, expired), &articlepb.GetRequest{Id: created.Id})
+	assert.Equal(t, codes.NotFound, status.Code(err), "expired headers are ignored")
+
+	for _, tc := range []struct {
+		ctx      context.Context
+		expected int
+	}{
+		{ctx, 0},
+		{metadata.AppendToOutgoingContext(ctx, ReadPrimaryHeader, until[0]), 1},
+	} {
+		stream, err := client.List(tc.ctx, &articlepb.ListRequest{})
+		if err != nil {
+			t.Fatalf("list failed: %v", err)
+		}
+		n := 0
+		for {
+			if _, err := stream.Recv(); err != nil {
+				assert.Equal(t, io.EOF, err)
+				break
+			}
+			n++
+		}
+		assert.Equal(t, tc.expected, n, "lists must be streamed from the database serving the reads of the call")
+	}
+}
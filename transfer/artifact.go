@@ -0,0 +1,187 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// ExportArticles is the job which writes an export to its artifact file.
+type ExportArticles struct {
+	ExportID int64 `json:"export_id"`
+}
+
+// Kind implements jobs.Args.
+func (ExportArticles) Kind() string { return "exports.write" }
+
+// CleanupExports is the job which removes the artifact files of expired exports.
+type CleanupExports struct{}
+
+// Kind implements jobs.Args.
+func (CleanupExports) Kind() string { return "exports.cleanup" }
+
+// ArticleSource streams articles matching a filter.
+type ArticleSource interface {
+	Count(filter models.ArticleFilter) (int, error)
+	EachMatching(filter models.ArticleFilter, fn func(*models.Article) error) error
+}
+
+// ExportStore defines database operations for bulk exports.
+type ExportStore interface {
+	Get(id int64) (*models.Export, error)
+	UpdateState(*models.Export) error
+	Expire() (*[]models.Export, error)
+}
+
+// Exporter writes exports to artifact files in Dir, which can be downloaded for TTL. Dir has to be
+// shared by every server and worker, since the server serving a download may not be the one whose
+// worker wrote it.
+type Exporter struct {
+	Store    ExportStore
+	Articles ArticleSource
+	Dir      string
+	TTL      time.Duration
+}
+
+// NewExporter creates and returns an exporter configured from viper.
+func NewExporter(store ExportStore, articles ArticleSource) *Exporter {
+	x := &Exporter{
+		Store:    store,
+		Articles: articles,
+		Dir:      ArtifactDir(),
+		TTL:      viper.GetDuration("export_ttl"),
+	}
+
+	if x.TTL <= 0 {
+		x.TTL = 24 * time.Hour
+	}
+
+	return x
+}
+
+// ArtifactDir returns the directory of export artifacts configured in viper.
+func ArtifactDir() string {
+	if dir := viper.GetString("export_dir"); dir != "" {
+		return dir
+	}
+	return "exports"
+}
+
+// ArtifactPath returns the path of the artifact file of an export in dir.
+func ArtifactPath(dir string, e *models.Export) string {
+	ext := e.Format
+	if e.Format == FormatMarkdownZip {
+		ext = "zip"
+	}
+	return filepath.Join(dir, fmt.Sprintf("export-%d.%s", e.ID, ext))
+}
+
+// Run writes an export to its artifact file, recording its progress every progressEvery articles.
+// Completed and expired exports are left as they are, so a repeated job does not write them again.
+func (x *Exporter) Run(ctx context.Context, id int64) error {
+	e, err := x.Store.Get(id)
+	if err != nil {
+		return err
+	}
+	if e.Status == models.ExportCompleted || e.Status == models.ExportExpired {
+		return nil
+	}
+
+	total, err := x.Articles.Count(e.Filter())
+	if err != nil {
+		return err
+	}
+
+	e.Status = models.ExportRunning
+	e.Exported, e.Total, e.Size, e.Error = 0, total, 0, ""
+	e.FinishedAt, e.ExpiresAt = nil, nil
+	if err := x.Store.UpdateState(e); err != nil {
+		return err
+	}
+
+	err = x.write(ctx, e)
+
+	now := time.Now()
+	e.FinishedAt = &now
+	if err != nil {
+		e.Status = models.ExportFailed
+		e.Error = err.Error()
+		if uerr := x.Store.UpdateState(e); uerr != nil {
+			return uerr
+		}
+		return err
+	}
+
+	expires := now.Add(x.TTL)
+	e.Status = models.ExportCompleted
+	e.ExpiresAt = &expires
+	return x.Store.UpdateState(e)
+}
+
+// write encodes the articles of an export into a temporary file which is renamed to the artifact
+// path once complete, so a partially written artifact is never served.
+func (x *Exporter) write(ctx context.Context, e *models.Export) error {
+	if err := os.MkdirAll(x.Dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(x.Dir, ".export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	enc, err := NewEncoder(e.Format, f)
+	if err != nil {
+		return err
+	}
+
+	n, err := ExportMatching(ctx, x.Articles, e.Filter(), enc, func(n int) {
+		e.Exported = n
+		// progress is informational, a failed update does not fail the export
+		x.Store.UpdateState(e)
+	})
+	if err != nil {
+		return err
+	}
+	e.Exported = n
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(f.Name())
+	if err != nil {
+		return err
+	}
+	e.Size = info.Size()
+
+	return os.Rename(f.Name(), ArtifactPath(x.Dir, e))
+}
+
+// Cleanup expires exports past their expiry, removes their artifact files and returns the number of
+// expired exports.
+func (x *Exporter) Cleanup() (int, error) {
+	exports, err := x.Store.Expire()
+	if err != nil {
+		return 0, err
+	}
+
+	// a file which cannot be removed does not keep the remaining files from being removed
+	var rerr error
+	for i := range *exports {
+		if err := os.Remove(ArtifactPath(x.Dir, &(*exports)[i])); err != nil && !os.IsNotExist(err) && rerr == nil {
+			rerr = err
+		}
+	}
+
+	return len(*exports), rerr
+}
@@ -0,0 +1,217 @@
+package transfer
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+type memoryArticleSource struct {
+	articles []models.Article
+	err      error
+}
+
+func (s *memoryArticleSource) matching(filter models.ArticleFilter) []models.Article {
+	var matched []models.Article
+	for _, a := range s.articles {
+		if filter.Author == "" || a.Author == filter.Author {
+			matched = append(matched, a)
+		}
+	}
+	return matched
+}
+
+func (s *memoryArticleSource) Count(filter models.ArticleFilter) (int, error) {
+	return len(s.matching(filter)), nil
+}
+
+func (s *memoryArticleSource) EachMatching(filter models.ArticleFilter, fn func(*models.Article) error) error {
+	if s.err != nil {
+		return s.err
+	}
+	for _, a := range s.matching(filter) {
+		if err := fn(&a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type memoryExportStore struct {
+	exports map[int64]models.Export
+	updates int
+}
+
+func (s *memoryExportStore) Get(id int64) (*models.Export, error) {
+	e := s.exports[id]
+	return &e, nil
+}
+
+func (s *memoryExportStore) UpdateState(e *models.Export) error {
+	s.updates++
+	s.exports[e.ID] = *e
+	return nil
+}
+
+func (s *memoryExportStore) Expire() (*[]models.Export, error) {
+	var expired []models.Export
+	for id, e := range s.exports {
+		if e.Status == models.ExportCompleted && !e.ExpiresAt.After(time.Now()) {
+			e.Status = models.ExportExpired
+			s.exports[id] = e
+			expired = append(expired, e)
+		}
+	}
+	return &expired, nil
+}
+
+func newTestExporter(t *testing.T, source *memoryArticleSource, exports ...models.Export) (*Exporter, *memoryExportStore) {
+	dir, err := ioutil.TempDir("", "exports")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+
+	store := &memoryExportStore{exports: map[int64]models.Export{}}
+	for _, e := range exports {
+		store.exports[e.ID] = e
+	}
+
+	x := NewExporter(store, source)
+	x.Dir = dir
+	x.TTL = time.Hour
+	return x, store
+}
+
+func TestExporterRun(t *testing.T) {
+	source := &memoryArticleSource{articles: []models.Article{
+		{ArticleID: models.ArticleID{ID: 1, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"}, Title: "Test Title", Content: "Test Content", Author: "Test Author", Slug: "test-title"},
+		{ArticleID: models.ArticleID{ID: 2, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAW"}, Title: "Another Test Title", Content: "Another Test Content", Author: "Another Test Author", Slug: "another-test-title"},
+	}}
+
+	tt := []struct {
+		name     string
+		export   models.Export
+		path     string
+		exported int
+		files    []string
+	}{
+		{
+			name:     "jsonl",
+			export:   models.Export{ID: 1, Format: FormatJSONL, Status: models.ExportPending},
+			path:     "export-1.jsonl",
+			exported: 2,
+		},
+		{
+			name:     "csv filtered by author",
+			export:   models.Export{ID: 2, Format: FormatCSV, Author: "Test Author", Status: models.ExportPending},
+			path:     "export-2.csv",
+			exported: 1,
+		},
+		{
+			name:     "markdown zip",
+			export:   models.Export{ID: 3, Format: FormatMarkdownZip, Status: models.ExportPending},
+			path:     "export-3.zip",
+			exported: 2,
+			files:    []string{"01ARZ3NDEKTSV4RRFFQ69G5FAV-test-title.md", "01ARZ3NDEKTSV4RRFFQ69G5FAW-another-test-title.md"},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			x, store := newTestExporter(t, source, tc.export)
+			defer os.RemoveAll(x.Dir)
+
+			if err := x.Run(context.Background(), tc.export.ID); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+
+			e := store.exports[tc.export.ID]
+			assert.Equal(t, models.ExportCompleted, e.Status)
+			assert.Equal(t, tc.exported, e.Exported)
+			assert.Equal(t, tc.exported, e.Total)
+			assert.WithinDuration(t, time.Now().Add(time.Hour), *e.ExpiresAt, time.Second)
+
+			info, err := os.Stat(filepath.Join(x.Dir, tc.path))
+			if err != nil {
+				t.Fatalf("stat artifact failed: %v", err)
+			}
+			assert.Equal(t, info.Size(), e.Size)
+
+			if tc.files != nil {
+				zr, err := zip.OpenReader(filepath.Join(x.Dir, tc.path))
+				if err != nil {
+					t.Fatalf("open zip failed: %v", err)
+				}
+				defer zr.Close()
+
+				var names []string
+				for _, f := range zr.File {
+					names = append(names, f.Name)
+				}
+				assert.Equal(t, tc.files, names)
+			}
+
+			// a completed export is not written again
+			updates := store.updates
+			if err := x.Run(context.Background(), tc.export.ID); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+			assert.Equal(t, updates, store.updates)
+		})
+	}
+}
+
+func TestExporterRunFailed(t *testing.T) {
+	source := &memoryArticleSource{err: errors.New("connection reset")}
+	x, store := newTestExporter(t, source, models.Export{ID: 1, Format: FormatJSONL, Status: models.ExportPending})
+	defer os.RemoveAll(x.Dir)
+
+	assert.EqualError(t, x.Run(context.Background(), 1), "connection reset")
+	assert.Equal(t, models.ExportFailed, store.exports[1].Status)
+	assert.Equal(t, "connection reset", store.exports[1].Error)
+	assert.NotNil(t, store.exports[1].FinishedAt)
+
+	// the partially written file is removed
+	files, err := ioutil.ReadDir(x.Dir)
+	if err != nil {
+		t.Fatalf("read dir failed: %v", err)
+	}
+	assert.Empty(t, files)
+}
+
+func TestExporterCleanup(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	valid := time.Now().Add(time.Hour)
+	x, store := newTestExporter(t, &memoryArticleSource{},
+		models.Export{ID: 1, Format: FormatCSV, Status: models.ExportCompleted, ExpiresAt: &expired},
+		models.Export{ID: 2, Format: FormatCSV, Status: models.ExportCompleted, ExpiresAt: &valid},
+	)
+	defer os.RemoveAll(x.Dir)
+
+	for _, name := range []string{"export-1.csv", "export-2.csv"} {
+		if err := ioutil.WriteFile(filepath.Join(x.Dir, name), []byte("id\n"), 0644); err != nil {
+			t.Fatalf("write file failed: %v", err)
+		}
+	}
+
+	n, err := x.Cleanup()
+	if err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	assert.Equal(t, 1, n)
+	assert.Equal(t, models.ExportExpired, store.exports[1].Status)
+
+	_, err = os.Stat(filepath.Join(x.Dir, "export-1.csv"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(x.Dir, "export-2.csv"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,130 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+var csvHeader = []string{"id", "title", "content", "author", "tags", "published_at", "content_format"}
+
+// csvRequired lists the columns a CSV file must contain.
+var csvRequired = []string{"title", "content", "author"}
+
+type csvEncoder struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+// Encode writes an article as a CSV record, preceded by the header on the first call.
+func (e *csvEncoder) Encode(a *models.Article) error {
+	if !e.wroteHeader {
+		if err := e.w.Write(csvHeader); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+
+	var publishedAt string
+	if a.PublishedAt != nil {
+		publishedAt = a.PublishedAt.Format(time.RFC3339)
+	}
+
+	return e.w.Write([]string{string(a.PublicID), a.Title, a.Content, a.Author, strings.Join(a.Tags, ","), publishedAt, a.ContentFormat})
+}
+
+func (e *csvEncoder) Close() error {
+	if !e.wroteHeader {
+		if err := e.w.Write(csvHeader); err != nil {
+			return err
+		}
+		e.wroteHeader = true
+	}
+
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type csvDecoder struct {
+	r       *csv.Reader
+	columns map[string]int
+}
+
+func newCSVDecoder(r io.Reader) *csvDecoder {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	return &csvDecoder{r: cr}
+}
+
+// Decode reads the next CSV record. The header may list the columns in any order and omit the
+// id, tags, published_at and content_format columns. Tags are separated by commas.
+func (d *csvDecoder) Decode(a *models.Article) error {
+	if d.columns == nil {
+		header, err := d.r.Read()
+		if err != nil {
+			return err
+		}
+
+		d.columns = make(map[string]int, len(header))
+		for i, name := range header {
+			d.columns[name] = i
+		}
+
+		for _, name := range csvRequired {
+			if _, ok := d.columns[name]; !ok {
+				return fmt.Errorf("csv header is missing column %q", name)
+			}
+		}
+	}
+
+	record, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+
+	*a = models.Article{
+		Title:   record[d.columns["title"]],
+		Content: record[d.columns["content"]],
+		Author:  record[d.columns["author"]],
+	}
+
+	// serial ids of files exported before public ids were introduced are ignored
+	if i, ok := d.columns["id"]; ok && record[i] != "" && !isSerial(record[i]) {
+		if a.PublicID, err = models.ParsePublicID(record[i]); err != nil {
+			return fmt.Errorf("csv id %q: %v", record[i], err)
+		}
+	}
+
+	if i, ok := d.columns["tags"]; ok && record[i] != "" {
+		a.Tags = strings.Split(record[i], ",")
+	}
+
+	if i, ok := d.columns["content_format"]; ok {
+		a.ContentFormat = record[i]
+	}
+
+	if i, ok := d.columns["published_at"]; ok && record[i] != "" {
+		t, err := time.Parse(time.RFC3339, record[i])
+		if err != nil {
+			return fmt.Errorf("csv published_at %q: %v", record[i], err)
+		}
+		a.PublishedAt = &t
+	}
+
+	return nil
+}
+
+// isSerial reports whether s is a serial article id.
+func isSerial(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
@@ -0,0 +1,39 @@
+package transfer
+
+import (
+	"context"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// Export streams every article in store to enc and returns the number of exported articles.
+// progress, if set, is called with the running total after every progressEvery articles.
+func Export(store *database.ArticleStore, enc Encoder, progress func(n int)) (int, error) {
+	return ExportMatching(context.Background(), store, models.ArticleFilter{}, enc, progress)
+}
+
+// ExportMatching streams the articles matching filter to enc like Export, and stops once ctx is done.
+func ExportMatching(ctx context.Context, store ArticleSource, filter models.ArticleFilter, enc Encoder, progress func(n int)) (int, error) {
+	n := 0
+	err := store.EachMatching(filter, func(a *models.Article) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := enc.Encode(a); err != nil {
+			return err
+		}
+
+		n++
+		if progress != nil && n%progressEvery == 0 {
+			progress(n)
+		}
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+
+	return n, enc.Close()
+}
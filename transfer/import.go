@@ -0,0 +1,148 @@
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/models"
+)
+
+const progressEvery = 1000
+
+// Importer writes decoded articles to the database in batches, recording a checkpoint after every
+// committed batch so that a failed import can be resumed without duplicating articles.
+type Importer struct {
+	Store     *database.ArticleStore
+	BatchSize int
+	// Checkpoint is the path of the file holding the number of committed records, empty disables checkpoints.
+	Checkpoint string
+	// DryRun validates every record without writing to the database.
+	DryRun bool
+	// Progress, if set, is called with the running total of processed records.
+	Progress func(n int)
+	// Invalid, if set, is called for every record failing validation during a dry run.
+	Invalid func(record int, err error)
+}
+
+// Import reads all records from dec and returns the number of records processed, including records
+// skipped from a previous run. On failure it returns the number of committed records instead.
+// Records are numbered from 1.
+func (im *Importer) Import(dec Decoder) (int, error) {
+	skip, err := im.readCheckpoint()
+	if err != nil {
+		return 0, err
+	}
+
+	batchSize := im.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	var batch []models.Article
+	n, committed, invalid := 0, skip, 0
+	for {
+		var a models.Article
+		if err := dec.Decode(&a); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return committed, fmt.Errorf("record %d: %v", n+1, err)
+		}
+
+		n++
+		if n <= skip {
+			continue
+		}
+
+		if err := a.Validate(); err != nil {
+			if !im.DryRun {
+				return committed, fmt.Errorf("record %d: %v", n, err)
+			}
+
+			invalid++
+			if im.Invalid != nil {
+				im.Invalid(n, err)
+			}
+		}
+
+		if !im.DryRun {
+			batch = append(batch, a)
+			if len(batch) == batchSize {
+				if err := im.commit(batch, n); err != nil {
+					return committed, err
+				}
+				committed = n
+				batch = batch[:0]
+			}
+		}
+
+		if im.Progress != nil && n%progressEvery == 0 {
+			im.Progress(n)
+		}
+	}
+
+	if len(batch) > 0 {
+		if err := im.commit(batch, n); err != nil {
+			return committed, err
+		}
+	}
+
+	if invalid > 0 {
+		return n, fmt.Errorf("%d of %d records are invalid", invalid, n)
+	}
+
+	if !im.DryRun && im.Checkpoint != "" {
+		if err := os.Remove(im.Checkpoint); err != nil && !os.IsNotExist(err) {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// commit inserts a batch of articles in one transaction and checkpoints the last committed record.
+func (im *Importer) commit(batch []models.Article, last int) error {
+	err := im.Store.RunInTransaction(func(store *database.ArticleStore) error {
+		for i := range batch {
+			if _, err := store.Import(&batch[i]); err != nil {
+				return fmt.Errorf("record %d: %v", last-len(batch)+i+1, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if im.Checkpoint == "" {
+		return nil
+	}
+
+	return ioutil.WriteFile(im.Checkpoint, []byte(strconv.Itoa(last)), 0644)
+}
+
+func (im *Importer) readCheckpoint() (int, error) {
+	if im.Checkpoint == "" || im.DryRun {
+		return 0, nil
+	}
+
+	b, err := ioutil.ReadFile(im.Checkpoint)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0, fmt.Errorf("checkpoint %s: %v", im.Checkpoint, err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,42 @@
+package transfer
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+type jsonlEncoder struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer) *jsonlEncoder {
+	bw := bufio.NewWriter(w)
+	return &jsonlEncoder{w: bw, enc: json.NewEncoder(bw)}
+}
+
+// Encode writes an article as a single line of JSON.
+func (e *jsonlEncoder) Encode(a *models.Article) error {
+	return e.enc.Encode(a)
+}
+
+func (e *jsonlEncoder) Close() error {
+	return e.w.Flush()
+}
+
+type jsonlDecoder struct {
+	dec *json.Decoder
+}
+
+func newJSONLDecoder(r io.Reader) *jsonlDecoder {
+	return &jsonlDecoder{dec: json.NewDecoder(bufio.NewReader(r))}
+}
+
+// Decode reads the next JSON article.
+func (d *jsonlDecoder) Decode(a *models.Article) error {
+	*a = models.Article{}
+	return d.dec.Decode(a)
+}
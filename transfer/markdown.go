@@ -0,0 +1,260 @@
+package transfer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/ykaseng/articles-library/content"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// FormatMarkdown writes one Markdown file with YAML front matter per article into a directory.
+const FormatMarkdown = "markdown"
+
+// FormatMarkdownZip writes the files of FormatMarkdown into a zip archive.
+const FormatMarkdownZip = "markdown-zip"
+
+// ErrNoFrontMatter is returned for Markdown files which do not start with a front matter block.
+var ErrNoFrontMatter = errors.New("markdown file does not start with --- front matter")
+
+const frontMatterDelimiter = "---\n"
+
+type frontMatter struct {
+	// ID is the public ID of the article, serial ids of files written before public IDs were
+	// introduced are ignored.
+	ID     string     `yaml:"id,omitempty"`
+	Title  string     `yaml:"title"`
+	Author string     `yaml:"author"`
+	Tags   []string   `yaml:"tags,omitempty"`
+	Date   *time.Time `yaml:"date,omitempty"`
+	// Format is the content format of the body, omitted for markdown.
+	Format string `yaml:"format,omitempty"`
+}
+
+// readMarkdown parses an article from Markdown with YAML front matter, the body becomes the content.
+func readMarkdown(b []byte) (*models.Article, error) {
+	b = bytes.Replace(b, []byte("\r\n"), []byte("\n"), -1)
+	if !bytes.HasPrefix(b, []byte(frontMatterDelimiter)) {
+		return nil, ErrNoFrontMatter
+	}
+	b = b[len(frontMatterDelimiter):]
+
+	end := bytes.Index(b, []byte("\n"+frontMatterDelimiter))
+	if end < 0 {
+		return nil, ErrNoFrontMatter
+	}
+
+	var fm frontMatter
+	if err := yaml.UnmarshalStrict(b[:end+1], &fm); err != nil {
+		return nil, err
+	}
+
+	body := string(b[end+1+len(frontMatterDelimiter):])
+	body = strings.TrimPrefix(body, "\n")
+	body = strings.TrimSuffix(body, "\n")
+
+	if fm.Format == "" {
+		fm.Format = content.FormatMarkdown
+	}
+
+	var id models.PublicID
+	if fm.ID != "" && !isSerial(fm.ID) {
+		var err error
+		if id, err = models.ParsePublicID(fm.ID); err != nil {
+			return nil, fmt.Errorf("front matter id %q: %v", fm.ID, err)
+		}
+	}
+
+	return &models.Article{
+		ArticleID:     models.ArticleID{PublicID: id},
+		Title:         fm.Title,
+		Content:       body,
+		ContentFormat: fm.Format,
+		Author:        fm.Author,
+		Tags:          fm.Tags,
+		PublishedAt:   fm.Date,
+	}, nil
+}
+
+// writeMarkdown renders an article as Markdown with YAML front matter.
+func writeMarkdown(a *models.Article) ([]byte, error) {
+	format := contentFormat(a)
+	if format == content.FormatMarkdown {
+		format = ""
+	}
+
+	fm, err := yaml.Marshal(&frontMatter{
+		ID:     string(a.PublicID),
+		Title:  a.Title,
+		Author: a.Author,
+		Tags:   a.Tags,
+		Date:   a.PublishedAt,
+		Format: format,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelimiter)
+	buf.Write(fm)
+	buf.WriteString(frontMatterDelimiter)
+	buf.WriteString("\n")
+	buf.WriteString(a.Content)
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// contentFormat returns the content format of an article, plain when unset.
+func contentFormat(a *models.Article) string {
+	if a.ContentFormat == "" {
+		return content.FormatPlain
+	}
+	return a.ContentFormat
+}
+
+// markdownFileName returns the file name of an article, made of its public ID and slug, or its
+// title when it has no slug.
+func markdownFileName(a *models.Article) string {
+	if a.Slug != "" {
+		return fmt.Sprintf("%s-%s.md", a.PublicID, a.Slug)
+	}
+
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(a.Title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+
+	name := strings.TrimSuffix(b.String(), "-")
+	if name == "" {
+		return fmt.Sprintf("%s.md", a.PublicID)
+	}
+	return fmt.Sprintf("%s-%s.md", a.PublicID, name)
+}
+
+type markdownEncoder struct {
+	dir   string
+	state *syncState
+}
+
+// NewMarkdownEncoder returns an Encoder writing one Markdown file per article into dir. Close records
+// the written articles as synced so that a later sync of dir can detect conflicting changes.
+func NewMarkdownEncoder(dir string) (Encoder, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	state, err := readSyncState(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &markdownEncoder{dir: dir, state: state}, nil
+}
+
+// Encode writes an article to its own Markdown file.
+func (e *markdownEncoder) Encode(a *models.Article) error {
+	b, err := writeMarkdown(a)
+	if err != nil {
+		return err
+	}
+
+	name := markdownFileName(a)
+	if err := ioutil.WriteFile(filepath.Join(e.dir, name), b, 0644); err != nil {
+		return err
+	}
+
+	e.state.Articles[a.PublicID] = syncEntry{Path: name, Hash: hashArticle(a)}
+	return nil
+}
+
+func (e *markdownEncoder) Close() error {
+	return e.state.write(e.dir)
+}
+
+type markdownZipEncoder struct {
+	w *zip.Writer
+}
+
+func newMarkdownZipEncoder(w io.Writer) *markdownZipEncoder {
+	return &markdownZipEncoder{w: zip.NewWriter(w)}
+}
+
+// Encode writes an article to its own Markdown file in the archive.
+func (e *markdownZipEncoder) Encode(a *models.Article) error {
+	b, err := writeMarkdown(a)
+	if err != nil {
+		return err
+	}
+
+	f, err := e.w.Create(markdownFileName(a))
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	return err
+}
+
+// Close writes the central directory of the archive.
+func (e *markdownZipEncoder) Close() error {
+	return e.w.Close()
+}
+
+const syncStateFile = ".articles-sync.json"
+
+// syncState records the path and content hash of every article at its last sync, by public ID.
+type syncState struct {
+	Articles map[models.PublicID]syncEntry `json:"articles"`
+}
+
+type syncEntry struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+}
+
+func readSyncState(dir string) (*syncState, error) {
+	state := &syncState{Articles: map[models.PublicID]syncEntry{}}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, syncStateFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, fmt.Errorf("%s: %v", syncStateFile, err)
+	}
+
+	return state, nil
+}
+
+func (s *syncState) write(dir string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, syncStateFile), b, 0644)
+}
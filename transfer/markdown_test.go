@@ -0,0 +1,160 @@
+package transfer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+func TestReadMarkdown(t *testing.T) {
+	date := time.Date(2019, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name     string
+		input    string
+		expected *models.Article
+		err      string
+	}{
+		{
+			name:  "front matter and body",
+			input: "---\nid: 01arz3ndektsv4rrffq69g5fav\ntitle: Test Title\nauthor: Test Author\ntags: [go, test]\ndate: 2019-10-01\n---\n\n# Heading\n\nTest Content\n",
+			expected: &models.Article{
+				ArticleID:     models.ArticleID{PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"},
+				Title:         "Test Title",
+				Content:       "# Heading\n\nTest Content",
+				ContentFormat: "markdown",
+				Author:        "Test Author",
+				Tags:          []string{"go", "test"},
+				PublishedAt:   &date,
+			},
+		},
+		{
+			name:     "windows line endings without id",
+			input:    "---\r\ntitle: Test Title\r\nauthor: Test Author\r\nformat: html\r\n---\r\n<p>Test Content</p>",
+			expected: &models.Article{Title: "Test Title", Content: "<p>Test Content</p>", ContentFormat: "html", Author: "Test Author"},
+		},
+		{
+			name:     "serial id",
+			input:    "---\nid: 3\ntitle: Test Title\nauthor: Test Author\n---\nTest Content\n",
+			expected: &models.Article{Title: "Test Title", Content: "Test Content", ContentFormat: "markdown", Author: "Test Author"},
+		},
+		{
+			name:  "invalid id",
+			input: "---\nid: abc\ntitle: Test Title\nauthor: Test Author\n---\nTest Content\n",
+			err:   `front matter id "abc": ` + models.ErrInvalidPublicID.Error(),
+		},
+		{
+			name:  "missing front matter",
+			input: "# Test Title\n",
+			err:   ErrNoFrontMatter.Error(),
+		},
+		{
+			name:  "unknown front matter field",
+			input: "---\ntitle: Test Title\nsubtitle: Test Subtitle\n---\nTest Content\n",
+			err:   "yaml: unmarshal errors:\n  line 2: field subtitle not found in type transfer.frontMatter",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := readMarkdown([]byte(tc.input))
+			if err != nil {
+				assert.Equal(t, tc.err, err.Error())
+				return
+			}
+
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	date := time.Date(2019, 10, 1, 9, 30, 0, 0, time.UTC)
+	article := &models.Article{
+		ArticleID:     models.ArticleID{PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"},
+		Title:         "Test Title",
+		Content:       "Test Content\n",
+		ContentFormat: "plain",
+		Author:        "Test Author",
+		Tags:          []string{"go"},
+		PublishedAt:   &date,
+	}
+
+	b, err := writeMarkdown(article)
+	if err != nil {
+		t.Fatalf("write markdown failed: %v", err)
+	}
+
+	assert.Equal(t, "---\nid: 01ARZ3NDEKTSV4RRFFQ69G5FAV\ntitle: Test Title\nauthor: Test Author\ntags:\n- go\ndate: 2019-10-01T09:30:00Z\nformat: plain\n---\n\nTest Content\n\n", string(b))
+
+	actual, err := readMarkdown(b)
+	if err != nil {
+		t.Fatalf("read markdown failed: %v", err)
+	}
+
+	assert.Equal(t, article, actual)
+	assert.Equal(t, hashArticle(article), hashArticle(actual))
+}
+
+func TestMarkdownFileName(t *testing.T) {
+	tt := []struct {
+		name     string
+		article  *models.Article
+		expected string
+	}{
+		{"slug", &models.Article{ArticleID: models.ArticleID{ID: 1, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"}, Title: "Hello, World!", Slug: "hello-world-1"}, "01ARZ3NDEKTSV4RRFFQ69G5FAV-hello-world-1.md"},
+		{"title words", &models.Article{ArticleID: models.ArticleID{ID: 1, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"}, Title: "Hello, World!"}, "01ARZ3NDEKTSV4RRFFQ69G5FAV-hello-world.md"},
+		{"title without letters", &models.Article{ArticleID: models.ArticleID{ID: 2, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAW"}, Title: "?!"}, "01ARZ3NDEKTSV4RRFFQ69G5FAW.md"},
+		{"unicode title", &models.Article{ArticleID: models.ArticleID{ID: 3, PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAX"}, Title: "Crème Brûlée"}, "01ARZ3NDEKTSV4RRFFQ69G5FAX-crème-brûlée.md"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, markdownFileName(tc.article))
+		})
+	}
+}
+
+func TestMarkdownEncoder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "articles")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	article := &models.Article{ArticleID: models.ArticleID{PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"}, Title: "Test Title", Content: "Test Content", ContentFormat: "markdown", Author: "Test Author", Slug: "test-title"}
+
+	enc, err := NewMarkdownEncoder(dir)
+	if err != nil {
+		t.Fatalf("create encoder failed: %v", err)
+	}
+	if err := enc.Encode(article); err != nil {
+		t.Errorf("encode failed: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Errorf("close failed: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "01ARZ3NDEKTSV4RRFFQ69G5FAV-test-title.md"))
+	if err != nil {
+		t.Fatalf("read file failed: %v", err)
+	}
+
+	actual, err := readMarkdown(b)
+	if err != nil {
+		t.Fatalf("read markdown failed: %v", err)
+	}
+	assert.Equal(t, models.Article{ArticleID: article.ArticleID, Title: article.Title, Content: article.Content, ContentFormat: article.ContentFormat, Author: article.Author}, *actual)
+
+	state, err := readSyncState(dir)
+	if err != nil {
+		t.Fatalf("read sync state failed: %v", err)
+	}
+	assert.Equal(t, map[models.PublicID]syncEntry{"01ARZ3NDEKTSV4RRFFQ69G5FAV": {Path: "01ARZ3NDEKTSV4RRFFQ69G5FAV-test-title.md", Hash: hashArticle(article)}}, state.Articles)
+}
@@ -0,0 +1,211 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ykaseng/articles-library/database"
+	"github.com/ykaseng/articles-library/models"
+)
+
+// Syncer imports and updates articles from a directory tree of Markdown files, matching files to
+// articles by the public ID in their front matter, or else by the slug of their title.
+type Syncer struct {
+	Store *database.ArticleStore
+	Dir   string
+	// DryRun reports the changes a sync would make without writing files or the database.
+	DryRun bool
+}
+
+// SyncReport lists the file paths affected by a sync, relative to the synced directory.
+type SyncReport struct {
+	// Created files had no matching article, they were inserted and their public ID written to the
+	// file.
+	Created []string
+	// Updated files changed since the last sync and were written to the database.
+	Updated []string
+	// Pulled articles changed in the database since the last sync and were written to the file.
+	Pulled []string
+	// Conflicts changed in both the file and the database since the last sync and were left untouched.
+	Conflicts []string
+	Unchanged []string
+}
+
+// Sync walks the directory and reconciles every Markdown file with the database.
+func (s *Syncer) Sync() (*SyncReport, error) {
+	state, err := readSyncState(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SyncReport{}
+	err = filepath.Walk(s.Dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(info.Name(), ".") && path != s.Dir {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() || filepath.Ext(path) != ".md" {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.Dir, path)
+		if err != nil {
+			return err
+		}
+
+		if err := s.syncFile(path, rel, state, report); err != nil {
+			return fmt.Errorf("%s: %v", rel, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.DryRun {
+		return report, nil
+	}
+
+	return report, state.write(s.Dir)
+}
+
+func (s *Syncer) syncFile(path, rel string, state *syncState, report *SyncReport) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	article, err := readMarkdown(b)
+	if err != nil {
+		return err
+	}
+
+	if err := article.Validate(); err != nil {
+		return err
+	}
+
+	existing, err := s.find(article)
+	if err != nil {
+		return err
+	}
+
+	fileHash := hashArticle(article)
+	if existing == nil {
+		report.Created = append(report.Created, rel)
+		if s.DryRun {
+			return nil
+		}
+
+		articleID, err := s.Store.Import(article)
+		if err != nil {
+			return err
+		}
+		article.ArticleID = *articleID
+
+		state.Articles[article.PublicID] = syncEntry{Path: rel, Hash: fileHash}
+		return s.writeFile(path, article)
+	}
+
+	dbHash := hashArticle(existing)
+	last, synced := state.Articles[existing.PublicID]
+	pulled := false
+	switch {
+	case fileHash == dbHash:
+		report.Unchanged = append(report.Unchanged, rel)
+	case synced && dbHash == last.Hash:
+		report.Updated = append(report.Updated, rel)
+		if !s.DryRun {
+			if err := s.Store.Update(existing.ID, article); err != nil {
+				return err
+			}
+		}
+	case synced && fileHash == last.Hash:
+		report.Pulled = append(report.Pulled, rel)
+		pulled = true
+		if !s.DryRun {
+			if err := s.writeFile(path, existing); err != nil {
+				return err
+			}
+		}
+		fileHash = dbHash
+	default:
+		report.Conflicts = append(report.Conflicts, rel)
+		return nil
+	}
+
+	// a file matched by its slug gets the public ID of its article, so that it is matched by the
+	// public ID from now on, pulled files already hold it
+	if article.PublicID != existing.PublicID && !pulled && !s.DryRun {
+		article.ArticleID = existing.ArticleID
+		if err := s.writeFile(path, article); err != nil {
+			return err
+		}
+	}
+
+	state.Articles[existing.PublicID] = syncEntry{Path: rel, Hash: fileHash}
+	return nil
+}
+
+// find returns the article of a file by its public ID, or else by the slug of its title, which
+// matches files without a public ID to the articles they were exported from. It returns nil when
+// there is none.
+func (s *Syncer) find(article *models.Article) (*models.Article, error) {
+	if article.PublicID != "" {
+		id, err := s.Store.Resolve(article.PublicID)
+		if err != nil && err != database.ErrArticleNotFound {
+			return nil, err
+		}
+		if err == nil {
+			articles, err := s.Store.Get(id)
+			if err != nil {
+				return nil, err
+			}
+			if len(*articles) > 0 {
+				return &(*articles)[0], nil
+			}
+		}
+	}
+
+	existing, err := s.Store.GetBySlug(models.Slugify(article.Title))
+	if err == database.ErrArticleNotFound {
+		return nil, nil
+	}
+	return existing, err
+}
+
+func (s *Syncer) writeFile(path string, a *models.Article) error {
+	b, err := writeMarkdown(a)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// hashArticle returns a hash over the synced fields of an article, ignoring its id.
+func hashArticle(a *models.Article) string {
+	var publishedAt string
+	if a.PublishedAt != nil {
+		publishedAt = a.PublishedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	h := sha256.New()
+	for _, field := range []string{a.Title, a.Author, strings.Join(a.Tags, ","), publishedAt, contentFormat(a), a.Content} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,58 @@
+// Package transfer encodes, decodes and moves articles between files and the database.
+package transfer
+
+import (
+	"errors"
+	"io"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// The list of supported file formats.
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// The list of error types returned from transfer.
+var (
+	ErrUnknownFormat = errors.New("unknown format, must be one of jsonl or csv")
+)
+
+// Encoder writes articles to a file format.
+type Encoder interface {
+	Encode(*models.Article) error
+	// Close flushes buffered articles, it does not close the underlying writer.
+	Close() error
+}
+
+// Decoder reads articles from a file format and returns io.EOF once all articles are read.
+type Decoder interface {
+	Decode(*models.Article) error
+}
+
+// NewEncoder returns an Encoder writing articles to w in format.
+func NewEncoder(format string, w io.Writer) (Encoder, error) {
+	switch format {
+	case FormatJSONL:
+		return newJSONLEncoder(w), nil
+	case FormatCSV:
+		return newCSVEncoder(w), nil
+	case FormatMarkdownZip:
+		return newMarkdownZipEncoder(w), nil
+	}
+
+	return nil, ErrUnknownFormat
+}
+
+// NewDecoder returns a Decoder reading articles in format from r.
+func NewDecoder(format string, r io.Reader) (Decoder, error) {
+	switch format {
+	case FormatJSONL:
+		return newJSONLDecoder(r), nil
+	case FormatCSV:
+		return newCSVDecoder(r), nil
+	}
+
+	return nil, ErrUnknownFormat
+}
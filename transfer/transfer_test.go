@@ -0,0 +1,138 @@
+package transfer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+func TestRoundTrip(t *testing.T) {
+	publishedAt := time.Date(2019, 10, 1, 9, 30, 0, 0, time.UTC)
+	articles := []models.Article{
+		{ArticleID: models.ArticleID{PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAV"}, Title: "Test Title", Content: "Test Content", Author: "Test Author", ContentFormat: "markdown", Tags: []string{"go", "test"}, PublishedAt: &publishedAt},
+		{ArticleID: models.ArticleID{PublicID: "01ARZ3NDEKTSV4RRFFQ69G5FAW"}, Title: "Another, \"quoted\" Title", Content: "Multi\nline\ncontent", Author: "Another Test Author"},
+	}
+
+	for _, format := range []string{FormatJSONL, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc, err := NewEncoder(format, &buf)
+			if err != nil {
+				t.Fatalf("create encoder failed: %v", err)
+			}
+
+			for i := range articles {
+				if err := enc.Encode(&articles[i]); err != nil {
+					t.Errorf("encode failed: %v", err)
+				}
+			}
+			if err := enc.Close(); err != nil {
+				t.Errorf("close failed: %v", err)
+			}
+
+			dec, err := NewDecoder(format, &buf)
+			if err != nil {
+				t.Fatalf("create decoder failed: %v", err)
+			}
+
+			var actual []models.Article
+			for {
+				var a models.Article
+				if err := dec.Decode(&a); err != nil {
+					assert.Equal(t, io.EOF, err)
+					break
+				}
+				actual = append(actual, a)
+			}
+
+			assert.Equal(t, articles, actual)
+		})
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := NewEncoder("xml", &bytes.Buffer{})
+	assert.Equal(t, ErrUnknownFormat, err)
+
+	_, err = NewDecoder("xml", &bytes.Buffer{})
+	assert.Equal(t, ErrUnknownFormat, err)
+}
+
+func TestCSVDecoder(t *testing.T) {
+	tt := []struct {
+		name     string
+		input    string
+		expected []models.Article
+		err      string
+	}{
+		{
+			name:     "columns in any order without id",
+			input:    "author,title,content\nTest Author,Test Title,Test Content\n",
+			expected: []models.Article{{Title: "Test Title", Content: "Test Content", Author: "Test Author"}},
+		},
+		{
+			name:  "missing column",
+			input: "title,content\nTest Title,Test Content\n",
+			err:   `csv header is missing column "author"`,
+		},
+		{
+			name:  "invalid id",
+			input: "id,title,content,author\none,Test Title,Test Content,Test Author\n",
+			err:   `csv id "one": article id must be a ULID`,
+		},
+		{
+			name:     "serial id",
+			input:    "id,title,content,author\n1,Test Title,Test Content,Test Author\n",
+			expected: []models.Article{{Title: "Test Title", Content: "Test Content", Author: "Test Author"}},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			dec, _ := NewDecoder(FormatCSV, strings.NewReader(tc.input))
+
+			var actual []models.Article
+			for {
+				var a models.Article
+				if err := dec.Decode(&a); err != nil {
+					if err != io.EOF {
+						assert.Equal(t, tc.err, err.Error())
+					}
+					break
+				}
+				actual = append(actual, a)
+			}
+
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestImporterDryRun(t *testing.T) {
+	input := `{"title":"Test Title","content":"Test Content","author":"Test Author"}
+{"title":"Test Title","author":"Test Author"}
+{"title":"Another Test Title","content":"Another Test Content","author":"Another Test Author"}
+`
+
+	var invalid []string
+	importer := &Importer{
+		DryRun: true,
+		Invalid: func(record int, err error) {
+			invalid = append(invalid, fmt.Sprintf("%d: %v", record, err))
+		},
+	}
+
+	dec, _ := NewDecoder(FormatJSONL, strings.NewReader(input))
+	n, err := importer.Import(dec)
+
+	assert.Equal(t, 3, n)
+	assert.EqualError(t, err, "1 of 3 records are invalid")
+	assert.Equal(t, []string{"2: content: cannot be blank."}, invalid)
+}
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned for deliveries to addresses which are not publicly routable.
+var ErrPrivateAddress = errors.New("webhook address is not publicly routable")
+
+// privateNets lists the ranges of addresses which are not publicly routable, other than the
+// loopback, link-local, multicast and unspecified addresses recognized by net.IP.
+var privateNets = parseCIDRs(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+	"fc00::/7",
+)
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets[i] = n
+	}
+	return nets
+}
+
+// publicIP reports whether ip is publicly routable. Loopback, private and link-local addresses,
+// such as the cloud metadata service at 169.254.169.254, are not.
+func publicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, n := range privateNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewClient returns the HTTP client sending deliveries with timeout. Unless allowPrivate is set it
+// refuses to connect to addresses which are not publicly routable, so that webhooks cannot reach
+// internal services. The address is checked when connecting, after DNS resolution, so hostnames
+// resolving to internal addresses are refused too. Redirects are not followed, as they could lead
+// to internal addresses as well, and fail the delivery.
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicIP(net.ParseIP(host)) {
+				return ErrPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		// no proxy, which would be dialed instead of the webhook and bypass the address check
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,196 @@
+// Package webhook delivers article events to registered webhooks with signed, retried requests.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+// maxErrorLength limits the response body recorded as the error of a failed attempt.
+const maxErrorLength = 512
+
+// DeliveryStore defines database operations for dispatching deliveries.
+type DeliveryStore interface {
+	Due(limit int, lease time.Duration) (*[]models.Delivery, error)
+	UpdateState(*models.Delivery) error
+}
+
+// Dispatcher sends due deliveries and reschedules failed ones.
+type Dispatcher struct {
+	Store  DeliveryStore
+	Client *http.Client
+	Logger logrus.FieldLogger
+
+	// MaxAttempts is the number of failed attempts after which a delivery is dead.
+	MaxAttempts int
+	// Backoff is the delay after the first failed attempt, it doubles with each further failure
+	// up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Tick is how often Run checks for due deliveries.
+	Tick time.Duration
+	// BatchSize is the number of due deliveries claimed at once.
+	BatchSize int
+}
+
+// NewDispatcher creates and returns a dispatcher configured from viper.
+func NewDispatcher(store DeliveryStore, logger logrus.FieldLogger) *Dispatcher {
+	timeout := viper.GetDuration("webhook_timeout")
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	d := &Dispatcher{
+		Store:       store,
+		Client:      NewClient(timeout, viper.GetBool("webhook_allow_private_addresses")),
+		Logger:      logger,
+		MaxAttempts: viper.GetInt("webhook_max_attempts"),
+		Backoff:     viper.GetDuration("webhook_backoff"),
+		MaxBackoff:  viper.GetDuration("webhook_max_backoff"),
+		Tick:        5 * time.Second,
+		BatchSize:   20,
+	}
+
+	if d.MaxAttempts <= 0 {
+		d.MaxAttempts = 10
+	}
+	if d.Backoff <= 0 {
+		d.Backoff = 30 * time.Second
+	}
+	if d.MaxBackoff < d.Backoff {
+		d.MaxBackoff = 6 * time.Hour
+	}
+
+	return d
+}
+
+// Run sends due deliveries every Tick until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	t := time.NewTicker(d.Tick)
+	defer t.Stop()
+
+	for {
+		if _, err := d.DispatchDue(); err != nil {
+			d.Logger.WithField("module", "webhook").Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// DispatchDue sends all deliveries which are due and returns the number of successful deliveries.
+// Failed attempts are logged and recorded on the delivery.
+func (d *Dispatcher) DispatchDue() (int, error) {
+	// a claimed delivery is not handed to another dispatcher until its request has timed out
+	lease := 2 * d.Client.Timeout
+
+	var delivered int
+	for {
+		deliveries, err := d.Store.Due(d.BatchSize, lease)
+		if err != nil {
+			return delivered, err
+		}
+		if len(*deliveries) == 0 {
+			return delivered, nil
+		}
+
+		for i := range *deliveries {
+			dl := &(*deliveries)[i]
+			if err := d.Deliver(dl); err != nil {
+				d.Logger.WithFields(logrus.Fields{"module": "webhook", "delivery_id": dl.ID, "webhook_id": dl.WebhookID, "attempts": dl.Attempts, "status": dl.Status}).Warn(err)
+				continue
+			}
+			delivered++
+		}
+	}
+}
+
+// Deliver attempts a delivery and records its outcome. A failed delivery is retried after an
+// exponentially growing delay until MaxAttempts is reached, after which it is dead.
+func (d *Dispatcher) Deliver(dl *models.Delivery) error {
+	dl.Attempts++
+	status, err := d.send(dl)
+
+	now := time.Now()
+	dl.ResponseStatus = status
+	if err != nil {
+		dl.LastError = err.Error()
+		if dl.Attempts >= d.MaxAttempts {
+			dl.Status = models.DeliveryDead
+		} else {
+			dl.NextAttemptAt = now.Add(d.backoff(dl.Attempts))
+		}
+	} else {
+		dl.Status = models.DeliveryDelivered
+		dl.LastError = ""
+		dl.DeliveredAt = &now
+	}
+
+	if uerr := d.Store.UpdateState(dl); uerr != nil && err == nil {
+		err = uerr
+	}
+
+	return err
+}
+
+// backoff returns the delay before the next attempt after attempts failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	b := d.Backoff
+	for i := 1; i < attempts && b < d.MaxBackoff; i++ {
+		b *= 2
+	}
+	if b > d.MaxBackoff {
+		b = d.MaxBackoff
+	}
+	return b
+}
+
+// send posts a delivery and returns the response status, any status other than 2xx is an error.
+func (d *Dispatcher) send(dl *models.Delivery) (int, error) {
+	if dl.Webhook == nil {
+		return 0, fmt.Errorf("webhook %d not found", dl.WebhookID)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, dl.Webhook.URL, bytes.NewReader(dl.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "articles-library")
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(dl.ID, 10))
+	req.Header.Set(EventHeader, dl.Event)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(dl.Webhook.Secret, timestamp, dl.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorLength))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d %s: %s", resp.StatusCode, http.StatusText(resp.StatusCode), bytes.TrimSpace(body))
+	}
+
+	// drain the body so the connection is reused
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxErrorLength))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,235 @@
+package webhook
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ykaseng/articles-library/models"
+)
+
+type memoryDeliveryStore struct {
+	due     []models.Delivery
+	updates []models.Delivery
+}
+
+func (s *memoryDeliveryStore) Due(limit int, lease time.Duration) (*[]models.Delivery, error) {
+	d := s.due
+	if len(d) > limit {
+		d = d[:limit]
+	}
+	s.due = s.due[len(d):]
+	return &d, nil
+}
+
+func (s *memoryDeliveryStore) UpdateState(d *models.Delivery) error {
+	s.updates = append(s.updates, *d)
+	return nil
+}
+
+func newTestDispatcher(store DeliveryStore) *Dispatcher {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	d := NewDispatcher(store, logger)
+	// test servers listen on the loopback interface
+	d.Client = NewClient(time.Second, true)
+	d.MaxAttempts = 3
+	d.Backoff = time.Minute
+	d.MaxBackoff = 10 * time.Minute
+	return d
+}
+
+func TestDeliver(t *testing.T) {
+	secret := "0123456789abcdef"
+	payload := []byte(`{"event":"created","article":{"id":1}}`)
+
+	tt := []struct {
+		name      string
+		status    int
+		attempts  int
+		expected  string
+		backoff   time.Duration
+		lastError string
+	}{
+		{name: "delivered", status: http.StatusNoContent, expected: models.DeliveryDelivered},
+		{name: "first failure", status: http.StatusInternalServerError, expected: models.DeliveryPending, backoff: time.Minute, lastError: "unexpected status 500 Internal Server Error: failed"},
+		{name: "second failure backs off", status: http.StatusBadGateway, attempts: 1, expected: models.DeliveryPending, backoff: 2 * time.Minute, lastError: "unexpected status 502 Bad Gateway: failed"},
+		{name: "last attempt is dead", status: http.StatusInternalServerError, attempts: 2, expected: models.DeliveryDead, lastError: "unexpected status 500 Internal Server Error: failed"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var received *http.Request
+			var body []byte
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = ioutil.ReadAll(r.Body)
+				w.WriteHeader(tc.status)
+				w.Write([]byte("failed\n"))
+			}))
+			defer srv.Close()
+
+			store := &memoryDeliveryStore{}
+			d := newTestDispatcher(store)
+			dl := &models.Delivery{
+				ID:        7,
+				WebhookID: 1,
+				Event:     models.EventCreated,
+				Payload:   payload,
+				Status:    models.DeliveryPending,
+				Attempts:  tc.attempts,
+				Webhook:   &models.Webhook{ID: 1, URL: srv.URL, Secret: secret},
+			}
+
+			err := d.Deliver(dl)
+			assert.Equal(t, tc.expected == models.DeliveryDelivered, err == nil, "unexpected error: %v", err)
+
+			assert.Equal(t, payload, body)
+			assert.Equal(t, "7", received.Header.Get(DeliveryHeader))
+			assert.Equal(t, models.EventCreated, received.Header.Get(EventHeader))
+			assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+			assert.NoError(t, Verify(secret, received.Header.Get(TimestampHeader), received.Header.Get(SignatureHeader), body, time.Minute))
+
+			assert.Equal(t, tc.expected, dl.Status)
+			assert.Equal(t, tc.attempts+1, dl.Attempts)
+			assert.Equal(t, tc.status, dl.ResponseStatus)
+			assert.Equal(t, tc.lastError, dl.LastError)
+			if tc.backoff > 0 {
+				assert.WithinDuration(t, time.Now().Add(tc.backoff), dl.NextAttemptAt, time.Second)
+			}
+			if tc.expected == models.DeliveryDelivered {
+				assert.NotNil(t, dl.DeliveredAt)
+			}
+			assert.Equal(t, []models.Delivery{*dl}, store.updates)
+		})
+	}
+}
+
+func TestDeliverUnreachable(t *testing.T) {
+	store := &memoryDeliveryStore{}
+	d := newTestDispatcher(store)
+	dl := &models.Delivery{ID: 1, Status: models.DeliveryPending, Webhook: &models.Webhook{URL: "http://127.0.0.1:1/hook"}}
+
+	assert.Error(t, d.Deliver(dl))
+	assert.Equal(t, models.DeliveryPending, dl.Status)
+	assert.Equal(t, 0, dl.ResponseStatus)
+	assert.NotEmpty(t, dl.LastError)
+}
+
+func TestBackoff(t *testing.T) {
+	d := newTestDispatcher(&memoryDeliveryStore{})
+
+	assert.Equal(t, time.Minute, d.backoff(1))
+	assert.Equal(t, 2*time.Minute, d.backoff(2))
+	assert.Equal(t, 8*time.Minute, d.backoff(4))
+	assert.Equal(t, 10*time.Minute, d.backoff(5))
+	assert.Equal(t, 10*time.Minute, d.backoff(50))
+}
+
+func TestDispatchDue(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	webhook := &models.Webhook{ID: 1, URL: srv.URL, Secret: "0123456789abcdef"}
+	store := &memoryDeliveryStore{}
+	for i := int64(1); i <= 3; i++ {
+		store.due = append(store.due, models.Delivery{ID: i, WebhookID: 1, Status: models.DeliveryPending, Payload: []byte(`{}`), Webhook: webhook})
+	}
+	// deliveries of deleted webhooks fail without a request
+	store.due = append(store.due, models.Delivery{ID: 4, WebhookID: 2, Status: models.DeliveryPending})
+
+	d := newTestDispatcher(store)
+	d.BatchSize = 2
+
+	delivered, err := d.DispatchDue()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, delivered)
+	assert.Equal(t, 3, requests)
+	assert.Len(t, store.updates, 4)
+	assert.Equal(t, "webhook 2 not found", store.updates[3].LastError)
+}
+
+func TestDeliverPrivateAddress(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer srv.Close()
+
+	tt := []struct {
+		name string
+		url  string
+	}{
+		{name: "loopback", url: srv.URL},
+		{name: "localhost", url: strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)},
+		{name: "metadata service", url: "http://169.254.169.254/latest/meta-data/"},
+		{name: "private", url: "http://10.0.0.1/hook"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			d := newTestDispatcher(&memoryDeliveryStore{})
+			d.Client = NewClient(time.Second, false)
+			dl := &models.Delivery{ID: 1, Status: models.DeliveryPending, Webhook: &models.Webhook{URL: tc.url}}
+
+			err := d.Deliver(dl)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), ErrPrivateAddress.Error())
+			}
+		})
+	}
+	assert.Equal(t, 0, requests, "private addresses must not be reached")
+}
+
+func TestDeliverRedirect(t *testing.T) {
+	target := 0
+	redirected := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target++
+	}))
+	defer redirected.Close()
+
+	srv := httptest.NewServer(http.RedirectHandler(redirected.URL, http.StatusTemporaryRedirect))
+	defer srv.Close()
+
+	d := newTestDispatcher(&memoryDeliveryStore{})
+	dl := &models.Delivery{ID: 1, Status: models.DeliveryPending, Payload: []byte(`{}`), Webhook: &models.Webhook{URL: srv.URL}}
+
+	assert.Error(t, d.Deliver(dl))
+	assert.Equal(t, http.StatusTemporaryRedirect, dl.ResponseStatus)
+	assert.Equal(t, 0, target, "redirects must not be followed")
+}
+
+func TestPublicIP(t *testing.T) {
+	tt := []struct {
+		ip     string
+		public bool
+	}{
+		{ip: "93.184.216.34", public: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", public: true},
+		{ip: "127.0.0.1"},
+		{ip: "10.1.2.3"},
+		{ip: "172.16.0.1"},
+		{ip: "192.168.1.1"},
+		{ip: "169.254.169.254"},
+		{ip: "100.64.0.1"},
+		{ip: "0.0.0.0"},
+		{ip: "::1"},
+		{ip: "fe80::1"},
+		{ip: "fd00::1"},
+		{ip: "::ffff:127.0.0.1"},
+	}
+
+	for _, tc := range tt {
+		assert.Equal(t, tc.public, publicIP(net.ParseIP(tc.ip)), tc.ip)
+	}
+}
@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The headers sent with every delivery.
+const (
+	DeliveryHeader  = "X-Webhook-Delivery"
+	EventHeader     = "X-Webhook-Event"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// signaturePrefix names the algorithm of a signature header value.
+const signaturePrefix = "sha256="
+
+// The list of error types returned from signature verification.
+var (
+	ErrInvalidSignature = errors.New("webhook signature does not match")
+	ErrInvalidTimestamp = errors.New("webhook timestamp is malformed or outside the tolerance")
+)
+
+// Sign returns the signature header value of a delivery body sent at a unix timestamp. The HMAC-SHA256
+// covers the timestamp so that a captured delivery cannot be replayed later.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp header values of a received delivery. Timestamps more
+// than tolerance away from now are rejected, a tolerance of zero accepts any timestamp.
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	if tolerance > 0 {
+		if d := time.Since(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+			return ErrInvalidTimestamp
+		}
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) || !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1570000000.{"event":"created"}' | openssl dgst -sha256 -hmac 0123456789abcdef
+	assert.Equal(t, "sha256=c5b191d67d68823e2caa2558f2b297c5c9616c7eaf4f870646f40d267bdf4d4b", Sign("0123456789abcdef", 1570000000, []byte(`{"event":"created"}`)))
+}
+
+func TestVerify(t *testing.T) {
+	secret := "0123456789abcdef"
+	body := []byte(`{"event":"created"}`)
+	now := time.Now().Unix()
+	ts := strconv.FormatInt(now, 10)
+
+	tt := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		tolerance time.Duration
+		err       error
+	}{
+		{name: "valid", secret: secret, timestamp: ts, signature: Sign(secret, now, body), tolerance: time.Minute},
+		{name: "wrong secret", secret: "another secret!!", timestamp: ts, signature: Sign(secret, now, body), tolerance: time.Minute, err: ErrInvalidSignature},
+		{name: "replayed timestamp", secret: secret, timestamp: "1570000000", signature: Sign(secret, 1570000000, body), tolerance: time.Minute, err: ErrInvalidTimestamp},
+		{name: "old timestamp without tolerance", secret: secret, timestamp: "1570000000", signature: Sign(secret, 1570000000, body)},
+		{name: "timestamp not signed", secret: secret, timestamp: strconv.FormatInt(now-1, 10), signature: Sign(secret, now, body), tolerance: time.Minute, err: ErrInvalidSignature},
+		{name: "malformed timestamp", secret: secret, timestamp: "yesterday", signature: Sign(secret, now, body), err: ErrInvalidTimestamp},
+		{name: "missing prefix", secret: secret, timestamp: ts, signature: Sign(secret, now, body)[len(signaturePrefix):], err: ErrInvalidSignature},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.err, Verify(tc.secret, tc.timestamp, tc.signature, body, tc.tolerance))
+		})
+	}
+}